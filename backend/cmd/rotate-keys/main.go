@@ -0,0 +1,160 @@
+// Command rotate-keys re-encrypts every value stored encrypted at rest, the columns listed in
+// encryptedTables, with the primary key of TOKEN_ENCRYPTION_KEYS. Run it after adding a new
+// key and making it primary, then remove the old key once every row has been rotated. With
+// -migrate-plaintext it also encrypts values stored before encryption at rest was introduced.
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/db"
+	"devplus-backend/internal/encryption"
+	"devplus-backend/pkg/logger"
+)
+
+// encryptedTable lists the EncryptedString columns of a table keyed by a uuid "id"
+type encryptedTable struct {
+	name    string
+	columns []string
+}
+
+// encryptedTables must cover every EncryptedString column in internal/models
+var encryptedTables = []encryptedTable{
+	{"public.users", []string{"access_token", "refresh_token"}},
+	{"code_host_accounts", []string{"access_token", "refresh_token"}},
+	{"notification_channels", []string{"target"}},
+	{"notification_deliveries", []string{"target"}},
+	{"slack_command_responses", []string{"response_url"}},
+	{"webhook_endpoints", []string{"url", "secret"}},
+}
+
+type options struct {
+	migratePlaintext bool
+	dryRun           bool
+	batchSize        int
+}
+
+func main() {
+	migratePlaintext := flag.Bool("migrate-plaintext", false, "encrypt values that are still stored in plaintext")
+	dryRun := flag.Bool("dry-run", false, "report what would change without writing")
+	batchSize := flag.Int("batch", 100, "number of rows to process per batch")
+	flag.Parse()
+
+	logger.InitLogger()
+	cfg := config.LoadConfig()
+
+	keyring, err := encryption.ParseKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load token encryption keys (set TOKEN_ENCRYPTION_KEYS)")
+	}
+	database := db.GetInstance()
+	defer db.Close()
+
+	log.Info().Str("primary_key_id", keyring.PrimaryID()).Bool("dry_run", *dryRun).Msg("[RotateKeys] Re-encrypting stored secrets")
+
+	opts := options{migratePlaintext: *migratePlaintext, dryRun: *dryRun, batchSize: *batchSize}
+	var failed int
+	for _, table := range encryptedTables {
+		rotated, tableFailed := rotateTable(database, keyring, table, opts)
+		log.Info().Str("table", table.name).Int("rotated_rows", rotated).Int("failures", tableFailed).Msg("[RotateKeys] Table done")
+		failed += tableFailed
+	}
+
+	log.Info().Int("failures", failed).Msg("[RotateKeys] Done")
+	if failed > 0 {
+		os.Exit(1)
+	}
+}
+
+// rotateTable re-encrypts the table's columns in batches of rows ordered by ID, and returns
+// the number of rows changed and of values that failed
+func rotateTable(database *gorm.DB, keyring *encryption.Keyring, table encryptedTable, opts options) (int, int) {
+	var rotated, failed int
+	lastID := ""
+	for {
+		var rows []map[string]interface{}
+		if err := database.Table(table.name).
+			Select("id::text AS id, "+strings.Join(table.columns, ", ")).
+			Where("id::text > ?", lastID).
+			Order("id::text").
+			Limit(opts.batchSize).
+			Find(&rows).Error; err != nil {
+			log.Error().Err(err).Str("table", table.name).Msg("[RotateKeys] Failed to load rows")
+			return rotated, failed + 1
+		}
+		if len(rows) == 0 {
+			return rotated, failed
+		}
+
+		for _, row := range rows {
+			id, _ := row["id"].(string)
+			lastID = id
+			updates := make(map[string]interface{})
+			for _, column := range table.columns {
+				value, ok := row[column].(string)
+				if !ok {
+					continue
+				}
+				reencrypted, changed, err := reencrypt(keyring, value, opts.migratePlaintext)
+				if err != nil {
+					log.Error().Err(err).Str("table", table.name).Str("id", id).Str("column", column).Msg("[RotateKeys] Cannot re-encrypt value")
+					failed++
+					continue
+				}
+				if changed {
+					updates[column] = reencrypted
+				}
+			}
+			if len(updates) == 0 {
+				continue
+			}
+
+			rotated++
+			if opts.dryRun {
+				continue
+			}
+			if err := database.Table(table.name).Where("id = ?", id).UpdateColumns(updates).Error; err != nil {
+				log.Error().Err(err).Str("table", table.name).Str("id", id).Msg("[RotateKeys] Failed to save re-encrypted values")
+				failed++
+			}
+		}
+	}
+}
+
+// reencrypt returns value sealed with the primary key, and whether it changed. Values already
+// on the primary key are left alone; plaintext is only encrypted when migratePlaintext is set.
+func reencrypt(keyring *encryption.Keyring, value string, migratePlaintext bool) (string, bool, error) {
+	if value == "" {
+		return value, false, nil
+	}
+
+	if !encryption.IsEncrypted(value) {
+		if !migratePlaintext {
+			return "", false, errors.New("value is stored in plaintext, rerun with -migrate-plaintext")
+		}
+		encrypted, err := keyring.Encrypt(value)
+		return encrypted, err == nil, err
+	}
+
+	keyID, err := encryption.KeyID(value)
+	if err != nil {
+		return "", false, err
+	}
+	if keyID == keyring.PrimaryID() {
+		return value, false, nil
+	}
+
+	plaintext, err := keyring.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+	encrypted, err := keyring.Encrypt(plaintext)
+	return encrypted, err == nil, err
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"slices"
+	"sync"
+	"testing"
+
+	"gorm.io/gorm/schema"
+
+	"devplus-backend/internal/encryption"
+	"devplus-backend/internal/models"
+)
+
+func testKey(t *testing.T, id string) string {
+	t.Helper()
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(key)
+}
+
+func TestReencrypt(t *testing.T) {
+	oldKey, newKey := testKey(t, "old"), testKey(t, "new")
+	before, err := encryption.ParseKeyring(oldKey, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := encryption.ParseKeyring(oldKey+","+newKey, "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	onOld, _ := before.Encrypt("secret")
+	onNew, _ := keyring.Encrypt("secret")
+
+	tests := []struct {
+		name             string
+		value            string
+		migratePlaintext bool
+		changed          bool
+		wantErr          bool
+	}{
+		{"old key", onOld, false, true, false},
+		{"primary key", onNew, false, false, false},
+		{"empty", "", false, false, false},
+		{"plaintext", "secret", false, false, true},
+		{"plaintext migrated", "secret", true, true, false},
+		{"unknown key", "enc:v1:gone:AAAA:AAAA", false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := reencrypt(keyring, tt.value, tt.migratePlaintext)
+			if (err != nil) != tt.wantErr || changed != tt.changed {
+				t.Fatalf("reencrypt() changed = %v, error = %v; want changed %v, error %v", changed, err, tt.changed, tt.wantErr)
+			}
+			if !changed {
+				return
+			}
+			if id, _ := encryption.KeyID(got); id != "new" {
+				t.Errorf("re-encrypted with key %q, want the primary key", id)
+			}
+			if plaintext, err := keyring.Decrypt(got); err != nil || plaintext != "secret" {
+				t.Errorf("Decrypt() = %q, %v", plaintext, err)
+			}
+		})
+	}
+}
+
+// Every EncryptedString column must be rotated, or removing an old key makes it unreadable
+func TestEncryptedTablesCoverEveryEncryptedColumn(t *testing.T) {
+	rotated := make(map[string]bool)
+	for _, table := range encryptedTables {
+		for _, column := range table.columns {
+			rotated[table.name+"."+column] = true
+		}
+	}
+
+	encryptedType := reflect.TypeOf(models.EncryptedString(""))
+	var found []string
+	for _, model := range []interface{}{
+		&models.User{},
+		&models.CodeHostAccount{},
+		&models.NotificationChannel{},
+		&models.NotificationDelivery{},
+		&models.SlackCommandResponse{},
+		&models.WebhookEndpoint{},
+	} {
+		s, err := schema.Parse(model, &sync.Map{}, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, field := range s.Fields {
+			if field.FieldType == encryptedType {
+				found = append(found, s.Table+"."+field.DBName)
+			}
+		}
+	}
+	for _, column := range found {
+		if !rotated[column] {
+			t.Errorf("%s is encrypted but not rotated", column)
+		}
+	}
+
+	// Catch models added after this list: count the EncryptedString fields in the source
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, "../../internal/models", nil, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var declared int
+	for _, pkg := range pkgs {
+		ast.Inspect(pkg, func(n ast.Node) bool {
+			structType, ok := n.(*ast.StructType)
+			if !ok {
+				return true
+			}
+			for _, field := range structType.Fields.List {
+				if ident, ok := field.Type.(*ast.Ident); ok && ident.Name == "EncryptedString" {
+					declared += max(len(field.Names), 1)
+				}
+			}
+			return true
+		})
+	}
+	if declared != len(found) || len(rotated) != len(found) {
+		slices.Sort(found)
+		t.Errorf("models declare %d EncryptedString fields and rotate-keys lists %d columns, but only %v were matched", declared, len(rotated), found)
+	}
+}
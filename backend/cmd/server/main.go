@@ -8,14 +8,25 @@ import (
 	"syscall"
 	"time"
 
+	"devplus-backend/internal/codehost"
 	"devplus-backend/internal/config"
 	"devplus-backend/internal/controllers/rest"
 	"devplus-backend/internal/db"
+	"devplus-backend/internal/encryption"
+	"devplus-backend/internal/githubapi"
+	"devplus-backend/internal/jobs"
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/oidc"
 	"devplus-backend/internal/repositories"
 	"devplus-backend/internal/router"
 	"devplus-backend/internal/services/ai"
 	"devplus-backend/internal/services/auth_service"
+	"devplus-backend/internal/services/code_host_service"
+	"devplus-backend/internal/services/github_app"
 	"devplus-backend/internal/services/github_service"
+	"devplus-backend/internal/services/notification_service"
+	"devplus-backend/internal/services/slack_service"
+	"devplus-backend/internal/services/workspace_service"
 	"devplus-backend/pkg/logger"
 
 	"github.com/rs/zerolog/log"
@@ -28,24 +39,88 @@ func main() {
 	// Initialize Config
 	cfg := config.LoadConfig()
 
+	// Initialize Token Encryption (stored GitHub tokens can't be read or written without it)
+	keyring, err := encryption.ParseKeyring(cfg.EncryptionKeys, cfg.EncryptionKeyID)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load token encryption keys (set TOKEN_ENCRYPTION_KEYS)")
+	}
+	encryption.SetDefault(keyring)
+
 	// Initialize Database
 	database := db.GetInstance()
 
+	// GitHub endpoints: github.com, or GitHub Enterprise Server when GITHUB_API_URL is set
+	githubEndpoints, err := githubapi.FromConfig(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid GitHub Enterprise configuration")
+	}
+
 	// Initialize Services
 	// Initialize AI Factory
-	aiFactory := ai.NewAIFactory(cfg.KestraURL, cfg.KestraUsername, cfg.KestraPassword)
+	aiFactory := ai.NewAIFactory(cfg.KestraURL, cfg.KestraUsername, cfg.KestraPassword, githubEndpoints)
+	callbacks := ai.NewCallbackSigner(cfg.KestraCallbackKey)
+	if !callbacks.Enabled() {
+		log.Warn().Msg("KESTRA_CALLBACK_SECRET is not set; AI analysis results will be rejected")
+	}
+	if cfg.GithubWebhookSecret == "" {
+		log.Warn().Msg("GITHUB_WEBHOOK_SECRET is not set; GitHub webhooks will be rejected")
+	}
 
 	// Initialize Services
-	authService := auth_service.NewAuthService()
+	codeHosts := codehost.FromConfig(cfg)
+	authService := auth_service.NewAuthService(githubEndpoints)
+	authService.RegisterCodeHosts(codeHosts)
 	githubRepo := repositories.NewGithubRepository(database)
-	githubService := github_service.NewGithubService(githubRepo, aiFactory, cfg.BackendURL)
+	workspaceRepo := repositories.NewWorkspaceRepository(database)
+	workspaceService := workspace_service.NewWorkspaceService(workspaceRepo)
+	codeHostService := code_host_service.NewCodeHostService(githubRepo, workspaceService, authService, codeHosts)
+	githubApp, err := github_app.NewApp(githubEndpoints, cfg.GithubAppID, cfg.GithubAppPrivateKey, cfg.GithubAppKeyPath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to load GitHub App credentials")
+	}
+	githubService := github_service.NewGithubService(githubEndpoints, githubRepo, workspaceService, githubApp, codeHostService, aiFactory, callbacks, cfg.BackendURL)
+	notificationService := notification_service.NewNotificationService(database, cfg)
+	slackService := slack_service.NewSlackService(database, cfg, githubService, workspaceService, authService)
+
+	// Start Background Jobs
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs.StartMetricsRollup(jobsCtx, githubService)
+	jobs.StartAuthCleanup(jobsCtx, authService)
+	jobs.StartNotificationRetries(jobsCtx, notificationService)
+	jobs.StartReleaseRiskRuns(jobsCtx, githubService)
+	if githubApp.Enabled() {
+		jobs.StartRepositorySync(jobsCtx, githubService)
+	}
 
 	// Initialize Controllers
 	authController := rest.NewAuthController(authService)
-	githubController := rest.NewGithubController(githubService)
+	githubController := rest.NewGithubController(githubService, authService, codeHostService, rest.EventNotifiers{notificationService, slackService}, callbacks, cfg.GithubWebhookSecret)
+	workspaceController := rest.NewWorkspaceController(workspaceService)
+	codeHostController := rest.NewCodeHostController(codeHostService, githubService)
+	notificationController := rest.NewNotificationController(notificationService)
+	slackController := rest.NewSlackController(slackService)
+
+	// Initialize Authenticator Chain: API tokens, OIDC JWTs when configured, then sessions
+	authenticators := []middleware.Authenticator{middleware.NewAPITokenAuthenticator(authService)}
+	if cfg.OIDCIssuer != "" {
+		if cfg.OIDCAudience == "" {
+			log.Fatal().Msg("OIDC_AUDIENCE is required when OIDC_ISSUER is set")
+		}
+		verifier := oidc.NewVerifier(cfg.OIDCIssuer, cfg.OIDCAudience, cfg.OIDCJWKSURL)
+		authenticators = append(authenticators, middleware.NewOIDCAuthenticator(verifier, authService))
+	}
+	authenticators = append(authenticators, middleware.NewSessionAuthenticator(authService))
+	authenticate := middleware.Authenticate(authService, authenticators...)
 
 	// Initialize Router
-	r := router.SetupRouter(authController, githubController)
+	r := router.SetupRouter(authController, githubController, workspaceController, codeHostController, notificationController, slackController, workspaceService, authenticate)
+
+	// Forwarding headers only name the client when they come from a trusted proxy
+	trustedProxies, err := middleware.ParseTrustedProxies(cfg.TrustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+	r.Use(middleware.ClientAddress(trustedProxies))
 
 	// Start Server
 	addr := ":" + cfg.BACKEND_PORT
@@ -73,6 +148,7 @@ func main() {
 	<-done
 
 	log.Info().Msg("Shutting down server...")
+	stopJobs()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
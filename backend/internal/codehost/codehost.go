@@ -0,0 +1,175 @@
+// Package codehost abstracts the code hosts DevPlus syncs repositories from besides GitHub
+// (GitLab, Gitea and Forgejo), which keeps its dedicated client in github_service. A Provider
+// covers what DevPlus needs from a host: OAuth login, repository and merge request listing,
+// merge request diffs for AI analysis and webhook verification.
+package codehost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/models"
+)
+
+var (
+	// ErrUnknownHost is returned for requests naming a code host that isn't configured
+	ErrUnknownHost = errors.New("unknown code host")
+	// ErrInvalidWebhook is returned for webhook deliveries that fail verification
+	ErrInvalidWebhook = errors.New("invalid webhook token")
+)
+
+// httpClient is shared by the providers for API calls
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Account is the user an access token belongs to
+type Account struct {
+	ID            int64
+	Username      string
+	Name          string
+	Email         string
+	EmailVerified bool
+	AvatarURL     string
+}
+
+// Repository is a repository (GitLab: project) the user can access on the host
+type Repository struct {
+	ID        int64
+	Name      string
+	Namespace string // Full path of the owning user or group, e.g. "acme/platform"
+	Kind      string // models.WorkspaceKindUser or models.WorkspaceKindOrganization
+	URL       string
+	UpdatedAt time.Time
+	// Role is the user's role on the repository as a models.Role* value, mapped from their
+	// permissions on the host
+	Role string
+}
+
+// MergeRequest is a merge request with its state mapped to the models.PRState* values
+type MergeRequest struct {
+	ID         int64
+	Number     int64 // Number within the repository (GitLab: iid)
+	Title      string
+	State      string
+	AuthorID   int64
+	AuthorName string
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// WebhookEvent is a verified webhook delivery DevPlus acts on
+type WebhookEvent struct {
+	RepositoryID int64
+	MergeRequest *MergeRequest
+	// Analyze is set when the merge request was opened or received new commits
+	Analyze bool
+}
+
+// Provider is a code host. Access tokens come from the host's OAuth flow, see OAuth2Config.
+type Provider interface {
+	Name() string
+	DisplayName() string
+	// OAuth2Config is the OAuth client DevPlus logs users in and obtains API tokens with
+	OAuth2Config() *oauth2.Config
+	CurrentUser(ctx context.Context, token string) (*Account, error)
+	ListRepositories(ctx context.Context, token string) ([]*Repository, error)
+	// NamespaceAdmin reports whether the account owns or administers the namespace of a
+	// repository: its own user namespace, or a group or organisation it owns
+	NamespaceAdmin(ctx context.Context, token string, account *Account, namespace string, kind string) (bool, error)
+	// ListMergeRequests returns the most recently updated merge requests in any state
+	ListMergeRequests(ctx context.Context, token string, repoID int64) ([]*MergeRequest, error)
+	// MergeRequestDiff returns the merge request's changes as a unified diff
+	MergeRequestDiff(ctx context.Context, token string, repoID int64, number int64) (string, error)
+	// ParseWebhook verifies a webhook delivery and returns the event, or nil for events
+	// DevPlus ignores
+	ParseWebhook(r *http.Request, body []byte) (*WebhookEvent, error)
+}
+
+// Hosts are the configured code hosts by name
+type Hosts map[string]Provider
+
+// FromConfig returns the code hosts with OAuth clients configured
+func FromConfig(cfg *config.Config) Hosts {
+	hosts := make(Hosts)
+	redirectBase := strings.TrimSuffix(cfg.CodeHostRedirectURL, "/")
+	if cfg.GitlabClientID != "" {
+		hosts[models.CodeHostGitlab] = NewGitLab(Config{
+			BaseURL:       cfg.GitlabURL,
+			ClientID:      cfg.GitlabClientID,
+			ClientSecret:  cfg.GitlabClientSecret,
+			WebhookSecret: cfg.GitlabWebhookSecret,
+			RedirectURL:   redirectBase + "/" + models.CodeHostGitlab + "/callback",
+		})
+	}
+	if cfg.GiteaURL != "" && cfg.GiteaClientID != "" {
+		hosts[models.CodeHostGitea] = NewGitea(Config{
+			BaseURL:       cfg.GiteaURL,
+			DisplayName:   cfg.GiteaDisplayName,
+			ClientID:      cfg.GiteaClientID,
+			ClientSecret:  cfg.GiteaClientSecret,
+			WebhookSecret: cfg.GiteaWebhookSecret,
+			RedirectURL:   redirectBase + "/" + models.CodeHostGitea + "/callback",
+		})
+	}
+	return hosts
+}
+
+// Get returns the named code host
+func (h Hosts) Get(name string) (Provider, error) {
+	provider, ok := h[name]
+	if !ok {
+		return nil, ErrUnknownHost
+	}
+	return provider, nil
+}
+
+// Names returns the host names in a stable order
+func (h Hosts) Names() []string {
+	names := make([]string, 0, len(h))
+	for name := range h {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Config configures a self-hostable code host
+type Config struct {
+	BaseURL       string
+	DisplayName   string // Optional, e.g. "Forgejo"
+	ClientID      string
+	ClientSecret  string
+	WebhookSecret string
+	RedirectURL   string
+}
+
+// APIError is an error response from a code host's API
+type APIError struct {
+	Host       string
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s api error %d: %s", e.Host, e.StatusCode, e.Message)
+}
+
+// isDenied reports whether the host answered that the resource doesn't exist or the token
+// can't see it
+func isDenied(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusForbidden)
+}
+
+// IsUnauthorized reports whether the host rejected the access token
+func IsUnauthorized(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
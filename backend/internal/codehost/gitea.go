@@ -0,0 +1,395 @@
+package codehost
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	// giteaPageSize is the largest page Gitea returns with its default MAX_RESPONSE_ITEMS
+	giteaPageSize = 50
+	// giteaMaxPages bounds listings to 100 items, like the GitLab provider
+	giteaMaxPages = 2
+)
+
+// Gitea is a self-hosted Gitea or Forgejo instance, accessed through its v1 REST API. The API
+// addresses repositories by owner and name, which are looked up from the repository ID so
+// renamed repositories keep syncing.
+type Gitea struct {
+	baseURL       string
+	displayName   string
+	oauth         *oauth2.Config
+	webhookSecret string
+}
+
+// NewGitea creates a Gitea provider for the instance at cfg.BaseURL
+func NewGitea(cfg Config) *Gitea {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	displayName := cfg.DisplayName
+	if displayName == "" {
+		displayName = "Gitea"
+	}
+	return &Gitea{
+		baseURL:     baseURL,
+		displayName: displayName,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			// Instances before Gitea 1.22 ignore these and grant full access
+			Scopes: []string{"read:user", "read:organization", "read:repository"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  baseURL + "/login/oauth/authorize",
+				TokenURL: baseURL + "/login/oauth/access_token",
+			},
+		},
+		webhookSecret: cfg.WebhookSecret,
+	}
+}
+
+func (g *Gitea) Name() string {
+	return models.CodeHostGitea
+}
+
+func (g *Gitea) DisplayName() string {
+	return g.displayName
+}
+
+func (g *Gitea) OAuth2Config() *oauth2.Config {
+	return g.oauth
+}
+
+type giteaUser struct {
+	ID        int64  `json:"id"`
+	Login     string `json:"login"`
+	FullName  string `json:"full_name"`
+	Email     string `json:"email"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+type giteaRepository struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	HTMLURL   string    `json:"html_url"`
+	Archived  bool      `json:"archived"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Owner     struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	Permissions struct {
+		Admin bool `json:"admin"`
+		Push  bool `json:"push"`
+	} `json:"permissions"`
+}
+
+type giteaPullRequest struct {
+	ID        int64     `json:"id"`
+	Number    int64     `json:"number"`
+	Title     string    `json:"title"`
+	State     string    `json:"state"`
+	Draft     bool      `json:"draft"`
+	Merged    bool      `json:"merged"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	User      struct {
+		ID    int64  `json:"id"`
+		Login string `json:"login"`
+	} `json:"user"`
+}
+
+// CurrentUser returns the token's user. Whether the primary email is verified comes from the
+// user's email list.
+func (g *Gitea) CurrentUser(ctx context.Context, token string) (*Account, error) {
+	var user giteaUser
+	if err := g.get(ctx, token, "/user", nil, &user); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.get(ctx, token, "/user/emails", nil, &emails); err != nil {
+		return nil, err
+	}
+	verified := false
+	for _, email := range emails {
+		if user.Email != "" && strings.EqualFold(email.Email, user.Email) {
+			verified = email.Verified
+		}
+	}
+
+	return &Account{
+		ID:            user.ID,
+		Username:      user.Login,
+		Name:          user.FullName,
+		Email:         user.Email,
+		EmailVerified: verified,
+		AvatarURL:     user.AvatarURL,
+	}, nil
+}
+
+// ListRepositories returns up to 100 unarchived repositories the user owns or can access
+// through an organisation or as a collaborator
+func (g *Gitea) ListRepositories(ctx context.Context, token string) ([]*Repository, error) {
+	// Repository owners don't say whether they are an organisation
+	var orgs []struct {
+		Name string `json:"name"`
+	}
+	if err := g.list(ctx, token, "/user/orgs", nil, &orgs); err != nil {
+		return nil, err
+	}
+	isOrg := make(map[string]bool, len(orgs))
+	for _, org := range orgs {
+		isOrg[strings.ToLower(org.Name)] = true
+	}
+
+	var giteaRepos []giteaRepository
+	if err := g.list(ctx, token, "/user/repos", nil, &giteaRepos); err != nil {
+		return nil, err
+	}
+
+	repos := make([]*Repository, 0, len(giteaRepos))
+	for _, repo := range giteaRepos {
+		if repo.Archived {
+			continue
+		}
+		kind := models.WorkspaceKindUser
+		if isOrg[strings.ToLower(repo.Owner.Login)] {
+			kind = models.WorkspaceKindOrganization
+		}
+		repos = append(repos, &Repository{
+			ID:        repo.ID,
+			Name:      repo.Name,
+			Namespace: repo.Owner.Login,
+			Kind:      kind,
+			URL:       repo.HTMLURL,
+			UpdatedAt: repo.UpdatedAt,
+			Role:      giteaRole(repo),
+		})
+	}
+	return repos, nil
+}
+
+// NamespaceAdmin reports whether the namespace is the user's own or an organisation they own
+// or administer
+func (g *Gitea) NamespaceAdmin(ctx context.Context, token string, account *Account, namespace string, kind string) (bool, error) {
+	if kind == models.WorkspaceKindUser {
+		return strings.EqualFold(namespace, account.Username), nil
+	}
+
+	var permissions struct {
+		IsOwner bool `json:"is_owner"`
+		IsAdmin bool `json:"is_admin"`
+	}
+	path := "/users/" + url.PathEscape(account.Username) + "/orgs/" + url.PathEscape(namespace) + "/permissions"
+	if err := g.get(ctx, token, path, nil, &permissions); err != nil {
+		if isDenied(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return permissions.IsOwner || permissions.IsAdmin, nil
+}
+
+// giteaRole maps the user's repository permissions to a role
+func giteaRole(repo giteaRepository) string {
+	switch {
+	case repo.Permissions.Admin:
+		return models.RoleOwner
+	case repo.Permissions.Push:
+		return models.RoleMaintainer
+	default:
+		return models.RoleViewer
+	}
+}
+
+// ListMergeRequests returns the repository's 100 most recently updated pull requests
+func (g *Gitea) ListMergeRequests(ctx context.Context, token string, repoID int64) ([]*MergeRequest, error) {
+	repoPath, err := g.repositoryPath(ctx, token, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("sort", "recentupdate")
+
+	var pulls []giteaPullRequest
+	if err := g.list(ctx, token, repoPath+"/pulls", query, &pulls); err != nil {
+		return nil, err
+	}
+
+	result := make([]*MergeRequest, 0, len(pulls))
+	for _, pr := range pulls {
+		result = append(result, giteaMergeRequest(pr))
+	}
+	return result, nil
+}
+
+// MergeRequestDiff returns the pull request's unified diff
+func (g *Gitea) MergeRequestDiff(ctx context.Context, token string, repoID int64, number int64) (string, error) {
+	repoPath, err := g.repositoryPath(ctx, token, repoID)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := g.request(ctx, token, fmt.Sprintf("%s/pulls/%d.diff", repoPath, number), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	diff, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(diff), nil
+}
+
+// ParseWebhook verifies the HMAC-SHA256 signature of the body with the configured secret and
+// parses pull request events. Forgejo sends its own headers alongside the Gitea ones.
+// Deliveries are rejected when no secret is configured.
+func (g *Gitea) ParseWebhook(r *http.Request, body []byte) (*WebhookEvent, error) {
+	signature := r.Header.Get("X-Gitea-Signature")
+	if signature == "" {
+		signature = r.Header.Get("X-Forgejo-Signature")
+	}
+	if g.webhookSecret == "" || !validGiteaSignature(body, signature, g.webhookSecret) {
+		return nil, ErrInvalidWebhook
+	}
+
+	event := r.Header.Get("X-Gitea-Event")
+	if event == "" {
+		event = r.Header.Get("X-Forgejo-Event")
+	}
+	if event != "pull_request" {
+		return nil, nil
+	}
+
+	var payload struct {
+		Action      string           `json:"action"`
+		PullRequest giteaPullRequest `json:"pull_request"`
+		Repository  struct {
+			ID int64 `json:"id"`
+		} `json:"repository"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid gitea webhook payload: %w", err)
+	}
+
+	return &WebhookEvent{
+		RepositoryID: payload.Repository.ID,
+		MergeRequest: giteaMergeRequest(payload.PullRequest),
+		Analyze:      payload.Action == "opened" || payload.Action == "reopened" || payload.Action == "synchronized",
+	}, nil
+}
+
+// repositoryPath returns the API path of the repository with the given ID
+func (g *Gitea) repositoryPath(ctx context.Context, token string, repoID int64) (string, error) {
+	var repo giteaRepository
+	if err := g.get(ctx, token, fmt.Sprintf("/repositories/%d", repoID), nil, &repo); err != nil {
+		return "", err
+	}
+	return "/repos/" + url.PathEscape(repo.Owner.Login) + "/" + url.PathEscape(repo.Name), nil
+}
+
+// list fetches up to giteaMaxPages pages of a listing endpoint into out, a pointer to a slice
+func (g *Gitea) list(ctx context.Context, token string, path string, query url.Values, out interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("limit", strconv.Itoa(giteaPageSize))
+
+	var items []json.RawMessage
+	for page := 1; page <= giteaMaxPages; page++ {
+		query.Set("page", strconv.Itoa(page))
+		var pageItems []json.RawMessage
+		if err := g.get(ctx, token, path, query, &pageItems); err != nil {
+			return err
+		}
+		items = append(items, pageItems...)
+		if len(pageItems) < giteaPageSize {
+			break
+		}
+	}
+
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+// get calls the API and decodes the JSON response into out
+func (g *Gitea) get(ctx context.Context, token string, path string, query url.Values, out interface{}) error {
+	resp, err := g.request(ctx, token, path, query)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// request sends a GET request to the API and returns the response if it succeeded
+func (g *Gitea) request(ctx context.Context, token string, path string, query url.Values) (*http.Response, error) {
+	endpoint := g.baseURL + "/api/v1" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{Host: models.CodeHostGitea, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	return resp, nil
+}
+
+// giteaMergeRequest maps a Gitea pull request to a merge request
+func giteaMergeRequest(pr giteaPullRequest) *MergeRequest {
+	return &MergeRequest{
+		ID:         pr.ID,
+		Number:     pr.Number,
+		Title:      pr.Title,
+		State:      models.PullRequestState(pr.State, pr.Draft, pr.Merged),
+		AuthorID:   pr.User.ID,
+		AuthorName: pr.User.Login,
+		CreatedAt:  pr.CreatedAt,
+		UpdatedAt:  pr.UpdatedAt,
+	}
+}
+
+// validGiteaSignature checks the hex-encoded HMAC-SHA256 of the body
+func validGiteaSignature(body []byte, signature string, secret string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
@@ -0,0 +1,163 @@
+package codehost
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"devplus-backend/internal/models"
+)
+
+func TestGiteaNamespaceAdmin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/users/alice/orgs/acme/permissions":
+			w.Write([]byte(`{"is_owner":true,"is_admin":false,"can_write":true,"can_read":true}`))
+		case "/api/v1/users/alice/orgs/tools/permissions":
+			w.Write([]byte(`{"is_owner":false,"is_admin":false,"can_write":true,"can_read":true}`))
+		default:
+			http.Error(w, `{"message":"not found"}`, http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	gitea := NewGitea(Config{BaseURL: server.URL})
+	account := &Account{ID: 7, Username: "alice"}
+	tests := []struct {
+		namespace string
+		kind      string
+		want      bool
+	}{
+		{"Alice", models.WorkspaceKindUser, true},
+		{"bob", models.WorkspaceKindUser, false},
+		{"acme", models.WorkspaceKindOrganization, true},
+		{"tools", models.WorkspaceKindOrganization, false},
+		{"private", models.WorkspaceKindOrganization, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			got, err := gitea.NamespaceAdmin(context.Background(), "token", account, tt.namespace, tt.kind)
+			if err != nil || got != tt.want {
+				t.Errorf("NamespaceAdmin() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGiteaNamespaceAdminReturnsServerErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	gitea := NewGitea(Config{BaseURL: server.URL})
+	if _, err := gitea.NamespaceAdmin(context.Background(), "token", &Account{Username: "alice"}, "acme", models.WorkspaceKindOrganization); err == nil {
+		t.Error("NamespaceAdmin() hid a server error")
+	}
+}
+
+const giteaPullRequestEvent = `{
+	"action": "%s",
+	"pull_request": {
+		"id": 501, "number": 9, "title": "Fix login", "state": "open", "draft": false, "merged": false,
+		"created_at": "2024-05-01T10:00:00Z", "updated_at": "2024-05-02T11:30:00Z",
+		"user": {"id": 3, "login": "bob"}
+	},
+	"repository": {"id": 77}
+}`
+
+func giteaSign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func giteaWebhookRequest(headers map[string]string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/gitea", nil)
+	for name, value := range headers {
+		r.Header.Set(name, value)
+	}
+	return r
+}
+
+func TestGiteaParseWebhookVerifiesSignature(t *testing.T) {
+	body := []byte(fmt.Sprintf(giteaPullRequestEvent, "opened"))
+	valid := giteaSign(body, "s3cret")
+	tampered := append([]byte(nil), body...)
+	tampered[len(tampered)-3] = '8'
+
+	tests := []struct {
+		name    string
+		secret  string
+		body    []byte
+		headers map[string]string
+		ok      bool
+	}{
+		{"gitea signature", "s3cret", body, map[string]string{"X-Gitea-Signature": valid, "X-Gitea-Event": "pull_request"}, true},
+		{"forgejo signature", "s3cret", body, map[string]string{"X-Forgejo-Signature": valid, "X-Forgejo-Event": "pull_request"}, true},
+		{"signed with another secret", "s3cret", body, map[string]string{"X-Gitea-Signature": giteaSign(body, "other"), "X-Gitea-Event": "pull_request"}, false},
+		{"tampered body", "s3cret", tampered, map[string]string{"X-Gitea-Signature": valid, "X-Gitea-Event": "pull_request"}, false},
+		{"truncated signature", "s3cret", body, map[string]string{"X-Gitea-Signature": valid[:32], "X-Gitea-Event": "pull_request"}, false},
+		{"signature not hex", "s3cret", body, map[string]string{"X-Gitea-Signature": "sha256=" + valid, "X-Gitea-Event": "pull_request"}, false},
+		{"missing signature", "s3cret", body, map[string]string{"X-Gitea-Event": "pull_request"}, false},
+		{"no secret configured", "", body, map[string]string{"X-Gitea-Signature": giteaSign(body, ""), "X-Gitea-Event": "pull_request"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitea := NewGitea(Config{BaseURL: "https://gitea.example.com", WebhookSecret: tt.secret})
+			event, err := gitea.ParseWebhook(giteaWebhookRequest(tt.headers), tt.body)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidWebhook) || event != nil {
+					t.Errorf("ParseWebhook() = %v, %v; want ErrInvalidWebhook", event, err)
+				}
+				return
+			}
+			if err != nil || event == nil {
+				t.Fatalf("ParseWebhook() = %v, %v", event, err)
+			}
+		})
+	}
+}
+
+func TestGiteaParseWebhook(t *testing.T) {
+	gitea := NewGitea(Config{BaseURL: "https://gitea.example.com", WebhookSecret: "s3cret"})
+	parse := func(event string, body []byte) (*WebhookEvent, error) {
+		return gitea.ParseWebhook(giteaWebhookRequest(map[string]string{
+			"X-Gitea-Signature": giteaSign(body, "s3cret"),
+			"X-Gitea-Event":     event,
+		}), body)
+	}
+
+	event, err := parse("pull_request", []byte(fmt.Sprintf(giteaPullRequestEvent, "opened")))
+	if err != nil || event == nil {
+		t.Fatalf("ParseWebhook() = %v, %v", event, err)
+	}
+	mr := event.MergeRequest
+	if event.RepositoryID != 77 || mr.ID != 501 || mr.Number != 9 || mr.Title != "Fix login" ||
+		mr.State != models.PRStateOpen || mr.AuthorID != 3 || mr.AuthorName != "bob" {
+		t.Errorf("ParseWebhook() = %+v, %+v", event, mr)
+	}
+
+	for action, want := range map[string]bool{"opened": true, "reopened": true, "synchronized": true, "edited": false, "closed": false} {
+		event, err := parse("pull_request", []byte(fmt.Sprintf(giteaPullRequestEvent, action)))
+		if err != nil {
+			t.Fatalf("ParseWebhook(%s): %v", action, err)
+		}
+		if event.Analyze != want {
+			t.Errorf("ParseWebhook(%s) Analyze = %v, want %v", action, event.Analyze, want)
+		}
+	}
+
+	if event, err := parse("push", []byte(`{}`)); err != nil || event != nil {
+		t.Errorf("ParseWebhook(push) = %v, %v; want it ignored", event, err)
+	}
+	if _, err := parse("pull_request", []byte(`{`)); err == nil || errors.Is(err, ErrInvalidWebhook) {
+		t.Errorf("ParseWebhook(malformed) error = %v, want a payload error", err)
+	}
+}
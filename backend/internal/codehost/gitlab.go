@@ -0,0 +1,381 @@
+package codehost
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"devplus-backend/internal/models"
+)
+
+// gitlabMaxDiffPages bounds the merge request diff fetched for AI analysis (100 files a page)
+const gitlabMaxDiffPages = 10
+
+// GitLab is gitlab.com or a self-managed GitLab instance, accessed through its v4 REST API
+type GitLab struct {
+	baseURL       string
+	oauth         *oauth2.Config
+	webhookSecret string
+}
+
+// NewGitLab creates a GitLab provider for the instance at cfg.BaseURL
+func NewGitLab(cfg Config) *GitLab {
+	baseURL := strings.TrimSuffix(cfg.BaseURL, "/")
+	return &GitLab{
+		baseURL: baseURL,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Scopes:       []string{"read_user", "read_api"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  baseURL + "/oauth/authorize",
+				TokenURL: baseURL + "/oauth/token",
+			},
+		},
+		webhookSecret: cfg.WebhookSecret,
+	}
+}
+
+func (g *GitLab) Name() string {
+	return models.CodeHostGitlab
+}
+
+func (g *GitLab) DisplayName() string {
+	return "GitLab"
+}
+
+func (g *GitLab) OAuth2Config() *oauth2.Config {
+	return g.oauth
+}
+
+type gitlabUser struct {
+	ID          int64   `json:"id"`
+	Username    string  `json:"username"`
+	Name        string  `json:"name"`
+	Email       string  `json:"email"`
+	AvatarURL   string  `json:"avatar_url"`
+	ConfirmedAt *string `json:"confirmed_at"`
+}
+
+type gitlabProject struct {
+	ID             int64     `json:"id"`
+	Path           string    `json:"path"`
+	WebURL         string    `json:"web_url"`
+	LastActivityAt time.Time `json:"last_activity_at"`
+	Namespace      struct {
+		Kind     string `json:"kind"`
+		FullPath string `json:"full_path"`
+	} `json:"namespace"`
+	Permissions struct {
+		ProjectAccess *gitlabAccess `json:"project_access"`
+		GroupAccess   *gitlabAccess `json:"group_access"`
+	} `json:"permissions"`
+}
+
+type gitlabAccess struct {
+	AccessLevel int `json:"access_level"`
+}
+
+// GitLab access levels
+const (
+	gitlabDeveloper  = 30
+	gitlabMaintainer = 40
+	gitlabOwner      = 50
+)
+
+type gitlabMergeRequest struct {
+	ID             int64     `json:"id"`
+	IID            int64     `json:"iid"`
+	Title          string    `json:"title"`
+	State          string    `json:"state"`
+	Draft          bool      `json:"draft"`
+	WorkInProgress bool      `json:"work_in_progress"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	Author         struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	} `json:"author"`
+}
+
+type gitlabDiff struct {
+	OldPath     string `json:"old_path"`
+	NewPath     string `json:"new_path"`
+	Diff        string `json:"diff"`
+	NewFile     bool   `json:"new_file"`
+	DeletedFile bool   `json:"deleted_file"`
+}
+
+// CurrentUser returns the token's user. GitLab only returns the primary email, which is
+// verified once the account is confirmed.
+func (g *GitLab) CurrentUser(ctx context.Context, token string) (*Account, error) {
+	var user gitlabUser
+	if _, err := g.get(ctx, token, "/user", nil, &user); err != nil {
+		return nil, err
+	}
+	return &Account{
+		ID:            user.ID,
+		Username:      user.Username,
+		Name:          user.Name,
+		Email:         user.Email,
+		EmailVerified: user.Email != "" && user.ConfirmedAt != nil,
+		AvatarURL:     user.AvatarURL,
+	}, nil
+}
+
+// ListRepositories returns the 100 most recently active projects the user is a member of
+func (g *GitLab) ListRepositories(ctx context.Context, token string) ([]*Repository, error) {
+	query := url.Values{}
+	query.Set("membership", "true")
+	query.Set("archived", "false")
+	query.Set("order_by", "last_activity_at")
+	query.Set("sort", "desc")
+	query.Set("per_page", "100")
+
+	var projects []gitlabProject
+	if _, err := g.get(ctx, token, "/projects", query, &projects); err != nil {
+		return nil, err
+	}
+
+	repos := make([]*Repository, 0, len(projects))
+	for _, project := range projects {
+		kind := models.WorkspaceKindOrganization
+		if project.Namespace.Kind == "user" {
+			kind = models.WorkspaceKindUser
+		}
+		repos = append(repos, &Repository{
+			ID:        project.ID,
+			Name:      project.Path,
+			Namespace: project.Namespace.FullPath,
+			Kind:      kind,
+			URL:       project.WebURL,
+			UpdatedAt: project.LastActivityAt,
+			Role:      gitlabRole(project),
+		})
+	}
+	return repos, nil
+}
+
+// NamespaceAdmin reports whether the namespace is the user's own or a group they own,
+// directly or through a parent group
+func (g *GitLab) NamespaceAdmin(ctx context.Context, token string, account *Account, namespace string, kind string) (bool, error) {
+	if kind == models.WorkspaceKindUser {
+		return strings.EqualFold(namespace, account.Username), nil
+	}
+
+	var member gitlabAccess
+	path := fmt.Sprintf("/groups/%s/members/all/%d", url.PathEscape(namespace), account.ID)
+	if _, err := g.get(ctx, token, path, nil, &member); err != nil {
+		if isDenied(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	return member.AccessLevel >= gitlabOwner, nil
+}
+
+// gitlabRole maps the user's project or group access, whichever is higher, to a role:
+// maintainers and owners manage the project, developers push to it
+func gitlabRole(project gitlabProject) string {
+	level := 0
+	for _, access := range []*gitlabAccess{project.Permissions.ProjectAccess, project.Permissions.GroupAccess} {
+		if access != nil && access.AccessLevel > level {
+			level = access.AccessLevel
+		}
+	}
+	switch {
+	case level >= gitlabMaintainer:
+		return models.RoleOwner
+	case level >= gitlabDeveloper:
+		return models.RoleMaintainer
+	default:
+		return models.RoleViewer
+	}
+}
+
+// ListMergeRequests returns the project's 100 most recently updated merge requests
+func (g *GitLab) ListMergeRequests(ctx context.Context, token string, repoID int64) ([]*MergeRequest, error) {
+	query := url.Values{}
+	query.Set("state", "all")
+	query.Set("order_by", "updated_at")
+	query.Set("sort", "desc")
+	query.Set("per_page", "100")
+
+	var mergeRequests []gitlabMergeRequest
+	if _, err := g.get(ctx, token, fmt.Sprintf("/projects/%d/merge_requests", repoID), query, &mergeRequests); err != nil {
+		return nil, err
+	}
+
+	result := make([]*MergeRequest, 0, len(mergeRequests))
+	for _, mr := range mergeRequests {
+		result = append(result, &MergeRequest{
+			ID:         mr.ID,
+			Number:     mr.IID,
+			Title:      mr.Title,
+			State:      gitlabState(mr.State, mr.Draft || mr.WorkInProgress),
+			AuthorID:   mr.Author.ID,
+			AuthorName: mr.Author.Username,
+			CreatedAt:  mr.CreatedAt,
+			UpdatedAt:  mr.UpdatedAt,
+		})
+	}
+	return result, nil
+}
+
+// MergeRequestDiff assembles a unified diff from the merge request's per-file diffs
+// (GitLab 15.7 or later)
+func (g *GitLab) MergeRequestDiff(ctx context.Context, token string, repoID int64, number int64) (string, error) {
+	var diff strings.Builder
+	page := "1"
+	for i := 0; i < gitlabMaxDiffPages && page != ""; i++ {
+		query := url.Values{}
+		query.Set("per_page", "100")
+		query.Set("page", page)
+
+		var files []gitlabDiff
+		header, err := g.get(ctx, token, fmt.Sprintf("/projects/%d/merge_requests/%d/diffs", repoID, number), query, &files)
+		if err != nil {
+			return "", err
+		}
+		for _, file := range files {
+			oldPath, newPath := "a/"+file.OldPath, "b/"+file.NewPath
+			if file.NewFile {
+				oldPath = "/dev/null"
+			}
+			if file.DeletedFile {
+				newPath = "/dev/null"
+			}
+			fmt.Fprintf(&diff, "diff --git a/%s b/%s\n--- %s\n+++ %s\n", file.OldPath, file.NewPath, oldPath, newPath)
+			diff.WriteString(file.Diff)
+			if !strings.HasSuffix(file.Diff, "\n") {
+				diff.WriteString("\n")
+			}
+		}
+		page = header.Get("X-Next-Page")
+	}
+	return diff.String(), nil
+}
+
+// ParseWebhook verifies the X-Gitlab-Token header against the configured secret and parses
+// merge request events. Deliveries are rejected when no secret is configured.
+func (g *GitLab) ParseWebhook(r *http.Request, body []byte) (*WebhookEvent, error) {
+	token := r.Header.Get("X-Gitlab-Token")
+	if g.webhookSecret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(g.webhookSecret)) != 1 {
+		return nil, ErrInvalidWebhook
+	}
+	if r.Header.Get("X-Gitlab-Event") != "Merge Request Hook" {
+		return nil, nil
+	}
+
+	var payload struct {
+		User struct {
+			ID       int64  `json:"id"`
+			Username string `json:"username"`
+		} `json:"user"`
+		Project struct {
+			ID int64 `json:"id"`
+		} `json:"project"`
+		ObjectAttributes struct {
+			ID             int64  `json:"id"`
+			IID            int64  `json:"iid"`
+			Title          string `json:"title"`
+			State          string `json:"state"`
+			Action         string `json:"action"`
+			Draft          bool   `json:"draft"`
+			WorkInProgress bool   `json:"work_in_progress"`
+			AuthorID       int64  `json:"author_id"`
+			CreatedAt      string `json:"created_at"`
+			UpdatedAt      string `json:"updated_at"`
+			OldRev         string `json:"oldrev"`
+		} `json:"object_attributes"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("invalid gitlab webhook payload: %w", err)
+	}
+
+	attrs := payload.ObjectAttributes
+	mr := &MergeRequest{
+		ID:        attrs.ID,
+		Number:    attrs.IID,
+		Title:     attrs.Title,
+		State:     gitlabState(attrs.State, attrs.Draft || attrs.WorkInProgress),
+		AuthorID:  attrs.AuthorID,
+		CreatedAt: parseGitlabTime(attrs.CreatedAt),
+		UpdatedAt: parseGitlabTime(attrs.UpdatedAt),
+	}
+	// The payload names the user who triggered the event, who isn't always the author
+	if payload.User.ID == attrs.AuthorID {
+		mr.AuthorName = payload.User.Username
+	}
+
+	return &WebhookEvent{
+		RepositoryID: payload.Project.ID,
+		MergeRequest: mr,
+		// "update" without oldrev is an edit of the title, labels etc., not new commits
+		Analyze: attrs.Action == "open" || attrs.Action == "reopen" || (attrs.Action == "update" && attrs.OldRev != ""),
+	}, nil
+}
+
+// get calls the API and decodes the JSON response into out, returning the response headers
+func (g *GitLab) get(ctx context.Context, token string, path string, query url.Values, out interface{}) (http.Header, error) {
+	endpoint := g.baseURL + "/api/v4" + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, &APIError{Host: models.CodeHostGitlab, StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return nil, err
+	}
+	return resp.Header, nil
+}
+
+// gitlabState maps a GitLab merge request state to a PR state
+func gitlabState(state string, draft bool) string {
+	switch state {
+	case "merged":
+		return models.PRStateMerged
+	case "closed", "locked":
+		return models.PRStateClosed
+	}
+	if draft {
+		return models.PRStateDraft
+	}
+	return models.PRStateOpen
+}
+
+// parseGitlabTime parses webhook timestamps, which older GitLab versions send as
+// "2006-01-02 15:04:05 UTC" instead of RFC 3339
+func parseGitlabTime(value string) time.Time {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t
+	}
+	if t, err := time.Parse("2006-01-02 15:04:05 MST", value); err == nil {
+		return t
+	}
+	return time.Time{}
+}
@@ -0,0 +1,178 @@
+package codehost
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+func TestGitLabNamespaceAdmin(t *testing.T) {
+	levels := map[string]int{"acme": gitlabOwner, "acme%2Fplatform": gitlabMaintainer}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for group, level := range levels {
+			if r.URL.EscapedPath() == "/api/v4/groups/"+group+"/members/all/7" {
+				fmt.Fprintf(w, `{"id":7,"access_level":%d}`, level)
+				return
+			}
+		}
+		http.Error(w, `{"message":"404 Not found"}`, http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	gitlab := NewGitLab(Config{BaseURL: server.URL})
+	account := &Account{ID: 7, Username: "alice"}
+	tests := []struct {
+		namespace string
+		kind      string
+		want      bool
+	}{
+		{"alice", models.WorkspaceKindUser, true},
+		{"bob", models.WorkspaceKindUser, false},
+		{"acme", models.WorkspaceKindOrganization, true},
+		{"acme/platform", models.WorkspaceKindOrganization, false},
+		{"other", models.WorkspaceKindOrganization, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.namespace, func(t *testing.T) {
+			got, err := gitlab.NamespaceAdmin(context.Background(), "token", account, tt.namespace, tt.kind)
+			if err != nil || got != tt.want {
+				t.Errorf("NamespaceAdmin() = %v, %v; want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestGitLabRole(t *testing.T) {
+	access := func(level int) *gitlabAccess { return &gitlabAccess{AccessLevel: level} }
+	tests := []struct {
+		name    string
+		project *gitlabAccess
+		group   *gitlabAccess
+		want    string
+	}{
+		{"guest", access(10), nil, models.RoleViewer},
+		{"reporter", access(20), nil, models.RoleViewer},
+		{"developer", access(gitlabDeveloper), nil, models.RoleMaintainer},
+		{"maintainer through the group", access(20), access(gitlabMaintainer), models.RoleOwner},
+		{"no access listed", nil, nil, models.RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var project gitlabProject
+			project.Permissions.ProjectAccess = tt.project
+			project.Permissions.GroupAccess = tt.group
+			if got := gitlabRole(project); got != tt.want {
+				t.Errorf("gitlabRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+const gitlabMergeRequestHook = `{
+	"object_kind": "merge_request",
+	"user": {"id": 7, "username": "alice"},
+	"project": {"id": 42},
+	"object_attributes": {
+		"id": 1001, "iid": 12, "title": "Add caching", "state": "opened", "action": "%s",
+		"draft": false, "author_id": 7, "oldrev": "%s",
+		"created_at": "2024-05-01 10:00:00 UTC", "updated_at": "2024-05-02T11:30:00Z"
+	}
+}`
+
+func gitlabWebhookRequest(token string, event string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/api/v1/webhooks/gitlab", nil)
+	if token != "" {
+		r.Header.Set("X-Gitlab-Token", token)
+	}
+	r.Header.Set("X-Gitlab-Event", event)
+	return r
+}
+
+func TestGitLabParseWebhookVerifiesToken(t *testing.T) {
+	body := []byte(fmt.Sprintf(gitlabMergeRequestHook, "open", ""))
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		ok     bool
+	}{
+		{"matching token", "s3cret", "s3cret", true},
+		{"wrong token", "s3cret", "guess", false},
+		{"token with a matching prefix", "s3cret", "s3cret-and-more", false},
+		{"missing token", "s3cret", "", false},
+		{"no secret configured", "", "", false},
+		{"no secret configured but a token sent", "", "anything", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gitlab := NewGitLab(Config{BaseURL: "https://gitlab.example.com", WebhookSecret: tt.secret})
+			event, err := gitlab.ParseWebhook(gitlabWebhookRequest(tt.token, "Merge Request Hook"), body)
+			if !tt.ok {
+				if !errors.Is(err, ErrInvalidWebhook) || event != nil {
+					t.Errorf("ParseWebhook() = %v, %v; want ErrInvalidWebhook", event, err)
+				}
+				return
+			}
+			if err != nil || event == nil {
+				t.Fatalf("ParseWebhook() = %v, %v", event, err)
+			}
+		})
+	}
+}
+
+func TestGitLabParseWebhook(t *testing.T) {
+	gitlab := NewGitLab(Config{BaseURL: "https://gitlab.example.com", WebhookSecret: "s3cret"})
+
+	event, err := gitlab.ParseWebhook(gitlabWebhookRequest("s3cret", "Merge Request Hook"), []byte(fmt.Sprintf(gitlabMergeRequestHook, "open", "")))
+	if err != nil || event == nil {
+		t.Fatalf("ParseWebhook() = %v, %v", event, err)
+	}
+	mr := event.MergeRequest
+	if event.RepositoryID != 42 || mr.ID != 1001 || mr.Number != 12 || mr.Title != "Add caching" ||
+		mr.State != models.PRStateOpen || mr.AuthorID != 7 || mr.AuthorName != "alice" {
+		t.Errorf("ParseWebhook() = %+v, %+v", event, mr)
+	}
+	if want := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC); !mr.CreatedAt.Equal(want) {
+		t.Errorf("CreatedAt = %v, want %v", mr.CreatedAt, want)
+	}
+	if want := time.Date(2024, 5, 2, 11, 30, 0, 0, time.UTC); !mr.UpdatedAt.Equal(want) {
+		t.Errorf("UpdatedAt = %v, want %v", mr.UpdatedAt, want)
+	}
+
+	analyze := []struct {
+		action string
+		oldRev string
+		want   bool
+	}{
+		{"open", "", true},
+		{"reopen", "", true},
+		{"update", "abc123", true},
+		{"update", "", false},
+		{"close", "", false},
+		{"merge", "", false},
+	}
+	for _, tt := range analyze {
+		body := []byte(fmt.Sprintf(gitlabMergeRequestHook, tt.action, tt.oldRev))
+		event, err := gitlab.ParseWebhook(gitlabWebhookRequest("s3cret", "Merge Request Hook"), body)
+		if err != nil {
+			t.Fatalf("ParseWebhook(%s): %v", tt.action, err)
+		}
+		if event.Analyze != tt.want {
+			t.Errorf("ParseWebhook(%s, oldrev %q) Analyze = %v, want %v", tt.action, tt.oldRev, event.Analyze, tt.want)
+		}
+	}
+
+	if event, err := gitlab.ParseWebhook(gitlabWebhookRequest("s3cret", "Push Hook"), []byte(`{}`)); err != nil || event != nil {
+		t.Errorf("ParseWebhook(Push Hook) = %v, %v; want it ignored", event, err)
+	}
+	if _, err := gitlab.ParseWebhook(gitlabWebhookRequest("s3cret", "Merge Request Hook"), []byte(`{`)); err == nil || strings.Contains(err.Error(), "token") {
+		t.Errorf("ParseWebhook(malformed) error = %v, want a payload error", err)
+	}
+}
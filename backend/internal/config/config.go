@@ -2,6 +2,7 @@ package config
 
 import (
 	"os"
+	"strings"
 
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog/log"
@@ -18,11 +19,59 @@ type Config struct {
 	GithubClientSecret  string
 	GithubWebhookSecret string
 	GithubRedirectURI   string
+	GithubAppID         string
+	GithubAppPrivateKey string
+	GithubAppKeyPath    string
+	GithubAPIURL        string // GitHub Enterprise Server, e.g. https://github.example.com/api/v3/
+	GithubUploadURL     string
+	GithubOAuthURL      string
 	FrontendURL         string
 	KestraURL           string
 	KestraUsername      string
 	KestraPassword      string
+	KestraCallbackKey   string // Signs the URLs Kestra workflows call back with results
 	BackendURL          string
+	EncryptionKeys      string
+	EncryptionKeyID     string
+	OIDCIssuer          string
+	OIDCAudience        string
+	OIDCJWKSURL         string
+	OIDCRedirectBaseURL string
+	OIDCProviders       []OIDCProviderConfig
+	GitlabURL           string
+	GitlabClientID      string
+	GitlabClientSecret  string
+	GitlabWebhookSecret string
+	CodeHostRedirectURL string
+	// Gitea or Forgejo, enabled when both the URL and an OAuth client are set
+	GiteaURL           string
+	GiteaDisplayName   string
+	GiteaClientID      string
+	GiteaClientSecret  string
+	GiteaWebhookSecret string
+	// Notifications: SMTP for email channels; webhooks may only reach public addresses unless
+	// private networks are allowed
+	SMTPHost        string
+	SMTPPort        string
+	SMTPUsername    string
+	SMTPPassword    string
+	SMTPFrom        string
+	PrivateWebhooks bool
+	// Slack app serving the /devplus command, enabled when the signing secret is set
+	SlackSigningSecret string
+	// Comma-separated proxy addresses or CIDR ranges whose forwarding headers are honoured
+	TrustedProxies string
+}
+
+// OIDCProviderConfig configures an OIDC identity provider users can log in with. Providers
+// are listed by name in OIDC_PROVIDERS and configured with OIDC_<NAME>_* variables.
+type OIDCProviderConfig struct {
+	Name         string
+	DisplayName  string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
 }
 
 func LoadConfig() *Config {
@@ -41,12 +90,66 @@ func LoadConfig() *Config {
 		GithubClientSecret:  getEnv("GITHUB_CLIENT_SECRET", ""),
 		GithubWebhookSecret: getEnv("GITHUB_WEBHOOK_SECRET", ""),
 		GithubRedirectURI:   getEnv("GITHUB_REDIRECT_URI", ""),
+		GithubAppID:         getEnv("GITHUB_APP_ID", ""),
+		GithubAppPrivateKey: getEnv("GITHUB_APP_PRIVATE_KEY", ""),
+		GithubAppKeyPath:    getEnv("GITHUB_APP_PRIVATE_KEY_PATH", ""),
+		GithubAPIURL:        getEnv("GITHUB_API_URL", ""),
+		GithubUploadURL:     getEnv("GITHUB_UPLOAD_URL", ""),
+		GithubOAuthURL:      getEnv("GITHUB_OAUTH_URL", ""),
 		FrontendURL:         getEnv("FRONTEND_URL", "http://localhost:3000/dashboard"),
 		KestraURL:           getEnv("KESTRA_URL", "http://localhost:8080"),
 		KestraUsername:      getEnv("KESTRA_USERNAME", ""),
 		KestraPassword:      getEnv("KESTRA_PASSWORD", ""),
+		KestraCallbackKey:   getEnv("KESTRA_CALLBACK_SECRET", ""),
 		BackendURL:          getEnv("BACKEND_URL", "http://host.docker.internal:8080"),
+		EncryptionKeys:      getEnv("TOKEN_ENCRYPTION_KEYS", ""),
+		EncryptionKeyID:     getEnv("TOKEN_ENCRYPTION_KEY_ID", ""),
+		OIDCIssuer:          getEnv("OIDC_ISSUER", ""),
+		OIDCAudience:        getEnv("OIDC_AUDIENCE", ""),
+		OIDCJWKSURL:         getEnv("OIDC_JWKS_URL", ""),
+		OIDCRedirectBaseURL: getEnv("OIDC_REDIRECT_BASE_URL", "http://localhost:8081/api/v1/auth/sso"),
+		OIDCProviders:       loadOIDCProviders(),
+		GitlabURL:           getEnv("GITLAB_URL", "https://gitlab.com"),
+		GitlabClientID:      getEnv("GITLAB_CLIENT_ID", ""),
+		GitlabClientSecret:  getEnv("GITLAB_CLIENT_SECRET", ""),
+		GitlabWebhookSecret: getEnv("GITLAB_WEBHOOK_SECRET", ""),
+		CodeHostRedirectURL: getEnv("CODE_HOST_REDIRECT_BASE_URL", "http://localhost:8081/api/v1/auth/hosts"),
+		GiteaURL:            getEnv("GITEA_URL", ""),
+		GiteaDisplayName:    getEnv("GITEA_DISPLAY_NAME", "Gitea"),
+		GiteaClientID:       getEnv("GITEA_CLIENT_ID", ""),
+		GiteaClientSecret:   getEnv("GITEA_CLIENT_SECRET", ""),
+		GiteaWebhookSecret:  getEnv("GITEA_WEBHOOK_SECRET", ""),
+		SMTPHost:            getEnv("SMTP_HOST", ""),
+		SMTPPort:            getEnv("SMTP_PORT", "587"),
+		SMTPUsername:        getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:        getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:            getEnv("SMTP_FROM", ""),
+		PrivateWebhooks:     getEnv("WEBHOOK_ALLOW_PRIVATE_NETWORKS", "false") == "true",
+		SlackSigningSecret:  getEnv("SLACK_SIGNING_SECRET", ""),
+		TrustedProxies:      getEnv("TRUSTED_PROXIES", ""),
+	}
+}
+
+// loadOIDCProviders reads the providers named in OIDC_PROVIDERS (comma-separated), e.g.
+// OIDC_PROVIDERS=okta with OIDC_OKTA_ISSUER, OIDC_OKTA_CLIENT_ID and OIDC_OKTA_CLIENT_SECRET
+func loadOIDCProviders() []OIDCProviderConfig {
+	var providers []OIDCProviderConfig
+	for _, name := range strings.Split(getEnv("OIDC_PROVIDERS", ""), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providers = append(providers, OIDCProviderConfig{
+			Name:         name,
+			DisplayName:  getEnv(prefix+"DISPLAY_NAME", name),
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			Scopes:       strings.Fields(getEnv(prefix+"SCOPES", "openid email profile")),
+		})
 	}
+	return providers
 }
 
 func getEnv(key, fallback string) string {
@@ -2,10 +2,15 @@ package rest
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/gorilla/mux"
+
+	"devplus-backend/internal/codehost"
 	"devplus-backend/internal/middleware"
 	"devplus-backend/internal/models"
 	"devplus-backend/internal/services/auth_service"
@@ -19,9 +24,20 @@ func NewAuthController(service *auth_service.AuthService) *AuthController {
 	return &AuthController{service: service}
 }
 
+// Login starts the GitHub OAuth flow. The frontend passes the S256 code_challenge of a
+// verifier it keeps, which it must present to exchange the resulting login code.
 func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
-	authURL, err := c.service.InitiateLogin()
+	if method := r.URL.Query().Get("code_challenge_method"); method != "" && method != "S256" {
+		http.Error(w, "Unsupported code_challenge_method, only S256 is allowed", http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := c.service.InitiateLogin(r.URL.Query().Get("code_challenge"))
 	if err != nil {
+		if errors.Is(err, auth_service.ErrInvalidCodeChallenge) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "Failed to initiate login", http.StatusInternalServerError)
 		return
 	}
@@ -38,20 +54,279 @@ func (c *AuthController) Callback(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	session, err := c.service.HandleCallback(code, state)
+	loginCode, err := c.service.HandleCallback(r.Context(), code, state)
+	if err != nil {
+		if errors.Is(err, auth_service.ErrGithubAccountInUse) {
+			frontendLoginURL := fmt.Sprintf("%s/login?error=github_account_in_use", c.service.Config.FrontendURL)
+			http.Redirect(w, r, frontendLoginURL, http.StatusTemporaryRedirect)
+			return
+		}
+		http.Error(w, "Authentication failed: "+err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	// Only a short-lived, single-use code goes in the URL; the frontend exchanges it for
+	// the session tokens with a POST to /auth/exchange
+	frontendCallbackURL := fmt.Sprintf("%s/auth/callback?code=%s", c.service.Config.FrontendURL, url.QueryEscape(loginCode))
+	http.Redirect(w, r, frontendCallbackURL, http.StatusTemporaryRedirect)
+}
+
+// Providers lists the identity providers the login page can offer
+func (c *AuthController) Providers(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.service.Providers())
+}
+
+// SSOLogin starts a login with an OIDC provider, bound to the frontend's PKCE challenge like
+// the GitHub login
+func (c *AuthController) SSOLogin(w http.ResponseWriter, r *http.Request) {
+	if method := r.URL.Query().Get("code_challenge_method"); method != "" && method != "S256" {
+		http.Error(w, "Unsupported code_challenge_method, only S256 is allowed", http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := c.service.InitiateSSOLogin(r.Context(), mux.Vars(r)["provider"], r.URL.Query().Get("code_challenge"))
+	if err != nil {
+		switch {
+		case errors.Is(err, auth_service.ErrUnknownProvider):
+			http.Error(w, "Unknown identity provider", http.StatusNotFound)
+		case errors.Is(err, auth_service.ErrInvalidCodeChallenge):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to initiate login", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+// SSOCallback completes an OIDC login and redirects to the frontend with a login code
+func (c *AuthController) SSOCallback(w http.ResponseWriter, r *http.Request) {
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		frontendLoginURL := fmt.Sprintf("%s/login?error=%s", c.service.Config.FrontendURL, url.QueryEscape(providerErr))
+		http.Redirect(w, r, frontendLoginURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+
+	if code == "" || state == "" {
+		http.Error(w, "Missing code or state", http.StatusBadRequest)
+		return
+	}
+
+	loginCode, err := c.service.HandleSSOCallback(r.Context(), mux.Vars(r)["provider"], code, state)
 	if err != nil {
+		if errors.Is(err, auth_service.ErrUnknownProvider) {
+			http.Error(w, "Unknown identity provider", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "Authentication failed: "+err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	// Return session token in URL for frontend to store
-	// This avoids all cross-origin cookie issues
-	frontendCallbackURL := fmt.Sprintf("%s/auth/callback?token=%s",
-		c.service.Config.FrontendURL, session.ID)
+	frontendCallbackURL := fmt.Sprintf("%s/auth/callback?code=%s", c.service.Config.FrontendURL, url.QueryEscape(loginCode))
+	http.Redirect(w, r, frontendCallbackURL, http.StatusTemporaryRedirect)
+}
+
+// LinkGithub returns the GitHub authorization URL for the signed-in user to link their GitHub
+// account. The frontend navigates there; the callback then redirects with a login code as usual.
+func (c *AuthController) LinkGithub(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		CodeChallenge string `json:"code_challenge"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := c.service.InitiateGithubLink(r.Context(), user.ID, req.CodeChallenge)
+	if err != nil {
+		if errors.Is(err, auth_service.ErrInvalidCodeChallenge) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "Failed to initiate GitHub link", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"url": authURL})
+}
+
+// ListIdentities returns the SSO identities linked to the user
+func (c *AuthController) ListIdentities(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	identities, err := c.service.ListIdentities(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identities)
+}
+
+// CodeHostLogin starts a login with a code host such as GitLab, bound to the frontend's PKCE
+// challenge like the GitHub login
+func (c *AuthController) CodeHostLogin(w http.ResponseWriter, r *http.Request) {
+	if method := r.URL.Query().Get("code_challenge_method"); method != "" && method != "S256" {
+		http.Error(w, "Unsupported code_challenge_method, only S256 is allowed", http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := c.service.InitiateCodeHostLogin(r.Context(), mux.Vars(r)["provider"], r.URL.Query().Get("code_challenge"))
+	if err != nil {
+		switch {
+		case errors.Is(err, codehost.ErrUnknownHost):
+			http.Error(w, "Unknown code host", http.StatusNotFound)
+		case errors.Is(err, auth_service.ErrInvalidCodeChallenge):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to initiate login", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	http.Redirect(w, r, authURL, http.StatusTemporaryRedirect)
+}
+
+// CodeHostCallback completes a code host login or link and redirects to the frontend with a
+// login code
+func (c *AuthController) CodeHostCallback(w http.ResponseWriter, r *http.Request) {
+	if providerErr := r.URL.Query().Get("error"); providerErr != "" {
+		frontendLoginURL := fmt.Sprintf("%s/login?error=%s", c.service.Config.FrontendURL, url.QueryEscape(providerErr))
+		http.Redirect(w, r, frontendLoginURL, http.StatusTemporaryRedirect)
+		return
+	}
+
+	code := r.URL.Query().Get("code")
+	state := r.URL.Query().Get("state")
+
+	if code == "" || state == "" {
+		http.Error(w, "Missing code or state", http.StatusBadRequest)
+		return
+	}
+
+	loginCode, err := c.service.HandleCodeHostCallback(r.Context(), mux.Vars(r)["provider"], code, state)
+	if err != nil {
+		switch {
+		case errors.Is(err, codehost.ErrUnknownHost):
+			http.Error(w, "Unknown code host", http.StatusNotFound)
+		case errors.Is(err, auth_service.ErrCodeHostAccountInUse):
+			frontendLoginURL := fmt.Sprintf("%s/login?error=account_in_use", c.service.Config.FrontendURL)
+			http.Redirect(w, r, frontendLoginURL, http.StatusTemporaryRedirect)
+		default:
+			http.Error(w, "Authentication failed: "+err.Error(), http.StatusUnauthorized)
+		}
+		return
+	}
+
+	frontendCallbackURL := fmt.Sprintf("%s/auth/callback?code=%s", c.service.Config.FrontendURL, url.QueryEscape(loginCode))
 	http.Redirect(w, r, frontendCallbackURL, http.StatusTemporaryRedirect)
 }
 
+// LinkCodeHost returns the code host's authorization URL for the signed-in user to link their
+// account on the host, like LinkGithub
+func (c *AuthController) LinkCodeHost(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		CodeChallenge string `json:"code_challenge"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	authURL, err := c.service.InitiateCodeHostLink(r.Context(), user.ID, mux.Vars(r)["provider"], req.CodeChallenge)
+	if err != nil {
+		switch {
+		case errors.Is(err, codehost.ErrUnknownHost):
+			http.Error(w, "Unknown code host", http.StatusNotFound)
+		case errors.Is(err, auth_service.ErrInvalidCodeChallenge):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to initiate account link", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"url": authURL})
+}
+
+// ListCodeHostAccounts returns the code host accounts linked to the user
+func (c *AuthController) ListCodeHostAccounts(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := c.service.ListCodeHostAccounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// Exchange redeems the login code from the callback redirect, together with the PKCE
+// verifier the login was started with, for session and refresh tokens
+func (c *AuthController) Exchange(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Code         string `json:"code"`
+		CodeVerifier string `json:"code_verifier"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" || req.CodeVerifier == "" {
+		http.Error(w, "code and code_verifier are required", http.StatusBadRequest)
+		return
+	}
+
+	issued, err := c.service.ExchangeLoginCode(r.Context(), req.Code, req.CodeVerifier, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, auth_service.ErrInvalidLoginCode) {
+			http.Error(w, "Unauthorized: Invalid or expired login code", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(issued)
+}
+
+// Logout deletes the session of the bearer token so it can't be used again
 func (c *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
+	if token, ok := middleware.BearerToken(r); ok {
+		if err := c.service.Logout(r.Context(), token); err != nil {
+			http.Error(w, "Failed to logout: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
 	http.SetCookie(w, &http.Cookie{
 		Name:     "session_token",
 		Value:    "",
@@ -63,8 +338,32 @@ func (c *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte("Logged out"))
 }
 
+// Refresh exchanges a refresh token for new session and refresh tokens
+func (c *AuthController) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RefreshToken == "" {
+		http.Error(w, "refresh_token is required", http.StatusBadRequest)
+		return
+	}
+
+	issued, err := c.service.RefreshSession(r.Context(), req.RefreshToken, clientInfo(r))
+	if err != nil {
+		if errors.Is(err, auth_service.ErrInvalidSession) {
+			http.Error(w, "Unauthorized: Invalid or expired refresh token", http.StatusUnauthorized)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(issued)
+}
+
 func (c *AuthController) GetCurrentUser(w http.ResponseWriter, r *http.Request) {
-	// Retrieve User from Context (populated by SessionMiddleware)
+	// Retrieve User from Context (populated by Authenticate)
 	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
 	if !ok {
 		http.Error(w, "Unauthorized", http.StatusUnauthorized)
@@ -74,3 +373,228 @@ func (c *AuthController) GetCurrentUser(w http.ResponseWriter, r *http.Request)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(user)
 }
+
+// GetStatus tells the frontend whether the user's GitHub authorization is still usable or
+// they have to log in again. Logging in again starts a new PKCE login, so no URL is returned.
+func (c *AuthController) GetStatus(w http.ResponseWriter, r *http.Request) {
+	// Retrieve User from Context (populated by Authenticate, which refreshes expiring tokens)
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	status := map[string]interface{}{
+		"authenticated":    true,
+		"needs_reauth":     user.NeedsReauth,
+		"token_expires_at": user.TokenExpiresAt,
+		"github_linked":    user.GithubID != nil,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// ListSessions returns the user's active sessions; the requesting one is marked current
+func (c *AuthController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	sessions, err := c.service.ListSessions(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	currentID, _ := r.Context().Value(middleware.SessionIDContextKey).(string)
+	for _, session := range sessions {
+		session.Current = session.ID == currentID
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// RevokeSession signs out one of the user's sessions
+func (c *AuthController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.RevokeSession(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		if errors.Is(err, auth_service.ErrSessionNotFound) {
+			http.Error(w, "Session not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RevokeAllSessions signs out all of the user's sessions, or all others with keep_current=true
+func (c *AuthController) RevokeAllSessions(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	keepSessionID := ""
+	if r.URL.Query().Get("keep_current") == "true" {
+		keepSessionID, _ = r.Context().Value(middleware.SessionIDContextKey).(string)
+	}
+
+	revoked, err := c.service.RevokeAllSessions(r.Context(), user.ID, keepSessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int64{"revoked": revoked})
+}
+
+// clientInfo describes the requesting device for session listings
+func clientInfo(r *http.Request) auth_service.ClientInfo {
+	return auth_service.ClientInfo{UserAgent: r.UserAgent(), IPAddress: middleware.ClientIP(r)}
+}
+
+// ListAPITokens returns the user's personal access tokens (without their secrets)
+func (c *AuthController) ListAPITokens(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	tokens, err := c.service.ListAPITokens(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tokens)
+}
+
+// CreateAPIToken creates a personal access token. The raw token is only returned here.
+func (c *AuthController) CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expires_in_days"` // 0 creates a token that doesn't expire
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		http.Error(w, "expires_in_days must not be negative", http.StatusBadRequest)
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		t := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &t
+	}
+
+	issued, err := c.service.CreateAPIToken(r.Context(), user.ID, req.Name, req.Scopes, expiresAt)
+	if err != nil {
+		if errors.Is(err, auth_service.ErrInvalidAPITokenRequest) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(issued)
+}
+
+// GetAPIToken returns one of the user's personal access tokens
+func (c *AuthController) GetAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	token, err := c.service.GetAPIToken(r.Context(), user.ID, mux.Vars(r)["id"])
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// UpdateAPIToken renames a personal access token or replaces its scopes
+func (c *AuthController) UpdateAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Name   *string  `json:"name"`
+		Scopes []string `json:"scopes"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := c.service.UpdateAPIToken(r.Context(), user.ID, mux.Vars(r)["id"], req.Name, req.Scopes)
+	if err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(token)
+}
+
+// RevokeAPIToken deletes a personal access token
+func (c *AuthController) RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.RevokeAPIToken(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		writeAPITokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAPITokenError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, auth_service.ErrAPITokenNotFound):
+		http.Error(w, "API token not found", http.StatusNotFound)
+	case errors.Is(err, auth_service.ErrInvalidAPITokenRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
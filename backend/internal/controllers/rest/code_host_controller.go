@@ -0,0 +1,117 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/codehost"
+	"devplus-backend/internal/interfaces"
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/auth_service"
+	"devplus-backend/internal/services/code_host_service"
+)
+
+// PullRequestAnalyzer triggers the AI analysis of a pull request
+type PullRequestAnalyzer interface {
+	AnalyzePullRequest(ctx context.Context, repoID string, prNumber int) error
+}
+
+// CodeHostController serves syncing and webhooks for code hosts other than GitHub
+type CodeHostController struct {
+	service  interfaces.CodeHostService
+	analyzer PullRequestAnalyzer
+}
+
+func NewCodeHostController(service interfaces.CodeHostService, analyzer PullRequestAnalyzer) *CodeHostController {
+	return &CodeHostController{
+		service:  service,
+		analyzer: analyzer,
+	}
+}
+
+// SyncRepositories syncs the repositories and merge requests the user can access on the host
+func (c *CodeHostController) SyncRepositories(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User from context
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service (Fetch from the host & Upsert)
+	provider := mux.Vars(r)["provider"]
+	repos, err := c.service.SyncRepositories(r.Context(), user.ID, provider)
+	if err != nil {
+		log.Error().Err(err).Str("provider", provider).Msg("[CodeHostController.SyncRepositories] Service error")
+		writeCodeHostError(w, err)
+		return
+	}
+
+	// 3. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repos)
+}
+
+// HandleWebhook records merge request events delivered by the host and analyses merge requests
+// that were opened or received new commits
+func (c *CodeHostController) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	provider := mux.Vars(r)["provider"]
+
+	// 1. Verify the delivery and upsert the merge request
+	pr, analyze, err := c.service.HandleWebhook(ctx, provider, r, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, codehost.ErrInvalidWebhook):
+			http.Error(w, "Unauthorized: Invalid webhook token", http.StatusUnauthorized)
+		case errors.Is(err, codehost.ErrUnknownHost):
+			http.Error(w, "Unknown code host", http.StatusNotFound)
+		case errors.Is(err, code_host_service.ErrUnknownRepository):
+			http.Error(w, "Repository not found", http.StatusNotFound)
+		default:
+			log.Error().Err(err).Str("provider", provider).Msg("[CodeHostController.HandleWebhook] Failed to handle webhook")
+			http.Error(w, "Failed to handle webhook: "+err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+	if pr == nil || !analyze {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// 2. Trigger AI Analysis
+	if err := c.analyzer.AnalyzePullRequest(ctx, *pr.RepoID, int(*pr.Number)); err != nil {
+		http.Error(w, "Failed to trigger analysis: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// writeCodeHostError responds to code host errors. Token problems get 403 rather than 401:
+// the DevPlus session is fine, the user has to (re)link the account on the host.
+func writeCodeHostError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, codehost.ErrUnknownHost):
+		http.Error(w, "Unknown code host", http.StatusNotFound)
+	case errors.Is(err, auth_service.ErrCodeHostNotLinked):
+		http.Error(w, "Forbidden: link an account on this code host first", http.StatusForbidden)
+	case errors.Is(err, auth_service.ErrCodeHostReauthRequired), codehost.IsUnauthorized(err):
+		http.Error(w, "Forbidden: code host authorization expired or was revoked, please link the account again", http.StatusForbidden)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,69 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"devplus-backend/internal/services/ai"
+	"devplus-backend/internal/services/notification_service"
+)
+
+type recordingNotifier struct {
+	events []notification_service.Event
+}
+
+func (n *recordingNotifier) Notify(ctx context.Context, event notification_service.Event) error {
+	n.events = append(n.events, event)
+	return nil
+}
+
+// Callbacks that fail verification are rejected before the analysis is stored or any
+// notification event is emitted; the controller has no service to reach
+func TestAICallbacksWithoutValidSignatureNotifyNobody(t *testing.T) {
+	notifier := &recordingNotifier{}
+	signer := ai.NewCallbackSigner("callback-secret")
+	c := NewGithubController(nil, nil, nil, notifier, signer, "webhook-secret")
+
+	forged, err := ai.NewCallbackSigner("guessed-secret").SignURL("http://backend/api/v1/webhook/ai", ai.CallbackPRAnalysis, "pr-1")
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+	otherPR, err := signer.SignURL("http://backend/api/v1/webhook/ai", ai.CallbackPRAnalysis, "pr-2")
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+	otherRun, err := signer.SignURL("http://backend/api/v1/webhook/release-risk?run_id=run-1", ai.CallbackReleaseRisk, "run-2")
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+
+	analysis := `"raw_analysis":"{\"summary\":\"LGTM\",\"decision\":\"APPROVE\",\"changelog\":\"-\",\"risk_score\":1}"`
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		url     string
+		body    string
+	}{
+		{"pr analysis unsigned", c.HandleAIWebhook, "/api/v1/webhook/ai", `{"pr_id":"pr-1",` + analysis + `}`},
+		{"pr analysis forged", c.HandleAIWebhook, forged, `{"pr_id":"pr-1",` + analysis + `}`},
+		{"pr analysis for another pull request", c.HandleAIWebhook, otherPR, `{"pr_id":"pr-1",` + analysis + `}`},
+		{"repo analysis unsigned", c.HandleRepoAIWebhook, "/api/v1/webhook/ai/repo", `{"repo_id":"repo-1",` + analysis + `}`},
+		{"release risk unsigned", c.HandleReleaseRiskCallback, "/api/v1/webhook/release-risk?run_id=run-1", `{"repository_id":"repo-1",` + analysis + `}`},
+		{"release risk signed for another run", c.HandleReleaseRiskCallback, otherRun, `{"repository_id":"repo-1",` + analysis + `}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			tt.handler(w, httptest.NewRequest("POST", tt.url, strings.NewReader(tt.body)))
+			if w.Code != http.StatusUnauthorized {
+				t.Errorf("status %d, want %d", w.Code, http.StatusUnauthorized)
+			}
+		})
+	}
+	if len(notifier.events) != 0 {
+		t.Errorf("unauthenticated callbacks emitted %d notification events", len(notifier.events))
+	}
+}
@@ -2,8 +2,13 @@ package rest
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"regexp"
 	"strconv"
@@ -15,18 +20,83 @@ import (
 	"devplus-backend/internal/interfaces"
 	"devplus-backend/internal/middleware"
 	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/ai"
+	"devplus-backend/internal/services/github_service"
+	"devplus-backend/internal/services/notification_service"
 )
 
+// ReauthMarker flags users whose GitHub token was rejected so they are asked to log in again
+type ReauthMarker interface {
+	MarkReauthRequired(ctx context.Context, userID string) error
+}
+
+// EventNotifier sends notifications about analysis, risk and sync events to subscribed channels
+type EventNotifier interface {
+	Notify(ctx context.Context, event notification_service.Event) error
+}
+
+// EventNotifiers sends each event to several notifiers, e.g. subscribed channels and Slack
+// commands waiting for the result
+type EventNotifiers []EventNotifier
+
+func (n EventNotifiers) Notify(ctx context.Context, event notification_service.Event) error {
+	var errs []error
+	for _, notifier := range n {
+		if err := notifier.Notify(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
 type GithubController struct {
-	service interfaces.GithubService
+	service       interfaces.GithubService
+	reauth        ReauthMarker
+	codeHosts     interfaces.CodeHostService
+	notifier      EventNotifier
+	callbacks     *ai.CallbackSigner
+	webhookSecret string
 }
 
-func NewGithubController(service interfaces.GithubService) *GithubController {
+func NewGithubController(service interfaces.GithubService, reauth ReauthMarker, codeHosts interfaces.CodeHostService, notifier EventNotifier, callbacks *ai.CallbackSigner, webhookSecret string) *GithubController {
 	return &GithubController{
-		service: service,
+		service:       service,
+		reauth:        reauth,
+		codeHosts:     codeHosts,
+		notifier:      notifier,
+		callbacks:     callbacks,
+		webhookSecret: webhookSecret,
 	}
 }
 
+// notify sends notifications about an event. Failures are only logged: the analysis was
+// stored and the callback must still succeed.
+func (c *GithubController) notify(ctx context.Context, event notification_service.Event) {
+	if err := c.notifier.Notify(ctx, event); err != nil {
+		log.Error().Err(err).Str("event", event.Type).Msg("[GithubController.notify] Failed to send notifications")
+	}
+}
+
+// handleGithubUnauthorized responds 401 and flags the user for re-login when GitHub rejected
+// their token (revoked or expired). It reports whether err was handled.
+func (c *GithubController) handleGithubUnauthorized(w http.ResponseWriter, r *http.Request, err error) bool {
+	if !github_service.IsGithubUnauthorized(err) {
+		return false
+	}
+	if user, ok := r.Context().Value(middleware.UserContextKey).(models.User); ok {
+		if user.GithubID == nil {
+			// SSO users without a linked GitHub account have no token to re-authorize
+			http.Error(w, "Forbidden: link a GitHub account to use this endpoint", http.StatusForbidden)
+			return true
+		}
+		if markErr := c.reauth.MarkReauthRequired(r.Context(), user.ID); markErr != nil {
+			log.Error().Err(markErr).Str("user_id", user.ID).Msg("[handleGithubUnauthorized] Failed to flag user for re-authentication")
+		}
+	}
+	http.Error(w, "Unauthorized: GitHub authorization expired or was revoked, please log in again", http.StatusUnauthorized)
+	return true
+}
+
 func (c *GithubController) GetRepositories(w http.ResponseWriter, r *http.Request) {
 	// 1. Get User ID from context
 	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
@@ -95,6 +165,9 @@ func (c *GithubController) SyncRepositories(w http.ResponseWriter, r *http.Reque
 	// 2. Call Service (Fetch from GitHub & Upsert)
 	repos, err := c.service.SyncRepositories(r.Context(), user.ID, token)
 	if err != nil {
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -117,6 +190,26 @@ func (c *GithubController) SyncRepository(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Repositories on other code hosts sync with the user's token for that host
+	repo, err := c.service.GetRepository(r.Context(), "", id)
+	if err != nil {
+		http.Error(w, "Repository not found", http.StatusNotFound)
+		return
+	}
+	if !repo.IsGithub() {
+		user, _ := r.Context().Value(middleware.UserContextKey).(models.User)
+		prs, err := c.codeHosts.SyncPullRequests(r.Context(), user.ID, id)
+		if err != nil {
+			log.Error().Err(err).Str("provider", repo.Provider).Msg("[SyncRepository] Service error")
+			writeCodeHostError(w, err)
+			return
+		}
+		c.notify(r.Context(), notification_service.Event{Type: models.EventSyncCompleted, Repository: repo, PRCount: len(prs)})
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(prs)
+		return
+	}
+
 	// 2. Get Token
 	token, ok := r.Context().Value(middleware.GithubTokenContextKey).(string)
 	if !ok || token == "" {
@@ -130,11 +223,15 @@ func (c *GithubController) SyncRepository(w http.ResponseWriter, r *http.Request
 	prs, err := c.service.SyncPullRequests(r.Context(), id, token)
 	if err != nil {
 		log.Error().Err(err).Msg("[SyncRepository] Service error")
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	log.Info().Int("count", len(prs)).Msg("[SyncRepository] Success. Returning PRs")
+	c.notify(r.Context(), notification_service.Event{Type: models.EventSyncCompleted, Repository: repo, PRCount: len(prs)})
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(prs)
 }
@@ -232,6 +329,9 @@ func (c *GithubController) GetPersonalMetrics(w http.ResponseWriter, r *http.Req
 	// 4. Call Service to fetch personal metrics from GitHub
 	metrics, err := c.service.GetPersonalMetrics(r.Context(), userVal.ID, token, userVal.Username, days)
 	if err != nil {
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
+		}
 		log.Error().Err(err).Msg("Failed to fetch personal metrics")
 		http.Error(w, "Failed to fetch personal metrics: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -242,6 +342,135 @@ func (c *GithubController) GetPersonalMetrics(w http.ResponseWriter, r *http.Req
 	json.NewEncoder(w).Encode(metrics)
 }
 
+func (c *GithubController) GetMetricsTimeSeries(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Query Params
+	query := r.URL.Query()
+	metricType := query.Get("type")
+	if metricType == "" {
+		http.Error(w, "type is required", http.StatusBadRequest)
+		return
+	}
+	filter := models.MetricsFilter{
+		RepoID: query.Get("repo_id"),
+	}
+	if startDate := query.Get("start_date"); startDate != "" {
+		filter.StartDate = &startDate
+	}
+	if endDate := query.Get("end_date"); endDate != "" {
+		filter.EndDate = &endDate
+	}
+
+	// 3. Call Service
+	series, err := c.service.GetMetricsTimeSeries(r.Context(), userVal.ID, metricType, query.Get("interval"), filter)
+	if err != nil {
+		if errors.Is(err, github_service.ErrInvalidMetricsQuery) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(series)
+}
+
+func (c *GithubController) GetCycleTimeMetrics(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse Query Params
+	query := r.URL.Query()
+	filter := models.MetricsFilter{
+		RepoID: query.Get("repo_id"),
+	}
+	if startDate := query.Get("start_date"); startDate != "" {
+		filter.StartDate = &startDate
+	}
+	if endDate := query.Get("end_date"); endDate != "" {
+		filter.EndDate = &endDate
+	}
+
+	// 3. Call Service
+	report, err := c.service.GetCycleTimeMetrics(r.Context(), userVal.ID, filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func (c *GithubController) GetDoraMetrics(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Get GitHub token from context
+	token, ok := r.Context().Value(middleware.GithubTokenContextKey).(string)
+	if !ok || token == "" {
+		http.Error(w, "GitHub token not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 3. Parse Query Params
+	query := r.URL.Query()
+	filter := models.MetricsFilter{
+		RepoID:      query.Get("repo_id"),
+		Environment: query.Get("environment"),
+	}
+	if startDate := query.Get("start_date"); startDate != "" {
+		filter.StartDate = &startDate
+	}
+	if endDate := query.Get("end_date"); endDate != "" {
+		filter.EndDate = &endDate
+	}
+
+	// 4. Call Service
+	report, err := c.service.GetDoraMetrics(r.Context(), userVal.ID, token, filter)
+	if err != nil {
+		if errors.Is(err, github_service.ErrInvalidDateRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, github_service.ErrRepositoryNotFound) {
+			http.Error(w, "Repository not found", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, github_service.ErrUnsupportedCodeHost) {
+			http.Error(w, "DORA metrics are "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
+		}
+		log.Error().Err(err).Msg("Failed to compute DORA metrics")
+		http.Error(w, "Failed to compute DORA metrics: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 5. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
 func (c *GithubController) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	// 1. Get User ID from context
 	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
@@ -419,6 +648,13 @@ func (c *GithubController) HandleAIWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// Only the workflow started for this pull request can report its analysis
+	if !c.callbacks.Verify(r, ai.CallbackPRAnalysis, payload.PRID) {
+		log.Warn().Str("pr_id", payload.PRID).Msg("[HandleAIWebhook] Rejected callback with an invalid signature")
+		http.Error(w, "Invalid callback signature", http.StatusUnauthorized)
+		return
+	}
+
 	if payload.RawAnalysis == "" {
 		log.Error().Msg("[HandleAIWebhook] raw_analysis is missing")
 		http.Error(w, "raw_analysis is required", http.StatusBadRequest)
@@ -474,6 +710,16 @@ func (c *GithubController) HandleAIWebhook(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	
+	// Post the analysis to GitHub as the App installation (skipped for repositories without one)
+	if err := c.service.PostPullRequestReview(ctx, payload.PRID); err != nil {
+		log.Error().Err(err).Str("pr_id", payload.PRID).Msg("[HandleAIWebhook] Failed to post review")
+	}
+
+	// Resume release risk runs that were waiting for this review
+	if err := c.service.ReleaseRiskPRAnalyzed(ctx, payload.PRID); err != nil {
+		log.Error().Err(err).Str("pr_id", payload.PRID).Msg("[HandleAIWebhook] Failed to resume release risk runs")
+	}
+
 	// Notify all connected SSE clients
 	pr, err := c.service.GetPullRequestByID(ctx, payload.PRID)
 	if err == nil && pr != nil {
@@ -486,6 +732,17 @@ func (c *GithubController) HandleAIWebhook(w http.ResponseWriter, r *http.Reques
 		}
 		notificationJSON, _ := json.Marshal(notificationData)
 		GlobalSSEManager.NotifyClients(prKey, FormatSSEMessage(string(notificationJSON)))
+
+		// Notify subscribed channels
+		if pr.Repository != nil {
+			c.notify(ctx, notification_service.Event{
+				Type:        models.EventPRAnalyzed,
+				Repository:  pr.Repository,
+				PullRequest: pr,
+				Decision:    aiResponse.Decision,
+				Summary:     aiResponse.Summary,
+			})
+		}
 	}
 	
 	w.WriteHeader(http.StatusOK)
@@ -500,6 +757,8 @@ func (c *GithubController) HandleGithubWebhook(w http.ResponseWriter, r *http.Re
 			Number int64  `json:"number"`
 			Title  string `json:"title"`
 			State  string `json:"state"`
+			Draft  bool   `json:"draft"`
+			Merged bool   `json:"merged"`
 			User   struct {
 				ID    int64  `json:"id"`
 				Login string `json:"login"`
@@ -510,14 +769,53 @@ func (c *GithubController) HandleGithubWebhook(w http.ResponseWriter, r *http.Re
 		} `json:"repository"`
 	}
 
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+
+	// Every event, including pushes and installation changes, must be signed by GitHub
+	if !validGithubSignature(body, r.Header.Get("X-Hub-Signature-256"), c.webhookSecret) {
+		log.Warn().Str("event", r.Header.Get("X-GitHub-Event")).Msg("[HandleGithubWebhook] Rejected webhook with an invalid signature")
+		http.Error(w, "Invalid webhook signature", http.StatusUnauthorized)
+		return
+	}
+
+	// Push events only carry commits to ingest
+	if r.Header.Get("X-GitHub-Event") == "push" {
+		if err := c.service.IngestPushEvent(r.Context(), body); err != nil {
+			log.Error().Err(err).Msg("[HandleGithubWebhook] Failed to ingest pushed commits")
+			http.Error(w, "Failed to ingest commits: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	// GitHub App installation changes link repositories to installation tokens
+	if event := r.Header.Get("X-GitHub-Event"); event == "installation" || event == "installation_repositories" {
+		if err := c.service.HandleInstallationEvent(r.Context(), event, body); err != nil {
+			log.Error().Err(err).Str("event", event).Msg("[HandleGithubWebhook] Failed to handle installation event")
+			http.Error(w, "Failed to handle installation event: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	var payload WebhookPayload
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+	if err := json.Unmarshal(body, &payload); err != nil {
 		http.Error(w, "Invalid payload", http.StatusBadRequest)
 		return
 	}
 
-	// Only process "opened" or "synchronize" (re-analysis on update)
-	if payload.Action != "opened" && payload.Action != "synchronize" {
+	// Analyse on "opened" or "synchronize" (re-analysis on update); other
+	// lifecycle actions only keep the stored state in sync
+	analyze := payload.Action == "opened" || payload.Action == "synchronize"
+	switch payload.Action {
+	case "opened", "synchronize", "closed", "reopened", "ready_for_review", "converted_to_draft", "edited":
+	default:
 		w.WriteHeader(http.StatusOK) // Ignore other actions
 		return
 	}
@@ -533,11 +831,12 @@ func (c *GithubController) HandleGithubWebhook(w http.ResponseWriter, r *http.Re
 	}
 
 	// 2. Upsert Pull Request
+	state := models.PullRequestState(payload.PullRequest.State, payload.PullRequest.Draft, payload.PullRequest.Merged)
 	pr := &models.PullRequest{
 		GithubPRID: &payload.PullRequest.ID,
 		Number:     &payload.PullRequest.Number,
 		Title:      &payload.PullRequest.Title,
-		State:      &payload.PullRequest.State,
+		State:      &state,
 		RepoID:     &repo.ID,
 		AuthorID:   &payload.PullRequest.User.ID,
 		AuthorName: &payload.PullRequest.User.Login,
@@ -548,6 +847,11 @@ func (c *GithubController) HandleGithubWebhook(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	if !analyze {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
 	// 3. Trigger AI Analysis
 	if err := c.service.AnalyzePullRequest(ctx, repo.ID, int(*pr.Number)); err != nil {
 		http.Error(w, "Failed to trigger analysis: "+err.Error(), http.StatusInternalServerError)
@@ -557,6 +861,21 @@ func (c *GithubController) HandleGithubWebhook(w http.ResponseWriter, r *http.Re
 	w.WriteHeader(http.StatusOK)
 }
 
+// validGithubSignature checks X-Hub-Signature-256, "sha256=" and the hex HMAC-SHA256 of the
+// body keyed with the webhook secret. Deliveries are rejected when no secret is configured.
+func validGithubSignature(body []byte, signature string, secret string) bool {
+	if secret == "" || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
 func (c *GithubController) AnalyzeRepository(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	repoID := vars["id"]
@@ -652,6 +971,13 @@ func (c *GithubController) HandleRepoAIWebhook(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Only the workflow started for this repository can report its analysis
+	if !c.callbacks.Verify(r, ai.CallbackRepoAnalysis, payload.RepoID) {
+		log.Warn().Str("repo_id", payload.RepoID).Msg("[HandleRepoAIWebhook] Rejected callback with an invalid signature")
+		http.Error(w, "Invalid callback signature", http.StatusUnauthorized)
+		return
+	}
+
 	if payload.RawAnalysis == "" {
 		log.Error().Msg("[HandleRepoAIWebhook] raw_analysis is missing")
 		http.Error(w, "raw_analysis is required", http.StatusBadRequest)
@@ -688,6 +1014,15 @@ func (c *GithubController) HandleRepoAIWebhook(w http.ResponseWriter, r *http.Re
 	}
 	notificationJSON, _ := json.Marshal(notificationData)
 	GlobalSSEManager.NotifyClients(payload.RepoID, FormatSSEMessage(string(notificationJSON)))
+
+	// Notify subscribed channels
+	if repo, err := c.service.GetRepository(ctx, "", payload.RepoID); err == nil {
+		c.notify(ctx, notification_service.Event{
+			Type:       models.EventRepoAnalyzed,
+			Repository: repo,
+			Summary:    payload.RawAnalysis,
+		})
+	}
 	
 	w.WriteHeader(http.StatusOK)
 }
@@ -745,76 +1080,38 @@ func (c *GithubController) CalculateReleaseRisk(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	// Get all PRs for the repository
-	allPRs, err := c.service.GetPullRequestsByRepoID(r.Context(), repoID)
-	if err != nil {
-		log.Error().Err(err).Str("repo_id", repoID).Msg("Failed to fetch pull requests")
-		http.Error(w, "Failed to fetch pull requests", http.StatusInternalServerError)
-		return
-	}
-
-	// Filter PRs based on selected IDs
-	prMap := make(map[string]*models.PullRequest)
-	for _, pr := range allPRs {
-		prMap[pr.ID] = pr
-	}
-
-	var selectedPRs []*models.PullRequest
-	for _, prID := range requestBody.PRIDs {
-		if pr, exists := prMap[prID]; exists {
-			selectedPRs = append(selectedPRs, pr)
-		}
-	}
-
-	if len(selectedPRs) == 0 {
-		http.Error(w, "No valid pull requests found from selection", http.StatusBadRequest)
+	token, ok := r.Context().Value(middleware.GithubTokenContextKey).(string)
+	if !ok || token == "" {
+		http.Error(w, "GitHub token not found in context", http.StatusUnauthorized)
 		return
 	}
 
-	// Get repository details
-	repo, err := c.service.GetRepository(r.Context(), userVal.ID, repoID)
+	// Score the release from full PR data and start the AI workflow
+	breakdown, suggestion, err := c.service.CalculateReleaseRisk(r.Context(), userVal.ID, repoID, requestBody.PRIDs, token)
 	if err != nil {
-		log.Error().Err(err).Str("repo_id", repoID).Msg("Failed to fetch repository")
-		http.Error(w, "Failed to fetch repository", http.StatusNotFound)
-		return
-	}
-
-	// Format PR data for AI (only selected PRs)
-	var prData strings.Builder
-	for i, pr := range selectedPRs {
-		title := ""
-		if pr.Title != nil {
-			title = *pr.Title
-		}
-		prData.WriteString(fmt.Sprintf("\n### PR #%d: %s\n", i+1, title))
-		
-		if pr.Number != nil {
-			prData.WriteString(fmt.Sprintf("- **PR Number**: %d\n", *pr.Number))
-		}
-		if pr.State != nil {
-			prData.WriteString(fmt.Sprintf("- **State**: %s\n", *pr.State))
+		if errors.Is(err, github_service.ErrNoReleasePRs) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
 		}
-		if pr.AuthorName != nil {
-			prData.WriteString(fmt.Sprintf("- **Author**: %s\n", *pr.AuthorName))
+		if errors.Is(err, github_service.ErrUnsupportedCodeHost) {
+			http.Error(w, "Release risk analysis is "+err.Error(), http.StatusBadRequest)
+			return
 		}
-		if pr.AISummary != nil && *pr.AISummary != "" {
-			prData.WriteString(fmt.Sprintf("- **AI Analysis**: %s\n", *pr.AISummary))
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
 		}
-		prData.WriteString("\n")
-	}
-
-	// Trigger Kestra workflow
-	if err := c.service.TriggerReleaseRiskAnalysis(r.Context(), repoID, repo.Owner, repo.Name, prData.String()); err != nil {
-		log.Error().Err(err).Str("repo_id", repoID).Msg("Failed to trigger release risk analysis")
-		http.Error(w, "Failed to trigger release risk analysis", http.StatusInternalServerError)
+		log.Error().Err(err).Str("repo_id", repoID).Msg("Failed to start release risk analysis")
+		http.Error(w, "Failed to start release risk analysis", http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusAccepted)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "analyzing",
-		"message": "Release risk analysis started for selected PRs",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"status":                 "analyzing",
+		"message":                "Release risk analysis started for selected PRs",
+		"release_risk_breakdown":     breakdown,
+		"release_version_suggestion": suggestion,
 	})
 }
 
@@ -837,6 +1134,14 @@ func (c *GithubController) HandleReleaseRiskCallback(w http.ResponseWriter, r *h
 		return
 	}
 
+	// Only the workflow started for this run can report its analysis
+	runID := r.URL.Query().Get("run_id")
+	if !c.callbacks.Verify(r, ai.CallbackReleaseRisk, runID) {
+		log.Warn().Str("repository_id", payload.RepositoryID).Str("run_id", runID).Msg("[HandleReleaseRiskCallback] Rejected callback with an invalid signature")
+		http.Error(w, "Invalid callback signature", http.StatusUnauthorized)
+		return
+	}
+
 	if payload.RawAnalysis == "" {
 		log.Error().Msg("[HandleReleaseRiskCallback] raw_analysis is missing")
 		http.Error(w, "raw_analysis is required", http.StatusBadRequest)
@@ -870,7 +1175,14 @@ func (c *GithubController) HandleReleaseRiskCallback(w http.ResponseWriter, r *h
 
 	// Update repository with release risk analysis
 	ctx := context.Background()
-	if err := c.service.UpdateReleaseRiskAnalysis(ctx, payload.RepositoryID, analysisResult.RiskScore, analysisResult.Changelog, payload.RawAnalysis); err != nil {
+	repo, err := c.service.UpdateReleaseRiskAnalysis(ctx, payload.RepositoryID, runID, analysisResult.RiskScore, analysisResult.Changelog, payload.RawAnalysis)
+	if errors.Is(err, github_service.ErrStaleReleaseRiskRun) {
+		// A newer run replaced this one; its result will follow
+		log.Info().Str("repository_id", payload.RepositoryID).Str("run_id", runID).Msg("[HandleReleaseRiskCallback] Ignoring result of a superseded run")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("[HandleReleaseRiskCallback] Failed to update release risk analysis")
 		http.Error(w, "Failed to update analysis: "+err.Error(), http.StatusInternalServerError)
 		return
@@ -878,19 +1190,155 @@ func (c *GithubController) HandleReleaseRiskCallback(w http.ResponseWriter, r *h
 
 	// Notify all connected SSE clients
 	notificationData := map[string]interface{}{
-		"status":                "completed",
-		"release_risk_score":    analysisResult.RiskScore,
-		"release_changelog":     analysisResult.Changelog,
-		"release_risk_analysis": payload.RawAnalysis,
-		"repo_id":               payload.RepositoryID,
+		"status":                     "completed",
+		"release_risk_score":         repo.ReleaseRiskScore,
+		"release_risk_breakdown":     repo.ReleaseRiskBreakdown,
+		"release_version_suggestion": repo.ReleaseVersionSuggestion,
+		"release_changelog":          analysisResult.Changelog,
+		"release_risk_analysis":      payload.RawAnalysis,
+		"repo_id":                    payload.RepositoryID,
 	}
 	notificationJSON, _ := json.Marshal(notificationData)
 	GlobalSSEManager.NotifyClients(payload.RepositoryID, FormatSSEMessage(string(notificationJSON)))
 
+	// Notify subscribed channels
+	c.notify(ctx, notification_service.Event{
+		Type:       models.EventReleaseRiskCalculated,
+		Repository: repo,
+		Summary:    analysisResult.Changelog,
+		RiskScore:  repo.ReleaseRiskScore,
+	})
+
 	log.Info().
 		Str("repository_id", payload.RepositoryID).
-		Int("risk_score", analysisResult.RiskScore).
+		Int("ai_risk_score", analysisResult.RiskScore).
+		Int("risk_score", repo.ReleaseRiskScore).
 		Msg("[HandleReleaseRiskCallback] Release risk analysis completed")
 
 	w.WriteHeader(http.StatusOK)
 }
+
+// GetCommits returns the most recent ingested commits of a repository
+func (c *GithubController) GetCommits(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Get ID from path and optional limit (default 100)
+	id := mux.Vars(r)["id"]
+	if id == "" {
+		http.Error(w, "Repository ID is required", http.StatusBadRequest)
+		return
+	}
+	limit := 100
+	if limitParam := r.URL.Query().Get("limit"); limitParam != "" {
+		if parsedLimit, err := strconv.Atoi(limitParam); err == nil && parsedLimit > 0 && parsedLimit <= 1000 {
+			limit = parsedLimit
+		}
+	}
+
+	// 3. Call Service
+	commits, err := c.service.GetCommits(r.Context(), userVal.ID, id, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(commits)
+}
+
+// GetPullRequestCommits returns the ingested commits of a pull request
+func (c *GithubController) GetPullRequestCommits(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Get ID and PR Number from path
+	vars := mux.Vars(r)
+	id := vars["id"]
+	prNumber, err := strconv.Atoi(vars["pr_number"])
+	if id == "" || err != nil {
+		http.Error(w, "Repository ID and a valid PR Number are required", http.StatusBadRequest)
+		return
+	}
+
+	// 3. Call Service
+	commits, err := c.service.GetPullRequestCommits(r.Context(), userVal.ID, id, prNumber)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(commits)
+}
+
+// GetReleases lists the GitHub releases DevPlus has published for a repository
+func (c *GithubController) GetReleases(w http.ResponseWriter, r *http.Request) {
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	repoID := mux.Vars(r)["id"]
+
+	releases, err := c.service.GetReleases(r.Context(), userVal.ID, repoID)
+	if err != nil {
+		log.Error().Err(err).Str("repo_id", repoID).Msg("Failed to fetch releases")
+		http.Error(w, "Failed to fetch releases", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(releases)
+}
+
+// PublishRelease creates or updates a draft GitHub Release from the repository's changelog
+func (c *GithubController) PublishRelease(w http.ResponseWriter, r *http.Request) {
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	token, ok := r.Context().Value(middleware.GithubTokenContextKey).(string)
+	if !ok || token == "" {
+		http.Error(w, "GitHub token not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	repoID := mux.Vars(r)["id"]
+
+	var opts models.PublishReleaseOptions
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	release, err := c.service.PublishRelease(r.Context(), userVal.ID, repoID, token, opts)
+	if err != nil {
+		if errors.Is(err, github_service.ErrUnsupportedCodeHost) {
+			http.Error(w, "Publishing releases is "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if c.handleGithubUnauthorized(w, r, err) {
+			return
+		}
+		log.Error().Err(err).Str("repo_id", repoID).Str("tag", opts.TagName).Msg("Failed to publish release")
+		http.Error(w, "Failed to publish release: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(release)
+}
@@ -0,0 +1,57 @@
+package rest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func githubSignature(secret string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidGithubSignature(t *testing.T) {
+	body := `{"action":"deleted","installation":{"id":1}}`
+	tests := []struct {
+		name      string
+		signature string
+		secret    string
+		want      bool
+	}{
+		{"valid", githubSignature("webhook-secret", body), "webhook-secret", true},
+		{"wrong secret", githubSignature("other-secret", body), "webhook-secret", false},
+		{"other body", githubSignature("webhook-secret", body+" "), "webhook-secret", false},
+		{"missing prefix", strings.TrimPrefix(githubSignature("webhook-secret", body), "sha256="), "webhook-secret", false},
+		{"not hex", "sha256=zz", "webhook-secret", false},
+		{"missing", "", "webhook-secret", false},
+		{"no secret configured", githubSignature("", body), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validGithubSignature([]byte(body), tt.signature, tt.secret); got != tt.want {
+				t.Errorf("validGithubSignature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// Unsigned deliveries are rejected before any event is handled, so the controller needs no
+// service to answer them
+func TestHandleGithubWebhookRejectsUnsignedEvents(t *testing.T) {
+	c := NewGithubController(nil, nil, nil, nil, nil, "webhook-secret")
+	for _, event := range []string{"installation", "push", "pull_request"} {
+		r := httptest.NewRequest("POST", "/api/v1/webhook/github", strings.NewReader(`{"action":"deleted"}`))
+		r.Header.Set("X-GitHub-Event", event)
+		w := httptest.NewRecorder()
+		c.HandleGithubWebhook(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s event: status %d, want %d", event, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
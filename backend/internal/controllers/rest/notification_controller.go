@@ -0,0 +1,316 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"devplus-backend/internal/interfaces"
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/notification_service"
+)
+
+// NotificationController manages the user's notification channels, rules and webhook
+// endpoints, and their delivery log
+type NotificationController struct {
+	service interfaces.NotificationService
+}
+
+func NewNotificationController(service interfaces.NotificationService) *NotificationController {
+	return &NotificationController{
+		service: service,
+	}
+}
+
+// ListChannels returns the user's notification channels (without their targets)
+func (c *NotificationController) ListChannels(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	channels, err := c.service.ListChannels(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(channels)
+}
+
+// CreateChannel adds a Slack, Teams, webhook or email channel
+func (c *NotificationController) CreateChannel(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req notification_service.ChannelInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	channel, err := c.service.CreateChannel(r.Context(), user.ID, req)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(channel)
+}
+
+// DeleteChannel deletes a channel and the rules sending to it
+func (c *NotificationController) DeleteChannel(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.DeleteChannel(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListRules returns the user's subscription rules
+func (c *NotificationController) ListRules(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := c.service.ListRules(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rules)
+}
+
+// CreateRule subscribes a channel, or the pull request's author, to an event
+func (c *NotificationController) CreateRule(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req notification_service.RuleInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	rule, err := c.service.CreateRule(r.Context(), user.ID, req)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRule deletes a subscription rule
+func (c *NotificationController) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.DeleteRule(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListDeliveries returns the user's delivery log, newest first. Supports ?status= (pending,
+// sent or failed) and ?limit= (default 50, at most 200).
+func (c *NotificationController) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	limit, ok := queryLimit(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.service.ListDeliveries(r.Context(), user.ID, r.URL.Query().Get("status"), limit)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// ListEndpoints returns the user's webhook endpoints (without their URLs and secrets)
+func (c *NotificationController) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	endpoints, err := c.service.ListEndpoints(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endpoints)
+}
+
+// CreateEndpoint registers a webhook endpoint and returns its signing secret, the only time
+// it is shown
+func (c *NotificationController) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req notification_service.EndpointInput
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	endpoint, err := c.service.CreateEndpoint(r.Context(), user.ID, req)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(endpoint)
+}
+
+// DeleteEndpoint deletes a webhook endpoint
+func (c *NotificationController) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.DeleteEndpoint(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TestEndpoint sends a webhook.test event to an endpoint and returns the delivery, with the
+// endpoint's response in last_error when it failed
+func (c *NotificationController) TestEndpoint(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	delivery, err := c.service.SendTestEvent(r.Context(), user.ID, mux.Vars(r)["id"])
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// ListDeadLetters returns webhook endpoint deliveries that failed after their last retry,
+// newest first. Supports ?limit= (default 50, at most 200).
+func (c *NotificationController) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	limit, ok := queryLimit(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, err := c.service.ListDeadLetters(r.Context(), user.ID, limit)
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+// ReplayDeadLetter queues a dead letter for delivery again
+func (c *NotificationController) ReplayDeadLetter(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	delivery, err := c.service.ReplayDeadLetter(r.Context(), user.ID, mux.Vars(r)["id"])
+	if err != nil {
+		writeNotificationError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(delivery)
+}
+
+// queryLimit parses the optional ?limit= parameter, responding 400 when it isn't a number
+func queryLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return 0, true
+	}
+	limit, err := strconv.Atoi(value)
+	if err != nil {
+		http.Error(w, "limit must be a number", http.StatusBadRequest)
+		return 0, false
+	}
+	return limit, true
+}
+
+func writeNotificationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, notification_service.ErrNotificationNotFound):
+		http.Error(w, "Notification channel, rule, endpoint or delivery not found", http.StatusNotFound)
+	case errors.Is(err, notification_service.ErrInvalidNotificationRequest):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/interfaces"
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/slack_service"
+)
+
+// SlackController serves the Slack app's slash command and interactivity requests, and the
+// user's linked Slack accounts
+type SlackController struct {
+	service interfaces.SlackService
+}
+
+func NewSlackController(service interfaces.SlackService) *SlackController {
+	return &SlackController{
+		service: service,
+	}
+}
+
+// HandleCommand runs a /devplus slash command and replies with a Block Kit message
+func (c *SlackController) HandleCommand(w http.ResponseWriter, r *http.Request) {
+	form, ok := c.verifiedForm(w, r)
+	if !ok {
+		return
+	}
+
+	reply, err := c.service.HandleCommand(r.Context(), slack_service.Command{
+		TeamID:      form.Get("team_id"),
+		UserID:      form.Get("user_id"),
+		UserName:    form.Get("user_name"),
+		Text:        form.Get("text"),
+		ResponseURL: form.Get("response_url"),
+	})
+	if err != nil {
+		log.Error().Err(err).Str("team_id", form.Get("team_id")).Msg("[SlackController.HandleCommand] Failed to handle command")
+		http.Error(w, "Failed to handle command", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reply)
+}
+
+// slackInteraction is the part of a block_actions payload DevPlus uses
+type slackInteraction struct {
+	Type string `json:"type"`
+	Team struct {
+		ID string `json:"id"`
+	} `json:"team"`
+	User struct {
+		ID       string `json:"id"`
+		Username string `json:"username"`
+	} `json:"user"`
+	Actions []struct {
+		ActionID string `json:"action_id"`
+		Value    string `json:"value"`
+	} `json:"actions"`
+	ResponseURL string `json:"response_url"`
+}
+
+// HandleInteraction handles button clicks on DevPlus messages. Slack only waits three seconds
+// for the acknowledgement, so the action runs in the background and replies to the response URL.
+func (c *SlackController) HandleInteraction(w http.ResponseWriter, r *http.Request) {
+	form, ok := c.verifiedForm(w, r)
+	if !ok {
+		return
+	}
+
+	var payload slackInteraction
+	if err := json.Unmarshal([]byte(form.Get("payload")), &payload); err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if payload.Type != "block_actions" || len(payload.Actions) == 0 {
+		return
+	}
+	interaction := slack_service.Interaction{
+		TeamID:      payload.Team.ID,
+		UserID:      payload.User.ID,
+		UserName:    payload.User.Username,
+		ActionID:    payload.Actions[0].ActionID,
+		Value:       payload.Actions[0].Value,
+		ResponseURL: payload.ResponseURL,
+	}
+	go func() {
+		if err := c.service.HandleInteraction(context.Background(), interaction); err != nil {
+			log.Error().Err(err).Str("action_id", interaction.ActionID).Msg("[SlackController.HandleInteraction] Failed to handle interaction")
+		}
+	}()
+}
+
+// verifiedForm checks the request's Slack signature and parses its form body
+func (c *SlackController) verifiedForm(w http.ResponseWriter, r *http.Request) (url.Values, bool) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return nil, false
+	}
+	if err := c.service.VerifyRequest(r, body); err != nil {
+		http.Error(w, "Unauthorized: Invalid Slack signature", http.StatusUnauthorized)
+		return nil, false
+	}
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		http.Error(w, "Invalid payload", http.StatusBadRequest)
+		return nil, false
+	}
+	return form, true
+}
+
+// LinkAccount links the Slack user of a /devplus link token to the current user
+func (c *SlackController) LinkAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := c.service.LinkAccount(r.Context(), user.ID, req.Token)
+	if err != nil {
+		writeSlackError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+// ListAccounts returns the Slack accounts linked to the current user
+func (c *SlackController) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	accounts, err := c.service.ListAccounts(r.Context(), user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// UnlinkAccount removes a linked Slack account
+func (c *SlackController) UnlinkAccount(w http.ResponseWriter, r *http.Request) {
+	user, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	if err := c.service.UnlinkAccount(r.Context(), user.ID, mux.Vars(r)["id"]); err != nil {
+		writeSlackError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeSlackError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, slack_service.ErrInvalidLinkToken):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, slack_service.ErrSlackAccountNotFound):
+		http.Error(w, "Slack account not found", http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
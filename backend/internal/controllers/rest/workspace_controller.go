@@ -0,0 +1,230 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+
+	"devplus-backend/internal/interfaces"
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/workspace_service"
+)
+
+type WorkspaceController struct {
+	service interfaces.WorkspaceService
+}
+
+func NewWorkspaceController(service interfaces.WorkspaceService) *WorkspaceController {
+	return &WorkspaceController{
+		service: service,
+	}
+}
+
+func (c *WorkspaceController) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service
+	workspaces, err := c.service.GetWorkspaces(r.Context(), userVal.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 3. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(workspaces)
+}
+
+func (c *WorkspaceController) GetMembers(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service
+	members, err := c.service.GetMembers(r.Context(), userVal.ID, mux.Vars(r)["id"])
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	// 3. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+func (c *WorkspaceController) AddMember(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse body
+	var req struct {
+		models.UserRef
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.UserID == "" && req.Username == "") {
+		http.Error(w, "user_id or username is required", http.StatusBadRequest)
+		return
+	}
+	if req.Role == "" {
+		req.Role = models.RoleViewer
+	}
+
+	// 3. Call Service
+	member, err := c.service.AddMember(r.Context(), userVal.ID, mux.Vars(r)["id"], req.UserRef, req.Role)
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(member)
+}
+
+func (c *WorkspaceController) UpdateMemberRole(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse body
+	var req struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Role == "" {
+		http.Error(w, "role is required", http.StatusBadRequest)
+		return
+	}
+
+	// 3. Call Service
+	vars := mux.Vars(r)
+	member, err := c.service.UpdateMemberRole(r.Context(), userVal.ID, vars["id"], vars["user_id"], req.Role)
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+func (c *WorkspaceController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service
+	vars := mux.Vars(r)
+	if err := c.service.RemoveMember(r.Context(), userVal.ID, vars["id"], vars["user_id"]); err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *WorkspaceController) GetRepositoryMembers(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service
+	members, err := c.service.GetRepositoryMembers(r.Context(), userVal.ID, mux.Vars(r)["id"])
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	// 3. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(members)
+}
+
+func (c *WorkspaceController) SetRepositoryMember(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Parse body
+	var req struct {
+		models.UserRef
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.UserID == "" && req.Username == "") || req.Role == "" {
+		http.Error(w, "user_id or username, and role are required", http.StatusBadRequest)
+		return
+	}
+
+	// 3. Call Service
+	member, err := c.service.SetRepositoryMember(r.Context(), userVal.ID, mux.Vars(r)["id"], req.UserRef, req.Role)
+	if err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	// 4. Return JSON
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(member)
+}
+
+func (c *WorkspaceController) RemoveRepositoryMember(w http.ResponseWriter, r *http.Request) {
+	// 1. Get User ID from context
+	userVal, ok := r.Context().Value(middleware.UserContextKey).(models.User)
+	if !ok {
+		http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	// 2. Call Service
+	vars := mux.Vars(r)
+	if err := c.service.RemoveRepositoryMember(r.Context(), userVal.ID, vars["id"], vars["user_id"]); err != nil {
+		writeWorkspaceError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeWorkspaceError maps workspace service errors to HTTP status codes
+func writeWorkspaceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, workspace_service.ErrForbidden):
+		http.Error(w, middleware.ForbiddenMessage, http.StatusForbidden)
+	case errors.Is(err, workspace_service.ErrNotFound):
+		http.Error(w, "Workspace or member not found", http.StatusNotFound)
+	case errors.Is(err, workspace_service.ErrInvalidRole):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, workspace_service.ErrLastOwner), errors.Is(err, workspace_service.ErrAmbiguousUser):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,210 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// prefix marks values produced by Encrypt; anything else is rejected by Decrypt
+const prefix = "enc:v1:"
+
+var (
+	// ErrNotConfigured is returned when no keyring has been set up
+	ErrNotConfigured = errors.New("token encryption keys are not configured")
+	// ErrNotEncrypted is returned when decrypting a value that isn't an encrypted envelope
+	ErrNotEncrypted = errors.New("value is not encrypted")
+	// ErrUnknownKey is returned for envelopes sealed with a key ID that isn't in the keyring
+	ErrUnknownKey = errors.New("unknown encryption key id")
+)
+
+// Keyring holds the master keys used for envelope encryption. Each value is encrypted with
+// a fresh data key, and the data key is wrapped with the primary master key; the master key
+// ID is stored alongside so older keys can still decrypt after rotation.
+type Keyring struct {
+	primaryID string
+	keys      map[string]cipher.AEAD
+}
+
+// ParseKeyring parses comma-separated "id:base64key" pairs of 32-byte AES-256 keys. New
+// values are encrypted with primaryID, or with the first key when primaryID is empty.
+func ParseKeyring(spec string, primaryID string) (*Keyring, error) {
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD)}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, encoded, ok := strings.Cut(entry, ":")
+		if !ok || id == "" {
+			return nil, fmt.Errorf("invalid encryption key entry %q, expected id:base64key", entry)
+		}
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("encryption key %q is not valid base64: %w", id, err)
+		}
+		if len(key) != 32 {
+			return nil, fmt.Errorf("encryption key %q must be 32 bytes, got %d", id, len(key))
+		}
+		aead, err := newGCM(key)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := keyring.keys[id]; exists {
+			return nil, fmt.Errorf("duplicate encryption key id %q", id)
+		}
+		keyring.keys[id] = aead
+		if keyring.primaryID == "" {
+			keyring.primaryID = id
+		}
+	}
+
+	if len(keyring.keys) == 0 {
+		return nil, ErrNotConfigured
+	}
+	if primaryID != "" {
+		if _, ok := keyring.keys[primaryID]; !ok {
+			return nil, fmt.Errorf("primary encryption key %q is not in the keyring", primaryID)
+		}
+		keyring.primaryID = primaryID
+	}
+	return keyring, nil
+}
+
+// PrimaryID returns the ID of the key new values are encrypted with
+func (k *Keyring) PrimaryID() string {
+	return k.primaryID
+}
+
+// Encrypt seals plaintext as "enc:v1:<key id>:<wrapped data key>:<ciphertext>"
+func (k *Keyring) Encrypt(plaintext string) (string, error) {
+	dataKey := make([]byte, 32)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", err
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+
+	// The key ID is bound as additional data so an envelope can't be relabelled
+	wrappedKey, err := seal(k.keys[k.primaryID], dataKey, []byte(k.primaryID))
+	if err != nil {
+		return "", err
+	}
+	ciphertext, err := seal(dataAEAD, []byte(plaintext), []byte(k.primaryID))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + k.primaryID + ":" +
+		base64.RawStdEncoding.EncodeToString(wrappedKey) + ":" +
+		base64.RawStdEncoding.EncodeToString(ciphertext), nil
+}
+
+// Decrypt opens a value produced by Encrypt with any key in the keyring
+func (k *Keyring) Decrypt(value string) (string, error) {
+	keyID, wrappedKey, ciphertext, err := parseEnvelope(value)
+	if err != nil {
+		return "", err
+	}
+	masterAEAD, ok := k.keys[keyID]
+	if !ok {
+		return "", fmt.Errorf("%w %q", ErrUnknownKey, keyID)
+	}
+
+	dataKey, err := open(masterAEAD, wrappedKey, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	dataAEAD, err := newGCM(dataKey)
+	if err != nil {
+		return "", err
+	}
+	plaintext, err := open(dataAEAD, ciphertext, []byte(keyID))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value looks like an envelope produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// KeyID returns the master key ID an envelope was sealed with
+func KeyID(value string) (string, error) {
+	keyID, _, _, err := parseEnvelope(value)
+	return keyID, err
+}
+
+func parseEnvelope(value string) (keyID string, wrappedKey []byte, ciphertext []byte, err error) {
+	if !IsEncrypted(value) {
+		return "", nil, nil, ErrNotEncrypted
+	}
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", nil, nil, errors.New("malformed encrypted value")
+	}
+	if wrappedKey, err = base64.RawStdEncoding.DecodeString(parts[1]); err != nil {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	if ciphertext, err = base64.RawStdEncoding.DecodeString(parts[2]); err != nil {
+		return "", nil, nil, fmt.Errorf("malformed encrypted value: %w", err)
+	}
+	return parts[0], wrappedKey, ciphertext, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts with a random nonce prepended to the ciphertext
+func seal(aead cipher.AEAD, plaintext []byte, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+func open(aead cipher.AEAD, sealed []byte, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+var (
+	defaultMu      sync.RWMutex
+	defaultKeyring *Keyring
+)
+
+// SetDefault installs the keyring used by EncryptedString columns
+func SetDefault(keyring *Keyring) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultKeyring = keyring
+}
+
+// Default returns the keyring installed with SetDefault, or ErrNotConfigured
+func Default() (*Keyring, error) {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	if defaultKeyring == nil {
+		return nil, ErrNotConfigured
+	}
+	return defaultKeyring, nil
+}
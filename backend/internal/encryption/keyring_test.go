@@ -0,0 +1,175 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func testKey(b byte) string {
+	return base64.StdEncoding.EncodeToString([]byte(strings.Repeat(string(rune(b)), 32)))
+}
+
+func mustParse(t *testing.T, spec string, primaryID string) *Keyring {
+	t.Helper()
+	keyring, err := ParseKeyring(spec, primaryID)
+	if err != nil {
+		t.Fatalf("ParseKeyring(%q, %q): %v", spec, primaryID, err)
+	}
+	return keyring
+}
+
+func TestParseKeyring(t *testing.T) {
+	tests := []struct {
+		name      string
+		spec      string
+		primaryID string
+		want      string // expected primary ID, empty when parsing fails
+	}{
+		{"single key", "k1:" + testKey('a'), "", "k1"},
+		{"first key is primary", "k1:" + testKey('a') + ", k2:" + testKey('b'), "", "k1"},
+		{"explicit primary", "k1:" + testKey('a') + ",k2:" + testKey('b'), "k2", "k2"},
+		{"trailing comma", "k1:" + testKey('a') + ",", "", "k1"},
+		{"empty", "", "", ""},
+		{"missing id", ":" + testKey('a'), "", ""},
+		{"missing separator", testKey('a'), "", ""},
+		{"invalid base64", "k1:not base64!", "", ""},
+		{"short key", "k1:" + base64.StdEncoding.EncodeToString([]byte("too short")), "", ""},
+		{"duplicate id", "k1:" + testKey('a') + ",k1:" + testKey('b'), "", ""},
+		{"unknown primary", "k1:" + testKey('a'), "k2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keyring, err := ParseKeyring(tt.spec, tt.primaryID)
+			if tt.want == "" {
+				if err == nil {
+					t.Errorf("ParseKeyring() = %v, want error", keyring.PrimaryID())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyring(): %v", err)
+			}
+			if keyring.PrimaryID() != tt.want {
+				t.Errorf("PrimaryID() = %q, want %q", keyring.PrimaryID(), tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseKeyring(" , ", ""); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("ParseKeyring(blank) error = %v, want ErrNotConfigured", err)
+	}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	keyring := mustParse(t, "k1:"+testKey('a'), "")
+
+	for _, plaintext := range []string{"gho_secret-token", "", "ünïcødé ✓"} {
+		sealed, err := keyring.Encrypt(plaintext)
+		if err != nil {
+			t.Fatalf("Encrypt(%q): %v", plaintext, err)
+		}
+		if !IsEncrypted(sealed) || !strings.HasPrefix(sealed, "enc:v1:k1:") {
+			t.Errorf("Encrypt(%q) = %q, want an enc:v1:k1: envelope", plaintext, sealed)
+		}
+		if plaintext != "" && strings.Contains(sealed, plaintext) {
+			t.Errorf("Encrypt(%q) leaks the plaintext", plaintext)
+		}
+		got, err := keyring.Decrypt(sealed)
+		if err != nil {
+			t.Fatalf("Decrypt: %v", err)
+		}
+		if got != plaintext {
+			t.Errorf("Decrypt(Encrypt(%q)) = %q", plaintext, got)
+		}
+	}
+
+	first, _ := keyring.Encrypt("same")
+	second, _ := keyring.Encrypt("same")
+	if first == second {
+		t.Error("Encrypt returned the same envelope twice; data keys and nonces must be random")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	old := mustParse(t, "k1:"+testKey('a'), "")
+	sealed, err := old.Encrypt("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After rotation k2 is primary but values sealed with k1 still decrypt
+	rotated := mustParse(t, "k1:"+testKey('a')+",k2:"+testKey('b'), "k2")
+	if got, err := rotated.Decrypt(sealed); err != nil || got != "token" {
+		t.Fatalf("Decrypt(old envelope) = %q, %v", got, err)
+	}
+	resealed, err := rotated.Encrypt("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := KeyID(resealed); err != nil || id != "k2" {
+		t.Errorf("KeyID(resealed) = %q, %v, want k2", id, err)
+	}
+
+	// Once k1 is retired its envelopes can't be opened
+	retired := mustParse(t, "k2:"+testKey('b'), "")
+	if _, err := retired.Decrypt(sealed); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("Decrypt with retired key error = %v, want ErrUnknownKey", err)
+	}
+}
+
+func TestDecryptRejectsTampering(t *testing.T) {
+	keyring := mustParse(t, "k1:"+testKey('a')+",k2:"+testKey('b'), "")
+	sealed, err := keyring.Encrypt("token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(strings.TrimPrefix(sealed, prefix), ":")
+
+	flip := func(encoded string) string {
+		raw, _ := base64.RawStdEncoding.DecodeString(encoded)
+		raw[len(raw)-1] ^= 1
+		return base64.RawStdEncoding.EncodeToString(raw)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"plaintext", "token", ErrNotEncrypted},
+		{"relabelled key id", prefix + "k2:" + parts[1] + ":" + parts[2], nil},
+		{"unknown key id", prefix + "k9:" + parts[1] + ":" + parts[2], ErrUnknownKey},
+		{"modified data key", prefix + "k1:" + flip(parts[1]) + ":" + parts[2], nil},
+		{"modified ciphertext", prefix + "k1:" + parts[1] + ":" + flip(parts[2]), nil},
+		{"truncated ciphertext", prefix + "k1:" + parts[1] + ":AAAA", nil},
+		{"missing part", prefix + "k1:" + parts[1], nil},
+		{"invalid base64", prefix + "k1:!!:" + parts[2], nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := keyring.Decrypt(tt.value)
+			if err == nil {
+				t.Fatalf("Decrypt() = %q, want error", got)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestDefault(t *testing.T) {
+	t.Cleanup(func() { SetDefault(nil) })
+
+	SetDefault(nil)
+	if _, err := Default(); !errors.Is(err, ErrNotConfigured) {
+		t.Errorf("Default() error = %v, want ErrNotConfigured", err)
+	}
+	keyring := mustParse(t, "k1:"+testKey('a'), "")
+	SetDefault(keyring)
+	if got, err := Default(); err != nil || got != keyring {
+		t.Errorf("Default() = %p, %v, want %p", got, err, keyring)
+	}
+}
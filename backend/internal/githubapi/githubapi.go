@@ -0,0 +1,106 @@
+// Package githubapi holds the GitHub endpoints DevPlus calls: github.com by default, or a
+// GitHub Enterprise Server instance when GITHUB_API_URL is set. Auth, sync, the GitHub App
+// and the AI fetchers all build their URLs and clients from the same Endpoints.
+package githubapi
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+
+	"devplus-backend/internal/config"
+)
+
+const (
+	DefaultAPIURL    = "https://api.github.com/"
+	DefaultUploadURL = "https://uploads.github.com/"
+	DefaultOAuthURL  = "https://github.com/login/oauth"
+)
+
+// Endpoints are the base URLs of a GitHub instance. The zero value is github.com.
+type Endpoints struct {
+	APIURL    string // With a trailing slash, e.g. https://github.example.com/api/v3/
+	UploadURL string // With a trailing slash, e.g. https://github.example.com/api/uploads/
+	OAuthURL  string // Without a trailing slash, e.g. https://github.example.com/login/oauth
+}
+
+// FromConfig returns the configured endpoints. For GitHub Enterprise Server only the API URL
+// is required: the upload and OAuth URLs default to the same host, and the API paths are
+// completed the way github.NewEnterpriseClient does, so https://github.example.com works.
+func FromConfig(cfg *config.Config) (Endpoints, error) {
+	if cfg.GithubAPIURL == "" || cfg.GithubAPIURL == DefaultAPIURL {
+		return Endpoints{APIURL: DefaultAPIURL, UploadURL: DefaultUploadURL, OAuthURL: DefaultOAuthURL}, nil
+	}
+
+	apiURL, err := url.Parse(cfg.GithubAPIURL)
+	if err != nil || apiURL.Scheme == "" || apiURL.Host == "" {
+		return Endpoints{}, fmt.Errorf("invalid GITHUB_API_URL %q", cfg.GithubAPIURL)
+	}
+	host := apiURL.Scheme + "://" + apiURL.Host
+	uploadURL := cfg.GithubUploadURL
+	if uploadURL == "" {
+		uploadURL = host
+	}
+	oauthURL := cfg.GithubOAuthURL
+	if oauthURL == "" {
+		oauthURL = host + "/login/oauth"
+	}
+
+	client, err := github.NewEnterpriseClient(cfg.GithubAPIURL, uploadURL, nil)
+	if err != nil {
+		return Endpoints{}, fmt.Errorf("invalid GitHub Enterprise URLs: %w", err)
+	}
+	return Endpoints{
+		APIURL:    client.BaseURL.String(),
+		UploadURL: client.UploadURL.String(),
+		OAuthURL:  strings.TrimSuffix(oauthURL, "/"),
+	}, nil
+}
+
+// IsEnterprise reports whether the endpoints are a GitHub Enterprise Server instance
+func (e Endpoints) IsEnterprise() bool {
+	return e.APIURL != "" && e.APIURL != DefaultAPIURL
+}
+
+// NewClient returns a go-github client for the instance. httpClient authenticates requests,
+// e.g. an oauth2 client.
+func (e Endpoints) NewClient(httpClient *http.Client) *github.Client {
+	if !e.IsEnterprise() {
+		return github.NewClient(httpClient)
+	}
+	client, err := github.NewEnterpriseClient(e.APIURL, e.UploadURL, httpClient)
+	if err != nil {
+		// FromConfig validated the URLs; never fall back to github.com with an Enterprise token
+		panic(fmt.Sprintf("githubapi: invalid endpoints: %v", err))
+	}
+	return client
+}
+
+// API returns the URL of a REST API path, e.g. API("repos/%s/%s/readme", owner, repo)
+func (e Endpoints) API(format string, args ...interface{}) string {
+	base := e.APIURL
+	if base == "" {
+		base = DefaultAPIURL
+	}
+	return base + strings.TrimPrefix(fmt.Sprintf(format, args...), "/")
+}
+
+// AuthorizeURL is the OAuth authorization page users are sent to
+func (e Endpoints) AuthorizeURL() string {
+	return e.oauthBase() + "/authorize"
+}
+
+// TokenURL is the OAuth endpoint codes and refresh tokens are exchanged at
+func (e Endpoints) TokenURL() string {
+	return e.oauthBase() + "/access_token"
+}
+
+func (e Endpoints) oauthBase() string {
+	if e.OAuthURL == "" {
+		return DefaultOAuthURL
+	}
+	return e.OAuthURL
+}
@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"net/http"
+
+	"devplus-backend/internal/models"
+)
+
+type CodeHostService interface {
+	SyncRepositories(ctx context.Context, userID string, hostName string) ([]*models.Repository, error)
+	SyncPullRequests(ctx context.Context, userID string, repoID string) ([]*models.PullRequest, error)
+	HandleWebhook(ctx context.Context, hostName string, r *http.Request, body []byte) (*models.PullRequest, bool, error)
+}
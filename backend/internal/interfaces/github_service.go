@@ -17,13 +17,25 @@ type GithubService interface {
 	GetPullRequestByID(ctx context.Context, prID string) (*models.PullRequest, error)
 	GetMetrics(ctx context.Context, userID string, filter models.MetricsFilter) (*models.DashboardStats, error)
 	GetPersonalMetrics(ctx context.Context, userID string, token string, username string, days int) (*models.PersonalMetrics, error)
+	GetMetricsTimeSeries(ctx context.Context, userID string, metricType string, interval string, filter models.MetricsFilter) (*models.TimeSeries, error)
+	GetCycleTimeMetrics(ctx context.Context, userID string, filter models.MetricsFilter) (*models.CycleTimeReport, error)
+	GetDoraMetrics(ctx context.Context, userID string, token string, filter models.MetricsFilter) (*models.DoraReport, error)
 	GetRepositoryByGithubID(ctx context.Context, githubID int64) (*models.Repository, error)
 	UpsertPullRequest(ctx context.Context, pr *models.PullRequest) error
 	UpdatePullRequestAnalysis(ctx context.Context, prID string, summary, decision string) error
 	AnalyzeRepository(ctx context.Context, repoID string) error
 	UpdateRepositoryAnalysis(ctx context.Context, repoID string, summary string) error
 	AnalyzePullRequest(ctx context.Context, repoID string, prNumber int) error
-	TriggerReleaseRiskAnalysis(ctx context.Context, repoID string, owner string, name string, prData string) error
-	UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, riskScore int, changelog string, rawAnalysis string) error
+	TriggerReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, owner string, name string, prData string) error
+	CalculateReleaseRisk(ctx context.Context, userID string, repoID string, prIDs []string, token string) (*models.RiskBreakdown, *models.VersionSuggestion, error)
+	ReleaseRiskPRAnalyzed(ctx context.Context, prID string) error
+	UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, aiRiskScore int, changelog string, rawAnalysis string) (*models.Repository, error)
 	GetPullRequestsByRepoID(ctx context.Context, repoID string) ([]*models.PullRequest, error)
+	GetCommits(ctx context.Context, userID string, repoID string, limit int) ([]*models.Commit, error)
+	GetPullRequestCommits(ctx context.Context, userID string, repoID string, number int) ([]*models.Commit, error)
+	IngestPushEvent(ctx context.Context, payload []byte) error
+	HandleInstallationEvent(ctx context.Context, event string, payload []byte) error
+	PostPullRequestReview(ctx context.Context, prID string) error
+	GetReleases(ctx context.Context, userID string, repoID string) ([]*models.Release, error)
+	PublishRelease(ctx context.Context, userID string, repoID string, token string, opts models.PublishReleaseOptions) (*models.Release, error)
 }
@@ -0,0 +1,24 @@
+package interfaces
+
+import (
+	"context"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/notification_service"
+)
+
+type NotificationService interface {
+	CreateChannel(ctx context.Context, userID string, input notification_service.ChannelInput) (*models.NotificationChannel, error)
+	ListChannels(ctx context.Context, userID string) ([]*models.NotificationChannel, error)
+	DeleteChannel(ctx context.Context, userID string, channelID string) error
+	CreateRule(ctx context.Context, userID string, input notification_service.RuleInput) (*models.NotificationRule, error)
+	ListRules(ctx context.Context, userID string) ([]*models.NotificationRule, error)
+	DeleteRule(ctx context.Context, userID string, ruleID string) error
+	ListDeliveries(ctx context.Context, userID string, status string, limit int) ([]*models.NotificationDelivery, error)
+	CreateEndpoint(ctx context.Context, userID string, input notification_service.EndpointInput) (*notification_service.IssuedWebhookEndpoint, error)
+	ListEndpoints(ctx context.Context, userID string) ([]*models.WebhookEndpoint, error)
+	DeleteEndpoint(ctx context.Context, userID string, endpointID string) error
+	SendTestEvent(ctx context.Context, userID string, endpointID string) (*models.NotificationDelivery, error)
+	ListDeadLetters(ctx context.Context, userID string, limit int) ([]*models.NotificationDelivery, error)
+	ReplayDeadLetter(ctx context.Context, userID string, deliveryID string) (*models.NotificationDelivery, error)
+}
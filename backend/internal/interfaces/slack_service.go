@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"net/http"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/slack_service"
+)
+
+type SlackService interface {
+	VerifyRequest(r *http.Request, body []byte) error
+	HandleCommand(ctx context.Context, cmd slack_service.Command) (*slack_service.Message, error)
+	HandleInteraction(ctx context.Context, interaction slack_service.Interaction) error
+	LinkAccount(ctx context.Context, userID string, token string) (*models.SlackAccount, error)
+	ListAccounts(ctx context.Context, userID string) ([]*models.SlackAccount, error)
+	UnlinkAccount(ctx context.Context, userID string, accountID string) error
+}
@@ -0,0 +1,19 @@
+package interfaces
+
+import (
+	"context"
+	"devplus-backend/internal/models"
+)
+
+type WorkspaceService interface {
+	GetWorkspaces(ctx context.Context, userID string) ([]*models.Workspace, error)
+	GetMembers(ctx context.Context, userID string, workspaceID string) ([]*models.WorkspaceMember, error)
+	AddMember(ctx context.Context, userID string, workspaceID string, ref models.UserRef, role string) (*models.WorkspaceMember, error)
+	UpdateMemberRole(ctx context.Context, userID string, workspaceID string, memberID string, role string) (*models.WorkspaceMember, error)
+	RemoveMember(ctx context.Context, userID string, workspaceID string, memberID string) error
+	AuthorizeRepository(ctx context.Context, userID string, repoID string, minRole string) error
+	AuthorizeRepositoryByName(ctx context.Context, userID string, owner string, name string, minRole string) error
+	GetRepositoryMembers(ctx context.Context, userID string, repoID string) ([]*models.RepositoryMember, error)
+	SetRepositoryMember(ctx context.Context, userID string, repoID string, ref models.UserRef, role string) (*models.RepositoryMember, error)
+	RemoveRepositoryMember(ctx context.Context, userID string, repoID string, memberID string) error
+}
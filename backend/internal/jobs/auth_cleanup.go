@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AuthCleanupInterval is how often expired sessions and other auth records are deleted
+const AuthCleanupInterval = time.Hour
+
+// AuthCleaner is implemented by services that can purge expired auth records
+type AuthCleaner interface {
+	CleanupExpiredAuth(ctx context.Context) error
+}
+
+// StartAuthCleanup purges expired auth records on startup and then every AuthCleanupInterval
+// until ctx is cancelled.
+func StartAuthCleanup(ctx context.Context, cleaner AuthCleaner) {
+	go func() {
+		ticker := time.NewTicker(AuthCleanupInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := cleaner.CleanupExpiredAuth(ctx); err != nil {
+				log.Error().Err(err).Msg("[Jobs.AuthCleanup] Cleanup failed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// MetricsRollupInterval is how often the current day's snapshot is refreshed
+const MetricsRollupInterval = time.Hour
+
+// MetricsRoller is implemented by services that can snapshot daily metrics
+type MetricsRoller interface {
+	RollupDailyMetrics(ctx context.Context, day time.Time) error
+}
+
+// StartMetricsRollup snapshots per-repository metrics on startup and then every
+// MetricsRollupInterval until ctx is cancelled. Each run finalises the previous day
+// and refreshes today's values.
+func StartMetricsRollup(ctx context.Context, roller MetricsRoller) {
+	go func() {
+		ticker := time.NewTicker(MetricsRollupInterval)
+		defer ticker.Stop()
+
+		for {
+			runMetricsRollup(ctx, roller)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func runMetricsRollup(ctx context.Context, roller MetricsRoller) {
+	now := time.Now().UTC()
+	for _, day := range []time.Time{now.AddDate(0, 0, -1), now} {
+		if err := roller.RollupDailyMetrics(ctx, day); err != nil {
+			log.Error().Err(err).Str("day", day.Format("2006-01-02")).Msg("[Jobs.MetricsRollup] Rollup failed")
+		}
+	}
+}
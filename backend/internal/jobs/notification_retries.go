@@ -0,0 +1,50 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// NotificationRetryInterval is how often failed notification deliveries that are due are retried
+	NotificationRetryInterval = time.Minute
+	// NotificationPurgeInterval is how often old deliveries are removed from the delivery log
+	NotificationPurgeInterval = 24 * time.Hour
+)
+
+// NotificationRetrier is implemented by services that can retry and purge notification deliveries
+type NotificationRetrier interface {
+	RetryDeliveries(ctx context.Context) error
+	PurgeDeliveries(ctx context.Context) error
+}
+
+// StartNotificationRetries retries due notification deliveries every NotificationRetryInterval
+// and purges old ones on startup and then every NotificationPurgeInterval, until ctx is cancelled.
+func StartNotificationRetries(ctx context.Context, retrier NotificationRetrier) {
+	go func() {
+		retries := time.NewTicker(NotificationRetryInterval)
+		defer retries.Stop()
+		purges := time.NewTicker(NotificationPurgeInterval)
+		defer purges.Stop()
+
+		if err := retrier.PurgeDeliveries(ctx); err != nil {
+			log.Error().Err(err).Msg("[Jobs.NotificationRetries] Purge failed")
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-retries.C:
+				if err := retrier.RetryDeliveries(ctx); err != nil {
+					log.Error().Err(err).Msg("[Jobs.NotificationRetries] Retry failed")
+				}
+			case <-purges.C:
+				if err := retrier.PurgeDeliveries(ctx); err != nil {
+					log.Error().Err(err).Msg("[Jobs.NotificationRetries] Purge failed")
+				}
+			}
+		}
+	}()
+}
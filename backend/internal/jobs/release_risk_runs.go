@@ -0,0 +1,51 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// ReleaseRiskRunInterval is how often release risk runs that stopped waiting are started
+	ReleaseRiskRunInterval = 30 * time.Second
+	// ReleaseRiskCleanupInterval is how often old release risk runs are deleted
+	ReleaseRiskCleanupInterval = 24 * time.Hour
+)
+
+// ReleaseRiskRunner is implemented by services that can resume and clean up release risk runs
+type ReleaseRiskRunner interface {
+	ResumeReleaseRiskRuns(ctx context.Context) error
+	CleanupReleaseRiskRuns(ctx context.Context) error
+}
+
+// StartReleaseRiskRuns starts the release risk runs whose PR analyses are in or timed out,
+// on startup and then every ReleaseRiskRunInterval, so runs survive restarts. Old runs are
+// deleted every ReleaseRiskCleanupInterval, until ctx is cancelled.
+func StartReleaseRiskRuns(ctx context.Context, runner ReleaseRiskRunner) {
+	go func() {
+		runs := time.NewTicker(ReleaseRiskRunInterval)
+		defer runs.Stop()
+		cleanups := time.NewTicker(ReleaseRiskCleanupInterval)
+		defer cleanups.Stop()
+
+		if err := runner.ResumeReleaseRiskRuns(ctx); err != nil {
+			log.Error().Err(err).Msg("[Jobs.ReleaseRiskRuns] Resume failed")
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-runs.C:
+				if err := runner.ResumeReleaseRiskRuns(ctx); err != nil {
+					log.Error().Err(err).Msg("[Jobs.ReleaseRiskRuns] Resume failed")
+				}
+			case <-cleanups.C:
+				if err := runner.CleanupReleaseRiskRuns(ctx); err != nil {
+					log.Error().Err(err).Msg("[Jobs.ReleaseRiskRuns] Cleanup failed")
+				}
+			}
+		}
+	}()
+}
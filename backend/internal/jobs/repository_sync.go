@@ -0,0 +1,36 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RepositorySyncInterval is how often repositories with a GitHub App installation are synced
+const RepositorySyncInterval = 30 * time.Minute
+
+// RepositorySyncer is implemented by services that can sync repositories without a user token
+type RepositorySyncer interface {
+	SyncInstalledRepositories(ctx context.Context) error
+}
+
+// StartRepositorySync syncs App-installed repositories on startup and then every
+// RepositorySyncInterval until ctx is cancelled.
+func StartRepositorySync(ctx context.Context, syncer RepositorySyncer) {
+	go func() {
+		ticker := time.NewTicker(RepositorySyncInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := syncer.SyncInstalledRepositories(ctx); err != nil {
+				log.Error().Err(err).Msg("[Jobs.RepositorySync] Sync failed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
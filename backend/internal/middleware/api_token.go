@@ -0,0 +1,68 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// APITokenValidator validates personal access tokens
+type APITokenValidator interface {
+	ValidateAPIToken(ctx context.Context, token string, ipAddress string) (*models.APIToken, error)
+}
+
+type apiTokenAuthenticator struct {
+	tokens APITokenValidator
+}
+
+// NewAPITokenAuthenticator authenticates personal access tokens (prefixed with dvp_)
+func NewAPITokenAuthenticator(tokens APITokenValidator) Authenticator {
+	return &apiTokenAuthenticator{tokens: tokens}
+}
+
+func (a *apiTokenAuthenticator) Authenticate(r *http.Request, token string) (*Principal, error) {
+	if !strings.HasPrefix(token, models.APITokenPrefix) {
+		return nil, ErrUnsupportedCredential
+	}
+	apiToken, err := a.tokens.ValidateAPIToken(r.Context(), token, ClientIP(r))
+	if err != nil {
+		return nil, &AuthError{Message: "Invalid or expired API token", Err: err}
+	}
+	return &Principal{User: apiToken.User, Method: AuthMethodAPIToken, Scopes: apiToken.Scopes, APIToken: apiToken}, nil
+}
+
+// TokenScopePolicy returns the scope a restricted principal needs for a request. allowed is
+// false for endpoints that require a browser session; an empty scope accepts any principal.
+type TokenScopePolicy func(r *http.Request) (scope string, allowed bool)
+
+// RequireTokenScope rejects requests from API-token and OIDC principals that the policy doesn't
+// allow or that lack the required scope. Session requests are passed through. Must run after
+// Authenticate.
+func RequireTokenScope(policy TokenScopePolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := PrincipalFromContext(r.Context())
+			if !ok || !principal.Restricted() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			scope, allowed := policy(r)
+			if !allowed {
+				http.Error(w, "Forbidden: this endpoint requires a browser session", http.StatusForbidden)
+				return
+			}
+			if scope != "" && !principal.HasScope(scope) {
+				log.Warn().Str("user_id", principal.User.ID).Str("method", string(principal.Method)).Str("path", r.URL.Path).Str("scope", scope).Msg("[RequireTokenScope] Missing scope")
+				http.Error(w, "Forbidden: token is missing scope "+scope, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -2,66 +2,159 @@ package middleware
 
 import (
 	"context"
-	"fmt"
+	"errors"
 	"net/http"
-	"os"
-	"strings"
 
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
 )
 
 type contextKey string
 
 const (
+	PrincipalContextKey   contextKey = "principal"
 	UserContextKey        contextKey = "user"
 	GithubTokenContextKey contextKey = "github_token"
+	SessionIDContextKey   contextKey = "session_id"
 )
 
-// AuthMiddleware validates the JWT token from the Authorization header
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header is missing", http.StatusUnauthorized)
-			return
-		}
+// AuthMethod is how a principal authenticated
+type AuthMethod string
 
-		parts := strings.Split(authHeader, " ")
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			http.Error(w, "Invalid authorization header format", http.StatusUnauthorized)
-			return
-		}
+const (
+	AuthMethodSession  AuthMethod = "session"
+	AuthMethodAPIToken AuthMethod = "api_token"
+	AuthMethodOIDC     AuthMethod = "oidc"
+)
 
-		tokenString := parts[1]
-		secret := os.Getenv("SUPABASE_JWT_SECRET")
-		if secret == "" {
-			log.Error().Msg("SUPABASE_JWT_SECRET is not set")
-			http.Error(w, "Internal server error", http.StatusInternalServerError)
-			return
+// Principal is the authenticated caller of a request, whichever credential it used
+type Principal struct {
+	User   models.User
+	Method AuthMethod
+	// Scopes limit what API-token and OIDC principals can do; sessions aren't limited
+	Scopes models.ScopeList
+
+	SessionID string           // Set for AuthMethodSession
+	APIToken  *models.APIToken // Set for AuthMethodAPIToken
+	Issuer    string           // Set for AuthMethodOIDC
+	Subject   string           // Set for AuthMethodOIDC
+}
+
+// Restricted reports whether the principal is limited to its scopes
+func (p *Principal) Restricted() bool {
+	return p.Method != AuthMethodSession
+}
+
+// HasScope reports whether the principal may act with scope
+func (p *Principal) HasScope(scope string) bool {
+	if !p.Restricted() {
+		return true
+	}
+	for _, s := range p.Scopes {
+		if s == scope {
+			return true
 		}
+	}
+	return false
+}
+
+// PrincipalFromContext returns the principal put in the context by Authenticate
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(PrincipalContextKey).(*Principal)
+	return principal, ok
+}
+
+// ErrUnsupportedCredential is returned by an Authenticator for bearer tokens of a kind it
+// doesn't handle, so the chain tries the next one
+var ErrUnsupportedCredential = errors.New("unsupported credential")
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+// AuthError is an authentication failure; Message is returned to the client while Err is
+// only logged
+type AuthError struct {
+	Message string
+	Err     error
+}
+
+func (e *AuthError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Authenticator resolves a bearer token to a principal
+type Authenticator interface {
+	Authenticate(r *http.Request, token string) (*Principal, error)
+}
+
+// GithubTokenSource returns a usable GitHub token for a user, refreshing it when needed
+type GithubTokenSource interface {
+	GithubToken(ctx context.Context, user *models.User) (string, error)
+}
+
+// Authenticate resolves the bearer token with the first authenticator in the chain that
+// supports it, and puts the principal, its user, session ID and GitHub token in the context.
+// Expiring GitHub tokens are refreshed through github; when that isn't possible the request
+// continues without a GitHub token and the user is flagged with NeedsReauth.
+func Authenticate(github GithubTokenSource, chain ...Authenticator) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Get token from Authorization header (format: "Bearer <token>")
+			if r.Header.Get("Authorization") == "" {
+				http.Error(w, "Unauthorized: No authorization header", http.StatusUnauthorized)
+				return
+			}
+			token, ok := BearerToken(r)
+			if !ok {
+				http.Error(w, "Unauthorized: Invalid authorization format", http.StatusUnauthorized)
+				return
 			}
-			return []byte(secret), nil
-		})
 
-		if err != nil {
-			log.Warn().Err(err).Msg("Failed to parse JWT token")
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			principal, err := authenticate(r, token, chain)
+			if err != nil {
+				message := "Invalid credentials"
+				var authErr *AuthError
+				if errors.As(err, &authErr) {
+					message = authErr.Message
+				}
+				log.Debug().Err(err).Str("path", r.URL.Path).Msg("[Authenticate] Authentication failed")
+				http.Error(w, "Unauthorized: "+message, http.StatusUnauthorized)
+				return
+			}
+
+			githubToken, err := github.GithubToken(r.Context(), &principal.User)
+			if err != nil {
+				log.Warn().Err(err).Str("user_id", principal.User.ID).Msg("[Authenticate] No usable GitHub token")
+			}
+
+			// Add Principal, User and Token to Context
+			ctx := context.WithValue(r.Context(), PrincipalContextKey, principal)
+			ctx = context.WithValue(ctx, UserContextKey, principal.User)
+			if principal.SessionID != "" {
+				ctx = context.WithValue(ctx, SessionIDContextKey, principal.SessionID)
+			}
+			// Populate GithubTokenContextKey with the (refreshed) access token so existing controllers work!
+			ctx = context.WithValue(ctx, GithubTokenContextKey, githubToken)
 
-		if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-			// Add claims to context
-			ctx := context.WithValue(r.Context(), UserContextKey, claims)
 			next.ServeHTTP(w, r.WithContext(ctx))
-		} else {
-			http.Error(w, "Invalid token claims", http.StatusUnauthorized)
+		})
+	}
+}
+
+func authenticate(r *http.Request, token string, chain []Authenticator) (*Principal, error) {
+	for _, authenticator := range chain {
+		principal, err := authenticator.Authenticate(r, token)
+		if errors.Is(err, ErrUnsupportedCredential) {
+			continue
 		}
-	})
+		return principal, err
+	}
+	return nil, &AuthError{Message: "Unsupported credentials"}
 }
 
 // GithubTokenMiddleware extracts the X-GitHub-Token header and stores it in the context
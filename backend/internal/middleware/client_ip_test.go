@@ -0,0 +1,82 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies(" 10.0.0.0/8, 192.168.1.10 ,, ::1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies: %v", err)
+	}
+	for ip, want := range map[string]bool{
+		"10.1.2.3":     true,
+		"192.168.1.10": true,
+		"192.168.1.11": false,
+		"::1":          true,
+		"203.0.113.7":  false,
+	} {
+		if got := isTrusted(ip, proxies); got != want {
+			t.Errorf("isTrusted(%s) = %v, want %v", ip, got, want)
+		}
+	}
+
+	for _, value := range []string{"10.0.0.0/33", "proxy.internal", "10.0.0"} {
+		if _, err := ParseTrustedProxies(value); err == nil {
+			t.Errorf("ParseTrustedProxies(%q) accepted an invalid entry", value)
+		}
+	}
+}
+
+func TestClientAddress(t *testing.T) {
+	trusted, _ := ParseTrustedProxies("10.0.0.0/8")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		realIP     string
+		want       string
+	}{
+		{"direct", "203.0.113.7:5000", nil, "", "203.0.113.7"},
+		{"spoofed by an untrusted client", "203.0.113.7:5000", []string{"198.51.100.1"}, "198.51.100.2", "203.0.113.7"},
+		{"trusted proxy", "10.0.0.2:5000", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"client prepends a fake hop", "10.0.0.2:5000", []string{"1.1.1.1, 198.51.100.1"}, "", "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:5000", []string{"198.51.100.1, 10.0.0.9", "10.0.0.5"}, "", "198.51.100.1"},
+		{"only trusted hops", "10.0.0.2:5000", []string{"10.0.0.9"}, "", "10.0.0.9"},
+		{"garbage hop", "10.0.0.2:5000", []string{"198.51.100.1, not-an-ip"}, "", "10.0.0.2"},
+		{"X-Real-IP from a trusted proxy", "10.0.0.2:5000", nil, "198.51.100.2", "198.51.100.2"},
+		{"invalid X-Real-IP", "10.0.0.2:5000", nil, "unknown", "10.0.0.2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			ClientAddress(trusted)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("ClientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientIPIgnoresHeadersWithoutMiddleware(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.RemoteAddr = "203.0.113.7:5000"
+	r.Header.Set("X-Forwarded-For", "198.51.100.1")
+	if got := ClientIP(r); got != "203.0.113.7" {
+		t.Errorf("ClientIP() = %s, want the remote address", got)
+	}
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/oidc"
+)
+
+// JWTVerifier validates JWTs from an OIDC provider
+type JWTVerifier interface {
+	Issuer() string
+	Verify(ctx context.Context, raw string) (*oidc.Claims, error)
+}
+
+// OIDCUserResolver maps the subject of a verified OIDC token to a DevPlus user
+type OIDCUserResolver interface {
+	ResolveOIDCUser(ctx context.Context, issuer string, claims *oidc.Claims) (*models.User, error)
+}
+
+type oidcAuthenticator struct {
+	verifier JWTVerifier
+	users    OIDCUserResolver
+}
+
+// NewOIDCAuthenticator authenticates JWTs issued by an external OIDC provider. The token's
+// signature is checked against the provider's JWKS along with its issuer, audience and
+// expiry; its scope claim limits what the principal can do.
+func NewOIDCAuthenticator(verifier JWTVerifier, users OIDCUserResolver) Authenticator {
+	return &oidcAuthenticator{verifier: verifier, users: users}
+}
+
+func (a *oidcAuthenticator) Authenticate(r *http.Request, token string) (*Principal, error) {
+	if !oidc.LooksLikeJWT(token) {
+		return nil, ErrUnsupportedCredential
+	}
+
+	claims, err := a.verifier.Verify(r.Context(), token)
+	if err != nil {
+		return nil, &AuthError{Message: "Invalid or expired token", Err: err}
+	}
+	user, err := a.users.ResolveOIDCUser(r.Context(), a.verifier.Issuer(), claims)
+	if err != nil {
+		return nil, &AuthError{Message: "Token does not belong to a DevPlus user", Err: err}
+	}
+
+	var scopes models.ScopeList
+	for _, scope := range claims.Scopes() {
+		if models.IsValidScope(scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	return &Principal{
+		User:    *user,
+		Method:  AuthMethodOIDC,
+		Scopes:  scopes,
+		Issuer:  a.verifier.Issuer(),
+		Subject: claims.Subject,
+	}, nil
+}
@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// ForbiddenMessage is the body of every 403 returned for missing or insufficient roles
+const ForbiddenMessage = "Forbidden: insufficient role"
+
+// RepositoryAuthorizer checks a user's effective role on a repository. Users whose role is
+// lower than minRole, or who can't access the repository at all, get models.ErrForbidden.
+type RepositoryAuthorizer interface {
+	AuthorizeRepository(ctx context.Context, userID string, repoID string, minRole string) error
+	AuthorizeRepositoryByName(ctx context.Context, userID string, owner string, name string, minRole string) error
+}
+
+// RequireRepositoryRole rejects requests whose user doesn't have at least minRole on the
+// repository addressed by the route: the {id} path variable, {owner}/{repo}, or else the
+// repo_id query parameter. Requests that don't address a repository are passed through.
+// Must run after Authenticate.
+func RequireRepositoryRole(authorizer RepositoryAuthorizer, minRole string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user, ok := r.Context().Value(UserContextKey).(models.User)
+			if !ok {
+				http.Error(w, "Unauthorized: User not found in context", http.StatusUnauthorized)
+				return
+			}
+
+			vars := mux.Vars(r)
+			var err error
+			switch {
+			case vars["id"] != "":
+				err = authorizer.AuthorizeRepository(r.Context(), user.ID, vars["id"], minRole)
+			case vars["owner"] != "" && vars["repo"] != "":
+				err = authorizer.AuthorizeRepositoryByName(r.Context(), user.ID, vars["owner"], vars["repo"], minRole)
+			case r.URL.Query().Get("repo_id") != "":
+				err = authorizer.AuthorizeRepository(r.Context(), user.ID, r.URL.Query().Get("repo_id"), minRole)
+			}
+
+			if errors.Is(err, models.ErrForbidden) {
+				log.Warn().Str("user_id", user.ID).Str("path", r.URL.Path).Str("min_role", minRole).Msg("[RequireRepositoryRole] Access denied")
+				http.Error(w, ForbiddenMessage, http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				log.Error().Err(err).Str("path", r.URL.Path).Msg("[RequireRepositoryRole] Failed to check repository role")
+				http.Error(w, "Internal server error", http.StatusInternalServerError)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
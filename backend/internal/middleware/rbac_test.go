@@ -0,0 +1,104 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"devplus-backend/internal/models"
+)
+
+// fakeAuthorizer grants the roles in its maps, like the workspace service: users without a
+// role on a repository, including unknown ones, are forbidden
+type fakeAuthorizer struct {
+	byID   map[string]string
+	byName map[string]string
+	err    error
+	calls  int
+}
+
+func (a *fakeAuthorizer) AuthorizeRepository(ctx context.Context, userID string, repoID string, minRole string) error {
+	return a.authorize(a.byID[repoID], minRole)
+}
+
+func (a *fakeAuthorizer) AuthorizeRepositoryByName(ctx context.Context, userID string, owner string, name string, minRole string) error {
+	return a.authorize(a.byName[owner+"/"+name], minRole)
+}
+
+func (a *fakeAuthorizer) authorize(role string, minRole string) error {
+	a.calls++
+	if a.err != nil {
+		return a.err
+	}
+	if !models.RoleAtLeast(role, minRole) {
+		return models.ErrForbidden
+	}
+	return nil
+}
+
+func TestRequireRepositoryRole(t *testing.T) {
+	tests := []struct {
+		name      string
+		minRole   string
+		vars      map[string]string
+		query     string
+		err       error
+		want      int
+		wantCalls int
+	}{
+		{"viewer reads by id", models.RoleViewer, map[string]string{"id": "repo-viewer"}, "", nil, http.StatusOK, 1},
+		{"viewer can't maintain by id", models.RoleMaintainer, map[string]string{"id": "repo-viewer"}, "", nil, http.StatusForbidden, 1},
+		{"maintainer by id", models.RoleMaintainer, map[string]string{"id": "repo-maintainer"}, "", nil, http.StatusOK, 1},
+		{"viewer reads by owner and name", models.RoleViewer, map[string]string{"owner": "acme", "repo": "viewer"}, "", nil, http.StatusOK, 1},
+		{"viewer can't maintain by owner and name", models.RoleMaintainer, map[string]string{"owner": "acme", "repo": "viewer"}, "", nil, http.StatusForbidden, 1},
+		{"maintainer by owner and name", models.RoleMaintainer, map[string]string{"owner": "acme", "repo": "maintainer"}, "", nil, http.StatusOK, 1},
+		{"viewer reads by repo_id", models.RoleViewer, nil, "repo_id=repo-viewer", nil, http.StatusOK, 1},
+		{"viewer can't maintain by repo_id", models.RoleMaintainer, nil, "repo_id=repo-viewer", nil, http.StatusForbidden, 1},
+		{"path id wins over repo_id", models.RoleMaintainer, map[string]string{"id": "repo-viewer"}, "repo_id=repo-maintainer", nil, http.StatusForbidden, 1},
+		{"unknown repository is forbidden, not not found", models.RoleViewer, map[string]string{"id": "repo-unknown"}, "", nil, http.StatusForbidden, 1},
+		{"unknown owner and name is forbidden, not not found", models.RoleViewer, map[string]string{"owner": "acme", "repo": "unknown"}, "", nil, http.StatusForbidden, 1},
+		{"no repository passes through", models.RoleOwner, nil, "", nil, http.StatusOK, 0},
+		{"owner without repo passes through", models.RoleOwner, map[string]string{"owner": "acme"}, "", nil, http.StatusOK, 0},
+		{"authorizer failure", models.RoleViewer, map[string]string{"id": "repo-viewer"}, "", errors.New("connection refused"), http.StatusInternalServerError, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorizer := &fakeAuthorizer{
+				byID:   map[string]string{"repo-viewer": models.RoleViewer, "repo-maintainer": models.RoleMaintainer},
+				byName: map[string]string{"acme/viewer": models.RoleViewer, "acme/maintainer": models.RoleMaintainer},
+				err:    tt.err,
+			}
+			r := httptest.NewRequest("GET", "/api/v1/repos?"+tt.query, nil)
+			r = r.WithContext(context.WithValue(r.Context(), UserContextKey, models.User{ID: "user-1"}))
+			r = mux.SetURLVars(r, tt.vars)
+
+			w := httptest.NewRecorder()
+			RequireRepositoryRole(authorizer, tt.minRole)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})).ServeHTTP(w, r)
+
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d", w.Code, tt.want)
+			}
+			if authorizer.calls != tt.wantCalls {
+				t.Errorf("authorizer called %d times, want %d", authorizer.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestRequireRepositoryRoleWithoutUser(t *testing.T) {
+	r := mux.SetURLVars(httptest.NewRequest("GET", "/", nil), map[string]string{"id": "repo-viewer"})
+	w := httptest.NewRecorder()
+	RequireRepositoryRole(&fakeAuthorizer{}, models.RoleViewer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("handler called without a user")
+	})).ServeHTTP(w, r)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
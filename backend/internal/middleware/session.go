@@ -3,45 +3,37 @@ package middleware
 import (
 	"context"
 	"net/http"
-	"time"
+	"strings"
 
-	"devplus-backend/internal/db"
 	"devplus-backend/internal/models"
 )
 
-// SessionMiddleware validates the session_token cookie
-func SessionMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get token from Authorization header (format: "Bearer <token>")
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Unauthorized: No authorization header", http.StatusUnauthorized)
-			return
-		}
-
-		// Extract token
-		var sessionID string
-		if len(authHeader) > 7 && authHeader[:7] == "Bearer " {
-			sessionID = authHeader[7:]
-		} else {
-			http.Error(w, "Unauthorized: Invalid authorization format", http.StatusUnauthorized)
-			return
-		}
+// SessionValidator validates browser session tokens
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, token string) (*models.Session, error)
+}
 
-		dbInstance := db.GetInstance()
+type sessionAuthenticator struct {
+	sessions SessionValidator
+}
 
-		var session models.Session
-		// Preload User to get AccessToken
-		if err := dbInstance.Preload("User").Where("id = ? AND expires_at > ?", sessionID, time.Now()).First(&session).Error; err != nil {
-			http.Error(w, "Unauthorized: Invalid or expired session", http.StatusUnauthorized)
-			return
-		}
+// NewSessionAuthenticator authenticates browser session tokens. It accepts any opaque bearer
+// token, so it belongs at the end of the chain.
+func NewSessionAuthenticator(sessions SessionValidator) Authenticator {
+	return &sessionAuthenticator{sessions: sessions}
+}
 
-		// Add User and Token to Context
-		ctx := context.WithValue(r.Context(), UserContextKey, session.User)
-		// Populate GithubTokenContextKey with the stored access token so existing controllers work!
-		ctx = context.WithValue(ctx, GithubTokenContextKey, session.User.AccessToken)
+func (a *sessionAuthenticator) Authenticate(r *http.Request, token string) (*Principal, error) {
+	// Validate (and slide) the session, with the User preloaded to get AccessToken
+	session, err := a.sessions.ValidateSession(r.Context(), token)
+	if err != nil {
+		return nil, &AuthError{Message: "Invalid or expired session", Err: err}
+	}
+	return &Principal{User: session.User, Method: AuthMethodSession, SessionID: session.ID}, nil
+}
 
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+// BearerToken extracts the token from an "Authorization: Bearer <token>" header
+func BearerToken(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return token, ok && token != ""
 }
@@ -0,0 +1,16 @@
+-- Releases published to GitHub from generated changelogs
+CREATE TABLE IF NOT EXISTS public.releases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repo_id UUID NOT NULL REFERENCES public.repositories(id) ON DELETE CASCADE,
+    tag_name TEXT NOT NULL,
+    name TEXT,
+    changelog TEXT,
+    draft BOOLEAN DEFAULT TRUE,
+    github_release_id BIGINT,
+    github_release_url TEXT,
+    changelog_pr_number BIGINT,
+    changelog_pr_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_releases_repo_tag ON public.releases(repo_id, tag_name);
@@ -0,0 +1,5 @@
+-- Store the itemised, rule-based release risk breakdown alongside the AI analysis
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS release_risk_score INTEGER DEFAULT 0;
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS release_changelog TEXT;
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS release_risk_analysis TEXT;
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS release_risk_breakdown JSONB;
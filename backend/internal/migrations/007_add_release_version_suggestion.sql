@@ -0,0 +1,2 @@
+-- Suggested next semantic version for the pending release
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS release_version_suggestion JSONB;
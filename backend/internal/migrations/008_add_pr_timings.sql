@@ -0,0 +1,22 @@
+-- Review and merge timing facts per pull request, captured during sync
+CREATE TABLE IF NOT EXISTS public.pr_timings (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    pull_request_id UUID NOT NULL REFERENCES public.pull_requests(id) ON DELETE CASCADE,
+    repo_id UUID NOT NULL REFERENCES public.repositories(id) ON DELETE CASCADE,
+    number BIGINT,
+    author TEXT,
+    opened_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    first_review_at TIMESTAMP WITH TIME ZONE,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    merged_at TIMESTAMP WITH TIME ZONE,
+    time_to_first_review_hours DOUBLE PRECISION,
+    time_to_approval_hours DOUBLE PRECISION,
+    approval_to_merge_hours DOUBLE PRECISION,
+    cycle_time_hours DOUBLE PRECISION,
+    review_rounds INTEGER DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pr_timings_pull_request_id ON public.pr_timings(pull_request_id);
+CREATE INDEX IF NOT EXISTS idx_pr_timings_repo_id ON public.pr_timings(repo_id);
+CREATE INDEX IF NOT EXISTS idx_pr_timings_opened_at ON public.pr_timings(opened_at);
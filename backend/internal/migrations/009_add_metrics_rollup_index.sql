@@ -0,0 +1,6 @@
+-- One snapshot per repository, metric type and day so the rollup job can upsert
+DELETE FROM public.metrics a
+    USING public.metrics b
+    WHERE a.repo_id = b.repo_id AND a.type = b.type AND a.date = b.date AND a.id < b.id;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_metrics_repo_type_date ON public.metrics(repo_id, type, date);
+CREATE INDEX IF NOT EXISTS idx_metrics_type_date ON public.metrics(type, date);
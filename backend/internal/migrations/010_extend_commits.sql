@@ -0,0 +1,16 @@
+-- Commit ingestion: author details, stats and pull request association.
+-- The same SHA can appear in forks, so uniqueness is per repository.
+ALTER TABLE public.commits DROP CONSTRAINT IF EXISTS commits_sha_key;
+DROP INDEX IF EXISTS public.idx_commits_sha;
+
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS author_email TEXT;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS author_login TEXT;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS committed_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS additions INTEGER;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS deletions INTEGER;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS url TEXT;
+ALTER TABLE public.commits ADD COLUMN IF NOT EXISTS pull_request_id UUID REFERENCES public.pull_requests(id) ON DELETE SET NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_commits_repo_sha ON public.commits(repo_id, sha);
+CREATE INDEX IF NOT EXISTS idx_commits_committed_at ON public.commits(committed_at);
+CREATE INDEX IF NOT EXISTS idx_commits_pull_request_id ON public.commits(pull_request_id);
@@ -0,0 +1,41 @@
+-- Workspaces: repositories belong to the GitHub organisation/account workspace instead of
+-- the user who happened to sync them last, and access is granted through membership.
+CREATE TABLE IF NOT EXISTS public.workspaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    kind TEXT,
+    github_account_login TEXT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_github_account_login ON public.workspaces(github_account_login);
+
+CREATE TABLE IF NOT EXISTS public.workspace_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    workspace_id UUID NOT NULL REFERENCES public.workspaces(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'maintainer', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspace_members_workspace_user ON public.workspace_members(workspace_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_workspace_members_user_id ON public.workspace_members(user_id);
+
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS workspace_id UUID REFERENCES public.workspaces(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_repositories_workspace_id ON public.repositories(workspace_id);
+
+-- Backfill: one workspace per repository owner, the syncing users become owners
+INSERT INTO public.workspaces (name, github_account_login, created_at, updated_at)
+SELECT DISTINCT owner, owner, NOW(), NOW() FROM public.repositories WHERE owner IS NOT NULL AND owner <> ''
+ON CONFLICT (github_account_login) DO NOTHING;
+
+UPDATE public.repositories r SET workspace_id = w.id
+FROM public.workspaces w
+WHERE w.github_account_login = r.owner AND r.workspace_id IS NULL;
+
+INSERT INTO public.workspace_members (workspace_id, user_id, role, created_at, updated_at)
+SELECT DISTINCT workspace_id, user_id, 'owner', NOW(), NOW() FROM public.repositories WHERE workspace_id IS NOT NULL
+ON CONFLICT (workspace_id, user_id) DO NOTHING;
+
+-- user_id now only records who added the repository
+COMMENT ON COLUMN public.repositories.user_id IS 'User who first synced the repository; access is granted via workspace_members';
@@ -0,0 +1,25 @@
+-- Per-repository role overrides. A repository member's role replaces their workspace role
+-- for that repository, and grants access to it without workspace membership.
+CREATE TABLE IF NOT EXISTS public.repository_members (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repo_id UUID NOT NULL REFERENCES public.repositories(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    role TEXT NOT NULL CHECK (role IN ('owner', 'maintainer', 'viewer')),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repository_members_repo_user ON public.repository_members(repo_id, user_id);
+CREATE INDEX IF NOT EXISTS idx_repository_members_user_id ON public.repository_members(user_id);
+
+-- Effective role of each user on each repository
+CREATE OR REPLACE VIEW public.repository_access AS
+SELECT rm.repo_id, rm.user_id, rm.role
+FROM public.repository_members rm
+UNION ALL
+SELECT r.id AS repo_id, wm.user_id, wm.role
+FROM public.repositories r
+JOIN public.workspace_members wm ON wm.workspace_id = r.workspace_id
+WHERE NOT EXISTS (
+    SELECT 1 FROM public.repository_members rm
+    WHERE rm.repo_id = r.id AND rm.user_id = wm.user_id
+);
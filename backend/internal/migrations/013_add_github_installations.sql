@@ -0,0 +1,16 @@
+-- GitHub App installations; repositories reference them through installation_id so
+-- background work can authenticate as the App instead of a user.
+CREATE TABLE IF NOT EXISTS public.github_installations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    installation_id BIGINT NOT NULL,
+    account_login TEXT,
+    account_type TEXT,
+    repository_selection TEXT,
+    suspended_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_github_installations_installation_id ON public.github_installations(installation_id);
+CREATE INDEX IF NOT EXISTS idx_github_installations_account_login ON public.github_installations(account_login);
+
+CREATE INDEX IF NOT EXISTS idx_repositories_installation_id ON public.repositories(installation_id);
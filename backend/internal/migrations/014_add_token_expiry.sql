@@ -0,0 +1,5 @@
+-- Expiry of GitHub user tokens (NULL for non-expiring tokens) and a flag for users whose
+-- tokens were revoked and who must log in again.
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS token_expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS refresh_token_expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.users ADD COLUMN IF NOT EXISTS needs_reauth BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,24 @@
+-- Session lifecycle: bearer and refresh tokens are stored as SHA-256 hashes, sessions slide
+-- on activity up to refresh_expires_at, and record the device they were created from.
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS token_hash TEXT;
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS refresh_token_hash TEXT;
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS refresh_expires_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS user_agent TEXT;
+ALTER TABLE public.sessions ADD COLUMN IF NOT EXISTS ip_address TEXT;
+
+-- Existing sessions used their ID as bearer token; keep them valid until they expire
+UPDATE public.sessions
+SET token_hash = encode(sha256(id::text::bytea), 'hex'),
+    refresh_expires_at = expires_at,
+    last_seen_at = created_at,
+    user_agent = '',
+    ip_address = ''
+WHERE token_hash IS NULL;
+
+ALTER TABLE public.sessions ALTER COLUMN token_hash SET NOT NULL;
+ALTER TABLE public.sessions ALTER COLUMN refresh_expires_at SET NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_token_hash ON public.sessions(token_hash);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_sessions_refresh_token_hash ON public.sessions(refresh_token_hash);
+CREATE INDEX IF NOT EXISTS idx_sessions_refresh_expires_at ON public.sessions(refresh_expires_at);
+CREATE INDEX IF NOT EXISTS idx_auth_states_expires_at ON public.auth_states(expires_at);
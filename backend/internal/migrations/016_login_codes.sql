@@ -0,0 +1,16 @@
+-- One-time login codes: the OAuth callback redirects with a short-lived code instead of a
+-- session token, and the frontend exchanges it with the PKCE verifier it started login with.
+ALTER TABLE public.auth_states ADD COLUMN IF NOT EXISTS code_challenge TEXT;
+
+CREATE TABLE IF NOT EXISTS public.login_codes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code_hash TEXT NOT NULL,
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    code_challenge TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_login_codes_code_hash ON public.login_codes(code_hash);
+CREATE INDEX IF NOT EXISTS idx_login_codes_user_id ON public.login_codes(user_id);
+CREATE INDEX IF NOT EXISTS idx_login_codes_expires_at ON public.login_codes(expires_at);
@@ -0,0 +1,22 @@
+-- SSO login through external OIDC providers. Users who signed in with SSO and haven't linked
+-- a GitHub account yet have no github_id; their provider accounts live in user_identities.
+ALTER TABLE public.users ALTER COLUMN github_id DROP NOT NULL;
+
+ALTER TABLE public.auth_states ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE public.auth_states ADD COLUMN IF NOT EXISTS provider_verifier TEXT;
+ALTER TABLE public.auth_states ADD COLUMN IF NOT EXISTS nonce TEXT;
+ALTER TABLE public.auth_states ADD COLUMN IF NOT EXISTS link_user_id UUID REFERENCES public.users(id) ON DELETE CASCADE;
+
+CREATE TABLE IF NOT EXISTS public.user_identities (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    issuer TEXT NOT NULL,
+    subject TEXT NOT NULL,
+    email TEXT,
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_identities_issuer_subject ON public.user_identities(issuer, subject);
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON public.user_identities(user_id);
@@ -0,0 +1,33 @@
+-- Code hosts besides GitHub. Repositories, merge requests and workspaces record the host they
+-- come from; repositories and merge requests on other hosts are identified by the host's IDs
+-- in external_id instead of github_repo_id / github_pr_id.
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS external_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_repositories_provider_external_id ON public.repositories(provider, external_id);
+
+ALTER TABLE public.pull_requests ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+ALTER TABLE public.pull_requests ADD COLUMN IF NOT EXISTS external_id BIGINT;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pull_requests_provider_external_id ON public.pull_requests(provider, external_id);
+
+-- The same login can exist on several hosts: workspaces are unique per host and account
+ALTER TABLE public.workspaces ADD COLUMN IF NOT EXISTS provider TEXT NOT NULL DEFAULT 'github';
+DROP INDEX IF EXISTS public.idx_workspaces_github_account_login;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_workspaces_provider_account ON public.workspaces(provider, github_account_login);
+
+-- Accounts on other code hosts and their OAuth tokens (encrypted like the GitHub tokens)
+CREATE TABLE IF NOT EXISTS public.code_host_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    provider TEXT NOT NULL,
+    external_id BIGINT NOT NULL,
+    username TEXT,
+    avatar_url TEXT,
+    access_token TEXT,
+    refresh_token TEXT,
+    token_expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_code_host_accounts_provider_external_id ON public.code_host_accounts(provider, external_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_code_host_accounts_user_provider ON public.code_host_accounts(user_id, provider);
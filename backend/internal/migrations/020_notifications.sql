@@ -0,0 +1,52 @@
+-- Notifications: user-owned channels (Slack, Teams, generic webhooks, email), subscription
+-- rules, and the delivery log with retry state. Channel and delivery targets are encrypted
+-- like the OAuth tokens.
+CREATE TABLE IF NOT EXISTS public.notification_channels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    target_hint TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_channels_user_id ON public.notification_channels(user_id);
+
+CREATE TABLE IF NOT EXISTS public.notification_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    repo_id UUID REFERENCES public.repositories(id) ON DELETE CASCADE,
+    channel_id UUID REFERENCES public.notification_channels(id) ON DELETE CASCADE,
+    notify_author BOOLEAN NOT NULL DEFAULT FALSE,
+    decision TEXT NOT NULL DEFAULT '',
+    risk_score_above INTEGER,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_rules_user_id ON public.notification_rules(user_id);
+CREATE INDEX IF NOT EXISTS idx_notification_rules_event ON public.notification_rules(event) WHERE enabled;
+
+CREATE TABLE IF NOT EXISTS public.notification_deliveries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    rule_id UUID REFERENCES public.notification_rules(id) ON DELETE SET NULL,
+    channel_id UUID REFERENCES public.notification_channels(id) ON DELETE SET NULL,
+    kind TEXT NOT NULL,
+    target TEXT NOT NULL,
+    target_hint TEXT NOT NULL DEFAULT '',
+    event TEXT NOT NULL,
+    repo_id UUID REFERENCES public.repositories(id) ON DELETE SET NULL,
+    message TEXT NOT NULL,
+    status TEXT NOT NULL DEFAULT 'pending',
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT NOT NULL DEFAULT '',
+    next_attempt_at TIMESTAMP WITH TIME ZONE,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_user_id ON public.notification_deliveries(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON public.notification_deliveries(next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,26 @@
+-- Slack app: Slack users linked to DevPlus users, and /devplus commands waiting for their
+-- analysis result. Response URLs are encrypted like the OAuth tokens.
+CREATE TABLE IF NOT EXISTS public.slack_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    team_id TEXT NOT NULL,
+    slack_user_id TEXT NOT NULL,
+    slack_username TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_slack_accounts_team_user ON public.slack_accounts(team_id, slack_user_id);
+CREATE INDEX IF NOT EXISTS idx_slack_accounts_user_id ON public.slack_accounts(user_id);
+
+CREATE TABLE IF NOT EXISTS public.slack_command_responses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    command TEXT NOT NULL,
+    repo_id UUID NOT NULL REFERENCES public.repositories(id) ON DELETE CASCADE,
+    pr_number BIGINT,
+    response_url TEXT NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_slack_command_responses_repo_id ON public.slack_command_responses(repo_id);
@@ -0,0 +1,21 @@
+-- Outgoing webhooks: endpoints third-party consumers register for DevPlus events, with a
+-- signing secret. Their deliveries share the notification delivery log and retries; failed
+-- ones are the dead letters. URLs and secrets are encrypted like the OAuth tokens.
+CREATE TABLE IF NOT EXISTS public.webhook_endpoints (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES public.users(id) ON DELETE CASCADE,
+    name TEXT NOT NULL,
+    url TEXT NOT NULL,
+    url_hint TEXT NOT NULL DEFAULT '',
+    events TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_endpoints_user_id ON public.webhook_endpoints(user_id);
+
+ALTER TABLE public.notification_deliveries
+    ADD COLUMN IF NOT EXISTS endpoint_id UUID REFERENCES public.webhook_endpoints(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_endpoint_failed ON public.notification_deliveries(user_id, created_at DESC) WHERE endpoint_id IS NOT NULL AND status = 'failed';
@@ -0,0 +1,4 @@
+-- Per-repository cursor for the default branch commit sync. Stored commits also come from
+-- pull requests and pushes to other branches, so their newest date can't serve as the cursor.
+-- Repositories start without one and re-read the initial window once; commits are upserted.
+ALTER TABLE public.repositories ADD COLUMN IF NOT EXISTS commits_synced_until TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,20 @@
+-- Release risk runs wait for the AI reviews of their pull requests in the database instead of
+-- in memory, so they survive restarts. The repository points at its current run; results of
+-- older runs are ignored.
+CREATE TABLE IF NOT EXISTS public.release_risk_runs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    repo_id UUID NOT NULL REFERENCES public.repositories(id) ON DELETE CASCADE,
+    pr_ids TEXT NOT NULL,
+    pending_pr_ids TEXT NOT NULL DEFAULT '',
+    facts JSONB,
+    tags TEXT,
+    status TEXT NOT NULL,
+    wait_until TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_release_risk_runs_waiting ON public.release_risk_runs(wait_until) WHERE status = 'waiting';
+
+ALTER TABLE public.repositories
+    ADD COLUMN IF NOT EXISTS release_risk_run_id UUID REFERENCES public.release_risk_runs(id) ON DELETE SET NULL;
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// APITokenPrefix starts every personal access token so they are recognisable in config files
+// and secret scanners, and distinguishable from session tokens
+const APITokenPrefix = "dvp_"
+
+// Scopes an API token can be granted
+const (
+	ScopeReposRead     = "repos:read"     // Read repositories, pull requests, commits, metrics and releases
+	ScopeReposWrite    = "repos:write"    // Sync repositories and publish releases
+	ScopeAnalysisWrite = "analysis:write" // Trigger AI analysis and release-risk calculation
+)
+
+// APITokenScopes lists every scope an API token can be granted
+var APITokenScopes = []string{ScopeReposRead, ScopeReposWrite, ScopeAnalysisWrite}
+
+// IsValidScope reports whether scope is one of APITokenScopes
+func IsValidScope(scope string) bool {
+	for _, s := range APITokenScopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIToken is a user-managed personal access token for API and CLI clients. Only a hash of
+// the token is stored; Prefix keeps its first characters so users can tell tokens apart.
+type APIToken struct {
+	ID         string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     string     `gorm:"index;not null" json:"user_id"`
+	User       User       `gorm:"foreignKey:UserID" json:"-"`
+	Name       string     `gorm:"not null" json:"name"`
+	Prefix     string     `gorm:"not null" json:"prefix"`
+	TokenHash  string     `gorm:"uniqueIndex;not null" json:"-"`
+	Scopes     ScopeList  `gorm:"type:text;not null" json:"scopes"`
+	ExpiresAt  *time.Time `json:"expires_at"` // Nil means the token doesn't expire
+	LastUsedAt *time.Time `json:"last_used_at"`
+	LastUsedIP string     `json:"last_used_ip"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// HasScope reports whether the token was granted scope
+func (t *APIToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// ScopeList is a list of scopes stored as a space-separated string
+type ScopeList []string
+
+// Value implements driver.Valuer
+func (l ScopeList) Value() (driver.Value, error) {
+	return strings.Join(l, " "), nil
+}
+
+// Scan implements sql.Scanner
+func (l *ScopeList) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*l = nil
+	case string:
+		*l = strings.Fields(v)
+	case []byte:
+		*l = strings.Fields(string(v))
+	default:
+		return fmt.Errorf("cannot scan %T into ScopeList", value)
+	}
+	return nil
+}
@@ -3,12 +3,35 @@ package models
 import "time"
 
 type AuthState struct {
-	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	State     string    `gorm:"uniqueIndex;not null" json:"state"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID            string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	State         string    `gorm:"uniqueIndex;not null" json:"state"`
+	CodeChallenge string    `json:"-"` // PKCE S256 challenge of the client that started the login
+	ExpiresAt     time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	// Provider is "github" or the name of an OIDC provider. For OIDC logins DevPlus is itself
+	// a PKCE client: ProviderVerifier and Nonce are checked when the provider redirects back.
+	Provider         string  `gorm:"not null;default:github" json:"provider"`
+	ProviderVerifier string  `json:"-"`
+	Nonce            string  `json:"-"`
+	LinkUserID       *string `gorm:"type:uuid" json:"-"` // Set when a signed-in user links their GitHub account
 }
 
 func (AuthState) TableName() string {
 	return "auth_states"
 }
+
+// LoginCode is the short-lived, single-use code handed to the frontend after the OAuth
+// callback. The frontend exchanges it, together with its PKCE verifier, for a session.
+type LoginCode struct {
+	ID            string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CodeHash      string    `gorm:"uniqueIndex;not null" json:"-"`
+	UserID        string    `gorm:"index;not null" json:"user_id"`
+	CodeChallenge string    `gorm:"not null" json:"-"`
+	ExpiresAt     time.Time `gorm:"not null" json:"expires_at"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (LoginCode) TableName() string {
+	return "login_codes"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CodeHostAccount links a user to their account on a code host other than GitHub, whose
+// account and tokens live on User. Its OAuth tokens are used to sync repositories and merge
+// requests from the host.
+type CodeHostAccount struct {
+	ID         string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     string `gorm:"type:uuid;uniqueIndex:idx_code_host_accounts_user_provider;not null" json:"user_id"`
+	Provider   string `gorm:"uniqueIndex:idx_code_host_accounts_provider_external_id;uniqueIndex:idx_code_host_accounts_user_provider;not null" json:"provider"`
+	ExternalID int64  `gorm:"uniqueIndex:idx_code_host_accounts_provider_external_id;not null" json:"external_id"`
+	Username   string `json:"username"`
+	AvatarURL  string `json:"avatar_url"`
+
+	AccessToken    EncryptedString `gorm:"type:text" json:"-"` // Encrypted at rest, don't expose in JSON
+	RefreshToken   EncryptedString `gorm:"type:text" json:"-"` // Encrypted at rest, don't expose in JSON
+	TokenExpiresAt *time.Time      `json:"token_expires_at"`
+
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (CodeHostAccount) TableName() string {
+	return "code_host_accounts"
+}
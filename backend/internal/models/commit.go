@@ -5,15 +5,23 @@ import (
 )
 
 type Commit struct {
-	ID         int64       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
-	CreatedAt  *time.Time  `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt  *time.Time  `gorm:"column:updated_at" json:"updated_at"`
-	DeletedAt  *time.Time  `gorm:"column:deleted_at;index:idx_commits_deleted_at" json:"deleted_at"`
-	SHA        *string     `gorm:"column:sha;uniqueIndex:idx_commits_sha" json:"sha"`
-	Message    *string     `gorm:"column:message" json:"message"`
-	AuthorName *string     `gorm:"column:author_name" json:"author_name"`
-	RepoID     *int64      `gorm:"column:repo_id" json:"repo_id"`
-	Repository *Repository `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"repository"`
+	ID            string       `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt     *time.Time   `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt     *time.Time   `gorm:"column:updated_at" json:"updated_at"`
+	DeletedAt     *time.Time   `gorm:"column:deleted_at;index:idx_commits_deleted_at" json:"deleted_at"`
+	SHA           *string      `gorm:"column:sha;uniqueIndex:idx_commits_repo_sha" json:"sha"`
+	Message       *string      `gorm:"column:message" json:"message"`
+	AuthorName    *string      `gorm:"column:author_name" json:"author_name"`
+	AuthorEmail   *string      `gorm:"column:author_email" json:"author_email"`
+	AuthorLogin   *string      `gorm:"column:author_login" json:"author_login"`
+	CommittedAt   *time.Time   `gorm:"column:committed_at;index:idx_commits_committed_at" json:"committed_at"`
+	Additions     *int         `gorm:"column:additions" json:"additions"`
+	Deletions     *int         `gorm:"column:deletions" json:"deletions"`
+	URL           *string      `gorm:"column:url" json:"url"`
+	RepoID        *string      `gorm:"column:repo_id;type:uuid;uniqueIndex:idx_commits_repo_sha" json:"repo_id"`
+	Repository    *Repository  `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"repository,omitempty"`
+	PullRequestID *string      `gorm:"column:pull_request_id;type:uuid;index:idx_commits_pull_request_id" json:"pull_request_id"`
+	PullRequest   *PullRequest `gorm:"foreignKey:PullRequestID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"pull_request,omitempty"`
 }
 
 func (Commit) TableName() string {
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"time"
+)
+
+// PRTiming holds review and merge timing facts for a pull request, derived from its
+// GitHub review and timeline data during sync. Durations are in hours.
+type PRTiming struct {
+	ID                     string       `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt              *time.Time   `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt              *time.Time   `gorm:"column:updated_at" json:"updated_at"`
+	PullRequestID          string       `gorm:"column:pull_request_id;type:uuid;not null;uniqueIndex:idx_pr_timings_pull_request_id" json:"pull_request_id"`
+	PullRequest            *PullRequest `gorm:"foreignKey:PullRequestID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	RepoID                 string       `gorm:"column:repo_id;type:uuid;not null;index:idx_pr_timings_repo_id" json:"repo_id"`
+	Number                 int64        `gorm:"column:number" json:"number"`
+	Author                 string       `gorm:"column:author" json:"author"`
+	OpenedAt               time.Time    `gorm:"column:opened_at" json:"opened_at"`
+	FirstReviewAt          *time.Time   `gorm:"column:first_review_at" json:"first_review_at"`
+	ApprovedAt             *time.Time   `gorm:"column:approved_at" json:"approved_at"`
+	MergedAt               *time.Time   `gorm:"column:merged_at" json:"merged_at"`
+	TimeToFirstReviewHours *float64     `gorm:"column:time_to_first_review_hours" json:"time_to_first_review_hours"`
+	TimeToApprovalHours    *float64     `gorm:"column:time_to_approval_hours" json:"time_to_approval_hours"`
+	ApprovalToMergeHours   *float64     `gorm:"column:approval_to_merge_hours" json:"approval_to_merge_hours"`
+	CycleTimeHours         *float64     `gorm:"column:cycle_time_hours" json:"cycle_time_hours"`
+	ReviewRounds           int          `gorm:"column:review_rounds" json:"review_rounds"`
+}
+
+func (PRTiming) TableName() string {
+	return "public.pr_timings"
+}
+
+// Percentiles summarises a distribution; values are nil when there are no samples
+type Percentiles struct {
+	Count int      `json:"count"`
+	P50   *float64 `json:"p50"`
+	P75   *float64 `json:"p75"`
+	P90   *float64 `json:"p90"`
+}
+
+// CycleTimeStats are the percentile summaries for a group of pull requests
+type CycleTimeStats struct {
+	PRCount           int         `json:"pr_count"`
+	TimeToFirstReview Percentiles `json:"time_to_first_review_hours"`
+	TimeToApproval    Percentiles `json:"time_to_approval_hours"`
+	ApprovalToMerge   Percentiles `json:"approval_to_merge_hours"`
+	CycleTime         Percentiles `json:"cycle_time_hours"`
+	ReviewRounds      Percentiles `json:"review_rounds"`
+}
+
+// CycleTimeGroup is the stats for one repository, author or week
+type CycleTimeGroup struct {
+	Key   string         `json:"key"`
+	Label string         `json:"label,omitempty"`
+	Stats CycleTimeStats `json:"stats"`
+}
+
+// CycleTimeReport is the response of the cycle time metrics endpoint
+type CycleTimeReport struct {
+	Overall  CycleTimeStats   `json:"overall"`
+	ByRepo   []CycleTimeGroup `json:"by_repo"`
+	ByAuthor []CycleTimeGroup `json:"by_author"`
+	ByWeek   []CycleTimeGroup `json:"by_week"`
+}
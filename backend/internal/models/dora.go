@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// DoraMetrics holds the four DORA key metrics for a repository or team over a date range
+type DoraMetrics struct {
+	RepoID    string    `json:"repo_id,omitempty"`
+	RepoName  string    `json:"repo_name,omitempty"`
+	StartDate time.Time `json:"start_date"`
+	EndDate   time.Time `json:"end_date"`
+	// Source is "deployments" when GitHub deployments exist, otherwise "releases"
+	Source string `json:"source,omitempty"`
+
+	Deployments         int     `json:"deployments"`
+	FailedDeployments   int     `json:"failed_deployments"`
+	MergedPRs           int     `json:"merged_prs"`
+	DeploymentFrequency float64 `json:"deployment_frequency_per_week"`
+	// Median hours from PR creation to the first deployment containing the merge
+	LeadTimeHours *float64 `json:"lead_time_hours"`
+	// Share of deployments that failed or needed a fix, between 0 and 1
+	ChangeFailureRate *float64 `json:"change_failure_rate"`
+	// Median hours from a failed deployment to the next successful one
+	TimeToRestoreHours *float64 `json:"time_to_restore_hours"`
+}
+
+// DoraReport groups per-repository DORA metrics with the team-wide aggregate
+type DoraReport struct {
+	Team         DoraMetrics   `json:"team"`
+	Repositories []DoraMetrics `json:"repositories"`
+}
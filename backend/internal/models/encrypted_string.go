@@ -0,0 +1,61 @@
+package models
+
+import (
+	"database/sql/driver"
+	"fmt"
+
+	"devplus-backend/internal/encryption"
+)
+
+// EncryptedString is a string column stored encrypted with the default keyring. Values are
+// encrypted on write and decrypted on read; stored values that aren't encrypted are rejected
+// rather than read as plaintext. Empty strings are stored as is.
+type EncryptedString string
+
+// Value implements driver.Valuer
+func (s EncryptedString) Value() (driver.Value, error) {
+	if s == "" {
+		return "", nil
+	}
+	keyring, err := encryption.Default()
+	if err != nil {
+		return nil, err
+	}
+	return keyring.Encrypt(string(s))
+}
+
+// Scan implements sql.Scanner
+func (s *EncryptedString) Scan(value interface{}) error {
+	var stored string
+	switch v := value.(type) {
+	case nil:
+		*s = ""
+		return nil
+	case string:
+		stored = v
+	case []byte:
+		stored = string(v)
+	default:
+		return fmt.Errorf("cannot scan %T into EncryptedString", value)
+	}
+	if stored == "" {
+		*s = ""
+		return nil
+	}
+
+	keyring, err := encryption.Default()
+	if err != nil {
+		return err
+	}
+	plaintext, err := keyring.Decrypt(stored)
+	if err != nil {
+		return err
+	}
+	*s = EncryptedString(plaintext)
+	return nil
+}
+
+// String returns the decrypted value
+func (s EncryptedString) String() string {
+	return string(s)
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"devplus-backend/internal/encryption"
+)
+
+func TestEncryptedString(t *testing.T) {
+	keyring, err := encryption.ParseKeyring("k1:"+base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	encryption.SetDefault(keyring)
+	t.Cleanup(func() { encryption.SetDefault(nil) })
+
+	stored, err := EncryptedString("gho_token").Value()
+	if err != nil {
+		t.Fatalf("Value: %v", err)
+	}
+	if !encryption.IsEncrypted(stored.(string)) {
+		t.Fatalf("Value() = %q, want an encrypted envelope", stored)
+	}
+
+	for _, value := range []interface{}{stored, []byte(stored.(string))} {
+		var s EncryptedString
+		if err := s.Scan(value); err != nil || s != "gho_token" {
+			t.Errorf("Scan(%T) = %q, %v", value, s, err)
+		}
+	}
+
+	if empty, err := EncryptedString("").Value(); err != nil || empty != "" {
+		t.Errorf("Value(empty) = %v, %v, want it stored as is", empty, err)
+	}
+	for _, value := range []interface{}{nil, ""} {
+		s := EncryptedString("previous")
+		if err := s.Scan(value); err != nil || s != "" {
+			t.Errorf("Scan(%#v) = %q, %v", value, s, err)
+		}
+	}
+
+	var s EncryptedString
+	if err := s.Scan("gho_plaintext"); !errors.Is(err, encryption.ErrNotEncrypted) {
+		t.Errorf("Scan(plaintext) error = %v, want ErrNotEncrypted", err)
+	}
+	if err := s.Scan(42); err == nil {
+		t.Error("Scan(int) succeeded")
+	}
+
+	encryption.SetDefault(nil)
+	if _, err := EncryptedString("gho_token").Value(); !errors.Is(err, encryption.ErrNotConfigured) {
+		t.Errorf("Value() without keyring error = %v, want ErrNotConfigured", err)
+	}
+}
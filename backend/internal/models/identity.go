@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// UserIdentity links a user to an account at an external OIDC identity provider
+type UserIdentity struct {
+	ID          string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID      string    `gorm:"index;not null" json:"user_id"`
+	Provider    string    `gorm:"not null" json:"provider"`
+	Issuer      string    `gorm:"uniqueIndex:idx_user_identities_issuer_subject;not null" json:"issuer"`
+	Subject     string    `gorm:"uniqueIndex:idx_user_identities_issuer_subject;not null" json:"subject"`
+	Email       string    `json:"email"`
+	LastLoginAt time.Time `json:"last_login_at"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
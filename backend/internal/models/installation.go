@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// GithubInstallation is an installation of the DevPlus GitHub App on an organisation or user
+// account, recorded from installation webhooks.
+type GithubInstallation struct {
+	ID                  string     `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt           *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt           *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	InstallationID      int64      `gorm:"column:installation_id;uniqueIndex:idx_github_installations_installation_id" json:"installation_id"`
+	AccountLogin        string     `gorm:"column:account_login" json:"account_login"`
+	AccountType         string     `gorm:"column:account_type" json:"account_type"`
+	RepositorySelection string     `gorm:"column:repository_selection" json:"repository_selection"`
+	SuspendedAt         *time.Time `gorm:"column:suspended_at" json:"suspended_at"`
+}
+
+func (GithubInstallation) TableName() string {
+	return "public.github_installations"
+}
@@ -4,18 +4,58 @@ import (
 	"time"
 )
 
+// Metric types snapshotted per repository by the daily rollup
+const (
+	MetricOpenPRs        = "open_prs"
+	MetricMergedPRs      = "merged_prs"
+	MetricAvgCycleTime   = "avg_cycle_time_hours"
+	MetricAIApprovalRate = "ai_approval_rate"
+	MetricRiskScore      = "risk_score"
+)
+
+// Time series intervals
+const (
+	IntervalDay   = "day"
+	IntervalWeek  = "week"
+	IntervalMonth = "month"
+)
+
 type Metric struct {
-	ID         int64       `gorm:"column:id;primaryKey;autoIncrement" json:"id"`
+	ID         string      `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	CreatedAt  *time.Time  `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt  *time.Time  `gorm:"column:updated_at" json:"updated_at"`
 	DeletedAt  *time.Time  `gorm:"column:deleted_at;index:idx_metrics_deleted_at" json:"deleted_at"`
-	RepoID     *int64      `gorm:"column:repo_id" json:"repo_id"`
-	Repository *Repository `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"repository"`
-	Type       *string     `gorm:"column:type" json:"type"`
+	RepoID     *string     `gorm:"column:repo_id;type:uuid;uniqueIndex:idx_metrics_repo_type_date" json:"repo_id"`
+	Repository *Repository `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:SET NULL;" json:"repository,omitempty"`
+	Type       *string     `gorm:"column:type;uniqueIndex:idx_metrics_repo_type_date" json:"type"`
 	Value      *float64    `gorm:"column:value" json:"value"`
-	Date       *time.Time  `gorm:"column:date" json:"date"`
+	Date       *time.Time  `gorm:"column:date;uniqueIndex:idx_metrics_repo_type_date" json:"date"`
 }
 
 func (Metric) TableName() string {
 	return "public.metrics"
 }
+
+// RepoMetricSnapshot is the set of values rolled up for a repository and day.
+// Nil values have no data for that day and are not stored.
+type RepoMetricSnapshot struct {
+	OpenPRs           *float64
+	MergedPRs         *float64
+	AvgCycleTimeHours *float64
+	AIApprovalRate    *float64
+	RiskScore         *float64
+}
+
+// TimeSeriesPoint is one bucket of a metrics time series
+type TimeSeriesPoint struct {
+	Date  time.Time `json:"date"`
+	Value float64   `json:"value"`
+}
+
+// TimeSeries is the response of the metrics time-series endpoint
+type TimeSeries struct {
+	Type     string            `json:"type"`
+	Interval string            `json:"interval"`
+	RepoID   string            `json:"repo_id,omitempty"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
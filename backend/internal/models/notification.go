@@ -0,0 +1,127 @@
+package models
+
+import (
+	"time"
+)
+
+// Events DevPlus notifies about
+const (
+	EventPRAnalyzed            = "pr.analyzed"
+	EventRepoAnalyzed          = "repo.analyzed"
+	EventReleaseRiskCalculated = "release.risk_calculated"
+	EventSyncCompleted         = "sync.completed"
+
+	// EventWebhookTest is sent to a webhook endpoint on request; nothing subscribes to it
+	EventWebhookTest = "webhook.test"
+)
+
+// NotificationEvents lists every event a notification rule or webhook endpoint can subscribe to
+var NotificationEvents = []string{EventPRAnalyzed, EventRepoAnalyzed, EventReleaseRiskCalculated, EventSyncCompleted}
+
+// Kinds of notification channel
+const (
+	ChannelSlack   = "slack"   // Slack incoming webhook
+	ChannelTeams   = "teams"   // Microsoft Teams incoming webhook (Workflows or connector)
+	ChannelWebhook = "webhook" // Generic JSON webhook
+	ChannelEmail   = "email"   // Email over SMTP
+)
+
+// Delivery statuses
+const (
+	DeliveryPending = "pending" // Waiting for its first attempt or a retry
+	DeliverySent    = "sent"
+	DeliveryFailed  = "failed" // Gave up after the last retry; dead letters for webhook endpoints
+)
+
+// NotificationChannel is a destination a user can send notifications to. The target (a
+// webhook URL or an email address) is encrypted since webhook URLs carry their credentials;
+// TargetHint identifies it in listings.
+type NotificationChannel struct {
+	ID         string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     string          `gorm:"index;not null" json:"user_id"`
+	Name       string          `gorm:"not null" json:"name"`
+	Kind       string          `gorm:"not null" json:"kind"`
+	Target     EncryptedString `gorm:"type:text;not null" json:"-"`
+	TargetHint string          `gorm:"not null" json:"target_hint"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// NotificationRule subscribes a channel, or the pull request's author, to an event. Rules
+// only fire for repositories their owner can view.
+type NotificationRule struct {
+	ID        string  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string  `gorm:"index;not null" json:"user_id"`
+	Event     string  `gorm:"not null" json:"event"`
+	RepoID    *string `gorm:"type:uuid" json:"repo_id"`    // Nil matches every repository the user can view
+	ChannelID *string `gorm:"type:uuid" json:"channel_id"` // Nil when NotifyAuthor is set
+	// NotifyAuthor emails the pull request's author instead of a channel (pr.analyzed only)
+	NotifyAuthor bool `gorm:"not null;default:false" json:"notify_author"`
+	// Conditions; empty ones always match
+	Decision       string    `gorm:"not null;default:''" json:"decision,omitempty"` // AI decision, e.g. REQUEST_CHANGES (pr.analyzed)
+	RiskScoreAbove *int      `json:"risk_score_above,omitempty"`                    // Release risk score threshold (release.risk_calculated)
+	Enabled        bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt      time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (NotificationRule) TableName() string {
+	return "notification_rules"
+}
+
+// NotificationDelivery is one notification sent, or being retried, to one destination. The
+// deliveries form the delivery log users can inspect.
+type NotificationDelivery struct {
+	ID            string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID        string          `gorm:"index;not null" json:"user_id"` // Owner of the rule
+	RuleID        *string         `gorm:"type:uuid" json:"rule_id"`
+	ChannelID     *string         `gorm:"type:uuid" json:"channel_id"`
+	EndpointID    *string         `gorm:"type:uuid" json:"endpoint_id"` // Set for webhook endpoint deliveries
+	Kind          string          `gorm:"not null" json:"kind"`
+	Target        EncryptedString `gorm:"type:text;not null" json:"-"`
+	TargetHint    string          `gorm:"not null" json:"target_hint"`
+	Event         string          `gorm:"not null" json:"event"`
+	RepoID        *string         `gorm:"type:uuid" json:"repo_id"`
+	Message       string          `gorm:"type:text;not null" json:"-"` // JSON-encoded message, rendered per channel kind
+	Status        string          `gorm:"not null;default:pending" json:"status"`
+	Attempts      int             `gorm:"not null;default:0" json:"attempts"`
+	LastError     string          `gorm:"not null;default:''" json:"last_error,omitempty"`
+	NextAttemptAt *time.Time      `json:"next_attempt_at"`
+	DeliveredAt   *time.Time      `json:"delivered_at"`
+	CreatedAt     time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (NotificationDelivery) TableName() string {
+	return "notification_deliveries"
+}
+
+// WebhookEndpoint is an outgoing webhook registered by a third-party consumer. It receives the
+// events it subscribes to for every repository its owner can view, signed with Secret
+// (HMAC-SHA256). The secret is only returned when the endpoint is created.
+type WebhookEndpoint struct {
+	ID        string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string          `gorm:"index;not null" json:"user_id"`
+	Name      string          `gorm:"not null" json:"name"`
+	URL       EncryptedString `gorm:"type:text;not null" json:"-"`
+	URLHint   string          `gorm:"not null" json:"url_hint"`
+	Events    ScopeList       `gorm:"type:text;not null" json:"events"` // Space-separated, like API token scopes
+	Secret    EncryptedString `gorm:"type:text;not null" json:"-"`
+	Enabled   bool            `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt time.Time       `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (WebhookEndpoint) TableName() string {
+	return "webhook_endpoints"
+}
+
+// Subscribes reports whether the endpoint receives event
+func (e *WebhookEndpoint) Subscribes(event string) bool {
+	for _, subscribed := range e.Events {
+		if subscribed == event {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,29 @@ import (
 	"time"
 )
 
+// Pull request states. GitHub only reports open/closed; drafts and merges are
+// tracked separately so merged and closed-unmerged PRs can be told apart.
+const (
+	PRStateOpen   = "open"
+	PRStateDraft  = "draft"
+	PRStateMerged = "merged"
+	PRStateClosed = "closed" // Closed without merging
+)
+
+// PullRequestState maps GitHub's (or Gitea's) state, draft and merged flags to a PR state
+func PullRequestState(githubState string, draft bool, merged bool) string {
+	switch {
+	case merged:
+		return PRStateMerged
+	case githubState == "closed":
+		return PRStateClosed
+	case draft:
+		return PRStateDraft
+	default:
+		return PRStateOpen
+	}
+}
+
 type PullRequest struct {
 	ID         string      `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	CreatedAt  *time.Time  `gorm:"column:created_at" json:"created_at"`
@@ -19,6 +42,10 @@ type PullRequest struct {
 	AuthorName *string     `gorm:"column:author_name" json:"author_name"`
 	AISummary  *string     `gorm:"column:ai_summary" json:"ai_summary"`
 	AIDecision *string     `gorm:"column:ai_decision" json:"ai_decision"`
+	// Merge requests from other code hosts than GitHub have no GithubPRID; they are
+	// identified by the host's merge request ID in ExternalID
+	Provider   string `gorm:"column:provider;not null;default:github;uniqueIndex:idx_pull_requests_provider_external_id" json:"provider"`
+	ExternalID *int64 `gorm:"column:external_id;uniqueIndex:idx_pull_requests_provider_external_id" json:"external_id"`
 }
 
 func (PullRequest) TableName() string {
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"time"
+)
+
+// Release tracks a changelog that has been published to GitHub as a release
+// (and optionally exported to a CHANGELOG.md pull request).
+type Release struct {
+	ID                string      `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt         *time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt         *time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	RepoID            string      `gorm:"column:repo_id;type:uuid;not null;uniqueIndex:idx_releases_repo_tag" json:"repo_id"`
+	Repository        *Repository `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"repository,omitempty"`
+	TagName           string      `gorm:"column:tag_name;not null;uniqueIndex:idx_releases_repo_tag" json:"tag_name"`
+	Name              string      `gorm:"column:name" json:"name"`
+	Changelog         string      `gorm:"column:changelog;type:text" json:"changelog"`
+	Draft             bool        `gorm:"column:draft" json:"draft"`
+	GithubReleaseID   *int64      `gorm:"column:github_release_id" json:"github_release_id"`
+	GithubReleaseURL  string      `gorm:"column:github_release_url" json:"github_release_url"`
+	ChangelogPRNumber *int64      `gorm:"column:changelog_pr_number" json:"changelog_pr_number"`
+	ChangelogPRURL    string      `gorm:"column:changelog_pr_url" json:"changelog_pr_url"`
+}
+
+func (Release) TableName() string {
+	return "public.releases"
+}
+
+// PublishReleaseOptions controls how a changelog is pushed to GitHub.
+type PublishReleaseOptions struct {
+	// TagName defaults to the suggested version from the last release risk run
+	TagName         string `json:"tag_name"`
+	Name            string `json:"name"`
+	TargetCommitish string `json:"target_commitish"`
+	// Changelog overrides the repository's stored release changelog when set.
+	Changelog string `json:"changelog"`
+	// ExportChangelogPR also opens (or updates) a pull request adding the
+	// changelog to CHANGELOG.md on the default branch.
+	ExportChangelogPR bool `json:"export_changelog_pr"`
+}
+
+// Release risk run states
+const (
+	ReleaseRiskWaiting    = "waiting"    // Waiting for the AI reviews of the selected PRs
+	ReleaseRiskAnalyzing  = "analyzing"  // The release risk workflow was started
+	ReleaseRiskCompleted  = "completed"  // The workflow reported its result
+	ReleaseRiskFailed     = "failed"     // The workflow could not be started
+	ReleaseRiskSuperseded = "superseded" // A newer run for the repository started first
+)
+
+// ReleaseRiskRun is one release risk calculation. It waits until the selected PRs have been
+// reviewed by the AI (or WaitUntil passes) and then starts the release risk workflow. Only
+// the repository's current run may update its release risk.
+type ReleaseRiskRun struct {
+	ID     string    `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	RepoID string    `gorm:"column:repo_id;type:uuid;not null" json:"repo_id"`
+	PRIDs  ScopeList `gorm:"column:pr_ids;type:text;not null" json:"pr_ids"`
+	// PendingPRIDs are the selected PRs whose AI review hasn't reported back yet
+	PendingPRIDs ScopeList `gorm:"column:pending_pr_ids;type:text;not null" json:"pending_pr_ids"`
+	// Facts are the PRs' risk facts collected from GitHub, in PRIDs order
+	Facts     []byte     `gorm:"column:facts;type:jsonb" json:"-"`
+	Tags      ScopeList  `gorm:"column:tags;type:text" json:"-"`
+	Status    string     `gorm:"column:status;not null" json:"status"`
+	WaitUntil time.Time  `gorm:"column:wait_until" json:"wait_until"`
+	CreatedAt *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt *time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+func (ReleaseRiskRun) TableName() string {
+	return "public.release_risk_runs"
+}
@@ -4,6 +4,13 @@ import (
 	"time"
 )
 
+// Code hosts repositories are synced from
+const (
+	CodeHostGithub = "github"
+	CodeHostGitlab = "gitlab"
+	CodeHostGitea  = "gitea" // Gitea and Forgejo
+)
+
 type Repository struct {
 	ID             string     `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	CreatedAt      *time.Time `gorm:"column:created_at" json:"created_at"`
@@ -17,12 +24,32 @@ type Repository struct {
 	UserID         string     `gorm:"column:user_id;type:uuid;not null" json:"user_id"`
 	User           *User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
 	AISummary      string     `gorm:"column:ai_summary;type:text" json:"ai_summary"`
+	// Workspace that owns the repository; UserID only records who first synced it
+	WorkspaceID string     `gorm:"column:workspace_id;type:uuid;index:idx_repositories_workspace_id" json:"workspace_id"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"workspace,omitempty"`
+	// Code host the repository lives on. Repositories on other hosts than GitHub have no
+	// GithubRepoID; they are identified by the host's project ID in ExternalID.
+	Provider   string `gorm:"column:provider;not null;default:github;uniqueIndex:idx_repositories_provider_external_id" json:"provider"`
+	ExternalID *int64 `gorm:"column:external_id;uniqueIndex:idx_repositories_provider_external_id" json:"external_id"`
+	// Date of the newest default branch commit read by the last sync
+	CommitsSyncedUntil *time.Time `gorm:"column:commits_synced_until" json:"-"`
 	// Release risk fields
 	ReleaseRiskScore    int    `gorm:"column:release_risk_score;default:0" json:"release_risk_score"`
 	ReleaseChangelog    string `gorm:"column:release_changelog;type:text" json:"release_changelog"`
 	ReleaseRiskAnalysis string `gorm:"column:release_risk_analysis;type:text" json:"release_risk_analysis"`
+	// Itemised rule-based score blended with the AI score
+	ReleaseRiskBreakdown *RiskBreakdown `gorm:"column:release_risk_breakdown;type:jsonb" json:"release_risk_breakdown"`
+	// Current release risk run; results of other runs are ignored
+	ReleaseRiskRunID *string `gorm:"column:release_risk_run_id;type:uuid" json:"release_risk_run_id"`
+	// Suggested next semantic version for the release
+	ReleaseVersionSuggestion *VersionSuggestion `gorm:"column:release_version_suggestion;type:jsonb" json:"release_version_suggestion"`
 }
 
 func (Repository) TableName() string {
 	return "public.repositories"
 }
+
+// IsGithub reports whether the repository is hosted on GitHub
+func (r *Repository) IsGithub() bool {
+	return r.Provider == "" || r.Provider == CodeHostGithub
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// RiskContribution is a single line item of a rule-based release risk score
+type RiskContribution struct {
+	Factor      string  `json:"factor"`
+	Description string  `json:"description"`
+	Value       float64 `json:"value"`
+	Points      float64 `json:"points"`
+	MaxPoints   float64 `json:"max_points"`
+}
+
+// RiskBreakdown explains how a release risk score was computed. RuleScore is
+// reproducible from the PR facts alone; Score blends it with the AI estimate.
+type RiskBreakdown struct {
+	Score         int                `json:"score"`
+	RuleScore     int                `json:"rule_score"`
+	AIScore       *int               `json:"ai_score"`
+	RuleWeight    float64            `json:"rule_weight"`
+	AIWeight      float64            `json:"ai_weight"`
+	PRCount       int                `json:"pr_count"`
+	Contributions []RiskContribution `json:"contributions"`
+}
+
+// Value implements driver.Valuer so the breakdown can be stored as jsonb
+func (b RiskBreakdown) Value() (driver.Value, error) {
+	return json.Marshal(b)
+}
+
+// Scan implements sql.Scanner for reading the jsonb column
+func (b *RiskBreakdown) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		return nil
+	default:
+		return errors.New("unsupported type for RiskBreakdown")
+	}
+	return json.Unmarshal(data, b)
+}
@@ -2,12 +2,21 @@ package models
 
 import "time"
 
+// Session is a login of a user on one device. The bearer and refresh tokens are only stored
+// as SHA-256 hashes; ID is a public identifier used to list and revoke sessions.
 type Session struct {
-	ID        string    `gorm:"primaryKey" json:"id"` // UUID
-	UserID    string    `gorm:"index;not null" json:"user_id"`
-	User      User      `gorm:"foreignKey:UserID" json:"-"`
-	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID               string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID           string    `gorm:"index;not null" json:"user_id"`
+	User             User      `gorm:"foreignKey:UserID" json:"-"`
+	TokenHash        string    `gorm:"uniqueIndex;not null" json:"-"`
+	RefreshTokenHash string    `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt        time.Time `gorm:"not null" json:"expires_at"`         // Sliding, extended on activity
+	RefreshExpiresAt time.Time `gorm:"not null" json:"refresh_expires_at"` // Absolute end of the session
+	LastSeenAt       time.Time `json:"last_seen_at"`
+	UserAgent        string    `json:"user_agent"`
+	IPAddress        string    `json:"ip_address"`
+	CreatedAt        time.Time `gorm:"autoCreateTime" json:"created_at"`
+	Current          bool      `gorm:"-" json:"current"` // Set when listing: the requesting session
 }
 
 func (Session) TableName() string {
@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Slack commands waiting for a result
+const (
+	SlackCommandAnalyze = "analyze"
+	SlackCommandRisk    = "risk"
+)
+
+// SlackAccount links a Slack user, in a Slack workspace (team), to the DevPlus user their
+// /devplus commands run as
+type SlackAccount struct {
+	ID            string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID        string    `gorm:"type:uuid;index;not null" json:"user_id"`
+	TeamID        string    `gorm:"uniqueIndex:idx_slack_accounts_team_user;not null" json:"team_id"`
+	SlackUserID   string    `gorm:"uniqueIndex:idx_slack_accounts_team_user;not null" json:"slack_user_id"`
+	SlackUsername string    `json:"slack_username"`
+	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (SlackAccount) TableName() string {
+	return "slack_accounts"
+}
+
+// SlackCommandResponse is a /devplus command waiting for its analysis to complete. The result
+// is posted to the command's response URL, which Slack accepts for 30 minutes.
+type SlackCommandResponse struct {
+	ID          string          `gorm:"primaryKey;type:uuid;default:gen_random_uuid()"`
+	UserID      string          `gorm:"type:uuid;not null"`
+	Command     string          `gorm:"not null"` // analyze or risk
+	RepoID      string          `gorm:"type:uuid;index;not null"`
+	PRNumber    *int64          `gorm:"column:pr_number"` // analyze only
+	ResponseURL EncryptedString `gorm:"type:text;not null"`
+	ExpiresAt   time.Time       `gorm:"not null"`
+	CreatedAt   time.Time       `gorm:"autoCreateTime"`
+}
+
+func (SlackCommandResponse) TableName() string {
+	return "slack_command_responses"
+}
@@ -2,13 +2,45 @@ package models
 
 type DashboardStats struct {
 	TotalPRs    int64 `json:"total_prs"`
-	OpenPRs     int64 `json:"open_prs"`
+	OpenPRs     int64 `json:"open_prs"` // Open and draft PRs
+	DraftPRs    int64 `json:"draft_prs"`
 	MergedPRs   int64 `json:"merged_prs"`
+	ClosedPRs   int64 `json:"closed_prs"` // Closed without merging
 	ActiveRepos int64 `json:"active_repos"`
+	// Breakdown of AI review decisions across the same PRs
+	AIDecisions AIDecisionBreakdown `json:"ai_decisions"`
+	// Per repository breakdown
+	Repositories []RepoStats `json:"repositories"`
+}
+
+// PRStateBreakdown counts pull requests by state
+type PRStateBreakdown struct {
+	Open   int64 `json:"open"`
+	Draft  int64 `json:"draft"`
+	Merged int64 `json:"merged"`
+	Closed int64 `json:"closed"`
+}
+
+// AIDecisionBreakdown counts pull requests by AI review decision
+type AIDecisionBreakdown struct {
+	Approved         int64 `json:"approved"`
+	ChangesRequested int64 `json:"changes_requested"`
+	Commented        int64 `json:"commented"`
+	NotAnalyzed      int64 `json:"not_analyzed"`
+}
+
+// RepoStats is the state and AI decision breakdown of one repository
+type RepoStats struct {
+	RepoID      string              `json:"repo_id"`
+	RepoName    string              `json:"repo_name"`
+	TotalPRs    int64               `json:"total_prs"`
+	States      PRStateBreakdown    `json:"states"`
+	AIDecisions AIDecisionBreakdown `json:"ai_decisions"`
 }
 
 type MetricsFilter struct {
-	RepoID    string
-	StartDate *string // RFC3339
-	EndDate   *string // RFC3339
+	RepoID      string
+	StartDate   *string // RFC3339
+	EndDate     *string // RFC3339
+	Environment string  // Deployment environment for DORA metrics (all when empty)
 }
@@ -5,16 +5,22 @@ import (
 )
 
 type User struct {
-	ID           string     `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
-	CreatedAt    time.Time  `gorm:"column:created_at;autoCreateTime" json:"created_at"`
-	UpdatedAt    time.Time  `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
-	DeletedAt    *time.Time `gorm:"column:deleted_at;index:idx_users_deleted_at" json:"deleted_at"`
-	GithubID     int64      `gorm:"column:github_id;uniqueIndex:idx_users_github_id;not null" json:"github_id"`
-	Username     string     `gorm:"column:username;not null" json:"username"`
-	Email        string     `gorm:"column:email" json:"email"`
-	AvatarURL    string     `gorm:"column:avatar_url" json:"avatar_url"`
-	AccessToken  string     `gorm:"column:access_token" json:"-"`  // Don't expose in JSON
-	RefreshToken string     `gorm:"column:refresh_token" json:"-"` // Don't expose in JSON
+	ID           string          `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt    time.Time       `gorm:"column:created_at;autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time       `gorm:"column:updated_at;autoUpdateTime" json:"updated_at"`
+	DeletedAt    *time.Time      `gorm:"column:deleted_at;index:idx_users_deleted_at" json:"deleted_at"`
+	GithubID     *int64          `gorm:"column:github_id;uniqueIndex:idx_users_github_id" json:"github_id"` // Nil for SSO users who haven't linked GitHub
+	Username     string          `gorm:"column:username;not null" json:"username"`
+	Email        string          `gorm:"column:email" json:"email"`
+	AvatarURL    string          `gorm:"column:avatar_url" json:"avatar_url"`
+	AccessToken  EncryptedString `gorm:"column:access_token;type:text" json:"-"`  // Encrypted at rest, don't expose in JSON
+	RefreshToken EncryptedString `gorm:"column:refresh_token;type:text" json:"-"` // Encrypted at rest, don't expose in JSON
+
+	// Expiring GitHub tokens are refreshed shortly before TokenExpiresAt. NeedsReauth is set
+	// when GitHub rejects the tokens and the user has to log in again.
+	TokenExpiresAt        *time.Time `gorm:"column:token_expires_at" json:"token_expires_at"`
+	RefreshTokenExpiresAt *time.Time `gorm:"column:refresh_token_expires_at" json:"-"`
+	NeedsReauth           bool       `gorm:"column:needs_reauth;not null;default:false" json:"needs_reauth"`
 }
 
 func (User) TableName() string {
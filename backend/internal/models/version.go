@@ -0,0 +1,36 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// VersionSuggestion is the next semantic version DevPlus proposes for a release
+type VersionSuggestion struct {
+	CurrentVersion   string   `json:"current_version"`
+	SuggestedVersion string   `json:"suggested_version"`
+	Bump             string   `json:"bump"` // major, minor, patch or none
+	Justification    []string `json:"justification"`
+}
+
+// Value implements driver.Valuer so the suggestion can be stored as jsonb
+func (v VersionSuggestion) Value() (driver.Value, error) {
+	return json.Marshal(v)
+}
+
+// Scan implements sql.Scanner for reading the jsonb column
+func (v *VersionSuggestion) Scan(value interface{}) error {
+	var data []byte
+	switch val := value.(type) {
+	case []byte:
+		data = val
+	case string:
+		data = []byte(val)
+	case nil:
+		return nil
+	default:
+		return errors.New("unsupported type for VersionSuggestion")
+	}
+	return json.Unmarshal(data, v)
+}
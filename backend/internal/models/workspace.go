@@ -0,0 +1,98 @@
+package models
+
+import (
+	"errors"
+	"time"
+)
+
+// Workspace roles, from most to least privileged
+const (
+	RoleOwner      = "owner"
+	RoleMaintainer = "maintainer"
+	RoleViewer     = "viewer"
+)
+
+// Workspace kinds, following the GitHub account that owns the repositories
+const (
+	WorkspaceKindOrganization = "organization"
+	WorkspaceKindUser         = "user"
+)
+
+// Workspace groups repositories owned by one GitHub organisation or account (or a namespace
+// on another code host) and the DevPlus users who can access them.
+type Workspace struct {
+	ID                 string     `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt          *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt          *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	Name               string     `gorm:"column:name;not null" json:"name"`
+	Kind               string     `gorm:"column:kind" json:"kind"`
+	GithubAccountLogin string     `gorm:"column:github_account_login;uniqueIndex:idx_workspaces_provider_account" json:"github_account_login"`
+	// Code host of the account; on other hosts than GitHub the login is the namespace path
+	Provider string `gorm:"column:provider;not null;default:github;uniqueIndex:idx_workspaces_provider_account" json:"provider"`
+	// Role of the requesting user, filled in when listing workspaces
+	Role string `gorm:"-" json:"role,omitempty"`
+}
+
+func (Workspace) TableName() string {
+	return "public.workspaces"
+}
+
+// WorkspaceMember grants a user a role in a workspace
+type WorkspaceMember struct {
+	ID          string     `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt   *time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt   *time.Time `gorm:"column:updated_at" json:"updated_at"`
+	WorkspaceID string     `gorm:"column:workspace_id;type:uuid;not null;uniqueIndex:idx_workspace_members_workspace_user" json:"workspace_id"`
+	Workspace   *Workspace `gorm:"foreignKey:WorkspaceID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	UserID      string     `gorm:"column:user_id;type:uuid;not null;uniqueIndex:idx_workspace_members_workspace_user" json:"user_id"`
+	User        *User      `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+	Role        string     `gorm:"column:role;not null" json:"role"`
+}
+
+func (WorkspaceMember) TableName() string {
+	return "public.workspace_members"
+}
+
+// ErrForbidden is returned when a user's role doesn't allow an operation
+var ErrForbidden = errors.New("forbidden")
+
+// ValidRole reports whether role is one of the workspace roles
+func ValidRole(role string) bool {
+	return role == RoleOwner || role == RoleMaintainer || role == RoleViewer
+}
+
+// RepositoryMember overrides a user's workspace role for a single repository, or grants
+// access to that repository alone.
+type RepositoryMember struct {
+	ID         string      `gorm:"column:id;primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	CreatedAt  *time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  *time.Time  `gorm:"column:updated_at" json:"updated_at"`
+	RepoID     string      `gorm:"column:repo_id;type:uuid;not null;uniqueIndex:idx_repository_members_repo_user" json:"repo_id"`
+	Repository *Repository `gorm:"foreignKey:RepoID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"-"`
+	UserID     string      `gorm:"column:user_id;type:uuid;not null;uniqueIndex:idx_repository_members_repo_user" json:"user_id"`
+	User       *User       `gorm:"foreignKey:UserID;references:ID;constraint:OnUpdate:CASCADE,OnDelete:CASCADE;" json:"user,omitempty"`
+	Role       string      `gorm:"column:role;not null" json:"role"`
+}
+
+func (RepositoryMember) TableName() string {
+	return "public.repository_members"
+}
+
+// UserRef identifies the user a membership request is about: by DevPlus user ID, or by their
+// login on a code host. Display usernames, which SSO users pick themselves, aren't accepted.
+type UserRef struct {
+	UserID   string `json:"user_id"`
+	Username string `json:"username"` // Login on Provider
+	Provider string `json:"provider"` // Code host of Username; GitHub when empty
+}
+
+// RoleAtLeast reports whether role grants at least the permissions of minRole
+func RoleAtLeast(role string, minRole string) bool {
+	return roleRank[role] >= roleRank[minRole] && roleRank[role] > 0
+}
+
+var roleRank = map[string]int{
+	RoleViewer:     1,
+	RoleMaintainer: 2,
+	RoleOwner:      3,
+}
@@ -0,0 +1,122 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeySetRefetchesUnknownKeys(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+	ctx := context.Background()
+
+	if _, err := verifier.Verify(ctx, provider.sign(t, "key-1", validClaims())); err != nil {
+		t.Fatalf("Verify(key-1): %v", err)
+	}
+	if _, err := verifier.Verify(ctx, provider.sign(t, "key-1", validClaims())); err != nil {
+		t.Fatalf("Verify(key-1) again: %v", err)
+	}
+	if got := provider.fetchCount(); got != 1 {
+		t.Fatalf("fetches = %d, want 1 for cached keys", got)
+	}
+
+	// The provider rotates keys; right after a fetch the unknown key ID doesn't refetch
+	provider.addKey(t, "key-2")
+	rotated := provider.sign(t, "key-2", validClaims())
+	if _, err := verifier.Verify(ctx, rotated); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify(key-2) within keySetMinRefresh error = %v, want ErrInvalidToken", err)
+	}
+	if got := provider.fetchCount(); got != 1 {
+		t.Errorf("fetches = %d, want 1 while throttled", got)
+	}
+
+	// Once keySetMinRefresh has passed, the unknown key ID triggers a refetch
+	verifier.keys.fetchedAt = time.Now().Add(-2 * keySetMinRefresh)
+	if _, err := verifier.Verify(ctx, rotated); err != nil {
+		t.Errorf("Verify(key-2) after keySetMinRefresh: %v", err)
+	}
+	if got := provider.fetchCount(); got != 2 {
+		t.Errorf("fetches = %d, want 2 after the refetch", got)
+	}
+
+	// Tokens naming keys the provider never published don't refetch again right away
+	provider.mu.Lock()
+	signingKey := provider.keys["key-1"]
+	provider.mu.Unlock()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, validClaims())
+	token.Header["kid"] = "key-404"
+	unknown, err := token.SignedString(signingKey)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := verifier.Verify(ctx, unknown); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(key-404) error = %v, want ErrInvalidToken", err)
+		}
+	}
+	if got := provider.fetchCount(); got != 2 {
+		t.Errorf("fetches = %d, want 2 for repeated unknown keys", got)
+	}
+}
+
+func TestKeySetRefetchesAfterTTL(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	keys := NewKeySet(provider.URL)
+	ctx := context.Background()
+
+	if _, err := keys.key(ctx, "key-1"); err != nil {
+		t.Fatalf("key(key-1): %v", err)
+	}
+	keys.fetchedAt = time.Now().Add(-keySetTTL - time.Minute)
+	if _, err := keys.key(ctx, "key-1"); err != nil {
+		t.Fatalf("key(key-1) after keySetTTL: %v", err)
+	}
+	if got := provider.fetchCount(); got != 2 {
+		t.Errorf("fetches = %d, want 2 once the keys are stale", got)
+	}
+}
+
+func TestKeySetServesCachedKeysWhenRefreshFails(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	keys := NewKeySet(provider.URL)
+	ctx := context.Background()
+
+	if _, err := keys.key(ctx, "key-1"); err != nil {
+		t.Fatalf("key(key-1): %v", err)
+	}
+
+	provider.mu.Lock()
+	provider.status = http.StatusServiceUnavailable
+	provider.mu.Unlock()
+	keys.fetchedAt = time.Now().Add(-keySetTTL - time.Minute)
+
+	if _, err := keys.key(ctx, "key-1"); err != nil {
+		t.Errorf("key(key-1) with the JWKS endpoint down: %v", err)
+	}
+	if _, err := keys.key(ctx, "key-2"); err == nil || errors.Is(err, ErrUnknownKey) {
+		t.Errorf("key(key-2) with the JWKS endpoint down error = %v, want the fetch error", err)
+	}
+}
+
+func TestKeySetMatchesTokensWithoutKeyID(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	keys := NewKeySet(provider.URL)
+
+	if _, err := keys.key(context.Background(), ""); err != nil {
+		t.Errorf("key(\"\") with a single key: %v", err)
+	}
+
+	provider.addKey(t, "key-2")
+	keys.fetchedAt = time.Now().Add(-2 * keySetMinRefresh)
+	if _, err := keys.key(context.Background(), "key-2"); err != nil {
+		t.Fatalf("key(key-2): %v", err)
+	}
+	if _, err := keys.key(context.Background(), ""); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("key(\"\") with several keys error = %v, want ErrUnknownKey", err)
+	}
+}
@@ -0,0 +1,142 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is the leeway allowed on exp, nbf and iat
+const clockSkew = 30 * time.Second
+
+// signingMethods are the asymmetric algorithms accepted for tokens; HMAC and "none" are rejected
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// ErrInvalidToken wraps every verification failure
+var ErrInvalidToken = errors.New("invalid oidc token")
+
+// Claims are the standard claims of an OIDC ID or access token that DevPlus reads
+type Claims struct {
+	jwt.RegisteredClaims
+	Email             string `json:"email"`
+	EmailVerified     bool   `json:"email_verified"`
+	Name              string `json:"name"`
+	PreferredUsername string `json:"preferred_username"`
+	Picture           string `json:"picture"`
+	Nonce             string `json:"nonce"`
+	// Scope is the space-separated "scope" claim, or the "scp" claim some providers use instead
+	Scope string `json:"-"`
+}
+
+// UnmarshalJSON reads "scope" and "scp", which can be a string or a list
+func (c *Claims) UnmarshalJSON(data []byte) error {
+	type plain Claims
+	var raw struct {
+		plain
+		ScopeClaim interface{} `json:"scope"`
+		ScpClaim   interface{} `json:"scp"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*c = Claims(raw.plain)
+	c.Scope = strings.TrimSpace(joinScopes(raw.ScopeClaim) + " " + joinScopes(raw.ScpClaim))
+	return nil
+}
+
+// Scopes returns the token's scopes
+func (c *Claims) Scopes() []string {
+	return strings.Fields(c.Scope)
+}
+
+func joinScopes(claim interface{}) string {
+	switch v := claim.(type) {
+	case string:
+		return v
+	case []interface{}:
+		scopes := make([]string, 0, len(v))
+		for _, s := range v {
+			if str, ok := s.(string); ok {
+				scopes = append(scopes, str)
+			}
+		}
+		return strings.Join(scopes, " ")
+	}
+	return ""
+}
+
+// Verifier validates JWTs issued by one OIDC provider: the signature against the provider's
+// JWKS, and the issuer, audience and expiry claims.
+type Verifier struct {
+	issuer   string
+	audience string
+	jwksURL  string
+
+	mu   sync.Mutex
+	keys *KeySet
+}
+
+// NewVerifier creates a verifier for tokens from issuer meant for audience. When jwksURL is
+// empty it is discovered from the issuer on first use.
+func NewVerifier(issuer string, audience string, jwksURL string) *Verifier {
+	return &Verifier{issuer: issuer, audience: audience, jwksURL: jwksURL}
+}
+
+// Issuer returns the issuer the verifier accepts tokens from
+func (v *Verifier) Issuer() string {
+	return v.issuer
+}
+
+// Verify parses and validates a raw JWT and returns its claims
+func (v *Verifier) Verify(ctx context.Context, raw string) (*Claims, error) {
+	keys, err := v.keySet(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, keys.Keyfunc(ctx),
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(v.issuer),
+		jwt.WithAudience(v.audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub claim", ErrInvalidToken)
+	}
+	return claims, nil
+}
+
+// keySet returns the provider's key set, discovering the JWKS URL first if needed. Failed
+// discoveries are retried on the next token.
+func (v *Verifier) keySet(ctx context.Context) (*KeySet, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.keys == nil {
+		if v.jwksURL == "" {
+			metadata, err := Discover(ctx, v.issuer)
+			if err != nil {
+				return nil, err
+			}
+			v.jwksURL = metadata.JWKSURI
+		}
+		v.keys = NewKeySet(v.jwksURL)
+	}
+	return v.keys, nil
+}
+
+// LooksLikeJWT reports whether a bearer token has the three dot-separated parts of a JWS
+func LooksLikeJWT(token string) bool {
+	return strings.Count(token, ".") == 2
+}
@@ -0,0 +1,238 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "devplus"
+)
+
+// testProvider serves a JWKS with the public halves of its signing keys and counts fetches
+type testProvider struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int
+	status  int
+}
+
+func newTestProvider(t *testing.T, kids ...string) *testProvider {
+	t.Helper()
+	p := &testProvider{keys: make(map[string]*rsa.PrivateKey), status: http.StatusOK}
+	for _, kid := range kids {
+		p.addKey(t, kid)
+	}
+	p.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.fetches++
+		if p.status != http.StatusOK {
+			w.WriteHeader(p.status)
+			return
+		}
+		set := struct {
+			Keys []jsonWebKey `json:"keys"`
+		}{}
+		for kid, key := range p.keys {
+			set.Keys = append(set.Keys, jsonWebKey{
+				Kid: kid,
+				Kty: "RSA",
+				Use: "sig",
+				N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(set)
+	}))
+	t.Cleanup(p.Close)
+	return p
+}
+
+func (p *testProvider) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+	p.mu.Lock()
+	p.keys[kid] = key
+	p.mu.Unlock()
+	return key
+}
+
+func (p *testProvider) fetchCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.fetches
+}
+
+// sign signs claims with the provider's key kid
+func (p *testProvider) sign(t *testing.T, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	p.mu.Lock()
+	key := p.keys[kid]
+	p.mu.Unlock()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+	return raw
+}
+
+func validClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":   testIssuer,
+		"aud":   testAudience,
+		"sub":   "user-1",
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+		"email": "dev@example.com",
+		"scp":   []string{"repos:read", "analysis:write"},
+	}
+}
+
+func TestVerify(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+
+	claims, err := verifier.Verify(context.Background(), provider.sign(t, "key-1", validClaims()))
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "user-1" || claims.Email != "dev@example.com" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if scopes := claims.Scopes(); len(scopes) != 2 || scopes[0] != "repos:read" || scopes[1] != "analysis:write" {
+		t.Errorf("Scopes() = %v, want [repos:read analysis:write]", scopes)
+	}
+}
+
+func TestVerifyRejectsInvalidClaims(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+
+	tests := []struct {
+		name   string
+		modify func(jwt.MapClaims)
+	}{
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "another-app" }},
+		{"audience list without ours", func(c jwt.MapClaims) { c["aud"] = []string{"another-app", "third-app"} }},
+		{"missing exp", func(c jwt.MapClaims) { delete(c, "exp") }},
+		{"expired beyond the leeway", func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{"not valid yet", func(c jwt.MapClaims) { c["nbf"] = time.Now().Add(time.Minute).Unix() }},
+		{"issued in the future", func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Minute).Unix() }},
+		{"missing sub", func(c jwt.MapClaims) { delete(c, "sub") }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validClaims()
+			tt.modify(claims)
+			if _, err := verifier.Verify(context.Background(), provider.sign(t, "key-1", claims)); !errors.Is(err, ErrInvalidToken) {
+				t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAllowsClockSkew(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+
+	claims := validClaims()
+	claims["exp"] = time.Now().Add(-10 * time.Second).Unix()
+	claims["aud"] = []string{"another-app", testAudience}
+	if _, err := verifier.Verify(context.Background(), provider.sign(t, "key-1", claims)); err != nil {
+		t.Errorf("Verify() within the leeway: %v", err)
+	}
+}
+
+func TestVerifyRejectsSymmetricAndUnsignedTokens(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims()).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("SignedString(none): %v", err)
+	}
+
+	// An HS256 token keyed with the RSA public key, the classic algorithm confusion attack
+	provider.mu.Lock()
+	publicKey := provider.keys["key-1"].PublicKey
+	provider.mu.Unlock()
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, validClaims())
+	hmacToken.Header["kid"] = "key-1"
+	hmac, err := hmacToken.SignedString(publicKey.N.Bytes())
+	if err != nil {
+		t.Fatalf("SignedString(HS256): %v", err)
+	}
+
+	for name, raw := range map[string]string{"none": unsigned, "HS256": hmac} {
+		if _, err := verifier.Verify(context.Background(), raw); !errors.Is(err, ErrInvalidToken) {
+			t.Errorf("Verify(%s) error = %v, want ErrInvalidToken", name, err)
+		}
+	}
+}
+
+func TestVerifyRejectsForeignSignature(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	other := newTestProvider(t, "key-1")
+	verifier := NewVerifier(testIssuer, testAudience, provider.URL)
+
+	if _, err := verifier.Verify(context.Background(), other.sign(t, "key-1", validClaims())); !errors.Is(err, ErrInvalidToken) {
+		t.Errorf("Verify() error = %v, want ErrInvalidToken", err)
+	}
+}
+
+func TestVerifyDiscoversJWKS(t *testing.T) {
+	provider := newTestProvider(t, "key-1")
+	var issuer string
+	discovery := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/.well-known/openid-configuration" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ProviderMetadata{Issuer: issuer, JWKSURI: provider.URL})
+	}))
+	defer discovery.Close()
+	issuer = discovery.URL
+
+	claims := validClaims()
+	claims["iss"] = issuer
+	if _, err := NewVerifier(issuer, testAudience, "").Verify(context.Background(), provider.sign(t, "key-1", claims)); err != nil {
+		t.Errorf("Verify() with a discovered JWKS: %v", err)
+	}
+}
+
+func TestLooksLikeJWT(t *testing.T) {
+	for token, want := range map[string]bool{
+		"eyJhbGciOiJSUzI1NiJ9.eyJzdWIiOiIxIn0.c2ln": true,
+		"dp_0123456789abcdef":                       false,
+		"a.b":                                       false,
+	} {
+		if got := LooksLikeJWT(token); got != want {
+			t.Errorf("LooksLikeJWT(%q) = %v, want %v", token, got, want)
+		}
+	}
+}
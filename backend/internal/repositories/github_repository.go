@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
@@ -21,12 +22,44 @@ type GithubRepository interface {
 	GetMetrics(ctx context.Context, userID string, filter models.MetricsFilter) (*models.DashboardStats, error)
 	GetRecentPullRequests(ctx context.Context, userID string, limit int) ([]*models.PullRequest, error)
 	GetRepositoryByGithubID(ctx context.Context, githubID int64) (*models.Repository, error)
+	GetRepositoryByExternalID(ctx context.Context, provider string, externalID int64) (*models.Repository, error)
 	UpsertPullRequest(ctx context.Context, pr *models.PullRequest) error
 	UpdatePullRequestAnalysis(ctx context.Context, prID string, summary, decision string) error
 	UpdateRepositoryAnalysis(ctx context.Context, repoID string, summary string) error
-	UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, riskScore int, changelog string, rawAnalysis string) error
+	UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, riskScore int, changelog string, rawAnalysis string, breakdown *models.RiskBreakdown) error
+	StartReleaseRiskRun(ctx context.Context, run *models.ReleaseRiskRun, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) error
+	GetReleaseRiskRun(ctx context.Context, runID string) (*models.ReleaseRiskRun, error)
+	UpdateReleaseRiskEstimate(ctx context.Context, repoID string, runID string, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) error
+	RemovePendingReleaseRiskPR(ctx context.Context, prID string) error
+	ClaimReadyReleaseRiskRuns(ctx context.Context, now time.Time) ([]*models.ReleaseRiskRun, error)
+	SetReleaseRiskRunStatus(ctx context.Context, runID string, status string) error
+	DeleteReleaseRiskRunsBefore(ctx context.Context, before time.Time) error
+	GetReleases(ctx context.Context, repoID string) ([]*models.Release, error)
+	GetReleaseByTag(ctx context.Context, repoID string, tagName string) (*models.Release, error)
+	SaveRelease(ctx context.Context, release *models.Release) error
+	UpsertPRTiming(ctx context.Context, timing *models.PRTiming) error
+	GetPRTimings(ctx context.Context, userID string, filter models.MetricsFilter) ([]*models.PRTiming, error)
+	ListAllRepositories(ctx context.Context) ([]*models.Repository, error)
+	GetRepoMetricSnapshot(ctx context.Context, repoID string, dayStart, dayEnd time.Time) (*models.RepoMetricSnapshot, error)
+	UpsertMetrics(ctx context.Context, metrics []*models.Metric) error
+	GetMetricPoints(ctx context.Context, userID string, metricType string, filter models.MetricsFilter) ([]*models.Metric, error)
+	UpsertCommits(ctx context.Context, commits []*models.Commit) error
+	GetCommitsByRepoID(ctx context.Context, repoID string, limit int) ([]*models.Commit, error)
+	GetCommitsByPullRequestID(ctx context.Context, prID string) ([]*models.Commit, error)
+	GetCommitsMissingStats(ctx context.Context, repoID string, limit int) ([]*models.Commit, error)
+	UpdateCommitSyncCursor(ctx context.Context, repoID string, until time.Time) error
+	UpsertGithubInstallation(ctx context.Context, installation *models.GithubInstallation) error
+	GetGithubInstallation(ctx context.Context, installationID int64) (*models.GithubInstallation, error)
+	GetGithubInstallationsByAccount(ctx context.Context, accountLogins []string) ([]*models.GithubInstallation, error)
+	DeleteGithubInstallation(ctx context.Context, installationID int64) error
+	SetInstallationRepositories(ctx context.Context, installationID int64, githubRepoIDs []int64) error
+	ListInstalledRepositories(ctx context.Context) ([]*models.Repository, error)
 }
 
+// accessibleRepos selects the repositories a user can access through workspace membership or a
+// repository role; repository queries scoped to a user are authorised through it.
+const accessibleRepos = "SELECT repo_id FROM public.repository_access WHERE user_id = ?"
+
 type gormGithubRepository struct {
 	db *gorm.DB
 }
@@ -36,15 +69,21 @@ func NewGithubRepository(db *gorm.DB) GithubRepository {
 }
 
 func (r *gormGithubRepository) UpsertRepository(ctx context.Context, repo *models.Repository) error {
+	// user_id is deliberately not updated: it records who first synced the repository,
+	// and access comes from the workspace, which follows the GitHub owner.
+	key := []clause.Column{{Name: "github_repo_id"}}
+	if !repo.IsGithub() {
+		key = []clause.Column{{Name: "provider"}, {Name: "external_id"}}
+	}
 	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "github_repo_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"name", "owner", "url", "updated_at", "user_id"}),
+		Columns:   key,
+		DoUpdates: clause.AssignmentColumns([]string{"name", "owner", "url", "updated_at", "workspace_id"}),
 	}).Create(repo).Error
 }
 
 func (r *gormGithubRepository) GetRepositories(ctx context.Context, userID string) ([]*models.Repository, error) {
 	var repos []*models.Repository
-	if err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("updated_at desc").Find(&repos).Error; err != nil {
+	if err := r.db.WithContext(ctx).Where("id IN ("+accessibleRepos+")", userID).Order("updated_at desc").Find(&repos).Error; err != nil {
 		return nil, err
 	}
 	return repos, nil
@@ -55,7 +94,7 @@ func (r *gormGithubRepository) GetRepository(ctx context.Context, userID string,
 	query := r.db.WithContext(ctx).Where("id = ?", id)
 	// Only filter by userID if it's provided (for user-specific queries)
 	if userID != "" {
-		query = query.Where("user_id = ?", userID)
+		query = query.Where("id IN ("+accessibleRepos+")", userID)
 	}
 	if err := query.First(&repo).Error; err != nil {
 		return nil, err
@@ -67,7 +106,7 @@ func (r *gormGithubRepository) GetPullRequests(ctx context.Context, userID strin
 	var prs []*models.PullRequest
 	// We need to join with Repositories to find by owner/repo name and filter by userID
 	err := r.db.WithContext(ctx).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").
-		Where("repositories.owner = ? AND repositories.name = ? AND repositories.user_id = ?", owner, repo, userID).
+		Where("repositories.owner = ? AND repositories.name = ? AND repositories.id IN ("+accessibleRepos+")", owner, repo, userID).
 		Order("pull_requests.number desc").
 		Find(&prs).Error
 	if err != nil {
@@ -77,82 +116,88 @@ func (r *gormGithubRepository) GetPullRequests(ctx context.Context, userID strin
 }
 
 func (r *gormGithubRepository) GetDashboardStats(ctx context.Context, userID string) (*models.DashboardStats, error) {
-	var stats models.DashboardStats
-
-	// Count PRs for repos owned by this user
-	prScope := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID)
-	
-	if err := prScope.Count(&stats.TotalPRs).Error; err != nil {
-		return nil, err
-	}
-	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ? AND pull_requests.state = ?", userID, "open").Count(&stats.OpenPRs).Error; err != nil {
-		return nil, err
-	}
-	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ? AND pull_requests.state = ?", userID, "closed").Count(&stats.MergedPRs).Error; err != nil {
-		return nil, err
-	}
-
-	// Active Repos (Total Repos for this user)
-	if err := r.db.WithContext(ctx).Model(&models.Repository{}).Where("user_id = ?", userID).Count(&stats.ActiveRepos).Error; err != nil {
-		return nil, err
-	}
-
-	return &stats, nil
+	return r.GetMetrics(ctx, userID, models.MetricsFilter{})
 }
 
 func (r *gormGithubRepository) GetMetrics(ctx context.Context, userID string, filter models.MetricsFilter) (*models.DashboardStats, error) {
-	var stats models.DashboardStats
+	stats := models.DashboardStats{Repositories: []models.RepoStats{}}
 
-	// Base scopes for PRs and Repos - filter by userID
-	prScope := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID)
-
-	// Apply filters to PR Scope
+	// Count PRs per repository, state and AI decision in one pass - filter by userID
+	var rows []struct {
+		RepoID   string
+		Owner    string
+		Name     string
+		State    string
+		Decision string
+		Count    int64
+	}
+	query := r.db.WithContext(ctx).Model(&models.PullRequest{}).
+		Select("pull_requests.repo_id, repositories.owner, repositories.name, pull_requests.state, UPPER(COALESCE(pull_requests.ai_decision, '')) AS decision, COUNT(*) AS count").
+		Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").
+		Where("repositories.id IN ("+accessibleRepos+")", userID)
 	if filter.RepoID != "" {
-		prScope = prScope.Where("pull_requests.repo_id = ?", filter.RepoID)
+		query = query.Where("pull_requests.repo_id = ?", filter.RepoID)
 	}
 	if filter.StartDate != nil {
-		prScope = prScope.Where("pull_requests.created_at >= ?", *filter.StartDate)
+		query = query.Where("pull_requests.created_at >= ?", *filter.StartDate)
 	}
 	if filter.EndDate != nil {
-		prScope = prScope.Where("pull_requests.created_at <= ?", *filter.EndDate)
+		query = query.Where("pull_requests.created_at <= ?", *filter.EndDate)
 	}
-
-	// Count PRs
-	if err := prScope.Count(&stats.TotalPRs).Error; err != nil {
+	if err := query.Group("pull_requests.repo_id, repositories.owner, repositories.name, pull_requests.state, decision").
+		Order("repositories.owner, repositories.name").
+		Scan(&rows).Error; err != nil {
 		return nil, err
 	}
 
-	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID).Scopes(func(db *gorm.DB) *gorm.DB {
-		if filter.RepoID != "" {
-			db = db.Where("pull_requests.repo_id = ?", filter.RepoID)
+	repoIndex := make(map[string]int)
+	for _, row := range rows {
+		idx, ok := repoIndex[row.RepoID]
+		if !ok {
+			idx = len(stats.Repositories)
+			repoIndex[row.RepoID] = idx
+			stats.Repositories = append(stats.Repositories, models.RepoStats{RepoID: row.RepoID, RepoName: row.Owner + "/" + row.Name})
 		}
-		if filter.StartDate != nil {
-			db = db.Where("pull_requests.created_at >= ?", *filter.StartDate)
+		repoStats := &stats.Repositories[idx]
+		repoStats.TotalPRs += row.Count
+		stats.TotalPRs += row.Count
+
+		switch row.State {
+		case models.PRStateOpen:
+			repoStats.States.Open += row.Count
+		case models.PRStateDraft:
+			repoStats.States.Draft += row.Count
+		case models.PRStateMerged:
+			repoStats.States.Merged += row.Count
+		case models.PRStateClosed:
+			repoStats.States.Closed += row.Count
 		}
-		if filter.EndDate != nil {
-			db = db.Where("pull_requests.created_at <= ?", *filter.EndDate)
+
+		switch row.Decision {
+		case "APPROVE":
+			repoStats.AIDecisions.Approved += row.Count
+			stats.AIDecisions.Approved += row.Count
+		case "REQUEST_CHANGES":
+			repoStats.AIDecisions.ChangesRequested += row.Count
+			stats.AIDecisions.ChangesRequested += row.Count
+		case "":
+			repoStats.AIDecisions.NotAnalyzed += row.Count
+			stats.AIDecisions.NotAnalyzed += row.Count
+		default:
+			repoStats.AIDecisions.Commented += row.Count
+			stats.AIDecisions.Commented += row.Count
 		}
-		return db
-	}).Where("pull_requests.state = ?", "open").Count(&stats.OpenPRs).Error; err != nil {
-		return nil, err
 	}
-	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID).Scopes(func(db *gorm.DB) *gorm.DB {
-		if filter.RepoID != "" {
-			db = db.Where("pull_requests.repo_id = ?", filter.RepoID)
-		}
-		if filter.StartDate != nil {
-			db = db.Where("pull_requests.created_at >= ?", *filter.StartDate)
-		}
-		if filter.EndDate != nil {
-			db = db.Where("pull_requests.created_at <= ?", *filter.EndDate)
-		}
-		return db
-	}).Where("pull_requests.state = ?", "closed").Count(&stats.MergedPRs).Error; err != nil {
-		return nil, err
+
+	for _, repoStats := range stats.Repositories {
+		stats.OpenPRs += repoStats.States.Open + repoStats.States.Draft
+		stats.DraftPRs += repoStats.States.Draft
+		stats.MergedPRs += repoStats.States.Merged
+		stats.ClosedPRs += repoStats.States.Closed
 	}
 
 	// Active Repos - filter by userID
-	repoScope := r.db.WithContext(ctx).Model(&models.Repository{}).Where("user_id = ?", userID)
+	repoScope := r.db.WithContext(ctx).Model(&models.Repository{}).Where("id IN ("+accessibleRepos+")", userID)
 	if filter.RepoID != "" {
 		repoScope = repoScope.Where("id = ?", filter.RepoID)
 	}
@@ -168,7 +213,7 @@ func (r *gormGithubRepository) GetPullRequest(ctx context.Context, userID string
 	query := r.db.WithContext(ctx).Preload("Repository").Where("pull_requests.repo_id = ? AND pull_requests.number = ?", repoID, number)
 	// Only filter by userID if it's provided (for user-specific queries)
 	if userID != "" {
-		query = query.Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID)
+		query = query.Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.id IN ("+accessibleRepos+")", userID)
 	}
 	if err := query.First(&pr).Error; err != nil {
 		return nil, err
@@ -194,7 +239,7 @@ func (r *gormGithubRepository) GetPullRequestsByRepoID(ctx context.Context, repo
 
 func (r *gormGithubRepository) GetRecentPullRequests(ctx context.Context, userID string, limit int) ([]*models.PullRequest, error) {
 	var prs []*models.PullRequest
-	if err := r.db.WithContext(ctx).Preload("Repository").Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.user_id = ?", userID).Order("pull_requests.created_at desc").Limit(limit).Find(&prs).Error; err != nil {
+	if err := r.db.WithContext(ctx).Preload("Repository").Joins("JOIN repositories ON repositories.id = pull_requests.repo_id").Where("repositories.id IN ("+accessibleRepos+")", userID).Order("pull_requests.created_at desc").Limit(limit).Find(&prs).Error; err != nil {
 		return nil, err
 	}
 	return prs, nil
@@ -208,10 +253,33 @@ func (r *gormGithubRepository) GetRepositoryByGithubID(ctx context.Context, gith
 	return &repo, nil
 }
 
+func (r *gormGithubRepository) GetRepositoryByExternalID(ctx context.Context, provider string, externalID int64) (*models.Repository, error) {
+	var repo models.Repository
+	if err := r.db.WithContext(ctx).Where("provider = ? AND external_id = ?", provider, externalID).First(&repo).Error; err != nil {
+		return nil, err
+	}
+	return &repo, nil
+}
+
 func (r *gormGithubRepository) UpsertPullRequest(ctx context.Context, pr *models.PullRequest) error {
+	if pr.Provider == "" || pr.Provider == models.CodeHostGithub {
+		return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "github_pr_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"number", "title", "state", "updated_at", "author_id", "author_name"}),
+		}).Create(pr).Error
+	}
+	// Merge requests from other hosts are keyed by provider and external ID. Their webhooks
+	// don't always name the author, so a known author name is kept.
 	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
-		Columns:   []clause.Column{{Name: "github_pr_id"}},
-		DoUpdates: clause.AssignmentColumns([]string{"number", "title", "state", "updated_at", "author_id", "author_name"}),
+		Columns: []clause.Column{{Name: "provider"}, {Name: "external_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"number":      gorm.Expr("excluded.number"),
+			"title":       gorm.Expr("excluded.title"),
+			"state":       gorm.Expr("excluded.state"),
+			"updated_at":  gorm.Expr("excluded.updated_at"),
+			"author_id":   gorm.Expr("excluded.author_id"),
+			"author_name": gorm.Expr("COALESCE(excluded.author_name, pull_requests.author_name)"),
+		}),
 	}).Create(pr).Error
 }
 
@@ -230,12 +298,362 @@ func (r *gormGithubRepository) UpdateRepositoryAnalysis(ctx context.Context, rep
 		Update("ai_summary", summary).Error
 }
 
-func (r *gormGithubRepository) UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, riskScore int, changelog string, rawAnalysis string) error {
-	return r.db.WithContext(ctx).Model(&models.Repository{}).
-		Where("id = ?", repoID).
+// UpdateReleaseRiskAnalysis stores the result of a release risk run. It returns
+// gorm.ErrRecordNotFound when the run is no longer the repository's current run.
+func (r *gormGithubRepository) UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, riskScore int, changelog string, rawAnalysis string, breakdown *models.RiskBreakdown) error {
+	result := r.db.WithContext(ctx).Model(&models.Repository{}).
+		Where("id = ? AND release_risk_run_id = ?", repoID, runID).
 		Updates(map[string]interface{}{
-			"release_risk_score":    riskScore,
-			"release_changelog":     changelog,
-			"release_risk_analysis": rawAnalysis,
+			"release_risk_score":     riskScore,
+			"release_changelog":      changelog,
+			"release_risk_analysis":  rawAnalysis,
+			"release_risk_breakdown": breakdown,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return r.db.WithContext(ctx).Model(&models.ReleaseRiskRun{}).Where("id = ?", runID).
+		Updates(map[string]interface{}{"status": models.ReleaseRiskCompleted, "updated_at": time.Now()}).Error
+}
+
+// StartReleaseRiskRun stores a new run with its first estimate and makes it the repository's
+// current run, superseding runs that are still waiting
+func (r *gormGithubRepository) StartReleaseRiskRun(ctx context.Context, run *models.ReleaseRiskRun, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.ReleaseRiskRun{}).
+			Where("repo_id = ? AND status = ?", run.RepoID, models.ReleaseRiskWaiting).
+			Updates(map[string]interface{}{"status": models.ReleaseRiskSuperseded, "updated_at": time.Now()}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Repository{}).
+			Where("id = ?", run.RepoID).
+			Updates(map[string]interface{}{
+				"release_risk_run_id":        run.ID,
+				"release_risk_breakdown":     breakdown,
+				"release_version_suggestion": suggestion,
+			}).Error
+	})
+}
+
+func (r *gormGithubRepository) GetReleaseRiskRun(ctx context.Context, runID string) (*models.ReleaseRiskRun, error) {
+	var run models.ReleaseRiskRun
+	if err := r.db.WithContext(ctx).Where("id = ?", runID).First(&run).Error; err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// UpdateReleaseRiskEstimate updates the rule-based breakdown and version suggestion of the
+// repository's current run. It returns gorm.ErrRecordNotFound when runID isn't current.
+func (r *gormGithubRepository) UpdateReleaseRiskEstimate(ctx context.Context, repoID string, runID string, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) error {
+	result := r.db.WithContext(ctx).Model(&models.Repository{}).
+		Where("id = ? AND release_risk_run_id = ?", repoID, runID).
+		Updates(map[string]interface{}{
+			"release_risk_breakdown":     breakdown,
+			"release_version_suggestion": suggestion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// RemovePendingReleaseRiskPR removes a reviewed PR from the waiting runs. The list is edited
+// in SQL so concurrent reviews of the same run don't overwrite each other.
+func (r *gormGithubRepository) RemovePendingReleaseRiskPR(ctx context.Context, prID string) error {
+	return r.db.WithContext(ctx).Model(&models.ReleaseRiskRun{}).
+		Where("status = ? AND ' ' || pending_pr_ids || ' ' LIKE ?", models.ReleaseRiskWaiting, "% "+prID+" %").
+		Updates(map[string]interface{}{
+			"pending_pr_ids": gorm.Expr("btrim(replace(' ' || pending_pr_ids || ' ', ?, ' '))", " "+prID+" "),
+			"updated_at":     time.Now(),
 		}).Error
 }
+
+// ClaimReadyReleaseRiskRuns marks the current runs that no longer wait for reviews, or have
+// waited long enough, as analyzing and returns them. Each run is claimed once.
+func (r *gormGithubRepository) ClaimReadyReleaseRiskRuns(ctx context.Context, now time.Time) ([]*models.ReleaseRiskRun, error) {
+	var runs []*models.ReleaseRiskRun
+	err := r.db.WithContext(ctx).Model(&runs).Clauses(clause.Returning{}).
+		Where("status = ?", models.ReleaseRiskWaiting).
+		Where("(pending_pr_ids = '' OR wait_until <= ?)", now).
+		Where("id IN (SELECT release_risk_run_id FROM public.repositories WHERE release_risk_run_id IS NOT NULL)").
+		Updates(map[string]interface{}{"status": models.ReleaseRiskAnalyzing, "updated_at": now}).Error
+	if err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+func (r *gormGithubRepository) SetReleaseRiskRunStatus(ctx context.Context, runID string, status string) error {
+	return r.db.WithContext(ctx).Model(&models.ReleaseRiskRun{}).Where("id = ?", runID).
+		Updates(map[string]interface{}{"status": status, "updated_at": time.Now()}).Error
+}
+
+// DeleteReleaseRiskRunsBefore deletes finished runs created before the given time. Current
+// runs are kept so their repository's results stay attributable.
+func (r *gormGithubRepository) DeleteReleaseRiskRunsBefore(ctx context.Context, before time.Time) error {
+	return r.db.WithContext(ctx).
+		Where("created_at < ? AND status <> ?", before, models.ReleaseRiskWaiting).
+		Where("id NOT IN (SELECT release_risk_run_id FROM public.repositories WHERE release_risk_run_id IS NOT NULL)").
+		Delete(&models.ReleaseRiskRun{}).Error
+}
+
+func (r *gormGithubRepository) GetReleases(ctx context.Context, repoID string) ([]*models.Release, error) {
+	var releases []*models.Release
+	if err := r.db.WithContext(ctx).Where("repo_id = ?", repoID).Order("created_at desc").Find(&releases).Error; err != nil {
+		return nil, err
+	}
+	return releases, nil
+}
+
+func (r *gormGithubRepository) GetReleaseByTag(ctx context.Context, repoID string, tagName string) (*models.Release, error) {
+	var release models.Release
+	if err := r.db.WithContext(ctx).Where("repo_id = ? AND tag_name = ?", repoID, tagName).First(&release).Error; err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (r *gormGithubRepository) SaveRelease(ctx context.Context, release *models.Release) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "repo_id"}, {Name: "tag_name"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"name", "changelog", "draft", "github_release_id", "github_release_url",
+			"changelog_pr_number", "changelog_pr_url", "updated_at",
+		}),
+	}).Create(release).Error
+}
+
+func (r *gormGithubRepository) UpsertPRTiming(ctx context.Context, timing *models.PRTiming) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "pull_request_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{
+			"author", "opened_at", "first_review_at", "approved_at", "merged_at",
+			"time_to_first_review_hours", "time_to_approval_hours", "approval_to_merge_hours",
+			"cycle_time_hours", "review_rounds", "updated_at",
+		}),
+	}).Create(timing).Error
+}
+
+func (r *gormGithubRepository) GetPRTimings(ctx context.Context, userID string, filter models.MetricsFilter) ([]*models.PRTiming, error) {
+	var timings []*models.PRTiming
+	query := r.db.WithContext(ctx).Joins("JOIN repositories ON repositories.id = pr_timings.repo_id").Where("repositories.id IN ("+accessibleRepos+")", userID)
+	if filter.RepoID != "" {
+		query = query.Where("pr_timings.repo_id = ?", filter.RepoID)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("pr_timings.opened_at >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("pr_timings.opened_at <= ?", *filter.EndDate)
+	}
+	if err := query.Order("pr_timings.opened_at").Find(&timings).Error; err != nil {
+		return nil, err
+	}
+	return timings, nil
+}
+
+func (r *gormGithubRepository) ListAllRepositories(ctx context.Context) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	if err := r.db.WithContext(ctx).Find(&repos).Error; err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
+
+func (r *gormGithubRepository) GetRepoMetricSnapshot(ctx context.Context, repoID string, dayStart, dayEnd time.Time) (*models.RepoMetricSnapshot, error) {
+	var snapshot models.RepoMetricSnapshot
+
+	var openPRs int64
+	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).Where("repo_id = ? AND state IN ?", repoID, []string{models.PRStateOpen, models.PRStateDraft}).Count(&openPRs).Error; err != nil {
+		return nil, err
+	}
+	open := float64(openPRs)
+	snapshot.OpenPRs = &open
+
+	var merged struct {
+		Count        int64
+		AvgCycleTime *float64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.PRTiming{}).
+		Select("COUNT(*) AS count, AVG(cycle_time_hours) AS avg_cycle_time").
+		Where("repo_id = ? AND merged_at >= ? AND merged_at < ?", repoID, dayStart, dayEnd).
+		Scan(&merged).Error; err != nil {
+		return nil, err
+	}
+	mergedCount := float64(merged.Count)
+	snapshot.MergedPRs = &mergedCount
+	snapshot.AvgCycleTimeHours = merged.AvgCycleTime
+
+	var decisions struct {
+		Total    int64
+		Approved int64
+	}
+	if err := r.db.WithContext(ctx).Model(&models.PullRequest{}).
+		Select("COUNT(*) AS total, COUNT(*) FILTER (WHERE UPPER(ai_decision) = 'APPROVE') AS approved").
+		Where("repo_id = ? AND ai_decision IS NOT NULL AND ai_decision <> ''", repoID).
+		Scan(&decisions).Error; err != nil {
+		return nil, err
+	}
+	if decisions.Total > 0 {
+		rate := float64(decisions.Approved) / float64(decisions.Total)
+		snapshot.AIApprovalRate = &rate
+	}
+
+	return &snapshot, nil
+}
+
+func (r *gormGithubRepository) UpsertMetrics(ctx context.Context, metrics []*models.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_id"}, {Name: "type"}, {Name: "date"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "updated_at"}),
+	}).Create(&metrics).Error
+}
+
+func (r *gormGithubRepository) GetMetricPoints(ctx context.Context, userID string, metricType string, filter models.MetricsFilter) ([]*models.Metric, error) {
+	var points []*models.Metric
+	query := r.db.WithContext(ctx).Joins("JOIN repositories ON repositories.id = metrics.repo_id").
+		Where("repositories.id IN ("+accessibleRepos+") AND metrics.type = ? AND metrics.deleted_at IS NULL", userID, metricType)
+	if filter.RepoID != "" {
+		query = query.Where("metrics.repo_id = ?", filter.RepoID)
+	}
+	if filter.StartDate != nil {
+		query = query.Where("metrics.date >= ?", *filter.StartDate)
+	}
+	if filter.EndDate != nil {
+		query = query.Where("metrics.date <= ?", *filter.EndDate)
+	}
+	if err := query.Order("metrics.date").Find(&points).Error; err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func (r *gormGithubRepository) UpsertCommits(ctx context.Context, commits []*models.Commit) error {
+	if len(commits) == 0 {
+		return nil
+	}
+	// Keep stats and PR association we already have when the incoming row lacks them (e.g. push webhooks)
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "repo_id"}, {Name: "sha"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"message":         gorm.Expr("excluded.message"),
+			"author_name":     gorm.Expr("excluded.author_name"),
+			"author_email":    gorm.Expr("excluded.author_email"),
+			"author_login":    gorm.Expr("COALESCE(excluded.author_login, commits.author_login)"),
+			"committed_at":    gorm.Expr("excluded.committed_at"),
+			"url":             gorm.Expr("COALESCE(excluded.url, commits.url)"),
+			"additions":       gorm.Expr("COALESCE(excluded.additions, commits.additions)"),
+			"deletions":       gorm.Expr("COALESCE(excluded.deletions, commits.deletions)"),
+			"pull_request_id": gorm.Expr("COALESCE(excluded.pull_request_id, commits.pull_request_id)"),
+			"updated_at":      gorm.Expr("excluded.updated_at"),
+		}),
+	}).Create(&commits).Error
+}
+
+func (r *gormGithubRepository) GetCommitsByRepoID(ctx context.Context, repoID string, limit int) ([]*models.Commit, error) {
+	var commits []*models.Commit
+	if err := r.db.WithContext(ctx).Where("repo_id = ?", repoID).Order("committed_at desc").Limit(limit).Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+func (r *gormGithubRepository) GetCommitsByPullRequestID(ctx context.Context, prID string) ([]*models.Commit, error) {
+	var commits []*models.Commit
+	if err := r.db.WithContext(ctx).Where("pull_request_id = ?", prID).Order("committed_at").Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+func (r *gormGithubRepository) GetCommitsMissingStats(ctx context.Context, repoID string, limit int) ([]*models.Commit, error) {
+	var commits []*models.Commit
+	if err := r.db.WithContext(ctx).Where("repo_id = ? AND additions IS NULL", repoID).Order("committed_at desc").Limit(limit).Find(&commits).Error; err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+func (r *gormGithubRepository) UpdateCommitSyncCursor(ctx context.Context, repoID string, until time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Repository{}).
+		Where("id = ?", repoID).
+		Update("commits_synced_until", until).Error
+}
+
+func (r *gormGithubRepository) UpsertGithubInstallation(ctx context.Context, installation *models.GithubInstallation) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "installation_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"account_login", "account_type", "repository_selection", "suspended_at", "updated_at"}),
+	}).Create(installation).Error
+}
+
+func (r *gormGithubRepository) GetGithubInstallation(ctx context.Context, installationID int64) (*models.GithubInstallation, error) {
+	var installation models.GithubInstallation
+	if err := r.db.WithContext(ctx).Where("installation_id = ?", installationID).First(&installation).Error; err != nil {
+		return nil, err
+	}
+	return &installation, nil
+}
+
+func (r *gormGithubRepository) GetGithubInstallationsByAccount(ctx context.Context, accountLogins []string) ([]*models.GithubInstallation, error) {
+	var installations []*models.GithubInstallation
+	if len(accountLogins) == 0 {
+		return installations, nil
+	}
+	if err := r.db.WithContext(ctx).Where("account_login IN ?", accountLogins).Find(&installations).Error; err != nil {
+		return nil, err
+	}
+	return installations, nil
+}
+
+// DeleteGithubInstallation removes an installation and unlinks its repositories
+func (r *gormGithubRepository) DeleteGithubInstallation(ctx context.Context, installationID int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Repository{}).Where("installation_id = ?", installationID).Update("installation_id", 0).Error; err != nil {
+			return err
+		}
+		return tx.Where("installation_id = ?", installationID).Delete(&models.GithubInstallation{}).Error
+	})
+}
+
+// SetInstallationRepositories links exactly the given GitHub repositories to an installation,
+// unlinking any others that were linked to it before.
+func (r *gormGithubRepository) SetInstallationRepositories(ctx context.Context, installationID int64, githubRepoIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		unlink := tx.Model(&models.Repository{}).Where("installation_id = ?", installationID)
+		if len(githubRepoIDs) > 0 {
+			unlink = unlink.Where("github_repo_id NOT IN ?", githubRepoIDs)
+		}
+		if err := unlink.Update("installation_id", 0).Error; err != nil {
+			return err
+		}
+		if len(githubRepoIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Repository{}).Where("github_repo_id IN ?", githubRepoIDs).Update("installation_id", installationID).Error
+	})
+}
+
+// ListInstalledRepositories returns repositories linked to an active App installation
+func (r *gormGithubRepository) ListInstalledRepositories(ctx context.Context) ([]*models.Repository, error) {
+	var repos []*models.Repository
+	if err := r.db.WithContext(ctx).
+		Where("installation_id IN (SELECT installation_id FROM public.github_installations WHERE suspended_at IS NULL)").
+		Find(&repos).Error; err != nil {
+		return nil, err
+	}
+	return repos, nil
+}
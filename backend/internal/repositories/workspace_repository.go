@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"devplus-backend/internal/models"
+)
+
+type WorkspaceRepository interface {
+	GetWorkspaceByAccount(ctx context.Context, provider string, login string) (*models.Workspace, error)
+	CreateWorkspace(ctx context.Context, workspace *models.Workspace) error
+	GetWorkspacesForUser(ctx context.Context, userID string) ([]*models.Workspace, error)
+	GetMember(ctx context.Context, workspaceID string, userID string) (*models.WorkspaceMember, error)
+	GetMembers(ctx context.Context, workspaceID string) ([]*models.WorkspaceMember, error)
+	AddMember(ctx context.Context, member *models.WorkspaceMember) error
+	UpdateMemberRole(ctx context.Context, workspaceID string, userID string, role string) error
+	RemoveMember(ctx context.Context, workspaceID string, userID string) error
+	CountOwners(ctx context.Context, workspaceID string) (int64, error)
+	GetUser(ctx context.Context, userID string) (*models.User, error)
+	FindUsersByLogin(ctx context.Context, provider string, login string, limit int) ([]*models.User, error)
+	GetRepositoryRole(ctx context.Context, repoID string, userID string) (string, error)
+	GetRepositoryRoleByName(ctx context.Context, owner string, name string, userID string) (string, error)
+	GetRepositoryMembers(ctx context.Context, repoID string) ([]*models.RepositoryMember, error)
+	UpsertRepositoryMember(ctx context.Context, member *models.RepositoryMember) error
+	AddRepositoryMember(ctx context.Context, member *models.RepositoryMember) error
+	RemoveRepositoryMember(ctx context.Context, repoID string, userID string) error
+}
+
+type gormWorkspaceRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkspaceRepository(db *gorm.DB) WorkspaceRepository {
+	return &gormWorkspaceRepository{db: db}
+}
+
+func (r *gormWorkspaceRepository) GetWorkspaceByAccount(ctx context.Context, provider string, login string) (*models.Workspace, error) {
+	var workspace models.Workspace
+	if err := r.db.WithContext(ctx).Where("provider = ? AND github_account_login = ?", provider, login).First(&workspace).Error; err != nil {
+		return nil, err
+	}
+	return &workspace, nil
+}
+
+func (r *gormWorkspaceRepository) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	// Concurrent syncs may race to create the same account workspace; keep the first one
+	if err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "provider"}, {Name: "github_account_login"}},
+		DoNothing: true,
+	}).Create(workspace).Error; err != nil {
+		return err
+	}
+	return r.db.WithContext(ctx).Where("provider = ? AND github_account_login = ?", workspace.Provider, workspace.GithubAccountLogin).First(workspace).Error
+}
+
+func (r *gormWorkspaceRepository) GetWorkspacesForUser(ctx context.Context, userID string) ([]*models.Workspace, error) {
+	var rows []struct {
+		models.Workspace
+		MemberRole string
+	}
+	if err := r.db.WithContext(ctx).Model(&models.Workspace{}).
+		Select("workspaces.*, workspace_members.role AS member_role").
+		Joins("JOIN workspace_members ON workspace_members.workspace_id = workspaces.id").
+		Where("workspace_members.user_id = ?", userID).
+		Order("workspaces.name").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	workspaces := make([]*models.Workspace, 0, len(rows))
+	for i := range rows {
+		w := rows[i].Workspace
+		w.Role = rows[i].MemberRole
+		workspaces = append(workspaces, &w)
+	}
+	return workspaces, nil
+}
+
+func (r *gormWorkspaceRepository) GetMember(ctx context.Context, workspaceID string, userID string) (*models.WorkspaceMember, error) {
+	var member models.WorkspaceMember
+	if err := r.db.WithContext(ctx).Where("workspace_id = ? AND user_id = ?", workspaceID, userID).First(&member).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+func (r *gormWorkspaceRepository) GetMembers(ctx context.Context, workspaceID string) ([]*models.WorkspaceMember, error) {
+	var members []*models.WorkspaceMember
+	if err := r.db.WithContext(ctx).Preload("User").Where("workspace_id = ?", workspaceID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *gormWorkspaceRepository) AddMember(ctx context.Context, member *models.WorkspaceMember) error {
+	// Existing members keep their role
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "workspace_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).Create(member).Error
+}
+
+func (r *gormWorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID string, userID string, role string) error {
+	return r.db.WithContext(ctx).Model(&models.WorkspaceMember{}).
+		Where("workspace_id = ? AND user_id = ?", workspaceID, userID).
+		Updates(map[string]interface{}{"role": role, "updated_at": gorm.Expr("NOW()")}).Error
+}
+
+func (r *gormWorkspaceRepository) RemoveMember(ctx context.Context, workspaceID string, userID string) error {
+	return r.db.WithContext(ctx).Where("workspace_id = ? AND user_id = ?", workspaceID, userID).Delete(&models.WorkspaceMember{}).Error
+}
+
+func (r *gormWorkspaceRepository) CountOwners(ctx context.Context, workspaceID string) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.WorkspaceMember{}).Where("workspace_id = ? AND role = ?", workspaceID, models.RoleOwner).Count(&count).Error
+	return count, err
+}
+
+func (r *gormWorkspaceRepository) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// FindUsersByLogin returns up to limit users whose account on the code host has the login.
+// GitHub logins are the usernames of users with a linked GitHub account; other hosts' logins
+// come from their linked code host accounts.
+func (r *gormWorkspaceRepository) FindUsersByLogin(ctx context.Context, provider string, login string, limit int) ([]*models.User, error) {
+	query := r.db.WithContext(ctx)
+	if provider == models.CodeHostGithub {
+		query = query.Where("github_id IS NOT NULL AND LOWER(username) = LOWER(?)", login)
+	} else {
+		query = query.Where("id IN (SELECT user_id FROM code_host_accounts WHERE provider = ? AND LOWER(username) = LOWER(?))", provider, login)
+	}
+
+	var users []*models.User
+	if err := query.Order("id").Limit(limit).Find(&users).Error; err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// GetRepositoryRole returns the user's effective role on a repository, or "" without access
+func (r *gormWorkspaceRepository) GetRepositoryRole(ctx context.Context, repoID string, userID string) (string, error) {
+	var roles []string
+	if err := r.db.WithContext(ctx).Table("public.repository_access").Where("repo_id = ? AND user_id = ?", repoID, userID).Pluck("role", &roles).Error; err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", nil
+	}
+	return roles[0], nil
+}
+
+// GetRepositoryRoleByName is GetRepositoryRole for a repository identified by owner and name
+func (r *gormWorkspaceRepository) GetRepositoryRoleByName(ctx context.Context, owner string, name string, userID string) (string, error) {
+	var roles []string
+	if err := r.db.WithContext(ctx).Table("public.repository_access").
+		Joins("JOIN public.repositories ON repositories.id = repository_access.repo_id").
+		Where("repositories.owner = ? AND repositories.name = ? AND repository_access.user_id = ?", owner, name, userID).
+		Pluck("repository_access.role", &roles).Error; err != nil {
+		return "", err
+	}
+	if len(roles) == 0 {
+		return "", nil
+	}
+	return roles[0], nil
+}
+
+func (r *gormWorkspaceRepository) GetRepositoryMembers(ctx context.Context, repoID string) ([]*models.RepositoryMember, error) {
+	var members []*models.RepositoryMember
+	if err := r.db.WithContext(ctx).Preload("User").Where("repo_id = ?", repoID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, err
+	}
+	return members, nil
+}
+
+func (r *gormWorkspaceRepository) UpsertRepositoryMember(ctx context.Context, member *models.RepositoryMember) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role", "updated_at"}),
+	}).Create(member).Error
+}
+
+// AddRepositoryMember adds a repository role unless the user already has one there
+func (r *gormWorkspaceRepository) AddRepositoryMember(ctx context.Context, member *models.RepositoryMember) error {
+	return r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "repo_id"}, {Name: "user_id"}},
+		DoNothing: true,
+	}).Create(member).Error
+}
+
+func (r *gormWorkspaceRepository) RemoveRepositoryMember(ctx context.Context, repoID string, userID string) error {
+	return r.db.WithContext(ctx).Where("repo_id = ? AND user_id = ?", repoID, userID).Delete(&models.RepositoryMember{}).Error
+}
@@ -3,15 +3,17 @@ package router
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 
 	"github.com/gorilla/mux"
 
 	"devplus-backend/internal/controllers/rest"
 	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
 )
 
 // SetupRouter configures all HTTP routes for the application.
-func SetupRouter(authController *rest.AuthController, githubController *rest.GithubController) *mux.Router {
+func SetupRouter(authController *rest.AuthController, githubController *rest.GithubController, workspaceController *rest.WorkspaceController, codeHostController *rest.CodeHostController, notificationController *rest.NotificationController, slackController *rest.SlackController, authorizer middleware.RepositoryAuthorizer, authenticate mux.MiddlewareFunc) *mux.Router {
 	router := mux.NewRouter()
 
 	// Apply Middleware
@@ -44,44 +46,114 @@ func SetupRouter(authController *rest.AuthController, githubController *rest.Git
 	v1.HandleFunc("/webhook/ai/repo", githubController.HandleRepoAIWebhook).Methods("POST")
 	v1.HandleFunc("/webhook/github", githubController.HandleGithubWebhook).Methods("POST")
 	v1.HandleFunc("/webhook/release-risk", githubController.HandleReleaseRiskCallback).Methods("POST")
+	v1.HandleFunc("/webhook/{provider}", codeHostController.HandleWebhook).Methods("POST")
+
+	// Slack App (Public, verified by Slack's request signature)
+	v1.HandleFunc("/slack/commands", slackController.HandleCommand).Methods("POST")
+	v1.HandleFunc("/slack/interactions", slackController.HandleInteraction).Methods("POST")
 
 	// Auth Routes (Nested under /auth)
 	auth := v1.PathPrefix("/auth").Subrouter()
 	auth.HandleFunc("/github/login", authController.Login).Methods("GET")
 	auth.HandleFunc("/github/callback", authController.Callback).Methods("GET")
+	auth.HandleFunc("/providers", authController.Providers).Methods("GET")
+	auth.HandleFunc("/sso/{provider}/login", authController.SSOLogin).Methods("GET")
+	auth.HandleFunc("/sso/{provider}/callback", authController.SSOCallback).Methods("GET")
+	auth.HandleFunc("/hosts/{provider}/login", authController.CodeHostLogin).Methods("GET")
+	auth.HandleFunc("/hosts/{provider}/callback", authController.CodeHostCallback).Methods("GET")
 	auth.HandleFunc("/logout", authController.Logout).Methods("POST")
+	auth.HandleFunc("/exchange", authController.Exchange).Methods("POST")
+	auth.HandleFunc("/refresh", authController.Refresh).Methods("POST")
 
 	// Protected Routes (Session Based)
 	// We create a new subrouter off v1 so wAdd new feature: AI-powered code reviewe can apply middleware ONLY to these routes
 	protected := v1.PathPrefix("/").Subrouter()
-	protected.Use(middleware.SessionMiddleware)
+	protected.Use(authenticate)
+	protected.Use(middleware.RequireTokenScope(apiTokenScope))
+
+	// Repository role checks (owner > maintainer > viewer, per workspace or repository)
+	viewer := repositoryRole(authorizer, models.RoleViewer)
+	maintainer := repositoryRole(authorizer, models.RoleMaintainer)
 
 	// Register existing controllers to protected routes
 	// User Routes
 	protected.HandleFunc("/auth/me", authController.GetCurrentUser).Methods("GET")
+	protected.HandleFunc("/auth/status", authController.GetStatus).Methods("GET")
+	protected.HandleFunc("/auth/sessions", authController.ListSessions).Methods("GET")
+	protected.HandleFunc("/auth/sessions", authController.RevokeAllSessions).Methods("DELETE")
+	protected.HandleFunc("/auth/sessions/{id}", authController.RevokeSession).Methods("DELETE")
+	protected.HandleFunc("/auth/github/link", authController.LinkGithub).Methods("POST")
+	protected.HandleFunc("/auth/identities", authController.ListIdentities).Methods("GET")
+	protected.HandleFunc("/auth/hosts", authController.ListCodeHostAccounts).Methods("GET")
+	protected.HandleFunc("/auth/hosts/{provider}/link", authController.LinkCodeHost).Methods("POST")
+	protected.HandleFunc("/auth/tokens", authController.ListAPITokens).Methods("GET")
+	protected.HandleFunc("/auth/tokens", authController.CreateAPIToken).Methods("POST")
+	protected.HandleFunc("/auth/tokens/{id}", authController.GetAPIToken).Methods("GET")
+	protected.HandleFunc("/auth/tokens/{id}", authController.UpdateAPIToken).Methods("PATCH")
+	protected.HandleFunc("/auth/tokens/{id}", authController.RevokeAPIToken).Methods("DELETE")
 	protected.HandleFunc("/repos", githubController.GetRepositories).Methods("GET")
-	protected.HandleFunc("/repos/{id}", githubController.GetRepository).Methods("GET")
+	protected.Handle("/repos/{id}", viewer(githubController.GetRepository)).Methods("GET")
 	protected.HandleFunc("/repos/sync", githubController.SyncRepositories).Methods("POST")
-	protected.HandleFunc("/repos/{id}/sync", githubController.SyncRepository).Methods("POST")
-	protected.HandleFunc("/repos/{id}/pulls", githubController.GetPullRequestsByRepoID).Methods("GET")
-	protected.HandleFunc("/repos/{owner}/{repo}/pulls", githubController.GetPullRequests).Methods("GET")
-	protected.HandleFunc("/repos/{id}/prs/{pr_number}", githubController.GetPullRequestDetail).Methods("GET")
+	protected.HandleFunc("/hosts/{provider}/sync", codeHostController.SyncRepositories).Methods("POST")
+	protected.Handle("/repos/{id}/sync", maintainer(githubController.SyncRepository)).Methods("POST")
+	protected.Handle("/repos/{id}/pulls", viewer(githubController.GetPullRequestsByRepoID)).Methods("GET")
+	protected.Handle("/repos/{owner}/{repo}/pulls", viewer(githubController.GetPullRequests)).Methods("GET")
+	protected.Handle("/repos/{id}/prs/{pr_number}", viewer(githubController.GetPullRequestDetail)).Methods("GET")
+	protected.Handle("/repos/{id}/commits", viewer(githubController.GetCommits)).Methods("GET")
+	protected.Handle("/repos/{id}/prs/{pr_number}/commits", viewer(githubController.GetPullRequestCommits)).Methods("GET")
+
+	// Workspace Routes
+	protected.HandleFunc("/workspaces", workspaceController.GetWorkspaces).Methods("GET")
+	protected.HandleFunc("/workspaces/{id}/members", workspaceController.GetMembers).Methods("GET")
+	protected.HandleFunc("/workspaces/{id}/members", workspaceController.AddMember).Methods("POST")
+	protected.HandleFunc("/workspaces/{id}/members/{user_id}", workspaceController.UpdateMemberRole).Methods("PUT")
+	protected.HandleFunc("/workspaces/{id}/members/{user_id}", workspaceController.RemoveMember).Methods("DELETE")
+
+	// Repository Member Routes
+	protected.HandleFunc("/repos/{id}/members", workspaceController.GetRepositoryMembers).Methods("GET")
+	protected.HandleFunc("/repos/{id}/members", workspaceController.SetRepositoryMember).Methods("PUT")
+	protected.HandleFunc("/repos/{id}/members/{user_id}", workspaceController.RemoveRepositoryMember).Methods("DELETE")
+
+	// Notification Routes
+	protected.HandleFunc("/notifications/channels", notificationController.ListChannels).Methods("GET")
+	protected.HandleFunc("/notifications/channels", notificationController.CreateChannel).Methods("POST")
+	protected.HandleFunc("/notifications/channels/{id}", notificationController.DeleteChannel).Methods("DELETE")
+	protected.HandleFunc("/notifications/rules", notificationController.ListRules).Methods("GET")
+	protected.HandleFunc("/notifications/rules", notificationController.CreateRule).Methods("POST")
+	protected.HandleFunc("/notifications/rules/{id}", notificationController.DeleteRule).Methods("DELETE")
+	protected.HandleFunc("/notifications/deliveries", notificationController.ListDeliveries).Methods("GET")
+	protected.HandleFunc("/notifications/endpoints", notificationController.ListEndpoints).Methods("GET")
+	protected.HandleFunc("/notifications/endpoints", notificationController.CreateEndpoint).Methods("POST")
+	protected.HandleFunc("/notifications/endpoints/{id}", notificationController.DeleteEndpoint).Methods("DELETE")
+	protected.HandleFunc("/notifications/endpoints/{id}/test", notificationController.TestEndpoint).Methods("POST")
+	protected.HandleFunc("/notifications/dead-letters", notificationController.ListDeadLetters).Methods("GET")
+	protected.HandleFunc("/notifications/dead-letters/{id}/replay", notificationController.ReplayDeadLetter).Methods("POST")
+
+	// Slack Account Routes
+	protected.HandleFunc("/slack/link", slackController.LinkAccount).Methods("POST")
+	protected.HandleFunc("/slack/accounts", slackController.ListAccounts).Methods("GET")
+	protected.HandleFunc("/slack/accounts/{id}", slackController.UnlinkAccount).Methods("DELETE")
 
 	// Dashboard Routes
-	protected.HandleFunc("/metrics", githubController.GetMetrics).Methods("GET")
+	protected.Handle("/metrics", viewer(githubController.GetMetrics)).Methods("GET")
 	protected.HandleFunc("/metrics/personal", githubController.GetPersonalMetrics).Methods("GET")
+	protected.Handle("/metrics/timeseries", viewer(githubController.GetMetricsTimeSeries)).Methods("GET")
+	protected.Handle("/metrics/cycle-time", viewer(githubController.GetCycleTimeMetrics)).Methods("GET")
+	protected.Handle("/metrics/dora", viewer(githubController.GetDoraMetrics)).Methods("GET")
 	protected.HandleFunc("/dashboard/stats", githubController.GetDashboardStats).Methods("GET")
 	protected.HandleFunc("/dashboard/recent-prs", githubController.GetRecentActivity).Methods("GET")
 
 	// AI Analysis Routes
 	// AI Analysis Routes
-	protected.HandleFunc("/repos/{id}/prs/{pr_number}/analyze", githubController.AnalyzePullRequest).Methods("POST")
-	protected.HandleFunc("/repos/{id}/prs/{pr_number}/analyze/stream", githubController.StreamPullRequestAnalysis).Methods("GET")
-	protected.HandleFunc("/repos/{id}/analyze", githubController.AnalyzeRepository).Methods("POST")
-	protected.HandleFunc("/repos/{id}/analyze/stream", githubController.StreamRepositoryAnalysis).Methods("GET")
+	protected.Handle("/repos/{id}/prs/{pr_number}/analyze", maintainer(githubController.AnalyzePullRequest)).Methods("POST")
+	protected.Handle("/repos/{id}/prs/{pr_number}/analyze/stream", viewer(githubController.StreamPullRequestAnalysis)).Methods("GET")
+	protected.Handle("/repos/{id}/analyze", maintainer(githubController.AnalyzeRepository)).Methods("POST")
+	protected.Handle("/repos/{id}/analyze/stream", viewer(githubController.StreamRepositoryAnalysis)).Methods("GET")
 
 	// Release Risk Routes
-	protected.HandleFunc("/repos/{id}/calculate-release-risk", githubController.CalculateReleaseRisk).Methods("POST")
+	protected.Handle("/repos/{id}/calculate-release-risk", maintainer(githubController.CalculateReleaseRisk)).Methods("POST")
+	protected.Handle("/repos/{id}/releases", viewer(githubController.GetReleases)).Methods("GET")
+	protected.Handle("/repos/{id}/releases", maintainer(githubController.PublishRelease)).Methods("POST")
 
 	// Webhooks (Should ideally be public or verified by signature, but putting under protected for now or separate if needed)
 	// If it's a callback from Kestra/Gemini, it might not have the user session.
@@ -92,3 +164,41 @@ func SetupRouter(authController *rest.AuthController, githubController *rest.Git
 
 	return router
 }
+
+// repositoryRole adapts middleware.RequireRepositoryRole to handler functions
+func repositoryRole(authorizer middleware.RepositoryAuthorizer, minRole string) func(http.HandlerFunc) http.Handler {
+	require := middleware.RequireRepositoryRole(authorizer, minRole)
+	return func(handler http.HandlerFunc) http.Handler {
+		return require(handler)
+	}
+}
+
+// apiTokenScope decides which scope an API token needs for a protected route. Managing
+// sessions, tokens, memberships, notifications and Slack accounts needs a browser session;
+// analysis triggers need analysis:write, other reads repos:read and other writes repos:write.
+func apiTokenScope(r *http.Request) (string, bool) {
+	path := r.URL.Path
+	if route := mux.CurrentRoute(r); route != nil {
+		if template, err := route.GetPathTemplate(); err == nil {
+			path = template
+		}
+	}
+	path = strings.TrimPrefix(path, "/api/v1")
+
+	switch {
+	case path == "/auth/me" || path == "/auth/status":
+		return "", true
+	case strings.HasPrefix(path, "/auth/"),
+		strings.HasPrefix(path, "/workspaces"),
+		strings.HasPrefix(path, "/notifications"),
+		strings.HasPrefix(path, "/slack"),
+		strings.Contains(path, "/members"):
+		return "", false
+	case r.Method == http.MethodPost && (strings.HasSuffix(path, "/analyze") || strings.HasSuffix(path, "/calculate-release-risk")):
+		return models.ScopeAnalysisWrite, true
+	case r.Method == http.MethodGet:
+		return models.ScopeReposRead, true
+	default:
+		return models.ScopeReposWrite, true
+	}
+}
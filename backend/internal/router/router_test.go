@@ -0,0 +1,123 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"devplus-backend/internal/middleware"
+	"devplus-backend/internal/models"
+)
+
+func TestAPITokenScope(t *testing.T) {
+	tests := []struct {
+		method      string
+		path        string
+		wantScope   string
+		wantAllowed bool
+	}{
+		{"GET", "/api/v1/auth/me", "", true},
+		{"GET", "/api/v1/auth/status", "", true},
+		{"GET", "/api/v1/auth/tokens", "", false},
+		{"DELETE", "/api/v1/auth/sessions", "", false},
+		{"GET", "/api/v1/workspaces", "", false},
+		{"PUT", "/api/v1/repos/{id}/members", "", false},
+		{"GET", "/api/v1/repos/{id}/members", "", false},
+		{"POST", "/api/v1/notifications/channels", "", false},
+		{"POST", "/api/v1/slack/link", "", false},
+		{"GET", "/api/v1/repos", models.ScopeReposRead, true},
+		{"GET", "/api/v1/repos/{id}/releases", models.ScopeReposRead, true},
+		{"GET", "/api/v1/repos/{id}/analyze/stream", models.ScopeReposRead, true},
+		{"GET", "/api/v1/metrics/dora", models.ScopeReposRead, true},
+		{"POST", "/api/v1/repos/{id}/analyze", models.ScopeAnalysisWrite, true},
+		{"POST", "/api/v1/repos/{id}/prs/{pr_number}/analyze", models.ScopeAnalysisWrite, true},
+		{"POST", "/api/v1/repos/{id}/calculate-release-risk", models.ScopeAnalysisWrite, true},
+		{"POST", "/api/v1/repos/sync", models.ScopeReposWrite, true},
+		{"POST", "/api/v1/repos/{id}/sync", models.ScopeReposWrite, true},
+		{"POST", "/api/v1/repos/{id}/releases", models.ScopeReposWrite, true},
+		{"POST", "/api/v1/hosts/{provider}/sync", models.ScopeReposWrite, true},
+	}
+	for _, tt := range tests {
+		scope, allowed := apiTokenScope(httptest.NewRequest(tt.method, tt.path, nil))
+		if scope != tt.wantScope || allowed != tt.wantAllowed {
+			t.Errorf("apiTokenScope(%s %s) = %q, %v, want %q, %v", tt.method, tt.path, scope, allowed, tt.wantScope, tt.wantAllowed)
+		}
+	}
+}
+
+func TestRequireTokenScope(t *testing.T) {
+	readOnly := &middleware.Principal{Method: middleware.AuthMethodAPIToken, Scopes: models.ScopeList{models.ScopeReposRead}}
+	analysis := &middleware.Principal{Method: middleware.AuthMethodAPIToken, Scopes: models.ScopeList{models.ScopeReposRead, models.ScopeAnalysisWrite}}
+	oidcReader := &middleware.Principal{Method: middleware.AuthMethodOIDC, Scopes: models.ScopeList{models.ScopeReposRead}}
+	session := &middleware.Principal{Method: middleware.AuthMethodSession, SessionID: "session-1"}
+
+	tests := []struct {
+		name      string
+		principal *middleware.Principal
+		method    string
+		path      string
+		want      int
+	}{
+		{"read-only token reads pull requests", readOnly, "GET", "/api/v1/repos/repo-1/pulls", http.StatusOK},
+		{"read-only token reads through owner and name", readOnly, "GET", "/api/v1/repos/acme/api/pulls", http.StatusOK},
+		{"read-only token can't sync", readOnly, "POST", "/api/v1/repos/repo-1/sync", http.StatusForbidden},
+		{"read-only token can't publish a release", readOnly, "POST", "/api/v1/repos/repo-1/releases", http.StatusForbidden},
+		{"read-only token can't delete a member", readOnly, "DELETE", "/api/v1/repos/repo-1/members/user-2", http.StatusForbidden},
+		{"read-only token can't trigger analysis", readOnly, "POST", "/api/v1/repos/repo-1/prs/7/analyze", http.StatusForbidden},
+		{"read-only token can't calculate release risk", readOnly, "POST", "/api/v1/repos/repo-1/calculate-release-risk", http.StatusForbidden},
+		{"read-only token reads the current user", readOnly, "GET", "/api/v1/auth/me", http.StatusOK},
+		{"read-only token can't list tokens", readOnly, "GET", "/api/v1/auth/tokens", http.StatusForbidden},
+		{"analysis token calculates release risk", analysis, "POST", "/api/v1/repos/repo-1/calculate-release-risk", http.StatusOK},
+		{"analysis token triggers analysis", analysis, "POST", "/api/v1/repos/repo-1/prs/7/analyze", http.StatusOK},
+		{"analysis token can't sync", analysis, "POST", "/api/v1/repos/repo-1/sync", http.StatusForbidden},
+		{"read-only OIDC principal reads", oidcReader, "GET", "/api/v1/repos/repo-1/pulls", http.StatusOK},
+		{"read-only OIDC principal can't sync", oidcReader, "POST", "/api/v1/repos/repo-1/sync", http.StatusForbidden},
+		{"session syncs", session, "POST", "/api/v1/repos/repo-1/sync", http.StatusOK},
+		{"session publishes a release", session, "POST", "/api/v1/repos/repo-1/releases", http.StatusOK},
+		{"session deletes a member", session, "DELETE", "/api/v1/repos/repo-1/members/user-2", http.StatusOK},
+		{"session lists tokens", session, "GET", "/api/v1/auth/tokens", http.StatusOK},
+		{"session manages workspaces", session, "POST", "/api/v1/workspaces/ws-1/members", http.StatusOK},
+		{"no principal passes through", nil, "POST", "/api/v1/repos/repo-1/sync", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			scopedRouter(tt.principal).ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			if w.Code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, w.Code, tt.want)
+			}
+		})
+	}
+}
+
+// scopedRouter registers a few of SetupRouter's protected routes behind RequireTokenScope,
+// with a stand-in for Authenticate that puts principal in the context
+func scopedRouter(principal *middleware.Principal) *mux.Router {
+	router := mux.NewRouter()
+	protected := router.PathPrefix("/api/v1").Subrouter()
+	protected.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if principal != nil {
+				r = r.WithContext(context.WithValue(r.Context(), middleware.PrincipalContextKey, principal))
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	protected.Use(middleware.RequireTokenScope(apiTokenScope))
+
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+	protected.HandleFunc("/auth/me", ok).Methods("GET")
+	protected.HandleFunc("/auth/tokens", ok).Methods("GET")
+	protected.HandleFunc("/workspaces/{id}/members", ok).Methods("POST")
+	protected.HandleFunc("/repos/{id}/members/{user_id}", ok).Methods("DELETE")
+	protected.HandleFunc("/repos/{id}/sync", ok).Methods("POST")
+	protected.HandleFunc("/repos/{id}/pulls", ok).Methods("GET")
+	protected.HandleFunc("/repos/{owner}/{repo}/pulls", ok).Methods("GET")
+	protected.HandleFunc("/repos/{id}/prs/{pr_number}/analyze", ok).Methods("POST")
+	protected.HandleFunc("/repos/{id}/calculate-release-risk", ok).Methods("POST")
+	protected.HandleFunc("/repos/{id}/releases", ok).Methods("POST")
+	return router
+}
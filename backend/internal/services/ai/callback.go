@@ -0,0 +1,77 @@
+package ai
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Callback kinds; a signature for one kind and subject can't be replayed against another
+const (
+	CallbackPRAnalysis   = "pr-analysis"
+	CallbackRepoAnalysis = "repo-analysis"
+	CallbackReleaseRisk  = "release-risk"
+)
+
+// callbackTTL is how long a workflow has to call back with its result
+const callbackTTL = 24 * time.Hour
+
+// CallbackSigner signs the URLs workflows call back with results and verifies the callbacks.
+// The signature covers the callback kind, the subject (pull request, repository or release
+// risk run) and an expiry, and is passed in the "expires" and "signature" query parameters.
+type CallbackSigner struct {
+	key []byte
+	now func() time.Time
+}
+
+func NewCallbackSigner(secret string) *CallbackSigner {
+	return &CallbackSigner{key: []byte(secret), now: time.Now}
+}
+
+// Enabled reports whether a secret is configured. Without one every callback is rejected.
+func (s *CallbackSigner) Enabled() bool {
+	return len(s.key) > 0
+}
+
+// SignURL adds the signature for the kind and subject to the callback URL
+func (s *CallbackSigner) SignURL(callbackURL string, kind string, subjectID string) (string, error) {
+	u, err := url.Parse(callbackURL)
+	if err != nil {
+		return "", err
+	}
+	expires := strconv.FormatInt(s.now().Add(callbackTTL).Unix(), 10)
+	query := u.Query()
+	query.Set("expires", expires)
+	query.Set("signature", s.signature(kind, subjectID, expires))
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Verify reports whether the request carries an unexpired signature for the kind and subject
+func (s *CallbackSigner) Verify(r *http.Request, kind string, subjectID string) bool {
+	if !s.Enabled() || subjectID == "" {
+		return false
+	}
+	query := r.URL.Query()
+	expires := query.Get("expires")
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || s.now().Unix() > expiresAt {
+		return false
+	}
+	got, err := hex.DecodeString(query.Get("signature"))
+	if err != nil {
+		return false
+	}
+	want, _ := hex.DecodeString(s.signature(kind, subjectID, expires))
+	return hmac.Equal(got, want)
+}
+
+func (s *CallbackSigner) signature(kind string, subjectID string, expires string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(kind + ":" + subjectID + ":" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
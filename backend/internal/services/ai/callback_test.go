@@ -0,0 +1,57 @@
+package ai
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCallbackSigner(t *testing.T) {
+	signer := NewCallbackSigner("callback-secret")
+	signed, err := signer.SignURL("http://backend/api/v1/webhook/ai?source=kestra", CallbackPRAnalysis, "pr-1")
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+	if !strings.Contains(signed, "source=kestra") {
+		t.Errorf("SignURL dropped the existing query: %s", signed)
+	}
+
+	tests := []struct {
+		name    string
+		signer  *CallbackSigner
+		url     string
+		kind    string
+		subject string
+		want    bool
+	}{
+		{"valid", signer, signed, CallbackPRAnalysis, "pr-1", true},
+		{"other subject", signer, signed, CallbackPRAnalysis, "pr-2", false},
+		{"other kind", signer, signed, CallbackReleaseRisk, "pr-1", false},
+		{"other secret", NewCallbackSigner("other-secret"), signed, CallbackPRAnalysis, "pr-1", false},
+		{"no secret", NewCallbackSigner(""), signed, CallbackPRAnalysis, "pr-1", false},
+		{"unsigned", signer, "http://backend/api/v1/webhook/ai", CallbackPRAnalysis, "pr-1", false},
+		{"tampered expiry", signer, strings.Replace(signed, "expires=", "expires=9", 1), CallbackPRAnalysis, "pr-1", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("POST", tt.url, nil)
+			if got := tt.signer.Verify(r, tt.kind, tt.subject); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCallbackSignerExpiry(t *testing.T) {
+	signer := NewCallbackSigner("callback-secret")
+	signed, err := signer.SignURL("http://backend/api/v1/webhook/ai/repo", CallbackRepoAnalysis, "repo-1")
+	if err != nil {
+		t.Fatalf("SignURL: %v", err)
+	}
+
+	signer.now = func() time.Time { return time.Now().Add(callbackTTL + time.Minute) }
+	if signer.Verify(httptest.NewRequest("POST", signed, nil), CallbackRepoAnalysis, "repo-1") {
+		t.Error("Verify accepted an expired callback")
+	}
+}
@@ -2,6 +2,7 @@ package ai
 
 import (
 	"context"
+	"devplus-backend/internal/githubapi"
 	"devplus-backend/internal/models"
 	"errors"
 )
@@ -16,20 +17,22 @@ type AIFactory struct {
 	kestraURL      string
 	kestraUsername string
 	kestraPassword string
+	github         githubapi.Endpoints
 }
 
-func NewAIFactory(kestraURL, kestraUsername, kestraPassword string) *AIFactory {
+func NewAIFactory(kestraURL, kestraUsername, kestraPassword string, github githubapi.Endpoints) *AIFactory {
 	return &AIFactory{
 		kestraURL:      kestraURL,
 		kestraUsername: kestraUsername,
 		kestraPassword: kestraPassword,
+		github:         github,
 	}
 }
 
 func (f *AIFactory) GetAIService(provider string) (AIService, error) {
 	switch provider {
 	case "kestra":
-		return NewKestraAIService(f.kestraURL, f.kestraUsername, f.kestraPassword), nil
+		return NewKestraAIService(f.kestraURL, f.kestraUsername, f.kestraPassword, f.github), nil
 	default:
 		return nil, errors.New("unsupported AI provider")
 	}
@@ -10,6 +10,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"devplus-backend/internal/githubapi"
 	"devplus-backend/internal/models"
 )
 
@@ -17,18 +18,47 @@ type KestraAIService struct {
 	kestraURL string
 	username  string
 	password  string
+	github    githubapi.Endpoints
 	client    *http.Client
 }
 
-func NewKestraAIService(kestraURL, username, password string) *KestraAIService {
+func NewKestraAIService(kestraURL, username, password string, github githubapi.Endpoints) *KestraAIService {
 	return &KestraAIService{
 		kestraURL: kestraURL,
 		username:  username,
 		password:  password,
+		github:    github,
 		client:    &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
+type githubTokenKey struct{}
+
+// WithGithubToken attaches a GitHub token (e.g. an App installation token) that the Kestra
+// service uses when fetching diffs, READMEs and file trees, so private repositories work
+// without a logged-in user.
+func WithGithubToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, githubTokenKey{}, token)
+}
+
+// authorizeGithubRequest adds the token from WithGithubToken to a GitHub API request, if any
+func authorizeGithubRequest(req *http.Request) {
+	if token, ok := req.Context().Value(githubTokenKey{}).(string); ok && token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+}
+
+type prDiffKey struct{}
+
+// WithPRDiff attaches a pull request diff the caller fetched itself, e.g. from a code host
+// other than GitHub. AnalyzePR sends it instead of fetching the diff from GitHub.
+func WithPRDiff(ctx context.Context, diff string) context.Context {
+	return context.WithValue(ctx, prDiffKey{}, diff)
+}
+
 type KestraExecutionRequest struct {
 	Namespace string                 `json:"namespace"`
 	FlowId    string                 `json:"flowId"`
@@ -45,11 +75,15 @@ func (s *KestraAIService) AnalyzePR(ctx context.Context, pr *models.PullRequest,
 		return fmt.Errorf("repository not loaded for PR %s", pr.ID)
 	}
 
-	// Fetch PR diff from GitHub API
-	prDiff, err := s.fetchPRDiff(ctx, pr.Repository.Owner, pr.Repository.Name, int(*pr.Number))
-	if err != nil {
-		log.Error().Err(err).Str("pr_id", pr.ID).Msg("[KestraService] Failed to fetch PR diff")
-		return fmt.Errorf("failed to fetch PR diff: %w", err)
+	// Fetch PR diff from GitHub API, unless the caller attached one
+	prDiff, ok := ctx.Value(prDiffKey{}).(string)
+	if !ok {
+		var err error
+		prDiff, err = s.fetchPRDiff(ctx, pr.Repository.Owner, pr.Repository.Name, int(*pr.Number))
+		if err != nil {
+			log.Error().Err(err).Str("pr_id", pr.ID).Msg("[KestraService] Failed to fetch PR diff")
+			return fmt.Errorf("failed to fetch PR diff: %w", err)
+		}
 	}
 
 	// Construct inputs for Kestra Flow
@@ -118,18 +152,18 @@ func (s *KestraAIService) AnalyzePR(ctx context.Context, pr *models.PullRequest,
 func (s *KestraAIService) AnalyzeRepo(ctx context.Context, repo *models.Repository, callbackURL string) error {
 	log.Info().Str("repo_id", repo.ID).Str("flow_id", "ai-repo-analysis").Msg("[KestraService] Analyzing Repository")
 
-	// Fetch README from GitHub API
-	readme, err := s.fetchReadme(ctx, repo.Owner, repo.Name)
-	if err != nil {
-		log.Warn().Err(err).Str("repo_id", repo.ID).Msg("[KestraService] Failed to fetch README, using placeholder")
-		readme = "No README available"
-	}
-
-	// Fetch file tree from GitHub API
-	fileTree, err := s.fetchFileTree(ctx, repo.Owner, repo.Name)
-	if err != nil {
-		log.Warn().Err(err).Str("repo_id", repo.ID).Msg("[KestraService] Failed to fetch file tree, using placeholder")
-		fileTree = "File tree not available"
+	// Fetch README and file tree from GitHub API; other code hosts get the placeholders
+	readme, fileTree := "No README available", "File tree not available"
+	if repo.IsGithub() {
+		var err error
+		if readme, err = s.fetchReadme(ctx, repo.Owner, repo.Name); err != nil {
+			log.Warn().Err(err).Str("repo_id", repo.ID).Msg("[KestraService] Failed to fetch README, using placeholder")
+			readme = "No README available"
+		}
+		if fileTree, err = s.fetchFileTree(ctx, repo.Owner, repo.Name); err != nil {
+			log.Warn().Err(err).Str("repo_id", repo.ID).Msg("[KestraService] Failed to fetch file tree, using placeholder")
+			fileTree = "File tree not available"
+		}
 	}
 
 	// Construct inputs for Kestra Flow
@@ -191,9 +225,9 @@ func (s *KestraAIService) AnalyzeRepo(ctx context.Context, repo *models.Reposito
 	return nil
 }
 
-// fetchReadme fetches the README content from GitHub API
+// fetchReadme fetches the README content from the GitHub API
 func (s *KestraAIService) fetchReadme(ctx context.Context, owner, repo string) (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/readme", owner, repo)
+	url := s.github.API("repos/%s/%s/readme", owner, repo)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -201,6 +235,7 @@ func (s *KestraAIService) fetchReadme(ctx context.Context, owner, repo string) (
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3.raw")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	authorizeGithubRequest(req)
 	
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -220,9 +255,9 @@ func (s *KestraAIService) fetchReadme(ctx context.Context, owner, repo string) (
 	return buf.String(), nil
 }
 
-// fetchFileTree fetches the repository file tree from GitHub API
+// fetchFileTree fetches the repository file tree from the GitHub API
 func (s *KestraAIService) fetchFileTree(ctx context.Context, owner, repo string) (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/git/trees/main?recursive=1", owner, repo)
+	url := s.github.API("repos/%s/%s/git/trees/main?recursive=1", owner, repo)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -230,6 +265,7 @@ func (s *KestraAIService) fetchFileTree(ctx context.Context, owner, repo string)
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	authorizeGithubRequest(req)
 	
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -264,9 +300,9 @@ func (s *KestraAIService) fetchFileTree(ctx context.Context, owner, repo string)
 	return fileTreeBuilder.String(), nil
 }
 
-// fetchPRDiff fetches the pull request diff from GitHub API
+// fetchPRDiff fetches the pull request diff from the GitHub API
 func (s *KestraAIService) fetchPRDiff(ctx context.Context, owner, repo string, prNumber int) (string, error) {
-	url := fmt.Sprintf("https://api.github.com/repos/%s/%s/pulls/%d", owner, repo, prNumber)
+	url := s.github.API("repos/%s/%s/pulls/%d", owner, repo, prNumber)
 	
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
@@ -274,6 +310,7 @@ func (s *KestraAIService) fetchPRDiff(ctx context.Context, owner, repo string, p
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3.diff")
 	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	authorizeGithubRequest(req)
 	
 	resp, err := s.client.Do(req)
 	if err != nil {
@@ -0,0 +1,190 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	// apiTokenDisplayLength is how much of a token is kept in clear for listings
+	apiTokenDisplayLength = len(models.APITokenPrefix) + 6
+	// apiTokenTouchInterval throttles writes when recording token usage
+	apiTokenTouchInterval = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidAPIToken is returned for unknown or expired API tokens
+	ErrInvalidAPIToken = errors.New("invalid or expired api token")
+	// ErrAPITokenNotFound is returned when addressing a token the user doesn't have
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrInvalidAPITokenRequest is returned for missing names, unknown scopes or past expiries
+	ErrInvalidAPITokenRequest = errors.New("invalid api token request")
+)
+
+// IssuedAPIToken carries the raw token of a newly created API token. It is only available
+// at creation; the database keeps a hash.
+type IssuedAPIToken struct {
+	*models.APIToken
+	Token string `json:"token"`
+}
+
+// CreateAPIToken creates a personal access token with the given scopes. A nil expiresAt
+// creates a token that doesn't expire.
+func (s *AuthService) CreateAPIToken(ctx context.Context, userID string, name string, scopes []string, expiresAt *time.Time) (*IssuedAPIToken, error) {
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidAPITokenRequest)
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w: expires_at must be in the future", ErrInvalidAPITokenRequest)
+	}
+
+	secret, _, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	raw := models.APITokenPrefix + secret
+
+	token := &models.APIToken{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    raw[:apiTokenDisplayLength],
+		TokenHash: hashToken(raw),
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+	}
+	if err := s.db.WithContext(ctx).Create(token).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("token_id", token.ID).Strs("scopes", scopes).Msg("[AuthService.CreateAPIToken] Created API token")
+	return &IssuedAPIToken{APIToken: token, Token: raw}, nil
+}
+
+// ListAPITokens returns the user's API tokens, newest first
+func (s *AuthService) ListAPITokens(ctx context.Context, userID string) ([]*models.APIToken, error) {
+	var tokens []*models.APIToken
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&tokens).Error; err != nil {
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// GetAPIToken returns one of the user's API tokens
+func (s *AuthService) GetAPIToken(ctx context.Context, userID string, tokenID string) (*models.APIToken, error) {
+	if _, err := uuid.Parse(tokenID); err != nil {
+		return nil, ErrAPITokenNotFound
+	}
+	var token models.APIToken
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", tokenID, userID).Limit(1).Find(&token)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrAPITokenNotFound
+	}
+	return &token, nil
+}
+
+// UpdateAPIToken renames a token and/or replaces its scopes; nil arguments are left as is
+func (s *AuthService) UpdateAPIToken(ctx context.Context, userID string, tokenID string, name *string, scopes []string) (*models.APIToken, error) {
+	token, err := s.GetAPIToken(ctx, userID, tokenID)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := map[string]interface{}{}
+	if name != nil {
+		trimmed := strings.TrimSpace(*name)
+		if trimmed == "" {
+			return nil, fmt.Errorf("%w: name is required", ErrInvalidAPITokenRequest)
+		}
+		token.Name = trimmed
+		updates["name"] = token.Name
+	}
+	if scopes != nil {
+		if token.Scopes, err = normalizeScopes(scopes); err != nil {
+			return nil, err
+		}
+		updates["scopes"] = token.Scopes
+	}
+	if len(updates) == 0 {
+		return token, nil
+	}
+
+	if err := s.db.WithContext(ctx).Model(token).UpdateColumns(updates).Error; err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+// RevokeAPIToken deletes one of the user's API tokens
+func (s *AuthService) RevokeAPIToken(ctx context.Context, userID string, tokenID string) error {
+	if _, err := uuid.Parse(tokenID); err != nil {
+		return ErrAPITokenNotFound
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", tokenID, userID).Delete(&models.APIToken{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// ValidateAPIToken returns the unexpired API token for a raw token with its user loaded, and
+// records when and from where it was last used
+func (s *AuthService) ValidateAPIToken(ctx context.Context, raw string, ipAddress string) (*models.APIToken, error) {
+	now := time.Now()
+	var token models.APIToken
+	if err := s.db.WithContext(ctx).Preload("User").
+		Where("token_hash = ? AND (expires_at IS NULL OR expires_at > ?)", hashToken(raw), now).
+		First(&token).Error; err != nil {
+		return nil, ErrInvalidAPIToken
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) > apiTokenTouchInterval || token.LastUsedIP != ipAddress {
+		token.LastUsedAt = &now
+		token.LastUsedIP = ipAddress
+		if err := s.db.WithContext(ctx).Model(&token).UpdateColumns(map[string]interface{}{
+			"last_used_at": token.LastUsedAt,
+			"last_used_ip": token.LastUsedIP,
+		}).Error; err != nil {
+			log.Error().Err(err).Str("token_id", token.ID).Msg("[AuthService.ValidateAPIToken] Failed to record token usage")
+		}
+	}
+
+	return &token, nil
+}
+
+// normalizeScopes validates and de-duplicates requested scopes
+func normalizeScopes(scopes []string) (models.ScopeList, error) {
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("%w: at least one scope is required", ErrInvalidAPITokenRequest)
+	}
+	seen := make(map[string]bool, len(scopes))
+	var normalized models.ScopeList
+	for _, scope := range scopes {
+		if !models.IsValidScope(scope) {
+			return nil, fmt.Errorf("%w: unknown scope %q", ErrInvalidAPITokenRequest, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
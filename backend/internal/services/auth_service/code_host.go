@@ -0,0 +1,275 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"devplus-backend/internal/codehost"
+	"devplus-backend/internal/models"
+)
+
+var (
+	// ErrCodeHostNotLinked is returned for users without an account on the code host
+	ErrCodeHostNotLinked = errors.New("no account linked on this code host")
+	// ErrCodeHostReauthRequired is returned when the code host rejected the stored refresh
+	// token; the user has to log in with or link the host again.
+	ErrCodeHostReauthRequired = errors.New("code host authorization expired, please link the account again")
+	// ErrCodeHostAccountInUse is returned when linking a code host account that belongs to
+	// another user
+	ErrCodeHostAccountInUse = errors.New("code host account is already linked to another user")
+)
+
+// RegisterCodeHosts sets the code hosts besides GitHub users can log in with and link
+func (s *AuthService) RegisterCodeHosts(hosts codehost.Hosts) {
+	s.codeHosts = hosts
+}
+
+// InitiateCodeHostLogin starts an OAuth login with a code host and returns its authorization
+// URL. Like GitHub logins it is bound to the client's PKCE challenge; DevPlus is itself a
+// PKCE client towards the host.
+func (s *AuthService) InitiateCodeHostLogin(ctx context.Context, hostName string, codeChallenge string) (string, error) {
+	return s.codeHostAuthorizeURL(ctx, hostName, codeChallenge, nil)
+}
+
+// InitiateCodeHostLink returns the code host's authorization URL for a signed-in user to link
+// their account on the host. The callback links the account to userID.
+func (s *AuthService) InitiateCodeHostLink(ctx context.Context, userID string, hostName string, codeChallenge string) (string, error) {
+	return s.codeHostAuthorizeURL(ctx, hostName, codeChallenge, &userID)
+}
+
+func (s *AuthService) codeHostAuthorizeURL(ctx context.Context, hostName string, codeChallenge string, linkUserID *string) (string, error) {
+	host, err := s.codeHosts.Get(hostName)
+	if err != nil {
+		return "", err
+	}
+	if !s256Challenge.MatchString(codeChallenge) {
+		return "", ErrInvalidCodeChallenge
+	}
+
+	authState := models.AuthState{
+		State:            uuid.New().String(),
+		CodeChallenge:    codeChallenge,
+		Provider:         hostName,
+		ProviderVerifier: oauth2.GenerateVerifier(),
+		LinkUserID:       linkUserID,
+		ExpiresAt:        time.Now().Add(10 * time.Minute),
+	}
+	if err := s.db.WithContext(ctx).Create(&authState).Error; err != nil {
+		return "", err
+	}
+	return host.OAuth2Config().AuthCodeURL(authState.State, oauth2.S256ChallengeOption(authState.ProviderVerifier)), nil
+}
+
+// HandleCodeHostCallback completes a code host login or link and returns a one-time login
+// code for the frontend to exchange with ExchangeLoginCode
+func (s *AuthService) HandleCodeHostCallback(ctx context.Context, hostName string, code string, state string) (string, error) {
+	host, err := s.codeHosts.Get(hostName)
+	if err != nil {
+		return "", err
+	}
+
+	// 1. Validate State
+	authState, err := s.consumeAuthState(ctx, hostName, state)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. Exchange Code for Token
+	token, err := host.OAuth2Config().Exchange(ctx, code, oauth2.VerifierOption(authState.ProviderVerifier))
+	if err != nil {
+		return "", err
+	}
+
+	// 3. Fetch the host account
+	account, err := host.CurrentUser(ctx, token.AccessToken)
+	if err != nil {
+		return "", err
+	}
+
+	// 4. Find or create the user, or link the account to the signed-in user who started the flow
+	user, err := s.saveCodeHostAccount(ctx, hostName, account, token, authState.LinkUserID)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().Str("user_id", user.ID).Str("provider", hostName).Msg("[AuthService.HandleCodeHostCallback] Code host login")
+
+	// 5. Issue a login code bound to the login's PKCE challenge
+	return s.issueLoginCode(ctx, user.ID, authState.CodeChallenge)
+}
+
+// saveCodeHostAccount stores the host account and its tokens and returns its user. Accounts
+// seen before log in their user; new ones are linked to linkUserID, the user with the same
+// verified email, or a new user. A user has at most one account per host.
+func (s *AuthService) saveCodeHostAccount(ctx context.Context, hostName string, account *codehost.Account, token *oauth2.Token, linkUserID *string) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing models.CodeHostAccount
+		result := tx.Where("provider = ? AND external_id = ?", hostName, account.ID).Limit(1).Find(&existing)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var userID string
+		switch {
+		case result.RowsAffected > 0 && linkUserID != nil && existing.UserID != *linkUserID:
+			return ErrCodeHostAccountInUse
+		case result.RowsAffected > 0:
+			userID = existing.UserID
+		case linkUserID != nil:
+			userID = *linkUserID
+		case account.Email != "" && account.EmailVerified:
+			result := tx.Where("LOWER(email) = LOWER(?)", account.Email).Limit(1).Find(&user)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected > 0 {
+				userID = user.ID
+			}
+		}
+
+		if userID == "" {
+			user = models.User{
+				Username:  account.Username,
+				Email:     account.Email,
+				AvatarURL: account.AvatarURL,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		} else if err := tx.Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		// Linking another account on the same host replaces the previous one
+		if err := tx.Where("user_id = ? AND provider = ? AND external_id <> ?", user.ID, hostName, account.ID).Delete(&models.CodeHostAccount{}).Error; err != nil {
+			return err
+		}
+
+		hostAccount := models.CodeHostAccount{
+			UserID:     user.ID,
+			Provider:   hostName,
+			ExternalID: account.ID,
+			Username:   account.Username,
+			AvatarURL:  account.AvatarURL,
+		}
+		applyOAuthToken(&hostAccount, token)
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "provider"}, {Name: "external_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"username", "avatar_url", "access_token", "refresh_token", "token_expires_at", "updated_at"}),
+		}).Create(&hostAccount).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// CodeHostToken returns a usable access token for the user's account on the code host,
+// refreshing it first when it expires within tokenRefreshMargin
+func (s *AuthService) CodeHostToken(ctx context.Context, userID string, hostName string) (string, error) {
+	host, err := s.codeHosts.Get(hostName)
+	if err != nil {
+		return "", err
+	}
+
+	account, err := s.codeHostAccount(ctx, userID, hostName)
+	if err != nil {
+		return "", err
+	}
+	if !codeHostTokenExpiring(account) {
+		return account.AccessToken.String(), nil
+	}
+
+	lock, _ := s.refreshLocks.LoadOrStore(hostName+":"+account.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// Another request may have refreshed while we waited
+	account, err = s.codeHostAccount(ctx, userID, hostName)
+	if err != nil {
+		return "", err
+	}
+	if !codeHostTokenExpiring(account) {
+		return account.AccessToken.String(), nil
+	}
+	if account.RefreshToken == "" {
+		return "", ErrCodeHostReauthRequired
+	}
+
+	// Moving the expiry forward by the margin makes the token source refresh now
+	token := &oauth2.Token{
+		AccessToken:  account.AccessToken.String(),
+		RefreshToken: account.RefreshToken.String(),
+		Expiry:       account.TokenExpiresAt.Add(-tokenRefreshMargin),
+	}
+	refreshed, err := host.OAuth2Config().TokenSource(ctx, token).Token()
+	var retrieveErr *oauth2.RetrieveError
+	if errors.As(err, &retrieveErr) {
+		log.Warn().Str("user_id", userID).Str("provider", hostName).Msg("[AuthService.CodeHostToken] Code host rejected the refresh token")
+		return "", ErrCodeHostReauthRequired
+	}
+	if err != nil {
+		// Transient failure: the current token may still be valid for a few minutes
+		log.Error().Err(err).Str("user_id", userID).Str("provider", hostName).Msg("[AuthService.CodeHostToken] Failed to refresh token")
+		if time.Now().Before(*account.TokenExpiresAt) {
+			return account.AccessToken.String(), nil
+		}
+		return "", err
+	}
+
+	applyOAuthToken(account, refreshed)
+	if err := s.db.WithContext(ctx).Model(account).Select("access_token", "refresh_token", "token_expires_at").Updates(account).Error; err != nil {
+		return "", err
+	}
+
+	log.Info().Str("user_id", userID).Str("provider", hostName).Msg("[AuthService.CodeHostToken] Refreshed code host token")
+	return account.AccessToken.String(), nil
+}
+
+// ListCodeHostAccounts returns the code host accounts linked to the user
+func (s *AuthService) ListCodeHostAccounts(ctx context.Context, userID string) ([]*models.CodeHostAccount, error) {
+	var accounts []*models.CodeHostAccount
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *AuthService) codeHostAccount(ctx context.Context, userID string, hostName string) (*models.CodeHostAccount, error) {
+	var account models.CodeHostAccount
+	result := s.db.WithContext(ctx).Where("user_id = ? AND provider = ?", userID, hostName).Limit(1).Find(&account)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrCodeHostNotLinked
+	}
+	return &account, nil
+}
+
+func codeHostTokenExpiring(account *models.CodeHostAccount) bool {
+	return account.TokenExpiresAt != nil && time.Until(*account.TokenExpiresAt) < tokenRefreshMargin
+}
+
+// applyOAuthToken stores an OAuth token on a code host account. Hosts that don't rotate
+// refresh tokens omit them from refresh responses; the stored one is kept then.
+func applyOAuthToken(account *models.CodeHostAccount, token *oauth2.Token) {
+	account.AccessToken = models.EncryptedString(token.AccessToken)
+	if token.RefreshToken != "" {
+		account.RefreshToken = models.EncryptedString(token.RefreshToken)
+	}
+	account.TokenExpiresAt = nil
+	if !token.Expiry.IsZero() {
+		expiresAt := token.Expiry
+		account.TokenExpiresAt = &expiresAt
+	}
+}
@@ -0,0 +1,90 @@
+package auth_service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"regexp"
+	"time"
+
+	"gorm.io/gorm/clause"
+
+	"devplus-backend/internal/models"
+)
+
+// LoginCodeTTL is how long the frontend has to exchange the code from the OAuth redirect
+const LoginCodeTTL = time.Minute
+
+var (
+	// ErrInvalidCodeChallenge is returned when login is started without a valid S256 challenge
+	ErrInvalidCodeChallenge = errors.New("code_challenge must be a base64url SHA-256 (S256) challenge")
+	// ErrInvalidLoginCode is returned for unknown, expired or already used login codes, and
+	// for verifiers that don't match the challenge the login was started with
+	ErrInvalidLoginCode = errors.New("invalid or expired login code")
+)
+
+// s256Challenge matches an unpadded base64url SHA-256 digest; RFC 7636 verifiers are 43-128
+// characters of the same alphabet plus "-._~"
+var (
+	s256Challenge = regexp.MustCompile(`^[A-Za-z0-9_-]{43}$`)
+	codeVerifier  = regexp.MustCompile(`^[A-Za-z0-9._~-]{43,128}$`)
+)
+
+// ExchangeLoginCode redeems the one-time code from the OAuth redirect for a session. The code
+// is deleted on first use, and the verifier must hash to the challenge given at login.
+func (s *AuthService) ExchangeLoginCode(ctx context.Context, code string, verifier string, client ClientInfo) (*IssuedSession, error) {
+	var loginCode models.LoginCode
+	result := s.db.WithContext(ctx).Clauses(clause.Returning{}).
+		Where("code_hash = ?", hashToken(code)).
+		Delete(&loginCode)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidLoginCode
+	}
+	if err := checkLoginCode(&loginCode, verifier, time.Now()); err != nil {
+		return nil, err
+	}
+
+	return s.createSession(ctx, loginCode.UserID, client)
+}
+
+// checkLoginCode verifies a redeemed login code: it must be unexpired and the verifier must
+// hash to the challenge the login was started with
+func checkLoginCode(loginCode *models.LoginCode, verifier string, now time.Time) error {
+	if now.After(loginCode.ExpiresAt) {
+		return ErrInvalidLoginCode
+	}
+	if !codeVerifier.MatchString(verifier) ||
+		subtle.ConstantTimeCompare([]byte(pkceChallenge(verifier)), []byte(loginCode.CodeChallenge)) != 1 {
+		return ErrInvalidLoginCode
+	}
+	return nil
+}
+
+// issueLoginCode stores a login code for the user bound to the login's PKCE challenge
+func (s *AuthService) issueLoginCode(ctx context.Context, userID string, codeChallenge string) (string, error) {
+	code, codeHash, err := newOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	loginCode := &models.LoginCode{
+		CodeHash:      codeHash,
+		UserID:        userID,
+		CodeChallenge: codeChallenge,
+		ExpiresAt:     time.Now().Add(LoginCodeTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(loginCode).Error; err != nil {
+		return "", err
+	}
+	return code, nil
+}
+
+// pkceChallenge derives the S256 challenge of a verifier
+func pkceChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
@@ -0,0 +1,71 @@
+package auth_service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+// A verifier and its S256 challenge, BASE64URL(SHA256(verifier)) without padding
+const (
+	testVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gXk2mjXFk"
+	testChallenge = "CkUhzr2WKnOQGO1-ZB4q3puJ6hsE4BaclE6gLLTImyY"
+)
+
+func TestPKCEChallenge(t *testing.T) {
+	if got := pkceChallenge(testVerifier); got != testChallenge {
+		t.Errorf("pkceChallenge() = %s, want %s", got, testChallenge)
+	}
+	if !s256Challenge.MatchString(testChallenge) {
+		t.Error("an S256 challenge doesn't match s256Challenge")
+	}
+}
+
+func TestInitiateLoginRequiresS256Challenge(t *testing.T) {
+	s := &AuthService{}
+	for _, challenge := range []string{
+		"",
+		"plain-verifier",
+		testChallenge + "=",      // padded
+		strings.Repeat("a", 42),  // too short for a SHA-256 digest
+		testChallenge[:42] + "+", // standard base64
+	} {
+		if _, err := s.InitiateLogin(challenge); !errors.Is(err, ErrInvalidCodeChallenge) {
+			t.Errorf("InitiateLogin(%q) error = %v, want ErrInvalidCodeChallenge", challenge, err)
+		}
+	}
+}
+
+func TestCheckLoginCode(t *testing.T) {
+	now := time.Now()
+	code := &models.LoginCode{CodeChallenge: testChallenge, ExpiresAt: now.Add(LoginCodeTTL)}
+
+	tests := []struct {
+		name     string
+		verifier string
+		now      time.Time
+		wantErr  bool
+	}{
+		{"matching verifier", testVerifier, now, false},
+		{"expired", testVerifier, now.Add(LoginCodeTTL + time.Second), true},
+		{"other verifier", strings.Repeat("a", 43), now, true},
+		{"challenge as verifier", testChallenge, now, true},
+		{"too short", testVerifier[:42], now, true},
+		{"invalid characters", testVerifier[:42] + "+", now, true},
+		{"missing", "", now, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLoginCode(code, tt.verifier, tt.now)
+			if tt.wantErr && !errors.Is(err, ErrInvalidLoginCode) {
+				t.Errorf("checkLoginCode() error = %v, want ErrInvalidLoginCode", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Errorf("checkLoginCode() error = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/oidc"
+)
+
+// ErrOIDCUserNotFound is returned when a verified OIDC token doesn't map to a DevPlus user
+var ErrOIDCUserNotFound = errors.New("no user for oidc subject")
+
+// ResolveOIDCUser maps a verified OIDC token to the user linked to its issuer and subject by
+// an SSO login, or else to the user with the same verified email address
+func (s *AuthService) ResolveOIDCUser(ctx context.Context, issuer string, claims *oidc.Claims) (*models.User, error) {
+	var user models.User
+	result := s.db.WithContext(ctx).
+		Joins("JOIN user_identities ON user_identities.user_id = users.id").
+		Where("user_identities.issuer = ? AND user_identities.subject = ?", issuer, claims.Subject).
+		Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &user, nil
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, ErrOIDCUserNotFound
+	}
+
+	result = s.db.WithContext(ctx).Where("LOWER(email) = LOWER(?)", claims.Email).Limit(1).Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrOIDCUserNotFound
+	}
+	return &user, nil
+}
@@ -0,0 +1,183 @@
+package auth_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/oidc"
+)
+
+// ErrUnknownProvider is returned for login requests naming a provider that isn't configured
+var ErrUnknownProvider = errors.New("unknown identity provider")
+
+// ExternalIdentity is a user account at an external identity provider
+type ExternalIdentity struct {
+	Provider      string
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Username      string
+	AvatarURL     string
+}
+
+// IdentityProvider is an external identity provider users can log in with besides GitHub.
+// DevPlus acts as an authorization code client with PKCE: the verifier and nonce are
+// generated per login and checked when the provider redirects back.
+type IdentityProvider interface {
+	Name() string
+	DisplayName() string
+	AuthCodeURL(ctx context.Context, state string, verifier string, nonce string) (string, error)
+	Exchange(ctx context.Context, code string, verifier string, nonce string) (*ExternalIdentity, error)
+}
+
+// Kinds of login providers, which the frontend starts logins for at different endpoints
+const (
+	ProviderKindGithub   = "github"
+	ProviderKindOIDC     = "oidc"
+	ProviderKindCodeHost = "code_host"
+)
+
+// ProviderInfo describes a login provider for the frontend's login page
+type ProviderInfo struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Kind        string `json:"kind"`
+}
+
+// OIDCProvider logs users in with any OpenID Connect provider. Endpoints come from the
+// provider's discovery document, fetched on first use.
+type OIDCProvider struct {
+	cfg         config.OIDCProviderConfig
+	redirectURL string
+
+	mu       sync.Mutex
+	oauth    *oauth2.Config
+	verifier *oidc.Verifier
+	userinfo string
+}
+
+// NewOIDCProvider creates a provider that redirects back to redirectURL
+func NewOIDCProvider(cfg config.OIDCProviderConfig, redirectURL string) *OIDCProvider {
+	return &OIDCProvider{cfg: cfg, redirectURL: redirectURL}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.cfg.Name
+}
+
+func (p *OIDCProvider) DisplayName() string {
+	return p.cfg.DisplayName
+}
+
+// AuthCodeURL returns the provider's authorization URL with the S256 challenge of verifier
+func (p *OIDCProvider) AuthCodeURL(ctx context.Context, state string, verifier string, nonce string) (string, error) {
+	oauthConfig, _, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+	return oauthConfig.AuthCodeURL(state, oauth2.S256ChallengeOption(verifier), oauth2.SetAuthURLParam("nonce", nonce)), nil
+}
+
+// Exchange redeems the authorization code and validates the returned ID token: signature,
+// issuer, audience (our client ID), expiry and nonce
+func (p *OIDCProvider) Exchange(ctx context.Context, code string, verifier string, nonce string) (*ExternalIdentity, error) {
+	oauthConfig, idTokens, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := oauthConfig.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("%s token exchange failed: %w", p.cfg.Name, err)
+	}
+	rawIDToken, _ := token.Extra("id_token").(string)
+	if rawIDToken == "" {
+		return nil, fmt.Errorf("%s returned no id_token", p.cfg.Name)
+	}
+
+	claims, err := idTokens.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if claims.Nonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", oidc.ErrInvalidToken)
+	}
+
+	identity := &ExternalIdentity{
+		Provider:      p.cfg.Name,
+		Issuer:        idTokens.Issuer(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		Name:          claims.Name,
+		Username:      claims.PreferredUsername,
+		AvatarURL:     claims.Picture,
+	}
+	// Some providers only put profile claims in the userinfo response
+	if identity.Email == "" {
+		p.fillFromUserinfo(ctx, token, identity)
+	}
+	return identity, nil
+}
+
+// discover loads the provider's endpoints and keys. Failed discoveries are retried on the
+// next login.
+func (p *OIDCProvider) discover(ctx context.Context) (*oauth2.Config, *oidc.Verifier, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.oauth == nil {
+		metadata, err := oidc.Discover(ctx, p.cfg.Issuer)
+		if err != nil {
+			return nil, nil, err
+		}
+		p.oauth = &oauth2.Config{
+			ClientID:     p.cfg.ClientID,
+			ClientSecret: p.cfg.ClientSecret,
+			RedirectURL:  p.redirectURL,
+			Scopes:       p.cfg.Scopes,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  metadata.AuthorizationEndpoint,
+				TokenURL: metadata.TokenEndpoint,
+			},
+		}
+		p.verifier = oidc.NewVerifier(metadata.Issuer, p.cfg.ClientID, metadata.JWKSURI)
+		p.userinfo = metadata.UserinfoEndpoint
+	}
+	return p.oauth, p.verifier, nil
+}
+
+func (p *OIDCProvider) fillFromUserinfo(ctx context.Context, token *oauth2.Token, identity *ExternalIdentity) {
+	if p.userinfo == "" {
+		return
+	}
+	resp, err := p.oauth.Client(ctx, token).Get(p.userinfo)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return
+	}
+
+	var info struct {
+		Subject       string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil || info.Subject != identity.Subject {
+		return
+	}
+	identity.Email = strings.TrimSpace(info.Email)
+	identity.EmailVerified = info.EmailVerified
+}
@@ -0,0 +1,210 @@
+package auth_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/oidc"
+)
+
+// mockOIDCServer is an OpenID provider serving discovery, a JWKS with one RSA key and a token
+// endpoint that returns idToken for any code
+type mockOIDCServer struct {
+	*httptest.Server
+	key       *rsa.PrivateKey
+	issuer    string // issuer published in the discovery document
+	idToken   string
+	exchanges int
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockOIDCServer{key: key}
+	m.Server = httptest.NewServer(http.HandlerFunc(m.serve))
+	m.issuer = m.URL
+	t.Cleanup(m.Close)
+	return m
+}
+
+func (m *mockOIDCServer) serve(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.issuer,
+			"authorization_endpoint": m.URL + "/authorize",
+			"token_endpoint":         m.URL + "/token",
+			"jwks_uri":               m.URL + "/jwks",
+		})
+	case "/jwks":
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kid": "key-1",
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(m.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(m.key.E)).Bytes()),
+		}}})
+	case "/token":
+		m.exchanges++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+			"id_token":     m.idToken,
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// claims returns valid ID token claims for the client, which tests then break one at a time
+func (m *mockOIDCServer) claims(nonce string) jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":                m.URL,
+		"sub":                "user-123",
+		"aud":                "devplus",
+		"exp":                now.Add(time.Hour).Unix(),
+		"iat":                now.Unix(),
+		"nonce":              nonce,
+		"email":              "alice@example.com",
+		"email_verified":     true,
+		"preferred_username": "alice",
+	}
+}
+
+func (m *mockOIDCServer) sign(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = "key-1"
+
+	var key interface{} = m.key
+	switch method {
+	case jwt.SigningMethodHS256:
+		// An attacker who knows the public key signs with it as an HMAC secret
+		key = m.key.PublicKey.N.Bytes()
+	case jwt.SigningMethodNone:
+		key = jwt.UnsafeAllowNoneSignatureType
+	}
+	raw, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return raw
+}
+
+func newTestOIDCProvider(issuer string) *OIDCProvider {
+	return NewOIDCProvider(config.OIDCProviderConfig{
+		Name:         "dev",
+		Issuer:       issuer,
+		ClientID:     "devplus",
+		ClientSecret: "secret",
+		Scopes:       []string{"openid", "email"},
+	}, "http://localhost:8081/api/v1/auth/sso/dev/callback")
+}
+
+func TestOIDCProviderExchange(t *testing.T) {
+	const nonce = "nonce-1"
+	server := newMockOIDCServer(t)
+	provider := newTestOIDCProvider(server.URL)
+
+	authURL, err := provider.AuthCodeURL(context.Background(), "state-1", testVerifier, nonce)
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	for _, param := range []string{"code_challenge=" + testChallenge, "code_challenge_method=S256", "nonce=" + nonce, "state=state-1"} {
+		if !strings.Contains(authURL, param) {
+			t.Errorf("authorization URL %s is missing %s", authURL, param)
+		}
+	}
+
+	valid := func() jwt.MapClaims { return server.claims(nonce) }
+	with := func(key string, value interface{}) jwt.MapClaims {
+		claims := valid()
+		claims[key] = value
+		return claims
+	}
+	without := func(key string) jwt.MapClaims {
+		claims := valid()
+		delete(claims, key)
+		return claims
+	}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		claims jwt.MapClaims
+		ok     bool
+	}{
+		{"valid", jwt.SigningMethodRS256, valid(), true},
+		{"nonce mismatch", jwt.SigningMethodRS256, with("nonce", "replayed"), false},
+		{"missing nonce", jwt.SigningMethodRS256, without("nonce"), false},
+		{"other audience", jwt.SigningMethodRS256, with("aud", "other-client"), false},
+		{"other issuer", jwt.SigningMethodRS256, with("iss", "https://evil.example.com"), false},
+		{"expired", jwt.SigningMethodRS256, with("exp", time.Now().Add(-time.Hour).Unix()), false},
+		{"missing expiry", jwt.SigningMethodRS256, without("exp"), false},
+		{"missing subject", jwt.SigningMethodRS256, without("sub"), false},
+		{"HS256 with the public key", jwt.SigningMethodHS256, valid(), false},
+		{"alg none", jwt.SigningMethodNone, valid(), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.idToken = server.sign(t, tt.method, tt.claims)
+			identity, err := provider.Exchange(context.Background(), "code", testVerifier, nonce)
+			if !tt.ok {
+				if !errors.Is(err, oidc.ErrInvalidToken) {
+					t.Errorf("Exchange() error = %v, want ErrInvalidToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			want := ExternalIdentity{
+				Provider:      "dev",
+				Issuer:        server.URL,
+				Subject:       "user-123",
+				Email:         "alice@example.com",
+				EmailVerified: true,
+				Username:      "alice",
+			}
+			if *identity != want {
+				t.Errorf("Exchange() = %+v, want %+v", *identity, want)
+			}
+		})
+	}
+}
+
+func TestOIDCProviderRejectsDiscoveryForAnotherIssuer(t *testing.T) {
+	server := newMockOIDCServer(t)
+	server.issuer = "https://evil.example.com"
+	provider := newTestOIDCProvider(server.URL)
+
+	if _, err := provider.AuthCodeURL(context.Background(), "state-1", testVerifier, "nonce-1"); err == nil {
+		t.Error("AuthCodeURL accepted a discovery document published for another issuer")
+	}
+	if _, err := provider.Exchange(context.Background(), "code", testVerifier, "nonce-1"); err == nil {
+		t.Error("Exchange accepted a discovery document published for another issuer")
+	}
+	if server.exchanges != 0 {
+		t.Errorf("the code was redeemed %d times with an untrusted provider", server.exchanges)
+	}
+}
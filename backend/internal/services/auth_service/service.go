@@ -2,55 +2,100 @@ package auth_service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
+	"devplus-backend/internal/codehost"
 	"devplus-backend/internal/config"
 	"devplus-backend/internal/db"
+	"devplus-backend/internal/githubapi"
 	"devplus-backend/internal/models"
 )
 
+// githubProvider names GitHub in auth states; other providers use their configured names
+const githubProvider = "github"
+
 type AuthService struct {
 	db     *gorm.DB
 	Config *config.Config
+	// github is github.com or the GitHub Enterprise Server instance users log in with
+	github githubapi.Endpoints
+
+	// providers are the OIDC identity providers users can log in with besides GitHub
+	providers     map[string]IdentityProvider
+	providerOrder []string
+	// codeHosts are the code hosts besides GitHub users can log in with and link
+	codeHosts codehost.Hosts
+
+	// refreshLocks serialises token refreshes per user; GitHub refresh tokens are single-use
+	refreshLocks sync.Map
 }
 
-func NewAuthService() *AuthService {
-	return &AuthService{
-		db:     db.GetInstance(),
-		Config: config.LoadConfig(),
+func NewAuthService(github githubapi.Endpoints) *AuthService {
+	cfg := config.LoadConfig()
+	s := &AuthService{
+		db:        db.GetInstance(),
+		Config:    cfg,
+		github:    github,
+		providers: make(map[string]IdentityProvider),
 	}
+	for _, providerCfg := range cfg.OIDCProviders {
+		redirectURL := strings.TrimSuffix(cfg.OIDCRedirectBaseURL, "/") + "/" + providerCfg.Name + "/callback"
+		s.RegisterProvider(NewOIDCProvider(providerCfg, redirectURL))
+	}
+	return s
+}
+
+// InitiateLogin generates a state token bound to the client's PKCE challenge and returns
+// the GitHub OAuth URL
+func (s *AuthService) InitiateLogin(codeChallenge string) (string, error) {
+	return s.githubAuthorizeURL(context.Background(), codeChallenge, nil)
 }
 
-// InitiateLogin generates a state token and returns the GitHub OAuth URL
-func (s *AuthService) InitiateLogin() (string, error) {
+// InitiateGithubLink returns the GitHub OAuth URL for a signed-in user (e.g. one who logged
+// in with SSO) to link their GitHub account. The callback links the account to userID.
+func (s *AuthService) InitiateGithubLink(ctx context.Context, userID string, codeChallenge string) (string, error) {
+	return s.githubAuthorizeURL(ctx, codeChallenge, &userID)
+}
+
+func (s *AuthService) githubAuthorizeURL(ctx context.Context, codeChallenge string, linkUserID *string) (string, error) {
+	if !s256Challenge.MatchString(codeChallenge) {
+		return "", ErrInvalidCodeChallenge
+	}
+
 	state := uuid.New().String()
 
 	authState := models.AuthState{
-		State:     state,
-		ExpiresAt: time.Now().Add(10 * time.Minute),
+		State:         state,
+		CodeChallenge: codeChallenge,
+		Provider:      githubProvider,
+		LinkUserID:    linkUserID,
+		ExpiresAt:     time.Now().Add(10 * time.Minute),
 	}
 
-	if err := s.db.Create(&authState).Error; err != nil {
+	if err := s.db.WithContext(ctx).Create(&authState).Error; err != nil {
 		return "", err
 	}
 
 	// Correct URL construction:
-	u, _ := url.Parse("https://github.com/login/oauth/authorize")
+	u, _ := url.Parse(s.github.AuthorizeURL())
 	q := u.Query()
 	q.Set("client_id", s.Config.GithubClientID)
 	if s.Config.GithubRedirectURI != "" {
 		q.Set("redirect_uri", s.Config.GithubRedirectURI)
 	}
-	q.Set("scope", "user:email read:user repo")
+	q.Set("scope", "user:email read:user read:org repo")
 	q.Set("state", state)
 	u.RawQuery = q.Encode()
 
@@ -58,10 +103,14 @@ func (s *AuthService) InitiateLogin() (string, error) {
 }
 
 type GitHubTokenResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
-	TokenType    string `json:"token_type"`
-	ExpiresIn    int    `json:"expires_in"`
+	AccessToken           string `json:"access_token"`
+	RefreshToken          string `json:"refresh_token"`
+	TokenType             string `json:"token_type"`
+	ExpiresIn             int    `json:"expires_in"`
+	RefreshTokenExpiresIn int    `json:"refresh_token_expires_in"`
+	// GitHub reports OAuth errors with a 200 status and these fields
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
 }
 
 type GitHubUser struct {
@@ -77,27 +126,25 @@ type GitHubEmail struct {
 	Verified bool   `json:"verified"`
 }
 
-// HandleCallback processes the implementation
-func (s *AuthService) HandleCallback(code, state string) (*models.Session, error) {
+// HandleCallback completes the OAuth flow and returns a one-time login code for the frontend
+// to exchange with ExchangeLoginCode
+func (s *AuthService) HandleCallback(ctx context.Context, code, state string) (string, error) {
 	// 1. Validate State
-	var authState models.AuthState
-	if err := s.db.Where("state = ? AND expires_at > ?", state, time.Now()).First(&authState).Error; err != nil {
-		return nil, errors.New("invalid or expired state token")
+	authState, err := s.consumeAuthState(ctx, githubProvider, state)
+	if err != nil {
+		return "", err
 	}
 
-	// Delete used state
-	s.db.Delete(&authState)
-
 	// 2. Exchange Code for Token
 	tokenResp, err := s.exchangeCodeForToken(code)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// 3. Fetch GitHub User
 	ghUser, err := s.fetchGitHubUser(tokenResp.AccessToken)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
 
 	// 4. Fetch Email if missing
@@ -105,47 +152,69 @@ func (s *AuthService) HandleCallback(code, state string) (*models.Session, error
 		ghUser.Email, _ = s.fetchGitHubEmail(tokenResp.AccessToken)
 	}
 
-	// 5. Upsert User
+	// 5. Upsert User, or link the account to the signed-in user who started the flow
+	var user *models.User
+	if authState.LinkUserID != nil {
+		user, err = s.linkGithubAccount(ctx, *authState.LinkUserID, ghUser, tokenResp)
+	} else {
+		user, err = s.upsertGithubUser(ctx, ghUser, tokenResp)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// 6. Issue a login code bound to the login's PKCE challenge
+	return s.issueLoginCode(ctx, user.ID, authState.CodeChallenge)
+}
+
+// consumeAuthState loads and deletes an unexpired auth state started for provider
+func (s *AuthService) consumeAuthState(ctx context.Context, provider string, state string) (*models.AuthState, error) {
+	var authState models.AuthState
+	if err := s.db.WithContext(ctx).Where("state = ? AND provider = ? AND expires_at > ?", state, provider, time.Now()).First(&authState).Error; err != nil {
+		return nil, errors.New("invalid or expired state token")
+	}
+
+	// Delete used state
+	s.db.WithContext(ctx).Delete(&authState)
+	return &authState, nil
+}
+
+// upsertGithubUser creates or updates the user with the GitHub account and its tokens
+func (s *AuthService) upsertGithubUser(ctx context.Context, ghUser *GitHubUser, tokenResp *GitHubTokenResponse) (*models.User, error) {
 	user := models.User{
-		GithubID:     ghUser.ID,
-		Username:     ghUser.Login,
-		Email:        ghUser.Email,
-		AvatarURL:    ghUser.AvatarURL,
-		AccessToken:  tokenResp.AccessToken,
-		RefreshToken: tokenResp.RefreshToken,
+		GithubID:  &ghUser.ID,
+		Username:  ghUser.Login,
+		Email:     ghUser.Email,
+		AvatarURL: ghUser.AvatarURL,
 	}
+	applyTokenResponse(&user, tokenResp)
 
 	// Check if user exists
 	var existingUser models.User
-	result := s.db.Where("github_id = ?", ghUser.ID).First(&existingUser)
+	result := s.db.WithContext(ctx).Where("github_id = ?", ghUser.ID).First(&existingUser)
 	if result.Error == nil {
 		// Update existing
-		existingUser.Username = user.Username
-		existingUser.Email = user.Email
-		existingUser.AvatarURL = user.AvatarURL
-		existingUser.AccessToken = user.AccessToken
-		existingUser.RefreshToken = user.RefreshToken
-		s.db.Save(&existingUser)
-		user = existingUser
-	} else {
-		// Create new
-		if err := s.db.Create(&user).Error; err != nil {
-			return nil, err
-		}
+		applyGithubAccount(&existingUser, ghUser, tokenResp)
+		s.db.WithContext(ctx).Save(&existingUser)
+		return &existingUser, nil
 	}
 
-	// 6. Create Session
-	session := models.Session{
-		ID:        uuid.New().String(),
-		UserID:    user.ID,                        // now string
-		ExpiresAt: time.Now().Add(24 * time.Hour), // 1 day session
-	}
-
-	if err := s.db.Create(&session).Error; err != nil {
+	// Create new
+	if err := s.db.WithContext(ctx).Create(&user).Error; err != nil {
 		return nil, err
 	}
+	return &user, nil
+}
 
-	return &session, nil
+// applyGithubAccount copies the GitHub profile and tokens onto a user
+func applyGithubAccount(user *models.User, ghUser *GitHubUser, tokenResp *GitHubTokenResponse) {
+	user.GithubID = &ghUser.ID
+	user.Username = ghUser.Login
+	if ghUser.Email != "" {
+		user.Email = ghUser.Email
+	}
+	user.AvatarURL = ghUser.AvatarURL
+	applyTokenResponse(user, tokenResp)
 }
 
 func (s *AuthService) exchangeCodeForToken(code string) (*GitHubTokenResponse, error) {
@@ -154,7 +223,12 @@ func (s *AuthService) exchangeCodeForToken(code string) (*GitHubTokenResponse, e
 	values.Set("client_secret", s.Config.GithubClientSecret)
 	values.Set("code", code)
 
-	req, err := http.NewRequest("POST", "https://github.com/login/oauth/access_token", bytes.NewBufferString(values.Encode()))
+	return s.requestToken(values)
+}
+
+// requestToken posts to the OAuth token endpoint of GitHub (or GitHub Enterprise Server)
+func (s *AuthService) requestToken(values url.Values) (*GitHubTokenResponse, error) {
+	req, err := http.NewRequest("POST", s.github.TokenURL(), bytes.NewBufferString(values.Encode()))
 	if err != nil {
 		return nil, err
 	}
@@ -176,11 +250,14 @@ func (s *AuthService) exchangeCodeForToken(code string) (*GitHubTokenResponse, e
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
 		return nil, err
 	}
+	if tokenResp.Error != "" {
+		return nil, &OAuthError{Code: tokenResp.Error, Description: tokenResp.ErrorDescription}
+	}
 	return &tokenResp, nil
 }
 
 func (s *AuthService) fetchGitHubUser(token string) (*GitHubUser, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user", nil)
+	req, err := http.NewRequest("GET", s.github.API("user"), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -204,7 +281,7 @@ func (s *AuthService) fetchGitHubUser(token string) (*GitHubUser, error) {
 }
 
 func (s *AuthService) fetchGitHubEmail(token string) (string, error) {
-	req, err := http.NewRequest("GET", "https://api.github.com/user/emails", nil)
+	req, err := http.NewRequest("GET", s.github.API("user/emails"), nil)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,234 @@
+package auth_service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	// SessionIdleTimeout is how long a session stays valid without activity
+	SessionIdleTimeout = 24 * time.Hour
+	// SessionMaxLifetime is how long a session can be kept alive by activity and refreshes
+	SessionMaxLifetime = 30 * 24 * time.Hour
+	// sessionTouchInterval throttles writes when sliding a session's expiry
+	sessionTouchInterval = 5 * time.Minute
+)
+
+var (
+	// ErrInvalidSession is returned for unknown, expired or revoked session or refresh tokens
+	ErrInvalidSession = errors.New("invalid or expired session")
+	// ErrSessionNotFound is returned when revoking a session the user doesn't have
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// ClientInfo describes the device a session is used from
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// IssuedSession carries the raw tokens of a new or refreshed session. They are only
+// available at issue time; the database keeps hashes.
+type IssuedSession struct {
+	Session      *models.Session `json:"-"`
+	Token        string          `json:"token"`
+	RefreshToken string          `json:"refresh_token"`
+	ExpiresAt    time.Time       `json:"expires_at"`
+}
+
+// ValidateSession returns the active session for a bearer token with its user loaded. Activity
+// slides the expiry forward by SessionIdleTimeout, capped at the session's refresh expiry.
+func (s *AuthService) ValidateSession(ctx context.Context, token string) (*models.Session, error) {
+	now := time.Now()
+	var session models.Session
+	if err := s.db.WithContext(ctx).Preload("User").
+		Where("token_hash = ? AND expires_at > ? AND refresh_expires_at > ?", hashToken(token), now, now).
+		First(&session).Error; err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	if now.Sub(session.LastSeenAt) > sessionTouchInterval {
+		session.LastSeenAt = now
+		session.ExpiresAt = slidingExpiry(now, session.RefreshExpiresAt)
+		if err := s.db.WithContext(ctx).Model(&session).UpdateColumns(map[string]interface{}{
+			"last_seen_at": session.LastSeenAt,
+			"expires_at":   session.ExpiresAt,
+		}).Error; err != nil {
+			log.Error().Err(err).Str("session_id", session.ID).Msg("[AuthService.ValidateSession] Failed to extend session")
+		}
+	}
+
+	return &session, nil
+}
+
+// RefreshSession exchanges a refresh token for new session and refresh tokens. Both are
+// rotated, so a refresh token works only once.
+func (s *AuthService) RefreshSession(ctx context.Context, refreshToken string, client ClientInfo) (*IssuedSession, error) {
+	now := time.Now()
+	var session models.Session
+	if err := s.db.WithContext(ctx).
+		Where("refresh_token_hash = ? AND refresh_expires_at > ?", hashToken(refreshToken), now).
+		First(&session).Error; err != nil {
+		return nil, ErrInvalidSession
+	}
+
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	newRefreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session.TokenHash = tokenHash
+	session.RefreshTokenHash = refreshHash
+	session.ExpiresAt = slidingExpiry(now, session.RefreshExpiresAt)
+	session.LastSeenAt = now
+	session.UserAgent = client.UserAgent
+	session.IPAddress = client.IPAddress
+
+	// Guard on the old hash so concurrent refreshes with the same token can't both succeed
+	result := s.db.WithContext(ctx).Model(&models.Session{}).
+		Where("id = ? AND refresh_token_hash = ?", session.ID, hashToken(refreshToken)).
+		UpdateColumns(map[string]interface{}{
+			"token_hash":         session.TokenHash,
+			"refresh_token_hash": session.RefreshTokenHash,
+			"expires_at":         session.ExpiresAt,
+			"last_seen_at":       session.LastSeenAt,
+			"user_agent":         session.UserAgent,
+			"ip_address":         session.IPAddress,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrInvalidSession
+	}
+
+	return &IssuedSession{Session: &session, Token: token, RefreshToken: newRefreshToken, ExpiresAt: session.ExpiresAt}, nil
+}
+
+// Logout deletes the session of a bearer token so it can't be used again
+func (s *AuthService) Logout(ctx context.Context, token string) error {
+	return s.db.WithContext(ctx).Where("token_hash = ?", hashToken(token)).Delete(&models.Session{}).Error
+}
+
+// ListSessions returns the user's active sessions, most recently used first
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]*models.Session, error) {
+	var sessions []*models.Session
+	if err := s.db.WithContext(ctx).
+		Where("user_id = ? AND refresh_expires_at > ?", userID, time.Now()).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// RevokeSession deletes one of the user's sessions
+func (s *AuthService) RevokeSession(ctx context.Context, userID string, sessionID string) error {
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", sessionID, userID).Delete(&models.Session{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeAllSessions deletes all of the user's sessions except keepSessionID (may be empty)
+// and returns how many were revoked
+func (s *AuthService) RevokeAllSessions(ctx context.Context, userID string, keepSessionID string) (int64, error) {
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if keepSessionID != "" {
+		query = query.Where("id <> ?", keepSessionID)
+	}
+	result := query.Delete(&models.Session{})
+	return result.RowsAffected, result.Error
+}
+
+// CleanupExpiredAuth deletes sessions past their refresh expiry, expired OAuth states and
+// unredeemed login codes
+func (s *AuthService) CleanupExpiredAuth(ctx context.Context) error {
+	now := time.Now()
+	sessions := s.db.WithContext(ctx).Where("refresh_expires_at < ?", now).Delete(&models.Session{})
+	if sessions.Error != nil {
+		return sessions.Error
+	}
+	states := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.AuthState{})
+	if states.Error != nil {
+		return states.Error
+	}
+
+	codes := s.db.WithContext(ctx).Where("expires_at < ?", now).Delete(&models.LoginCode{})
+	if codes.Error != nil {
+		return codes.Error
+	}
+
+	log.Info().Int64("sessions", sessions.RowsAffected).Int64("auth_states", states.RowsAffected).Int64("login_codes", codes.RowsAffected).Msg("[AuthService.CleanupExpiredAuth] Removed expired auth records")
+	return nil
+}
+
+// createSession starts a session for the user on the given device
+func (s *AuthService) createSession(ctx context.Context, userID string, client ClientInfo) (*IssuedSession, error) {
+	token, tokenHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, refreshHash, err := newOpaqueToken()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	refreshExpiresAt := now.Add(SessionMaxLifetime)
+	session := &models.Session{
+		UserID:           userID,
+		TokenHash:        tokenHash,
+		RefreshTokenHash: refreshHash,
+		ExpiresAt:        slidingExpiry(now, refreshExpiresAt),
+		RefreshExpiresAt: refreshExpiresAt,
+		LastSeenAt:       now,
+		UserAgent:        client.UserAgent,
+		IPAddress:        client.IPAddress,
+	}
+	if err := s.db.WithContext(ctx).Create(session).Error; err != nil {
+		return nil, err
+	}
+
+	return &IssuedSession{Session: session, Token: token, RefreshToken: refreshToken, ExpiresAt: session.ExpiresAt}, nil
+}
+
+func slidingExpiry(now time.Time, refreshExpiresAt time.Time) time.Time {
+	expiresAt := now.Add(SessionIdleTimeout)
+	if expiresAt.After(refreshExpiresAt) {
+		return refreshExpiresAt
+	}
+	return expiresAt
+}
+
+// newOpaqueToken returns a random URL-safe token and its hash for storage
+func newOpaqueToken() (token string, hash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(raw)
+	return token, hashToken(token), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,216 @@
+package auth_service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+)
+
+// ErrGithubAccountInUse is returned when linking a GitHub account that belongs to another user
+var ErrGithubAccountInUse = errors.New("github account is already linked to another user")
+
+// RegisterProvider adds an identity provider users can log in with
+func (s *AuthService) RegisterProvider(provider IdentityProvider) {
+	if _, exists := s.providers[provider.Name()]; !exists {
+		s.providerOrder = append(s.providerOrder, provider.Name())
+	}
+	s.providers[provider.Name()] = provider
+}
+
+// Providers lists the login providers: GitHub first, then the other code hosts and the
+// configured OIDC providers
+func (s *AuthService) Providers() []ProviderInfo {
+	providers := []ProviderInfo{{Name: githubProvider, DisplayName: "GitHub", Kind: ProviderKindGithub}}
+	for _, name := range s.codeHosts.Names() {
+		providers = append(providers, ProviderInfo{Name: name, DisplayName: s.codeHosts[name].DisplayName(), Kind: ProviderKindCodeHost})
+	}
+	for _, name := range s.providerOrder {
+		providers = append(providers, ProviderInfo{Name: name, DisplayName: s.providers[name].DisplayName(), Kind: ProviderKindOIDC})
+	}
+	return providers
+}
+
+// InitiateSSOLogin starts an authorization code flow with an OIDC provider and returns the
+// provider's authorization URL. Like GitHub logins it is bound to the client's PKCE challenge.
+func (s *AuthService) InitiateSSOLogin(ctx context.Context, providerName string, codeChallenge string) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+	if !s256Challenge.MatchString(codeChallenge) {
+		return "", ErrInvalidCodeChallenge
+	}
+
+	nonce, err := newNonce()
+	if err != nil {
+		return "", err
+	}
+	authState := models.AuthState{
+		State:            uuid.New().String(),
+		CodeChallenge:    codeChallenge,
+		Provider:         providerName,
+		ProviderVerifier: oauth2.GenerateVerifier(),
+		Nonce:            nonce,
+		ExpiresAt:        time.Now().Add(10 * time.Minute),
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx, authState.State, authState.ProviderVerifier, authState.Nonce)
+	if err != nil {
+		return "", err
+	}
+	if err := s.db.WithContext(ctx).Create(&authState).Error; err != nil {
+		return "", err
+	}
+	return authURL, nil
+}
+
+// HandleSSOCallback completes an OIDC login and returns a one-time login code for the
+// frontend to exchange with ExchangeLoginCode
+func (s *AuthService) HandleSSOCallback(ctx context.Context, providerName string, code string, state string) (string, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return "", ErrUnknownProvider
+	}
+
+	// 1. Validate State
+	authState, err := s.consumeAuthState(ctx, providerName, state)
+	if err != nil {
+		return "", err
+	}
+
+	// 2. Exchange Code and validate the ID token
+	identity, err := provider.Exchange(ctx, code, authState.ProviderVerifier, authState.Nonce)
+	if err != nil {
+		return "", err
+	}
+
+	// 3. Find or create the user for the identity
+	user, err := s.findOrCreateSSOUser(ctx, identity)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().Str("user_id", user.ID).Str("provider", providerName).Msg("[AuthService.HandleSSOCallback] SSO login")
+
+	// 4. Issue a login code bound to the login's PKCE challenge
+	return s.issueLoginCode(ctx, user.ID, authState.CodeChallenge)
+}
+
+// ListIdentities returns the external identities linked to the user
+func (s *AuthService) ListIdentities(ctx context.Context, userID string) ([]*models.UserIdentity, error) {
+	var identities []*models.UserIdentity
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&identities).Error; err != nil {
+		return nil, err
+	}
+	return identities, nil
+}
+
+// findOrCreateSSOUser returns the user linked to the identity. Unlinked identities are linked
+// to the user with the same verified email, or get a new user without GitHub access.
+func (s *AuthService) findOrCreateSSOUser(ctx context.Context, identity *ExternalIdentity) (*models.User, error) {
+	var user models.User
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		var linked models.UserIdentity
+		result := tx.Where("issuer = ? AND subject = ?", identity.Issuer, identity.Subject).Limit(1).Find(&linked)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			if err := tx.Model(&linked).UpdateColumns(map[string]interface{}{"last_login_at": now, "email": identity.Email}).Error; err != nil {
+				return err
+			}
+			return tx.Where("id = ?", linked.UserID).First(&user).Error
+		}
+
+		found := int64(0)
+		if identity.Email != "" && identity.EmailVerified {
+			result := tx.Where("LOWER(email) = LOWER(?)", identity.Email).Limit(1).Find(&user)
+			if result.Error != nil {
+				return result.Error
+			}
+			found = result.RowsAffected
+		}
+		if found == 0 {
+			user = models.User{
+				Username:  ssoUsername(identity),
+				Email:     identity.Email,
+				AvatarURL: identity.AvatarURL,
+			}
+			if err := tx.Create(&user).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Create(&models.UserIdentity{
+			UserID:      user.ID,
+			Provider:    identity.Provider,
+			Issuer:      identity.Issuer,
+			Subject:     identity.Subject,
+			Email:       identity.Email,
+			LastLoginAt: now,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// linkGithubAccount attaches a GitHub account and its tokens to a signed-in user
+func (s *AuthService) linkGithubAccount(ctx context.Context, userID string, ghUser *GitHubUser, tokenResp *GitHubTokenResponse) (*models.User, error) {
+	var owner models.User
+	result := s.db.WithContext(ctx).Where("github_id = ? AND id <> ?", ghUser.ID, userID).Limit(1).Find(&owner)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return nil, ErrGithubAccountInUse
+	}
+
+	var user models.User
+	if err := s.db.WithContext(ctx).Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+	applyGithubAccount(&user, ghUser, tokenResp)
+	if err := s.db.WithContext(ctx).Save(&user).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("github_login", ghUser.Login).Msg("[AuthService.linkGithubAccount] Linked GitHub account")
+	return &user, nil
+}
+
+// ssoUsername picks a display username for a new SSO user
+func ssoUsername(identity *ExternalIdentity) string {
+	switch {
+	case identity.Username != "":
+		return identity.Username
+	case identity.Email != "":
+		local, _, _ := strings.Cut(identity.Email, "@")
+		return local
+	case identity.Name != "":
+		return identity.Name
+	default:
+		return identity.Subject
+	}
+}
+
+func newNonce() (string, error) {
+	raw := make([]byte, 16)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
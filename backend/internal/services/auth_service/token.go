@@ -0,0 +1,136 @@
+package auth_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// tokenRefreshMargin is how long before expiry an access token is refreshed
+const tokenRefreshMargin = 5 * time.Minute
+
+var (
+	// ErrReauthRequired is returned when the user's GitHub tokens were revoked or expired and
+	// can't be refreshed; the user has to log in again.
+	ErrReauthRequired = errors.New("github authorization expired, please log in again")
+	// ErrGithubNotLinked is returned for SSO users who haven't linked a GitHub account
+	ErrGithubNotLinked = errors.New("no github account linked")
+)
+
+// OAuthError is an error response from GitHub's OAuth token endpoint
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return fmt.Sprintf("github oauth error %s: %s", e.Code, e.Description)
+}
+
+// GithubToken returns a usable access token for the user, refreshing it first when it
+// expires within tokenRefreshMargin. Users whose tokens can't be refreshed are marked as
+// needing re-authentication and get ErrReauthRequired. The user is updated in place.
+func (s *AuthService) GithubToken(ctx context.Context, user *models.User) (string, error) {
+	if user.GithubID == nil {
+		return "", ErrGithubNotLinked
+	}
+	if user.NeedsReauth || user.AccessToken == "" {
+		return "", ErrReauthRequired
+	}
+	if !needsRefresh(user) {
+		return user.AccessToken.String(), nil
+	}
+
+	lock, _ := s.refreshLocks.LoadOrStore(user.ID, &sync.Mutex{})
+	lock.(*sync.Mutex).Lock()
+	defer lock.(*sync.Mutex).Unlock()
+
+	// Another request may have refreshed while we waited
+	if err := s.db.WithContext(ctx).Where("id = ?", user.ID).First(user).Error; err != nil {
+		return "", err
+	}
+	if user.NeedsReauth {
+		return "", ErrReauthRequired
+	}
+	if !needsRefresh(user) {
+		return user.AccessToken.String(), nil
+	}
+
+	if user.RefreshToken == "" || (user.RefreshTokenExpiresAt != nil && time.Now().After(*user.RefreshTokenExpiresAt)) {
+		log.Warn().Str("user_id", user.ID).Msg("[AuthService.GithubToken] Token expired and no usable refresh token")
+		return "", s.markReauth(ctx, user)
+	}
+
+	values := url.Values{}
+	values.Set("client_id", s.Config.GithubClientID)
+	values.Set("client_secret", s.Config.GithubClientSecret)
+	values.Set("grant_type", "refresh_token")
+	values.Set("refresh_token", user.RefreshToken.String())
+
+	tokenResp, err := s.requestToken(values)
+	var oauthErr *OAuthError
+	if errors.As(err, &oauthErr) {
+		log.Warn().Str("user_id", user.ID).Str("error", oauthErr.Code).Msg("[AuthService.GithubToken] GitHub rejected the refresh token")
+		return "", s.markReauth(ctx, user)
+	}
+	if err != nil {
+		// Transient failure: the current token may still be valid for a few minutes
+		log.Error().Err(err).Str("user_id", user.ID).Msg("[AuthService.GithubToken] Failed to refresh token")
+		if user.TokenExpiresAt != nil && time.Now().Before(*user.TokenExpiresAt) {
+			return user.AccessToken.String(), nil
+		}
+		return "", err
+	}
+
+	applyTokenResponse(user, tokenResp)
+	if err := s.db.WithContext(ctx).Model(user).Select("access_token", "refresh_token", "token_expires_at", "refresh_token_expires_at").Updates(user).Error; err != nil {
+		return "", err
+	}
+
+	log.Info().Str("user_id", user.ID).Msg("[AuthService.GithubToken] Refreshed GitHub token")
+	return user.AccessToken.String(), nil
+}
+
+// MarkReauthRequired flags a user whose token GitHub rejected, e.g. after it was revoked
+func (s *AuthService) MarkReauthRequired(ctx context.Context, userID string) error {
+	log.Warn().Str("user_id", userID).Msg("[AuthService.MarkReauthRequired] GitHub token rejected, user must log in again")
+	return s.db.WithContext(ctx).Model(&models.User{}).Where("id = ?", userID).Update("needs_reauth", true).Error
+}
+
+func (s *AuthService) markReauth(ctx context.Context, user *models.User) error {
+	user.NeedsReauth = true
+	if err := s.MarkReauthRequired(ctx, user.ID); err != nil {
+		return err
+	}
+	return ErrReauthRequired
+}
+
+func needsRefresh(user *models.User) bool {
+	return user.TokenExpiresAt != nil && time.Until(*user.TokenExpiresAt) < tokenRefreshMargin
+}
+
+// applyTokenResponse stores tokens and their expiry times on the user. Non-expiring tokens
+// (expires_in omitted) have no expiry.
+func applyTokenResponse(user *models.User, tokenResp *GitHubTokenResponse) {
+	now := time.Now()
+	user.AccessToken = models.EncryptedString(tokenResp.AccessToken)
+	user.RefreshToken = models.EncryptedString(tokenResp.RefreshToken)
+	user.TokenExpiresAt = nil
+	user.RefreshTokenExpiresAt = nil
+	if tokenResp.ExpiresIn > 0 {
+		expiresAt := now.Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+		user.TokenExpiresAt = &expiresAt
+	}
+	if tokenResp.RefreshTokenExpiresIn > 0 {
+		expiresAt := now.Add(time.Duration(tokenResp.RefreshTokenExpiresIn) * time.Second)
+		user.RefreshTokenExpiresAt = &expiresAt
+	}
+	user.NeedsReauth = false
+}
@@ -0,0 +1,243 @@
+package code_host_service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/codehost"
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/repositories"
+)
+
+// ErrUnknownRepository is returned for webhooks about repositories DevPlus hasn't synced
+var ErrUnknownRepository = errors.New("repository not synced")
+
+// CodeHostTokens returns users' access tokens for their code host accounts
+type CodeHostTokens interface {
+	CodeHostToken(ctx context.Context, userID string, hostName string) (string, error)
+}
+
+// WorkspaceProvisioner assigns synced repositories to the workspace of their namespace
+type WorkspaceProvisioner interface {
+	EnsureAccountWorkspace(ctx context.Context, provider string, accountLogin string, kind string, userID string, accountAdmin bool) (*models.Workspace, error)
+	GrantRepositoryAccess(ctx context.Context, workspaceID string, repoID string, userID string, role string) error
+}
+
+// CodeHostService syncs repositories and merge requests from code hosts other than GitHub
+// into the same tables as GitHub data, so they show up on the same dashboard
+type CodeHostService struct {
+	repo       repositories.GithubRepository
+	workspaces WorkspaceProvisioner
+	tokens     CodeHostTokens
+	hosts      codehost.Hosts
+}
+
+func NewCodeHostService(repo repositories.GithubRepository, workspaces WorkspaceProvisioner, tokens CodeHostTokens, hosts codehost.Hosts) *CodeHostService {
+	return &CodeHostService{
+		repo:       repo,
+		workspaces: workspaces,
+		tokens:     tokens,
+		hosts:      hosts,
+	}
+}
+
+// SyncRepositories syncs the repositories the user can access on the host, and their merge
+// requests. Repositories whose merge requests can't be synced are still returned.
+func (s *CodeHostService) SyncRepositories(ctx context.Context, userID string, hostName string) ([]*models.Repository, error) {
+	host, token, err := s.client(ctx, userID, hostName)
+	if err != nil {
+		return nil, err
+	}
+
+	// The syncing user decides whether they administer each namespace
+	account, err := host.CurrentUser(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	hostRepos, err := host.ListRepositories(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*models.Repository
+	workspaceIDs := make(map[string]string)
+	admins := make(map[string]bool)
+	for _, hostRepo := range hostRepos {
+		workspaceID, ok := workspaceIDs[hostRepo.Namespace]
+		if !ok {
+			admin, err := host.NamespaceAdmin(ctx, token, account, hostRepo.Namespace, hostRepo.Kind)
+			if err != nil {
+				return nil, err
+			}
+			workspace, err := s.workspaces.EnsureAccountWorkspace(ctx, hostName, hostRepo.Namespace, hostRepo.Kind, userID, admin)
+			if err != nil {
+				return nil, err
+			}
+			workspaceID = workspace.ID
+			workspaceIDs[hostRepo.Namespace] = workspaceID
+			admins[hostRepo.Namespace] = admin
+		}
+
+		externalID := hostRepo.ID
+		updatedAt := hostRepo.UpdatedAt
+		r := &models.Repository{
+			Provider:    hostName,
+			ExternalID:  &externalID,
+			Name:        hostRepo.Name,
+			Owner:       hostRepo.Namespace,
+			URL:         hostRepo.URL,
+			UpdatedAt:   &updatedAt,
+			UserID:      userID,
+			WorkspaceID: workspaceID,
+		}
+		if err := s.repo.UpsertRepository(ctx, r); err != nil {
+			return nil, err
+		}
+		savedRepo, err := s.repo.GetRepositoryByExternalID(ctx, hostName, externalID)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, savedRepo)
+
+		// Users who don't administer the namespace only get the repositories the host listed,
+		// read-only when the host doesn't report their permissions
+		if !admins[hostRepo.Namespace] {
+			role := hostRepo.Role
+			if role == "" {
+				role = models.RoleViewer
+			}
+			if err := s.workspaces.GrantRepositoryAccess(ctx, workspaceID, savedRepo.ID, userID, role); err != nil {
+				return nil, err
+			}
+		}
+
+		if _, err := s.syncMergeRequests(ctx, host, token, savedRepo); err != nil {
+			// e.g. projects with merge requests disabled
+			log.Error().Err(err).Str("repo_id", savedRepo.ID).Str("provider", hostName).Msg("[CodeHostService.SyncRepositories] Failed to sync merge requests")
+		}
+	}
+
+	log.Info().Str("user_id", userID).Str("provider", hostName).Int("count", len(result)).Msg("[CodeHostService.SyncRepositories] Synced repositories")
+	return result, nil
+}
+
+// SyncPullRequests syncs the merge requests of a repository with the user's token
+func (s *CodeHostService) SyncPullRequests(ctx context.Context, userID string, repoID string) ([]*models.PullRequest, error) {
+	repo, err := s.repo.GetRepository(ctx, "", repoID)
+	if err != nil {
+		return nil, err
+	}
+	host, token, err := s.client(ctx, userID, repo.Provider)
+	if err != nil {
+		return nil, err
+	}
+	return s.syncMergeRequests(ctx, host, token, repo)
+}
+
+// PullRequestDiff fetches a merge request's diff for AI analysis. Analyses also run from
+// webhooks without a signed-in user, so the token of the user who first synced the
+// repository is used.
+func (s *CodeHostService) PullRequestDiff(ctx context.Context, repo *models.Repository, number int) (string, error) {
+	if repo.ExternalID == nil {
+		return "", ErrUnknownRepository
+	}
+	host, token, err := s.client(ctx, repo.UserID, repo.Provider)
+	if err != nil {
+		return "", err
+	}
+	return host.MergeRequestDiff(ctx, token, *repo.ExternalID, int64(number))
+}
+
+// HandleWebhook verifies a webhook delivery from the host and records the merge request it
+// is about. It returns the merge request and whether it should be analysed, or nil for
+// events DevPlus ignores.
+func (s *CodeHostService) HandleWebhook(ctx context.Context, hostName string, r *http.Request, body []byte) (*models.PullRequest, bool, error) {
+	host, err := s.hosts.Get(hostName)
+	if err != nil {
+		return nil, false, err
+	}
+
+	event, err := host.ParseWebhook(r, body)
+	if err != nil || event == nil || event.MergeRequest == nil {
+		return nil, false, err
+	}
+
+	repo, err := s.repo.GetRepositoryByExternalID(ctx, hostName, event.RepositoryID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, ErrUnknownRepository
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	pr := pullRequestModel(repo, event.MergeRequest)
+	if err := s.repo.UpsertPullRequest(ctx, pr); err != nil {
+		return nil, false, err
+	}
+
+	log.Info().Str("repo_id", repo.ID).Int64("number", event.MergeRequest.Number).Bool("analyze", event.Analyze).Msg("[CodeHostService.HandleWebhook] Merge request event")
+	return pr, event.Analyze, nil
+}
+
+func (s *CodeHostService) syncMergeRequests(ctx context.Context, host codehost.Provider, token string, repo *models.Repository) ([]*models.PullRequest, error) {
+	if repo.ExternalID == nil {
+		return nil, ErrUnknownRepository
+	}
+	mergeRequests, err := host.ListMergeRequests(ctx, token, *repo.ExternalID)
+	if err != nil {
+		return nil, err
+	}
+
+	var synced []*models.PullRequest
+	for _, mr := range mergeRequests {
+		pr := pullRequestModel(repo, mr)
+		if err := s.repo.UpsertPullRequest(ctx, pr); err != nil {
+			log.Error().Err(err).Int64("number", mr.Number).Msg("[CodeHostService.syncMergeRequests] Upsert Error")
+			return nil, err
+		}
+		synced = append(synced, pr)
+	}
+
+	log.Info().Str("repo_id", repo.ID).Int("count", len(synced)).Msg("[CodeHostService.syncMergeRequests] Synced merge requests")
+	return synced, nil
+}
+
+// client returns the named host and the user's access token for it
+func (s *CodeHostService) client(ctx context.Context, userID string, hostName string) (codehost.Provider, string, error) {
+	host, err := s.hosts.Get(hostName)
+	if err != nil {
+		return nil, "", err
+	}
+	token, err := s.tokens.CodeHostToken(ctx, userID, hostName)
+	if err != nil {
+		return nil, "", err
+	}
+	return host, token, nil
+}
+
+// pullRequestModel maps a merge request to the pull request stored for the repository
+func pullRequestModel(repo *models.Repository, mr *codehost.MergeRequest) *models.PullRequest {
+	pr := &models.PullRequest{
+		Provider:   repo.Provider,
+		ExternalID: &mr.ID,
+		Number:     &mr.Number,
+		Title:      &mr.Title,
+		State:      &mr.State,
+		RepoID:     &repo.ID,
+		AuthorID:   &mr.AuthorID,
+	}
+	if mr.AuthorName != "" {
+		pr.AuthorName = &mr.AuthorName
+	}
+	if !mr.CreatedAt.IsZero() {
+		pr.CreatedAt = &mr.CreatedAt
+	}
+	if !mr.UpdatedAt.IsZero() {
+		pr.UpdatedAt = &mr.UpdatedAt
+	}
+	return pr
+}
@@ -0,0 +1,143 @@
+package github_app
+
+import (
+	"context"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+
+	"devplus-backend/internal/githubapi"
+)
+
+const (
+	// appJWTLifetime stays under GitHub's 10 minute maximum for app JWTs
+	appJWTLifetime = 9 * time.Minute
+	// appJWTClockSkew backdates the JWT's issued-at time to tolerate clock drift
+	appJWTClockSkew = time.Minute
+	// tokenRefreshMargin is how long before expiry a cached installation token is replaced
+	tokenRefreshMargin = 5 * time.Minute
+)
+
+// ErrNotConfigured is returned when no GitHub App ID or private key is set
+var ErrNotConfigured = errors.New("github app is not configured")
+
+type installationToken struct {
+	token     string
+	expiresAt time.Time
+}
+
+// App authenticates as a GitHub App and mints installation access tokens, which act on
+// behalf of the installation instead of a logged-in user. Tokens are cached per
+// installation and refreshed shortly before they expire.
+type App struct {
+	appID  int64
+	key    *rsa.PrivateKey
+	github githubapi.Endpoints
+
+	mu     sync.Mutex
+	tokens map[int64]*installationToken
+}
+
+// NewApp creates an App from its ID and PEM private key. The key can be given inline or,
+// when privateKey is empty, read from keyPath. Without an ID and key the App is disabled
+// and every token request returns ErrNotConfigured. Installation tokens are minted on the
+// GitHub instance at endpoints.
+func NewApp(endpoints githubapi.Endpoints, appID string, privateKey string, keyPath string) (*App, error) {
+	app := &App{github: endpoints, tokens: make(map[int64]*installationToken)}
+	if appID == "" {
+		return app, nil
+	}
+
+	id, err := strconv.ParseInt(appID, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid GitHub App ID %q: %w", appID, err)
+	}
+
+	pemBytes := []byte(privateKey)
+	if privateKey == "" {
+		if keyPath == "" {
+			return nil, fmt.Errorf("GitHub App %d has no private key", id)
+		}
+		if pemBytes, err = os.ReadFile(keyPath); err != nil {
+			return nil, fmt.Errorf("failed to read GitHub App private key: %w", err)
+		}
+	}
+
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse GitHub App private key: %w", err)
+	}
+
+	app.appID = id
+	app.key = key
+	return app, nil
+}
+
+// Enabled reports whether the App has credentials to mint installation tokens
+func (a *App) Enabled() bool {
+	return a != nil && a.appID != 0 && a.key != nil
+}
+
+// InstallationToken returns a cached access token for the installation, minting a new one
+// when there is none or it is about to expire.
+func (a *App) InstallationToken(ctx context.Context, installationID int64) (string, error) {
+	if !a.Enabled() {
+		return "", ErrNotConfigured
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if cached, ok := a.tokens[installationID]; ok && time.Until(cached.expiresAt) > tokenRefreshMargin {
+		return cached.token, nil
+	}
+
+	appJWT, err := a.appJWT()
+	if err != nil {
+		return "", err
+	}
+
+	client := a.github.NewClient(oauth2.NewClient(ctx, oauth2.StaticTokenSource(&oauth2.Token{AccessToken: appJWT})))
+	token, _, err := client.Apps.CreateInstallationToken(ctx, installationID, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create installation token for %d: %w", installationID, err)
+	}
+
+	expiresAt := time.Now().Add(time.Hour)
+	if token.ExpiresAt != nil {
+		expiresAt = token.ExpiresAt.Time
+	}
+	a.tokens[installationID] = &installationToken{token: token.GetToken(), expiresAt: expiresAt}
+
+	log.Info().Int64("installation_id", installationID).Time("expires_at", expiresAt).Msg("[GithubApp.InstallationToken] Minted installation token")
+	return token.GetToken(), nil
+}
+
+// Forget drops the cached token of an installation, e.g. after it was suspended or removed
+func (a *App) Forget(installationID int64) {
+	if a == nil {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.tokens, installationID)
+}
+
+// appJWT signs the short-lived RS256 JWT that authenticates as the App itself
+func (a *App) appJWT() (string, error) {
+	now := time.Now()
+	claims := jwt.RegisteredClaims{
+		Issuer:    strconv.FormatInt(a.appID, 10),
+		IssuedAt:  jwt.NewNumericDate(now.Add(-appJWTClockSkew)),
+		ExpiresAt: jwt.NewNumericDate(now.Add(appJWTLifetime)),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(a.key)
+}
@@ -0,0 +1,190 @@
+package github_service
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	// initialCommitWindow is how far back the first sync of a repository reads its default branch
+	initialCommitWindow = 30 * 24 * time.Hour
+	// maxCommitPages bounds the default branch pages fetched per sync
+	maxCommitPages = 5
+	// maxCommitStatsPerSync bounds the per-commit API calls made to fill additions/deletions
+	maxCommitStatsPerSync = 100
+)
+
+// GetCommits returns the most recent ingested commits of a repository
+func (s *GithubService) GetCommits(ctx context.Context, userID string, repoID string, limit int) ([]*models.Commit, error) {
+	if _, err := s.repo.GetRepository(ctx, userID, repoID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetCommitsByRepoID(ctx, repoID, limit)
+}
+
+// GetPullRequestCommits returns the ingested commits of a pull request
+func (s *GithubService) GetPullRequestCommits(ctx context.Context, userID string, repoID string, number int) ([]*models.Commit, error) {
+	pr, err := s.repo.GetPullRequest(ctx, userID, repoID, number)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.GetCommitsByPullRequestID(ctx, pr.ID)
+}
+
+// IngestPushEvent stores the commits of a push webhook payload to the default branch; commits
+// on other branches arrive with their pull requests. Push payloads carry no line stats or PR
+// association; those are filled in by the next sync.
+func (s *GithubService) IngestPushEvent(ctx context.Context, payload []byte) error {
+	var event github.PushEvent
+	if err := json.Unmarshal(payload, &event); err != nil {
+		return err
+	}
+	if event.GetRef() != "refs/heads/"+event.GetRepo().GetDefaultBranch() {
+		return nil
+	}
+
+	repo, err := s.repo.GetRepositoryByGithubID(ctx, event.GetRepo().GetID())
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var commits []*models.Commit
+	for _, c := range event.Commits {
+		sha := c.GetID()
+		if sha == "" {
+			continue
+		}
+		commit := &models.Commit{
+			CreatedAt: &now,
+			UpdatedAt: &now,
+			SHA:       github.String(sha),
+			Message:   c.Message,
+			URL:       c.URL,
+			RepoID:    &repo.ID,
+		}
+		if author := c.GetAuthor(); author != nil {
+			commit.AuthorName = author.Name
+			commit.AuthorEmail = author.Email
+			commit.AuthorLogin = author.Login
+		}
+		if c.Timestamp != nil {
+			commit.CommittedAt = &c.Timestamp.Time
+		}
+		commits = append(commits, commit)
+	}
+
+	log.Info().Str("repo_id", repo.ID).Str("ref", event.GetRef()).Int("count", len(commits)).Msg("[Service.IngestPushEvent] Storing pushed commits")
+	return s.repo.UpsertCommits(ctx, commits)
+}
+
+// syncCommits ingests new commits on the default branch and the commits of the given pull
+// requests, then fills in line stats for commits that don't have them yet.
+func (s *GithubService) syncCommits(ctx context.Context, client *github.Client, repo *models.Repository, prs []*models.PullRequest) error {
+	// 1. Default branch commits since the newest one the last sync read
+	since := time.Now().Add(-initialCommitWindow)
+	if repo.CommitsSyncedUntil != nil {
+		since = *repo.CommitsSyncedUntil
+	}
+
+	var until time.Time
+	opts := &github.CommitsListOptions{Since: since, ListOptions: github.ListOptions{PerPage: 100}}
+	for page := 0; page < maxCommitPages; page++ {
+		ghCommits, resp, err := client.Repositories.ListCommits(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return err
+		}
+		commits := toCommitModels(repo, nil, ghCommits)
+		if err := s.repo.UpsertCommits(ctx, commits); err != nil {
+			return err
+		}
+		for _, commit := range commits {
+			if commit.CommittedAt != nil && commit.CommittedAt.After(until) {
+				until = *commit.CommittedAt
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	if until.After(since) {
+		if err := s.repo.UpdateCommitSyncCursor(ctx, repo.ID, until); err != nil {
+			return err
+		}
+		repo.CommitsSyncedUntil = &until
+	}
+
+	// 2. Commits of each synced pull request
+	for _, pr := range prs {
+		if pr.ID == "" || pr.Number == nil {
+			continue
+		}
+		ghCommits, _, err := client.PullRequests.ListCommits(ctx, repo.Owner, repo.Name, int(*pr.Number), &github.ListOptions{PerPage: 100})
+		if err != nil {
+			log.Error().Err(err).Int64("pr_number", *pr.Number).Msg("[Service.syncCommits] Failed to list PR commits")
+			continue
+		}
+		if err := s.repo.UpsertCommits(ctx, toCommitModels(repo, &pr.ID, ghCommits)); err != nil {
+			return err
+		}
+	}
+
+	// 3. Line stats are only returned by the single commit endpoint
+	missing, err := s.repo.GetCommitsMissingStats(ctx, repo.ID, maxCommitStatsPerSync)
+	if err != nil {
+		return err
+	}
+	for _, commit := range missing {
+		ghCommit, _, err := client.Repositories.GetCommit(ctx, repo.Owner, repo.Name, *commit.SHA, nil)
+		if err != nil {
+			log.Error().Err(err).Str("sha", *commit.SHA).Msg("[Service.syncCommits] Failed to fetch commit stats")
+			continue
+		}
+		if err := s.repo.UpsertCommits(ctx, toCommitModels(repo, nil, []*github.RepositoryCommit{ghCommit})); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// toCommitModels converts GitHub commits to models, optionally associating them with a pull request
+func toCommitModels(repo *models.Repository, prID *string, ghCommits []*github.RepositoryCommit) []*models.Commit {
+	now := time.Now()
+	commits := make([]*models.Commit, 0, len(ghCommits))
+	for _, c := range ghCommits {
+		commit := &models.Commit{
+			CreatedAt:     &now,
+			UpdatedAt:     &now,
+			SHA:           c.SHA,
+			Message:       github.String(strings.TrimSpace(c.GetCommit().GetMessage())),
+			URL:           c.HTMLURL,
+			RepoID:        &repo.ID,
+			PullRequestID: prID,
+		}
+		if author := c.GetCommit().GetAuthor(); author != nil {
+			commit.AuthorName = author.Name
+			commit.AuthorEmail = author.Email
+		}
+		if c.Author != nil {
+			commit.AuthorLogin = c.Author.Login
+		}
+		if date := c.GetCommit().GetCommitter().Date; date != nil {
+			commit.CommittedAt = &date.Time
+		}
+		if c.Stats != nil {
+			commit.Additions = c.Stats.Additions
+			commit.Deletions = c.Stats.Deletions
+		}
+		commits = append(commits, commit)
+	}
+	return commits
+}
@@ -0,0 +1,101 @@
+package github_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/metrics"
+)
+
+// maxTimelinePages bounds the timeline pages fetched per pull request
+const maxTimelinePages = 5
+
+// GetCycleTimeMetrics returns review latency and cycle time percentiles for the user's
+// pull requests opened within the filter's date range, grouped by repository, author and week.
+func (s *GithubService) GetCycleTimeMetrics(ctx context.Context, userID string, filter models.MetricsFilter) (*models.CycleTimeReport, error) {
+	timings, err := s.repo.GetPRTimings(ctx, userID, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	repos, err := s.repo.GetRepositories(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	repoNames := make(map[string]string, len(repos))
+	for _, repo := range repos {
+		repoNames[repo.ID] = repo.Owner + "/" + repo.Name
+	}
+
+	return metrics.BuildCycleTimeReport(timings, repoNames), nil
+}
+
+// syncPRTiming derives timing facts for a synced pull request from its GitHub timeline and stores them
+func (s *GithubService) syncPRTiming(ctx context.Context, client *github.Client, repo *models.Repository, prModel *models.PullRequest, pr *github.PullRequest) error {
+	if prModel.ID == "" {
+		return nil
+	}
+
+	events, err := listTimelineEvents(ctx, client, repo, pr.GetNumber())
+	if err != nil {
+		return err
+	}
+
+	var mergedAt *time.Time
+	if pr.MergedAt != nil {
+		mergedAt = &pr.MergedAt.Time
+	}
+
+	timing := metrics.BuildPRTiming(pr.GetUser().GetLogin(), pr.GetCreatedAt().Time, mergedAt, events)
+	timing.PullRequestID = prModel.ID
+	timing.RepoID = repo.ID
+	timing.Number = int64(pr.GetNumber())
+	now := time.Now()
+	timing.CreatedAt = &now
+	timing.UpdatedAt = &now
+
+	return s.repo.UpsertPRTiming(ctx, &timing)
+}
+
+// listTimelineEvents fetches the commits, reviews and ready-for-review events of a pull request
+func listTimelineEvents(ctx context.Context, client *github.Client, repo *models.Repository, number int) ([]metrics.TimelineEvent, error) {
+	var events []metrics.TimelineEvent
+	opts := &github.ListOptions{PerPage: 100}
+
+	for page := 0; page < maxTimelinePages; page++ {
+		timeline, resp, err := client.Issues.ListIssueTimeline(ctx, repo.Owner, repo.Name, number, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, t := range timeline {
+			switch t.GetEvent() {
+			case "committed":
+				if t.Committer != nil && t.Committer.Date != nil {
+					events = append(events, metrics.TimelineEvent{Kind: metrics.EventCommit, At: t.Committer.Date.Time})
+				}
+			case "reviewed":
+				if t.SubmittedAt == nil || t.GetState() == "pending" {
+					continue
+				}
+				events = append(events, metrics.TimelineEvent{
+					Kind:        metrics.EventReview,
+					At:          t.SubmittedAt.Time,
+					Actor:       t.GetUser().GetLogin(),
+					ReviewState: t.GetState(),
+				})
+			case "ready_for_review":
+				events = append(events, metrics.TimelineEvent{Kind: metrics.EventReadyForReview, At: t.GetCreatedAt().Time})
+			}
+		}
+
+		if resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return events, nil
+}
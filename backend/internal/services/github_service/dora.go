@@ -0,0 +1,299 @@
+package github_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/metrics"
+)
+
+const (
+	// defaultMetricsWindow is used when no start date is given
+	defaultMetricsWindow = 30 * 24 * time.Hour
+	// maxDoraPages bounds the GitHub pages fetched per repository and resource
+	maxDoraPages = 10
+)
+
+var (
+	// ErrInvalidDateRange is returned when start_date/end_date can't be parsed or are reversed
+	ErrInvalidDateRange = errors.New("invalid date range")
+	// ErrRepositoryNotFound is returned when the repository doesn't exist or the user can't see it
+	ErrRepositoryNotFound = errors.New("repository not found")
+	// ErrUnsupportedCodeHost is returned for features that only work with GitHub repositories
+	ErrUnsupportedCodeHost = errors.New("only available for GitHub repositories")
+)
+
+// GetDoraMetrics computes deployment frequency, lead time for changes, change failure rate and
+// time to restore for each of the user's GitHub repositories (or the one in filter.RepoID) and
+// for all of them together. GitHub deployments are used when a repository has any; otherwise its
+// published releases stand in for deployments. Each repository's history is cached for
+// doraCacheTTL per environment.
+func (s *GithubService) GetDoraMetrics(ctx context.Context, userID string, token string, filter models.MetricsFilter) (*models.DoraReport, error) {
+	start, end, err := parseDateRange(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []*models.Repository
+	if filter.RepoID != "" {
+		repo, err := s.repo.GetRepository(ctx, userID, filter.RepoID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRepositoryNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !repo.IsGithub() {
+			return nil, ErrUnsupportedCodeHost
+		}
+		repos = []*models.Repository{repo}
+	} else {
+		all, err := s.repo.GetRepositories(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		// Deployments and releases are only read from GitHub
+		for _, repo := range all {
+			if repo.IsGithub() {
+				repos = append(repos, repo)
+			}
+		}
+	}
+
+	log.Info().Str("user_id", userID).Int("repos", len(repos)).Time("start", start).Time("end", end).Msg("[Service.GetDoraMetrics] Computing DORA metrics")
+
+	client := s.newGithubClient(ctx, token)
+	report := &models.DoraReport{Repositories: []models.DoraMetrics{}}
+	var inputs []metrics.Input
+
+	for _, repo := range repos {
+		input, err := s.collectDoraInput(ctx, client, repo, start, filter.Environment)
+		if err != nil {
+			log.Error().Err(err).Str("repo", repo.Owner+"/"+repo.Name).Msg("[Service.GetDoraMetrics] Failed to fetch deployment history")
+			return nil, err
+		}
+		inputs = append(inputs, input)
+
+		m := metrics.Compute([]metrics.Input{input}, start, end)
+		m.RepoID = repo.ID
+		m.RepoName = repo.Owner + "/" + repo.Name
+		report.Repositories = append(report.Repositories, m)
+	}
+
+	report.Team = metrics.Compute(inputs, start, end)
+	return report, nil
+}
+
+// collectDoraInput returns the deployments (or releases) and merged pull requests created
+// since start, from the cache when a fresh enough fetch covers start
+func (s *GithubService) collectDoraInput(ctx context.Context, client *github.Client, repo *models.Repository, start time.Time, environment string) (metrics.Input, error) {
+	key := doraCacheKey(repo.ID, environment)
+	now := time.Now()
+	if input, ok := s.dora.get(key, start, now); ok {
+		return input, nil
+	}
+
+	input := metrics.Input{Source: metrics.SourceDeployments}
+
+	deployments, finished, err := listDeployments(ctx, client, repo, start, environment, s.dora.finished(key))
+	if err != nil {
+		return input, err
+	}
+	input.Deployments = deployments
+
+	if len(deployments) == 0 {
+		input.Source = metrics.SourceReleases
+		if input.Deployments, err = listReleaseDeployments(ctx, client, repo, start); err != nil {
+			return input, err
+		}
+	}
+
+	if input.Changes, err = listMergedChanges(ctx, client, repo, start); err != nil {
+		return input, err
+	}
+
+	s.dora.put(key, &doraCacheEntry{since: start, fetchedAt: now, input: input, finished: finished})
+	return input, nil
+}
+
+// listDeployments returns completed deployments created since start. The deployment time is
+// taken from its final status so that restore times reflect when the fix actually landed.
+// Statuses are only fetched for deployments that aren't in known, the finished deployments of
+// an earlier fetch; the finished deployments of this fetch are returned for the next one.
+func listDeployments(ctx context.Context, client *github.Client, repo *models.Repository, start time.Time, environment string, known map[int64]metrics.Deployment) ([]metrics.Deployment, map[int64]metrics.Deployment, error) {
+	var result []metrics.Deployment
+	finished := make(map[int64]metrics.Deployment)
+	opts := &github.DeploymentsListOptions{
+		Environment: environment,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for page := 0; page < maxDoraPages; page++ {
+		deployments, resp, err := client.Repositories.ListDeployments(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		reachedStart := false
+		for _, d := range deployments {
+			if d.GetCreatedAt().Before(start) {
+				// Deployments are listed newest first
+				reachedStart = true
+				break
+			}
+			if deployment, ok := known[d.GetID()]; ok {
+				finished[d.GetID()] = deployment
+				result = append(result, deployment)
+				continue
+			}
+			statuses, _, err := client.Repositories.ListDeploymentStatuses(ctx, repo.Owner, repo.Name, d.GetID(), &github.ListOptions{PerPage: 1})
+			if err != nil {
+				return nil, nil, err
+			}
+			if len(statuses) == 0 {
+				continue
+			}
+			latest := statuses[0]
+			switch latest.GetState() {
+			case "success", "inactive":
+				finished[d.GetID()] = metrics.Deployment{At: latest.GetCreatedAt().Time}
+			case "failure", "error":
+				finished[d.GetID()] = metrics.Deployment{At: latest.GetCreatedAt().Time, Failed: true}
+			default:
+				// pending, queued and in_progress deployments haven't finished yet
+				continue
+			}
+			result = append(result, finished[d.GetID()])
+		}
+
+		if reachedStart || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, finished, nil
+}
+
+// listReleaseDeployments returns published (non-draft) releases since start as deployments
+func listReleaseDeployments(ctx context.Context, client *github.Client, repo *models.Repository, start time.Time) ([]metrics.Deployment, error) {
+	var result []metrics.Deployment
+	opts := &github.ListOptions{PerPage: 100}
+
+	for page := 0; page < maxDoraPages; page++ {
+		releases, resp, err := client.Repositories.ListReleases(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		reachedStart := false
+		for _, r := range releases {
+			if r.GetDraft() || r.PublishedAt == nil {
+				continue
+			}
+			if r.GetPublishedAt().Before(start) {
+				reachedStart = true
+				continue
+			}
+			result = append(result, metrics.Deployment{At: r.GetPublishedAt().Time})
+		}
+
+		if reachedStart || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
+
+// listMergedChanges returns pull requests merged since start
+func listMergedChanges(ctx context.Context, client *github.Client, repo *models.Repository, start time.Time) ([]metrics.Change, error) {
+	var result []metrics.Change
+	opts := &github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for page := 0; page < maxDoraPages; page++ {
+		prs, resp, err := client.PullRequests.List(ctx, repo.Owner, repo.Name, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		reachedStart := false
+		for _, pr := range prs {
+			if pr.GetUpdatedAt().Before(start) {
+				// Sorted by last update, so nothing older can have been merged in range
+				reachedStart = true
+				break
+			}
+			if pr.MergedAt == nil || pr.GetMergedAt().Before(start) {
+				continue
+			}
+			var labels []string
+			for _, l := range pr.Labels {
+				labels = append(labels, l.GetName())
+			}
+			result = append(result, metrics.Change{
+				CreatedAt: pr.GetCreatedAt().Time,
+				MergedAt:  pr.GetMergedAt().Time,
+				Title:     pr.GetTitle(),
+				Labels:    labels,
+			})
+		}
+
+		if reachedStart || resp.NextPage == 0 {
+			break
+		}
+		opts.Page = resp.NextPage
+	}
+	return result, nil
+}
+
+// parseDateRange resolves the filter dates, defaulting to the last 30 days. Dates may be
+// RFC3339 timestamps or plain YYYY-MM-DD days; a plain end date includes the whole day.
+func parseDateRange(filter models.MetricsFilter) (time.Time, time.Time, error) {
+	end := time.Now().UTC()
+	if filter.EndDate != nil && strings.TrimSpace(*filter.EndDate) != "" {
+		t, dateOnly, err := parseDate(*filter.EndDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: end_date: %v", ErrInvalidDateRange, err)
+		}
+		if dateOnly {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		end = t
+	}
+
+	start := end.Add(-defaultMetricsWindow)
+	if filter.StartDate != nil && strings.TrimSpace(*filter.StartDate) != "" {
+		t, _, err := parseDate(*filter.StartDate)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: start_date: %v", ErrInvalidDateRange, err)
+		}
+		start = t
+	}
+
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: start_date must be before end_date", ErrInvalidDateRange)
+	}
+	return start, end, nil
+}
+
+func parseDate(value string) (time.Time, bool, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	return t, true, err
+}
@@ -0,0 +1,72 @@
+package github_service
+
+import (
+	"sync"
+	"time"
+
+	"devplus-backend/internal/services/metrics"
+)
+
+// doraCacheTTL is how long a repository's deployment history is reused before GitHub is
+// asked again
+const doraCacheTTL = 15 * time.Minute
+
+// doraCache keeps the DORA input of each repository and environment, so the metrics endpoint
+// doesn't refetch the deployment history on every request. Finished deployments are also
+// remembered by ID, so a refresh only asks for the statuses of deployments not seen finishing.
+type doraCache struct {
+	mu      sync.Mutex
+	entries map[string]*doraCacheEntry
+}
+
+type doraCacheEntry struct {
+	// since is the start the input was fetched from; it covers any later start
+	since     time.Time
+	fetchedAt time.Time
+	input     metrics.Input
+	finished  map[int64]metrics.Deployment
+}
+
+func newDoraCache() *doraCache {
+	return &doraCache{entries: make(map[string]*doraCacheEntry)}
+}
+
+func doraCacheKey(repoID string, environment string) string {
+	return repoID + ":" + environment
+}
+
+// get returns the cached input if it is fresh and was fetched from start or earlier
+func (c *doraCache) get(key string, start time.Time, now time.Time) (metrics.Input, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || now.Sub(entry.fetchedAt) > doraCacheTTL || entry.since.After(start) {
+		return metrics.Input{}, false
+	}
+	return entry.input, true
+}
+
+// finished returns the finished deployments recorded for key by the last fetch
+func (c *doraCache) finished(key string) map[int64]metrics.Deployment {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		return entry.finished
+	}
+	return nil
+}
+
+// put stores a freshly fetched input and drops entries nobody has asked for since they expired
+func (c *doraCache) put(key string, entry *doraCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for k, e := range c.entries {
+		if entry.fetchedAt.Sub(e.fetchedAt) > doraCacheTTL {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry
+}
@@ -0,0 +1,56 @@
+package github_service
+
+import (
+	"testing"
+	"time"
+
+	"devplus-backend/internal/services/metrics"
+)
+
+func TestDoraCache(t *testing.T) {
+	now := time.Now()
+	since := now.Add(-30 * 24 * time.Hour)
+	input := metrics.Input{Source: metrics.SourceDeployments, Deployments: []metrics.Deployment{{At: now}}}
+	finished := map[int64]metrics.Deployment{42: {At: now}}
+
+	cache := newDoraCache()
+	key := doraCacheKey("repo-1", "production")
+	cache.put(key, &doraCacheEntry{since: since, fetchedAt: now, input: input, finished: finished})
+
+	tests := []struct {
+		name  string
+		key   string
+		start time.Time
+		now   time.Time
+		want  bool
+	}{
+		{"same period", key, since, now, true},
+		{"later start", key, since.Add(time.Hour), now.Add(time.Minute), true},
+		{"earlier start", key, since.Add(-time.Hour), now, false},
+		{"expired", key, since, now.Add(doraCacheTTL + time.Second), false},
+		{"other environment", doraCacheKey("repo-1", "staging"), since, now, false},
+		{"other repository", doraCacheKey("repo-2", "production"), since, now, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := cache.get(tt.key, tt.start, tt.now)
+			if ok != tt.want {
+				t.Fatalf("get() ok = %v, want %v", ok, tt.want)
+			}
+			if ok && len(got.Deployments) != 1 {
+				t.Errorf("get() returned %d deployments, want 1", len(got.Deployments))
+			}
+		})
+	}
+
+	// Finished deployments outlive the cached input, so an expired entry still saves the
+	// status lookups of deployments that had finished
+	if got := cache.finished(key); got[42] != finished[42] {
+		t.Errorf("finished() = %v, want %v", got, finished)
+	}
+
+	cache.put(doraCacheKey("repo-2", ""), &doraCacheEntry{since: since, fetchedAt: now.Add(doraCacheTTL + time.Second)})
+	if cache.finished(key) != nil {
+		t.Error("put() kept an entry that expired before the new fetch")
+	}
+}
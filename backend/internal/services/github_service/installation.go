@@ -0,0 +1,202 @@
+package github_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// HandleInstallationEvent records GitHub App installation changes from the "installation"
+// and "installation_repositories" webhooks and links the affected repositories.
+func (s *GithubService) HandleInstallationEvent(ctx context.Context, event string, payload []byte) error {
+	switch event {
+	case "installation":
+		var e github.InstallationEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return err
+		}
+		installation := e.GetInstallation()
+		log.Info().Int64("installation_id", installation.GetID()).Str("action", e.GetAction()).Str("account", installation.GetAccount().GetLogin()).Msg("[Service.HandleInstallationEvent] Installation event")
+
+		switch e.GetAction() {
+		case "deleted":
+			s.installations.Forget(installation.GetID())
+			return s.repo.DeleteGithubInstallation(ctx, installation.GetID())
+		case "suspend":
+			s.installations.Forget(installation.GetID())
+			return s.saveInstallation(ctx, installation)
+		default: // created, unsuspend, new_permissions_accepted
+			if err := s.saveInstallation(ctx, installation); err != nil {
+				return err
+			}
+			return s.linkInstallationRepositories(ctx, installation.GetID())
+		}
+
+	case "installation_repositories":
+		var e github.InstallationRepositoriesEvent
+		if err := json.Unmarshal(payload, &e); err != nil {
+			return err
+		}
+		installation := e.GetInstallation()
+		log.Info().Int64("installation_id", installation.GetID()).Str("action", e.GetAction()).Int("added", len(e.RepositoriesAdded)).Int("removed", len(e.RepositoriesRemoved)).Msg("[Service.HandleInstallationEvent] Installation repositories changed")
+
+		if err := s.saveInstallation(ctx, installation); err != nil {
+			return err
+		}
+		return s.linkInstallationRepositories(ctx, installation.GetID())
+	}
+
+	return nil
+}
+
+// SyncInstalledRepositories syncs pull requests of every repository linked to an active App
+// installation using installation tokens, so data stays fresh without a logged-in user.
+func (s *GithubService) SyncInstalledRepositories(ctx context.Context) error {
+	if !s.installations.Enabled() {
+		return nil
+	}
+
+	repos, err := s.repo.ListInstalledRepositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, repo := range repos {
+		token := s.installationToken(ctx, repo)
+		if token == "" {
+			continue
+		}
+		if _, err := s.SyncPullRequests(ctx, repo.ID, token); err != nil {
+			log.Error().Err(err).Str("repo_id", repo.ID).Msg("[Service.SyncInstalledRepositories] Failed to sync repository")
+		}
+	}
+
+	log.Info().Int("repositories", len(repos)).Msg("[Service.SyncInstalledRepositories] Background sync finished")
+	return nil
+}
+
+// PostPullRequestReview posts the stored AI analysis of a PR as a review comment, authenticated
+// as the App installation. Repositories without an installation are skipped.
+func (s *GithubService) PostPullRequestReview(ctx context.Context, prID string) error {
+	pr, err := s.repo.GetPullRequestByID(ctx, prID)
+	if err != nil {
+		return err
+	}
+	if pr.Repository == nil || pr.Number == nil || pr.AISummary == nil || *pr.AISummary == "" {
+		return nil
+	}
+
+	token := s.installationToken(ctx, pr.Repository)
+	if token == "" {
+		return nil
+	}
+
+	body := "### DevPlus AI review\n\n"
+	if pr.AIDecision != nil && *pr.AIDecision != "" {
+		body += fmt.Sprintf("**Decision:** %s\n\n", *pr.AIDecision)
+	}
+	body += *pr.AISummary
+
+	client := s.newGithubClient(ctx, token)
+	if _, _, err := client.PullRequests.CreateReview(ctx, pr.Repository.Owner, pr.Repository.Name, int(*pr.Number), &github.PullRequestReviewRequest{
+		Body:  github.String(body),
+		Event: github.String("COMMENT"),
+	}); err != nil {
+		return err
+	}
+
+	log.Info().Str("pr_id", prID).Int64("installation_id", pr.Repository.InstallationID).Msg("[Service.PostPullRequestReview] Posted AI review")
+	return nil
+}
+
+// installationToken returns an installation token for the repository, or "" when the App isn't
+// configured, the repository has no installation or minting fails.
+func (s *GithubService) installationToken(ctx context.Context, repo *models.Repository) string {
+	if !s.installations.Enabled() || repo.InstallationID == 0 {
+		return ""
+	}
+	token, err := s.installations.InstallationToken(ctx, repo.InstallationID)
+	if err != nil {
+		log.Error().Err(err).Str("repo_id", repo.ID).Int64("installation_id", repo.InstallationID).Msg("[Service.installationToken] Failed to get installation token")
+		return ""
+	}
+	return token
+}
+
+func (s *GithubService) saveInstallation(ctx context.Context, installation *github.Installation) error {
+	now := time.Now()
+	record := &models.GithubInstallation{
+		CreatedAt:           &now,
+		UpdatedAt:           &now,
+		InstallationID:      installation.GetID(),
+		AccountLogin:        installation.GetAccount().GetLogin(),
+		AccountType:         installation.GetAccount().GetType(),
+		RepositorySelection: installation.GetRepositorySelection(),
+	}
+	if installation.SuspendedAt != nil {
+		record.SuspendedAt = &installation.SuspendedAt.Time
+	}
+	return s.repo.UpsertGithubInstallation(ctx, record)
+}
+
+// linkInstallationRepositories links the repositories an installation can access, as listed
+// by GitHub with an installation token. Repositories not synced yet are linked on their
+// first sync through linkAccountInstallations.
+func (s *GithubService) linkInstallationRepositories(ctx context.Context, installationID int64) error {
+	if !s.installations.Enabled() {
+		return nil
+	}
+
+	token, err := s.installations.InstallationToken(ctx, installationID)
+	if err != nil {
+		return err
+	}
+	client := s.newGithubClient(ctx, token)
+
+	var githubRepoIDs []int64
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		list, resp, err := client.Apps.ListRepos(ctx, opt)
+		if err != nil {
+			return err
+		}
+		for _, repo := range list.Repositories {
+			githubRepoIDs = append(githubRepoIDs, repo.GetID())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	log.Info().Int64("installation_id", installationID).Int("repositories", len(githubRepoIDs)).Msg("[Service.linkInstallationRepositories] Linking repositories")
+	return s.repo.SetInstallationRepositories(ctx, installationID, githubRepoIDs)
+}
+
+// linkAccountInstallations links freshly synced repositories to the active installations of
+// their owners. Failures are logged; user-token sync doesn't depend on them.
+func (s *GithubService) linkAccountInstallations(ctx context.Context, owners []string) {
+	if !s.installations.Enabled() || len(owners) == 0 {
+		return
+	}
+
+	installations, err := s.repo.GetGithubInstallationsByAccount(ctx, owners)
+	if err != nil {
+		log.Error().Err(err).Msg("[Service.linkAccountInstallations] Failed to load installations")
+		return
+	}
+	for _, installation := range installations {
+		if installation.SuspendedAt != nil {
+			continue
+		}
+		if err := s.linkInstallationRepositories(ctx, installation.InstallationID); err != nil {
+			log.Error().Err(err).Int64("installation_id", installation.InstallationID).Msg("[Service.linkAccountInstallations] Failed to link repositories")
+		}
+	}
+}
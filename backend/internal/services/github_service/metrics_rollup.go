@@ -0,0 +1,95 @@
+package github_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/metrics"
+)
+
+// ErrInvalidMetricsQuery is returned for an unknown metric type or interval
+var ErrInvalidMetricsQuery = errors.New("invalid metrics query")
+
+// RollupDailyMetrics snapshots per-repository metrics for the UTC day containing day into the
+// metrics table. Running it again for the same day overwrites that day's values. Point-in-time
+// values (open PRs, AI approval rate, risk score) are only taken for the current day, since
+// they can't be reconstructed for past days.
+func (s *GithubService) RollupDailyMetrics(ctx context.Context, day time.Time) error {
+	dayStart := time.Date(day.UTC().Year(), day.UTC().Month(), day.UTC().Day(), 0, 0, 0, 0, time.UTC)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	isToday := time.Now().UTC().Before(dayEnd)
+
+	repos, err := s.repo.ListAllRepositories(ctx)
+	if err != nil {
+		return err
+	}
+
+	log.Info().Str("day", dayStart.Format("2006-01-02")).Int("repos", len(repos)).Msg("[Service.RollupDailyMetrics] Rolling up metrics")
+
+	now := time.Now()
+	var rows []*models.Metric
+	for _, repo := range repos {
+		snapshot, err := s.repo.GetRepoMetricSnapshot(ctx, repo.ID, dayStart, dayEnd)
+		if err != nil {
+			log.Error().Err(err).Str("repo_id", repo.ID).Msg("[Service.RollupDailyMetrics] Failed to compute snapshot")
+			continue
+		}
+
+		values := map[string]*float64{
+			models.MetricMergedPRs:    snapshot.MergedPRs,
+			models.MetricAvgCycleTime: snapshot.AvgCycleTimeHours,
+		}
+		if isToday {
+			risk := float64(repo.ReleaseRiskScore)
+			values[models.MetricOpenPRs] = snapshot.OpenPRs
+			values[models.MetricAIApprovalRate] = snapshot.AIApprovalRate
+			values[models.MetricRiskScore] = &risk
+		}
+
+		for metricType, value := range values {
+			if value == nil {
+				continue
+			}
+			rows = append(rows, &models.Metric{
+				CreatedAt: &now,
+				UpdatedAt: &now,
+				RepoID:    &repo.ID,
+				Type:      &metricType,
+				Value:     value,
+				Date:      &dayStart,
+			})
+		}
+	}
+
+	return s.repo.UpsertMetrics(ctx, rows)
+}
+
+// GetMetricsTimeSeries returns the rolled up values of metricType bucketed by interval
+func (s *GithubService) GetMetricsTimeSeries(ctx context.Context, userID string, metricType string, interval string, filter models.MetricsFilter) (*models.TimeSeries, error) {
+	if !metrics.ValidMetricType(metricType) {
+		return nil, fmt.Errorf("%w: unknown metric type %q", ErrInvalidMetricsQuery, metricType)
+	}
+	if interval == "" {
+		interval = models.IntervalDay
+	}
+	if !metrics.ValidInterval(interval) {
+		return nil, fmt.Errorf("%w: interval must be day, week or month", ErrInvalidMetricsQuery)
+	}
+
+	points, err := s.repo.GetMetricPoints(ctx, userID, metricType, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TimeSeries{
+		Type:     metricType,
+		Interval: interval,
+		RepoID:   filter.RepoID,
+		Points:   metrics.BuildTimeSeries(points, metricType, interval),
+	}, nil
+}
@@ -0,0 +1,289 @@
+package github_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/oauth2"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+)
+
+const changelogPath = "CHANGELOG.md"
+
+var branchSanitizer = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// newGithubClient builds an authenticated GitHub API client for the given token
+func (s *GithubService) newGithubClient(ctx context.Context, token string) *github.Client {
+	ts := oauth2.StaticTokenSource(
+		&oauth2.Token{AccessToken: token},
+	)
+	return s.github.NewClient(oauth2.NewClient(ctx, ts))
+}
+
+// isGithubNotFound reports whether err is a 404 response from the GitHub API
+func isGithubNotFound(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusNotFound
+}
+
+// IsGithubUnauthorized reports whether err is a 401 response from the GitHub API, i.e. the
+// token was revoked or has expired
+func IsGithubUnauthorized(err error) bool {
+	var ghErr *github.ErrorResponse
+	return errors.As(err, &ghErr) && ghErr.Response != nil && ghErr.Response.StatusCode == http.StatusUnauthorized
+}
+
+// GetReleases returns the releases DevPlus has published for a repository
+func (s *GithubService) GetReleases(ctx context.Context, userID string, repoID string) ([]*models.Release, error) {
+	if _, err := s.repo.GetRepository(ctx, userID, repoID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetReleases(ctx, repoID)
+}
+
+// PublishRelease creates or updates a draft GitHub Release for the given tag using the
+// repository's generated changelog, which is only stored from signed release risk callbacks.
+// Publishing the same tag again updates the existing release (and changelog pull request) in
+// place instead of creating duplicates.
+func (s *GithubService) PublishRelease(ctx context.Context, userID string, repoID string, token string, opts models.PublishReleaseOptions) (*models.Release, error) {
+	repo, err := s.repo.GetRepository(ctx, userID, repoID)
+	if err != nil {
+		return nil, err
+	}
+	if !repo.IsGithub() {
+		return nil, ErrUnsupportedCodeHost
+	}
+
+	opts.TagName = strings.TrimSpace(opts.TagName)
+	if opts.TagName == "" && repo.ReleaseVersionSuggestion != nil {
+		// Default to the version suggested by the last release risk run
+		opts.TagName = repo.ReleaseVersionSuggestion.SuggestedVersion
+	}
+	if opts.TagName == "" {
+		return nil, errors.New("tag_name is required")
+	}
+
+	changelog := opts.Changelog
+	if changelog == "" {
+		changelog = repo.ReleaseChangelog
+	}
+	if strings.TrimSpace(changelog) == "" {
+		return nil, errors.New("no changelog available for this repository; run release risk analysis first")
+	}
+
+	name := opts.Name
+	if name == "" {
+		name = opts.TagName
+	}
+
+	log.Info().Str("repo_id", repoID).Str("tag", opts.TagName).Msg("[Service.PublishRelease] Publishing release")
+
+	release, err := s.repo.GetReleaseByTag(ctx, repoID, opts.TagName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		release = &models.Release{RepoID: repoID, TagName: opts.TagName}
+	} else if err != nil {
+		return nil, err
+	}
+	release.Name = name
+	release.Changelog = changelog
+
+	client := s.newGithubClient(ctx, token)
+
+	ghRelease, err := s.upsertGithubRelease(ctx, client, repo, release, opts.TargetCommitish)
+	if err != nil {
+		log.Error().Err(err).Str("repo_id", repoID).Str("tag", opts.TagName).Msg("[Service.PublishRelease] Failed to upsert GitHub release")
+		return nil, err
+	}
+	release.GithubReleaseID = github.Int64(ghRelease.GetID())
+	release.GithubReleaseURL = ghRelease.GetHTMLURL()
+	release.Draft = ghRelease.GetDraft()
+
+	if opts.ExportChangelogPR {
+		pr, err := s.exportChangelogPR(ctx, client, repo, opts.TagName, changelog)
+		if err != nil {
+			log.Error().Err(err).Str("repo_id", repoID).Str("tag", opts.TagName).Msg("[Service.PublishRelease] Failed to export CHANGELOG.md")
+			return nil, err
+		}
+		if pr != nil {
+			release.ChangelogPRNumber = github.Int64(int64(pr.GetNumber()))
+			release.ChangelogPRURL = pr.GetHTMLURL()
+		}
+	}
+
+	now := time.Now()
+	release.UpdatedAt = &now
+	if release.CreatedAt == nil {
+		release.CreatedAt = &now
+	}
+	if err := s.repo.SaveRelease(ctx, release); err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("repo_id", repoID).Str("tag", opts.TagName).Str("url", release.GithubReleaseURL).Msg("[Service.PublishRelease] Release published")
+	return s.repo.GetReleaseByTag(ctx, repoID, opts.TagName)
+}
+
+// upsertGithubRelease edits the release we previously created (or one already on GitHub
+// for the same tag) and otherwise creates a new draft release.
+func (s *GithubService) upsertGithubRelease(ctx context.Context, client *github.Client, repo *models.Repository, release *models.Release, target string) (*github.RepositoryRelease, error) {
+	var existingID int64
+	if release.GithubReleaseID != nil {
+		existingID = *release.GithubReleaseID
+	} else {
+		ghRelease, _, err := client.Repositories.GetReleaseByTag(ctx, repo.Owner, repo.Name, release.TagName)
+		if err != nil && !isGithubNotFound(err) {
+			return nil, err
+		}
+		if ghRelease != nil {
+			existingID = ghRelease.GetID()
+		}
+	}
+
+	if existingID != 0 {
+		// Only the content is updated; the draft/published state stays whatever the maintainers chose on GitHub.
+		edit := &github.RepositoryRelease{
+			Name: github.String(release.Name),
+			Body: github.String(release.Changelog),
+		}
+		ghRelease, _, err := client.Repositories.EditRelease(ctx, repo.Owner, repo.Name, existingID, edit)
+		if err == nil || !isGithubNotFound(err) {
+			return ghRelease, err
+		}
+		// The release was deleted on GitHub; fall through and recreate it.
+		log.Warn().Int64("release_id", existingID).Msg("[Service.PublishRelease] Release no longer exists on GitHub, recreating")
+	}
+
+	create := &github.RepositoryRelease{
+		TagName: github.String(release.TagName),
+		Name:    github.String(release.Name),
+		Body:    github.String(release.Changelog),
+		Draft:   github.Bool(true),
+	}
+	if target != "" {
+		create.TargetCommitish = github.String(target)
+	}
+	ghRelease, _, err := client.Repositories.CreateRelease(ctx, repo.Owner, repo.Name, create)
+	return ghRelease, err
+}
+
+// exportChangelogPR writes the changelog for tag into CHANGELOG.md on a dedicated branch and
+// makes sure a pull request for that branch is open. Re-running replaces the tag's section.
+func (s *GithubService) exportChangelogPR(ctx context.Context, client *github.Client, repo *models.Repository, tag string, changelog string) (*github.PullRequest, error) {
+	ghRepo, _, err := client.Repositories.Get(ctx, repo.Owner, repo.Name)
+	if err != nil {
+		return nil, err
+	}
+	baseBranch := ghRepo.GetDefaultBranch()
+	branch := "devplus/changelog-" + branchSanitizer.ReplaceAllString(tag, "-")
+
+	// 1. Ensure the working branch exists
+	if _, _, err := client.Git.GetRef(ctx, repo.Owner, repo.Name, "refs/heads/"+branch); err != nil {
+		if !isGithubNotFound(err) {
+			return nil, err
+		}
+		baseRef, _, err := client.Git.GetRef(ctx, repo.Owner, repo.Name, "refs/heads/"+baseBranch)
+		if err != nil {
+			return nil, err
+		}
+		newRef := &github.Reference{
+			Ref:    github.String("refs/heads/" + branch),
+			Object: &github.GitObject{SHA: baseRef.Object.SHA},
+		}
+		if _, _, err := client.Git.CreateRef(ctx, repo.Owner, repo.Name, newRef); err != nil {
+			return nil, err
+		}
+	}
+
+	// 2. Read the current CHANGELOG.md from the branch (it may not exist yet)
+	var current, fileSHA string
+	file, _, _, err := client.Repositories.GetContents(ctx, repo.Owner, repo.Name, changelogPath, &github.RepositoryContentGetOptions{Ref: branch})
+	if err != nil && !isGithubNotFound(err) {
+		return nil, err
+	}
+	if file != nil {
+		if current, err = file.GetContent(); err != nil {
+			return nil, err
+		}
+		fileSHA = file.GetSHA()
+	}
+
+	// 3. Commit the updated file if anything changed
+	updated := upsertChangelogSection(current, tag, changelog)
+	if updated != current {
+		fileOpts := &github.RepositoryContentFileOptions{
+			Message: github.String(fmt.Sprintf("docs: update CHANGELOG for %s", tag)),
+			Content: []byte(updated),
+			Branch:  github.String(branch),
+		}
+		if fileSHA != "" {
+			fileOpts.SHA = github.String(fileSHA)
+			_, _, err = client.Repositories.UpdateFile(ctx, repo.Owner, repo.Name, changelogPath, fileOpts)
+		} else {
+			_, _, err = client.Repositories.CreateFile(ctx, repo.Owner, repo.Name, changelogPath, fileOpts)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// 4. Reuse the open pull request for this branch, or open one
+	openPRs, _, err := client.PullRequests.List(ctx, repo.Owner, repo.Name, &github.PullRequestListOptions{
+		State: "open",
+		Head:  repo.Owner + ":" + branch,
+		Base:  baseBranch,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(openPRs) > 0 {
+		return openPRs[0], nil
+	}
+
+	pr, _, err := client.PullRequests.Create(ctx, repo.Owner, repo.Name, &github.NewPullRequest{
+		Title: github.String(fmt.Sprintf("docs: update CHANGELOG for %s", tag)),
+		Head:  github.String(branch),
+		Base:  github.String(baseBranch),
+		Body:  github.String(fmt.Sprintf("Adds the DevPlus generated changelog for `%s` to `%s`.", tag, changelogPath)),
+	})
+	return pr, err
+}
+
+// upsertChangelogSection replaces the marked section for tag in content, or inserts it
+// below the top-level heading when it isn't there yet.
+func upsertChangelogSection(content, tag, changelog string) string {
+	startMarker := fmt.Sprintf("<!-- devplus:release %s start -->", tag)
+	endMarker := fmt.Sprintf("<!-- devplus:release %s end -->", tag)
+	section := fmt.Sprintf("%s\n## %s\n\n%s\n%s\n", startMarker, tag, strings.TrimSpace(changelog), endMarker)
+
+	if start := strings.Index(content, startMarker); start >= 0 {
+		if end := strings.Index(content[start:], endMarker); end >= 0 {
+			end = start + end + len(endMarker)
+			if end < len(content) && content[end] == '\n' {
+				end++
+			}
+			return content[:start] + section + content[end:]
+		}
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return "# Changelog\n\n" + section
+	}
+
+	// Insert after the first "# " heading if there is one, otherwise prepend
+	if strings.HasPrefix(content, "# ") {
+		if idx := strings.Index(content, "\n"); idx >= 0 {
+			return content[:idx+1] + "\n" + section + "\n" + strings.TrimLeft(content[idx+1:], "\n")
+		}
+		return content + "\n\n" + section
+	}
+	return section + "\n" + content
+}
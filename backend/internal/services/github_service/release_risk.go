@@ -0,0 +1,476 @@
+package github_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/risk"
+	"devplus-backend/internal/services/versioning"
+)
+
+const (
+	// maxFamiliarityPaths bounds the number of commit lookups made per PR when
+	// checking whether the author has worked on the touched paths before.
+	maxFamiliarityPaths = 3
+	// maxListedFiles bounds the per-PR file list sent to the release risk workflow
+	maxListedFiles = 25
+	// maxTagPages bounds the tag pages read to find the latest version
+	maxTagPages = 20
+
+	// releaseRiskWaitTimeout is how long a run waits for missing PR reviews before the
+	// release risk workflow runs without them
+	releaseRiskWaitTimeout = 5 * time.Minute
+	// releaseRiskRunRetention is how long finished release risk runs are kept
+	releaseRiskRunRetention = 30 * 24 * time.Hour
+)
+
+var (
+	// ErrNoReleasePRs is returned when none of the requested PR IDs belong to the repository
+	ErrNoReleasePRs = errors.New("no valid pull requests found from selection")
+	// ErrStaleReleaseRiskRun is returned for results of a run that a newer run replaced
+	ErrStaleReleaseRiskRun = errors.New("release risk run is no longer current")
+)
+
+var (
+	linkedIssuePattern    = regexp.MustCompile(`(?i)\b(?:close[sd]?|fix(?:e[sd])?|resolve[sd]?)\s*:?\s+((?:[\w.-]+/[\w.-]+)?#\d+)`)
+	breakingFooterPattern = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE:`)
+)
+
+// CalculateReleaseRisk scores the selected pull requests, suggests the next version and
+// starts a release risk run with their full metadata. PRs without an AI summary are analysed
+// first; the run is stored and starts the AI release risk workflow once those analyses land
+// (or time out), see ResumeReleaseRiskRuns.
+func (s *GithubService) CalculateReleaseRisk(ctx context.Context, userID string, repoID string, prIDs []string, token string) (*models.RiskBreakdown, *models.VersionSuggestion, error) {
+	repo, err := s.repo.GetRepository(ctx, userID, repoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	// PR facts and tags are read from GitHub
+	if !repo.IsGithub() {
+		return nil, nil, ErrUnsupportedCodeHost
+	}
+
+	allPRs, err := s.repo.GetPullRequestsByRepoID(ctx, repoID)
+	if err != nil {
+		return nil, nil, err
+	}
+	prMap := make(map[string]*models.PullRequest)
+	for _, pr := range allPRs {
+		prMap[pr.ID] = pr
+	}
+	var selected []*models.PullRequest
+	for _, id := range prIDs {
+		// PRs without a number can't be looked up on the code host
+		if pr, ok := prMap[id]; ok && pr.Number != nil {
+			selected = append(selected, pr)
+		}
+	}
+	if len(selected) == 0 {
+		return nil, nil, ErrNoReleasePRs
+	}
+
+	// 1. Gather facts from GitHub and compute the deterministic score
+	client := s.newGithubClient(ctx, token)
+	facts := make([]risk.PRFacts, 0, len(selected))
+	for _, pr := range selected {
+		f, err := s.collectPRFacts(ctx, client, repo, pr)
+		if err != nil {
+			log.Error().Err(err).Str("pr_id", pr.ID).Msg("[Service.CalculateReleaseRisk] Failed to collect PR facts")
+			return nil, nil, err
+		}
+		facts = append(facts, *f)
+	}
+	breakdown := risk.Score(facts)
+	log.Info().Str("repo_id", repoID).Int("rule_score", breakdown.RuleScore).Int("prs", len(facts)).Msg("[Service.CalculateReleaseRisk] Computed rule-based release risk")
+
+	// 2. Suggest the next version from the latest tag and the release contents
+	tags, err := listTagNames(ctx, client, repo)
+	if err != nil {
+		log.Warn().Err(err).Str("repo_id", repoID).Msg("[Service.CalculateReleaseRisk] Failed to list tags, suggesting an initial version")
+	}
+	suggestion := suggestVersion(tags, selected, facts, nil)
+
+	// 3. Store the run as the repository's current one; it waits for PRs without an AI summary
+	run := &models.ReleaseRiskRun{
+		RepoID:    repoID,
+		Tags:      tags,
+		Status:    models.ReleaseRiskWaiting,
+		WaitUntil: time.Now().Add(releaseRiskWaitTimeout),
+	}
+	pending := make(map[string]bool)
+	for _, pr := range selected {
+		run.PRIDs = append(run.PRIDs, pr.ID)
+		if pr.AISummary == nil || *pr.AISummary == "" {
+			run.PendingPRIDs = append(run.PendingPRIDs, pr.ID)
+			pending[pr.ID] = true
+		}
+	}
+	if run.Facts, err = json.Marshal(facts); err != nil {
+		return nil, nil, err
+	}
+	if err := s.repo.StartReleaseRiskRun(ctx, run, breakdown, suggestion); err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Kick off analysis for the pending PRs; each analysis callback resumes the run
+	for _, pr := range selected {
+		if !pending[pr.ID] {
+			continue
+		}
+		if err := s.AnalyzePullRequest(ctx, repoID, int(*pr.Number)); err != nil {
+			log.Warn().Err(err).Str("pr_id", pr.ID).Msg("[Service.CalculateReleaseRisk] Failed to trigger PR analysis, continuing without it")
+			if err := s.repo.RemovePendingReleaseRiskPR(ctx, pr.ID); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	// 5. Start the workflow right away when no analysis is pending. The request may end before
+	// the workflow is triggered, so this doesn't use its cancellation.
+	if err := s.ResumeReleaseRiskRuns(context.WithoutCancel(ctx)); err != nil {
+		log.Error().Err(err).Str("run_id", run.ID).Msg("[Service.CalculateReleaseRisk] Failed to resume release risk runs")
+	}
+
+	return breakdown, suggestion, nil
+}
+
+// ReleaseRiskPRAnalyzed records that the AI review of a PR is in and resumes the release risk
+// runs that were waiting for it
+func (s *GithubService) ReleaseRiskPRAnalyzed(ctx context.Context, prID string) error {
+	if err := s.repo.RemovePendingReleaseRiskPR(ctx, prID); err != nil {
+		return err
+	}
+	return s.ResumeReleaseRiskRuns(ctx)
+}
+
+// ResumeReleaseRiskRuns starts the release risk workflow for the current runs whose PR
+// reviews are in or that waited longer than releaseRiskWaitTimeout. Runs are claimed in the
+// database, so each one starts once even when callbacks and the background job race.
+func (s *GithubService) ResumeReleaseRiskRuns(ctx context.Context) error {
+	runs, err := s.repo.ClaimReadyReleaseRiskRuns(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		if err := s.runReleaseRisk(ctx, run); err != nil {
+			status := models.ReleaseRiskFailed
+			if errors.Is(err, ErrStaleReleaseRiskRun) {
+				status = models.ReleaseRiskSuperseded
+			}
+			log.Error().Err(err).Str("run_id", run.ID).Str("repo_id", run.RepoID).Msg("[Service.ResumeReleaseRiskRuns] Failed to start release risk workflow")
+			if err := s.repo.SetReleaseRiskRunStatus(ctx, run.ID, status); err != nil {
+				log.Error().Err(err).Str("run_id", run.ID).Msg("[Service.ResumeReleaseRiskRuns] Failed to update run status")
+			}
+		}
+	}
+	return nil
+}
+
+// CleanupReleaseRiskRuns deletes finished release risk runs older than releaseRiskRunRetention
+func (s *GithubService) CleanupReleaseRiskRuns(ctx context.Context) error {
+	return s.repo.DeleteReleaseRiskRunsBefore(ctx, time.Now().Add(-releaseRiskRunRetention))
+}
+
+// runReleaseRisk re-scores a claimed run with the AI reviews that came in and triggers the
+// release risk workflow for it
+func (s *GithubService) runReleaseRisk(ctx context.Context, run *models.ReleaseRiskRun) error {
+	repo, err := s.repo.GetRepository(ctx, "", run.RepoID)
+	if err != nil {
+		return err
+	}
+
+	var facts []risk.PRFacts
+	if err := json.Unmarshal(run.Facts, &facts); err != nil {
+		return err
+	}
+	if len(facts) != len(run.PRIDs) {
+		return fmt.Errorf("release risk run has %d facts for %d pull requests", len(facts), len(run.PRIDs))
+	}
+
+	prs := make([]*models.PullRequest, 0, len(run.PRIDs))
+	summaries := make(map[string]string)
+	for i, prID := range run.PRIDs {
+		pr, err := s.repo.GetPullRequestByID(ctx, prID)
+		if err != nil {
+			return err
+		}
+		if pr.AISummary != nil {
+			summaries[prID] = *pr.AISummary
+		}
+		if pr.AIDecision != nil {
+			facts[i].AIDecision = *pr.AIDecision
+		}
+		prs = append(prs, pr)
+	}
+	if len(run.PendingPRIDs) > 0 {
+		log.Warn().Str("run_id", run.ID).Int("pending", len(run.PendingPRIDs)).Msg("[Service.runReleaseRisk] Timed out waiting for PR analyses, continuing without them")
+	}
+
+	// Re-score now that the new AI findings are known; the new summaries may also reveal
+	// breaking changes
+	breakdown := risk.Score(facts)
+	suggestion := suggestVersion(run.Tags, prs, facts, summaries)
+	if err := s.repo.UpdateReleaseRiskEstimate(ctx, repo.ID, run.ID, breakdown, suggestion); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrStaleReleaseRiskRun
+		}
+		return err
+	}
+
+	prData := formatReleasePRData(prs, facts, summaries, breakdown, suggestion)
+	return s.TriggerReleaseRiskAnalysis(ctx, repo.ID, run.ID, repo.Owner, repo.Name, prData)
+}
+
+// formatReleasePRData renders the release contents as markdown for the release risk workflow
+func formatReleasePRData(prs []*models.PullRequest, facts []risk.PRFacts, summaries map[string]string, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) string {
+	var b strings.Builder
+
+	for i, pr := range prs {
+		f := facts[i]
+		b.WriteString(fmt.Sprintf("\n### PR #%d: %s\n", f.Number, f.Title))
+		if f.State != "" {
+			b.WriteString(fmt.Sprintf("- **State**: %s\n", f.State))
+		}
+		familiarity := "no prior commits in the touched paths"
+		if f.AuthorFamiliar {
+			familiarity = "has prior commits in the touched paths"
+		}
+		b.WriteString(fmt.Sprintf("- **Author**: %s (%s)\n", f.Author, familiarity))
+		if len(f.Labels) > 0 {
+			b.WriteString(fmt.Sprintf("- **Labels**: %s\n", strings.Join(f.Labels, ", ")))
+		}
+		if len(f.LinkedIssues) > 0 {
+			b.WriteString(fmt.Sprintf("- **Linked Issues**: %s\n", strings.Join(f.LinkedIssues, ", ")))
+		}
+		b.WriteString(fmt.Sprintf("- **Diffstat**: +%d / -%d lines across %d files\n", f.Additions, f.Deletions, f.ChangedFiles))
+
+		var migrations, manifests []string
+		for _, file := range f.Files {
+			if risk.IsMigrationFile(file) {
+				migrations = append(migrations, file)
+			} else if risk.IsDependencyManifest(file) {
+				manifests = append(manifests, file)
+			}
+		}
+		if len(migrations) > 0 {
+			b.WriteString(fmt.Sprintf("- **Migrations**: %s\n", strings.Join(migrations, ", ")))
+		}
+		if len(manifests) > 0 {
+			b.WriteString(fmt.Sprintf("- **Dependency Manifests**: %s\n", strings.Join(manifests, ", ")))
+		}
+		if len(f.Files) > 0 {
+			listed := f.Files
+			if len(listed) > maxListedFiles {
+				listed = listed[:maxListedFiles]
+			}
+			b.WriteString("- **Files Changed**:\n")
+			for _, file := range listed {
+				b.WriteString(fmt.Sprintf("  - `%s`\n", file))
+			}
+			if len(f.Files) > maxListedFiles {
+				b.WriteString(fmt.Sprintf("  - ... and %d more\n", len(f.Files)-maxListedFiles))
+			}
+		}
+		if summary := summaries[pr.ID]; summary != "" {
+			b.WriteString(fmt.Sprintf("- **AI Analysis**: %s\n", summary))
+		} else {
+			b.WriteString("- **AI Analysis**: not available\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if breakdown != nil {
+		b.WriteString(fmt.Sprintf("\n### Rule-based Risk Factors (score %d/100)\n", breakdown.RuleScore))
+		for _, c := range breakdown.Contributions {
+			b.WriteString(fmt.Sprintf("- **%s**: %.1f/%.0f points - %s\n", c.Factor, c.Points, c.MaxPoints, c.Description))
+		}
+	}
+
+	if suggestion != nil {
+		current := suggestion.CurrentVersion
+		if current == "" {
+			current = "none"
+		}
+		b.WriteString(fmt.Sprintf("\n### Suggested Version: %s (%s bump from %s)\n", suggestion.SuggestedVersion, suggestion.Bump, current))
+		for _, reason := range suggestion.Justification {
+			b.WriteString(fmt.Sprintf("- %s\n", reason))
+		}
+	}
+
+	return b.String()
+}
+
+// suggestVersion classifies the release contents and proposes the next semantic version.
+// summaries overrides the PRs' stored AI summaries when provided.
+func suggestVersion(tags []string, prs []*models.PullRequest, facts []risk.PRFacts, summaries map[string]string) *models.VersionSuggestion {
+	changes := make([]versioning.Change, 0, len(prs))
+	for i, pr := range prs {
+		summary := summaries[pr.ID]
+		if summary == "" && pr.AISummary != nil {
+			summary = *pr.AISummary
+		}
+		changes = append(changes, versioning.Change{
+			Number:         facts[i].Number,
+			Title:          facts[i].Title,
+			Labels:         facts[i].Labels,
+			BreakingFooter: facts[i].BreakingFooter,
+			AISummary:      summary,
+		})
+	}
+	return versioning.Suggest(tags, changes)
+}
+
+// listTagNames returns the names of the repository's tags. GitHub doesn't list tags in version
+// order, so every page is read, up to maxTagPages.
+func listTagNames(ctx context.Context, client *github.Client, repo *models.Repository) ([]string, error) {
+	var names []string
+	opt := &github.ListOptions{PerPage: 100}
+	for page := 0; page < maxTagPages; page++ {
+		tags, resp, err := client.Repositories.ListTags(ctx, repo.Owner, repo.Name, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			names = append(names, tag.GetName())
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return names, nil
+}
+
+// collectPRFacts fetches metadata, diffstat, touched files and author familiarity for a PR from GitHub
+func (s *GithubService) collectPRFacts(ctx context.Context, client *github.Client, repo *models.Repository, pr *models.PullRequest) (*risk.PRFacts, error) {
+	if pr.Number == nil {
+		return &risk.PRFacts{}, nil
+	}
+	number := int(*pr.Number)
+
+	ghPR, _, err := client.PullRequests.Get(ctx, repo.Owner, repo.Name, number)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &risk.PRFacts{
+		Number:       *pr.Number,
+		Title:        ghPR.GetTitle(),
+		Author:       ghPR.GetUser().GetLogin(),
+		Additions:    ghPR.GetAdditions(),
+		Deletions:    ghPR.GetDeletions(),
+		ChangedFiles: ghPR.GetChangedFiles(),
+		State:        models.PullRequestState(ghPR.GetState(), ghPR.GetDraft(), ghPR.GetMerged()),
+		LinkedIssues: parseLinkedIssues(ghPR.GetBody()),
+		// Conventional Commits footer signalling a breaking change
+		BreakingFooter: breakingFooterPattern.MatchString(ghPR.GetBody()),
+	}
+	for _, label := range ghPR.Labels {
+		f.Labels = append(f.Labels, label.GetName())
+	}
+	if pr.AIDecision != nil {
+		f.AIDecision = *pr.AIDecision
+	}
+
+	opt := &github.ListOptions{PerPage: 100}
+	for {
+		files, resp, err := client.PullRequests.ListFiles(ctx, repo.Owner, repo.Name, number, opt)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			f.Files = append(f.Files, file.GetFilename())
+		}
+		// GitHub caps the file listing at 3000 entries; 300 is plenty for scoring
+		if resp.NextPage == 0 || len(f.Files) >= 300 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+
+	f.AuthorFamiliar = s.isAuthorFamiliar(ctx, client, repo, f.Author, f.Files, ghPR.GetCreatedAt().Time)
+	return f, nil
+}
+
+// parseLinkedIssues extracts issues referenced with closing keywords ("Fixes #12", "closes org/repo#3")
+func parseLinkedIssues(body string) []string {
+	var issues []string
+	seen := make(map[string]bool)
+	for _, m := range linkedIssuePattern.FindAllStringSubmatch(body, -1) {
+		if !seen[m[1]] {
+			seen[m[1]] = true
+			issues = append(issues, m[1])
+		}
+	}
+	return issues
+}
+
+// isAuthorFamiliar reports whether the author committed to at least half of the
+// directories touched by the PR before it was opened.
+func (s *GithubService) isAuthorFamiliar(ctx context.Context, client *github.Client, repo *models.Repository, author string, files []string, openedAt time.Time) bool {
+	if author == "" || len(files) == 0 {
+		return false
+	}
+
+	dirs := touchedDirs(files, maxFamiliarityPaths)
+	familiar := 0
+	for _, dir := range dirs {
+		opt := &github.CommitsListOptions{
+			Author:      author,
+			Path:        dir,
+			Until:       openedAt,
+			ListOptions: github.ListOptions{PerPage: 1},
+		}
+		commits, _, err := client.Repositories.ListCommits(ctx, repo.Owner, repo.Name, opt)
+		if err != nil {
+			log.Debug().Err(err).Str("path", dir).Msg("[Service.CalculateReleaseRisk] Failed to list author commits")
+			continue
+		}
+		if len(commits) > 0 {
+			familiar++
+		}
+	}
+
+	return familiar*2 >= len(dirs)
+}
+
+// touchedDirs returns up to limit distinct directories (two levels deep) from files,
+// ordered by how many of the files live in them.
+func touchedDirs(files []string, limit int) []string {
+	counts := make(map[string]int)
+	var order []string
+	for _, f := range files {
+		dir := path.Dir(f)
+		if dir == "." {
+			// Root level files; an empty path matches any prior commit by the author
+			dir = ""
+		}
+		if parts := strings.SplitN(dir, "/", 3); len(parts) > 2 {
+			dir = parts[0] + "/" + parts[1]
+		}
+		if _, ok := counts[dir]; !ok {
+			order = append(order, dir)
+		}
+		counts[dir]++
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return counts[order[i]] > counts[order[j]]
+	})
+	if len(order) > limit {
+		order = order[:limit]
+	}
+	return order
+}
@@ -0,0 +1,121 @@
+package github_service
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/risk"
+)
+
+func TestParseLinkedIssues(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"fixes", "Fixes #12", []string{"#12"}},
+		{"every closing keyword", "close #1, closes #2, closed #3, fix #4, fixes #5, fixed #6, resolve #7, resolves #8, resolved #9", []string{"#1", "#2", "#3", "#4", "#5", "#6", "#7", "#8", "#9"}},
+		{"case and colon", "RESOLVES: #7", []string{"#7"}},
+		{"cross-repo reference", "closes acme/api#3", []string{"acme/api#3"}},
+		{"dots and dashes in the repository", "Fixes my-org/my.repo#42", []string{"my-org/my.repo#42"}},
+		{"same number in another repository", "Fixes #12 and fixes acme/api#12", []string{"#12", "acme/api#12"}},
+		{"duplicates", "Fixes #1, fixed #2\n\nAlso closes #1 and FIXES #2", []string{"#1", "#2"}},
+		{"mention without a keyword", "See #5 and relates to #6", nil},
+		{"keyword inside a word", "prefixes #4", nil},
+		{"no space before the number", "Fixes#12", nil},
+		{"issue URL", "Fixes https://github.com/acme/api/issues/3", nil},
+		{"empty body", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinkedIssues(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinkedIssues(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatReleasePRData(t *testing.T) {
+	files := []string{"db/migrations/0042_add_sessions.sql", "go.mod"}
+	for i := 0; i < maxListedFiles; i++ {
+		files = append(files, fmt.Sprintf("internal/file%02d.go", i))
+	}
+	prs := []*models.PullRequest{{ID: "pr-1"}, {ID: "pr-2"}}
+	facts := []risk.PRFacts{
+		{
+			Number:         12,
+			Title:          "feat: sessions",
+			Author:         "octocat",
+			Additions:      120,
+			Deletions:      30,
+			ChangedFiles:   len(files),
+			Files:          files,
+			AuthorFamiliar: true,
+			State:          models.PRStateOpen,
+			Labels:         []string{"feature", "backend"},
+			LinkedIssues:   parseLinkedIssues("Fixes #3, closes acme/api#3 and fixes #3 again"),
+		},
+		{Number: 13, Title: "fix: typo", Author: "hubot", Additions: 1, Deletions: 1, ChangedFiles: 1, Files: []string{"README.md"}},
+	}
+	summaries := map[string]string{"pr-1": "Adds server-side sessions."}
+	breakdown := &models.RiskBreakdown{
+		RuleScore: 42,
+		Contributions: []models.RiskContribution{
+			{Factor: "migrations", Description: "1 migration file", Points: 10, MaxPoints: 15},
+		},
+	}
+	suggestion := &models.VersionSuggestion{SuggestedVersion: "v0.1.0", Bump: "minor", Justification: []string{"#12 is a feature"}}
+
+	got := formatReleasePRData(prs, facts, summaries, breakdown, suggestion)
+	first, second, _ := strings.Cut(got, "### PR #13")
+
+	for _, want := range []string{
+		"### PR #12: feat: sessions\n",
+		"- **State**: open\n",
+		"- **Author**: octocat (has prior commits in the touched paths)\n",
+		"- **Labels**: feature, backend\n",
+		"- **Linked Issues**: #3, acme/api#3\n",
+		"- **Diffstat**: +120 / -30 lines across 27 files\n",
+		"- **Migrations**: db/migrations/0042_add_sessions.sql\n",
+		"- **Dependency Manifests**: go.mod\n",
+		"  - `internal/file22.go`\n",
+		"  - ... and 2 more\n",
+		"- **AI Analysis**: Adds server-side sessions.\n",
+	} {
+		if !strings.Contains(first, want) {
+			t.Errorf("first PR is missing %q in\n%s", want, first)
+		}
+	}
+	if strings.Contains(first, "internal/file23.go") {
+		t.Errorf("files beyond maxListedFiles were listed in\n%s", first)
+	}
+
+	for _, want := range []string{
+		": fix: typo\n",
+		"- **Author**: hubot (no prior commits in the touched paths)\n",
+		"- **AI Analysis**: not available\n",
+		"### Rule-based Risk Factors (score 42/100)\n",
+		"- **migrations**: 10.0/15 points - 1 migration file\n",
+		"### Suggested Version: v0.1.0 (minor bump from none)\n",
+		"- #12 is a feature\n",
+	} {
+		if !strings.Contains(second, want) {
+			t.Errorf("second PR or summary is missing %q in\n%s", want, second)
+		}
+	}
+	for _, unwanted := range []string{"**State**", "**Labels**", "**Linked Issues**", "**Migrations**"} {
+		if strings.Contains(second, unwanted) {
+			t.Errorf("second PR has %s without any in\n%s", unwanted, second)
+		}
+	}
+}
+
+func TestFormatReleasePRDataWithoutEstimate(t *testing.T) {
+	got := formatReleasePRData([]*models.PullRequest{{ID: "pr-1"}}, []risk.PRFacts{{Number: 1, Title: "chore: bump"}}, nil, nil, nil)
+	if strings.Contains(got, "Rule-based Risk Factors") || strings.Contains(got, "Suggested Version") {
+		t.Errorf("formatReleasePRData() without breakdown or suggestion =\n%s", got)
+	}
+}
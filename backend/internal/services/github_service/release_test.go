@@ -0,0 +1,81 @@
+package github_service
+
+import "testing"
+
+func TestUpsertChangelogSection(t *testing.T) {
+	section := func(tag, body string) string {
+		return "<!-- devplus:release " + tag + " start -->\n## " + tag + "\n\n" + body + "\n<!-- devplus:release " + tag + " end -->\n"
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		tag       string
+		changelog string
+		want      string
+	}{
+		{
+			name:      "empty file",
+			content:   "",
+			tag:       "v1.0.0",
+			changelog: "- Add login",
+			want:      "# Changelog\n\n<!-- devplus:release v1.0.0 start -->\n## v1.0.0\n\n- Add login\n<!-- devplus:release v1.0.0 end -->\n",
+		},
+		{
+			name:      "blank file",
+			content:   "\n  \n",
+			tag:       "v1.0.0",
+			changelog: "\n- Add login\n\n",
+			want:      "# Changelog\n\n" + section("v1.0.0", "- Add login"),
+		},
+		{
+			name:      "heading without a newline",
+			content:   "# Changelog",
+			tag:       "v1.0.0",
+			changelog: "- Add login",
+			want:      "# Changelog\n\n" + section("v1.0.0", "- Add login"),
+		},
+		{
+			name:      "inserted above an older section",
+			content:   "# Changelog\n\n" + section("v1.0.0", "- Add login"),
+			tag:       "v1.1.0",
+			changelog: "- Add logout",
+			want:      "# Changelog\n\n" + section("v1.1.0", "- Add logout") + "\n" + section("v1.0.0", "- Add login"),
+		},
+		{
+			name:      "inserted above hand-written sections",
+			content:   "# Changelog\n\n\n## 0.9.0\n\n- Initial release\n",
+			tag:       "v1.0.0",
+			changelog: "- Add login",
+			want:      "# Changelog\n\n" + section("v1.0.0", "- Add login") + "\n## 0.9.0\n\n- Initial release\n",
+		},
+		{
+			name:      "replaces the section of the same version",
+			content:   "# Changelog\n\n" + section("v1.1.0", "- Draft notes") + "\n" + section("v1.0.0", "- Add login"),
+			tag:       "v1.1.0",
+			changelog: "- Add logout",
+			want:      "# Changelog\n\n" + section("v1.1.0", "- Add logout") + "\n" + section("v1.0.0", "- Add login"),
+		},
+		{
+			name:      "replaces an older version's section in place",
+			content:   "# Changelog\n\n" + section("v1.1.0", "- Add logout") + "\n" + section("v1.0.0", "- Add login") + "\nFooter\n",
+			tag:       "v1.0.0",
+			changelog: "- Add login\n- Fix typo",
+			want:      "# Changelog\n\n" + section("v1.1.0", "- Add logout") + "\n" + section("v1.0.0", "- Add login\n- Fix typo") + "\nFooter\n",
+		},
+		{
+			name:      "prepended without a heading",
+			content:   "Release notes\n",
+			tag:       "v1.0.0",
+			changelog: "- Add login",
+			want:      section("v1.0.0", "- Add login") + "\nRelease notes\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := upsertChangelogSection(tt.content, tt.tag, tt.changelog); got != tt.want {
+				t.Errorf("upsertChangelogSection() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
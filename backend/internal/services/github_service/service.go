@@ -2,29 +2,63 @@ package github_service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/go-github/v50/github"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/oauth2"
+	"gorm.io/gorm"
 
+	"devplus-backend/internal/githubapi"
 	"devplus-backend/internal/models"
 	"devplus-backend/internal/repositories"
 	"devplus-backend/internal/services/ai"
+	"devplus-backend/internal/services/risk"
 )
 
+// WorkspaceProvisioner assigns synced repositories to the workspace of their GitHub owner
+type WorkspaceProvisioner interface {
+	EnsureAccountWorkspace(ctx context.Context, provider string, accountLogin string, kind string, userID string, accountAdmin bool) (*models.Workspace, error)
+	GrantRepositoryAccess(ctx context.Context, workspaceID string, repoID string, userID string, role string) error
+}
+
+// InstallationTokens mints and caches GitHub App installation access tokens
+type InstallationTokens interface {
+	Enabled() bool
+	InstallationToken(ctx context.Context, installationID int64) (string, error)
+	Forget(installationID int64)
+}
+
+// CodeHostDiffs fetches merge request diffs from code hosts other than GitHub
+type CodeHostDiffs interface {
+	PullRequestDiff(ctx context.Context, repo *models.Repository, number int) (string, error)
+}
+
 type GithubService struct {
-	repo       repositories.GithubRepository
-	aiFactory  *ai.AIFactory
-	backendURL string
+	github        githubapi.Endpoints
+	repo          repositories.GithubRepository
+	workspaces    WorkspaceProvisioner
+	installations InstallationTokens
+	codeHosts     CodeHostDiffs
+	aiFactory     *ai.AIFactory
+	callbacks     *ai.CallbackSigner
+	backendURL    string
+	dora          *doraCache
 }
 
-func NewGithubService(repo repositories.GithubRepository, aiFactory *ai.AIFactory, backendURL string) *GithubService {
+func NewGithubService(endpoints githubapi.Endpoints, repo repositories.GithubRepository, workspaces WorkspaceProvisioner, installations InstallationTokens, codeHosts CodeHostDiffs, aiFactory *ai.AIFactory, callbacks *ai.CallbackSigner, backendURL string) *GithubService {
 	return &GithubService{
-		repo:       repo,
-		aiFactory:  aiFactory,
-		backendURL: backendURL,
+		github:        endpoints,
+		repo:          repo,
+		workspaces:    workspaces,
+		installations: installations,
+		codeHosts:     codeHosts,
+		aiFactory:     aiFactory,
+		callbacks:     callbacks,
+		backendURL:    backendURL,
+		dora:          newDoraCache(),
 	}
 }
 
@@ -33,7 +67,7 @@ func (s *GithubService) SyncRepositories(ctx context.Context, userID string, tok
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	client := s.github.NewClient(tc)
 
 	opt := &github.RepositoryListOptions{
 		Sort:        "updated",
@@ -48,8 +82,35 @@ func (s *GithubService) SyncRepositories(ctx context.Context, userID string, tok
 
 	// ... existing repo sync logic ...
 
+	// The syncing user's login decides whether they own their personal workspace
+	ghUser, _, err := client.Users.Get(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
 	var result []*models.Repository
+	var owners []string
+	workspaceIDs := make(map[string]string)
+	admins := make(map[string]bool)
 	for _, repo := range repos {
+		ownerLogin := repo.GetOwner().GetLogin()
+		workspaceID, ok := workspaceIDs[ownerLogin]
+		if !ok {
+			kind := models.WorkspaceKindUser
+			if repo.GetOwner().GetType() == "Organization" {
+				kind = models.WorkspaceKindOrganization
+			}
+			admin := accountAdmin(ctx, client, repo.GetOwner(), ghUser.GetLogin())
+			workspace, err := s.workspaces.EnsureAccountWorkspace(ctx, models.CodeHostGithub, ownerLogin, kind, userID, admin)
+			if err != nil {
+				return nil, err
+			}
+			workspaceID = workspace.ID
+			workspaceIDs[ownerLogin] = workspaceID
+			admins[ownerLogin] = admin
+			owners = append(owners, ownerLogin)
+		}
+
 		r := &models.Repository{
 			GithubRepoID: repo.ID,
 			Name:         repo.GetName(),
@@ -57,6 +118,7 @@ func (s *GithubService) SyncRepositories(ctx context.Context, userID string, tok
 			URL:          repo.GetHTMLURL(),
 			UpdatedAt:    &repo.UpdatedAt.Time,
 			UserID:       userID,
+			WorkspaceID:  workspaceID,
 		}
 
 		// Use Repository for Upsert
@@ -75,6 +137,13 @@ func (s *GithubService) SyncRepositories(ctx context.Context, userID string, tok
 		}
 		result = append(result, savedRepo)
 
+		// Users who don't administer the owner only get the repositories GitHub listed
+		if !admins[ownerLogin] {
+			if err := s.workspaces.GrantRepositoryAccess(ctx, workspaceID, savedRepo.ID, userID, repositoryRole(repo.GetPermissions())); err != nil {
+				return nil, err
+			}
+		}
+
 		// Call new SyncPullRequests
 		if _, err := s.SyncPullRequests(ctx, savedRepo.ID, token); err != nil {
 			// Log error but continue
@@ -84,6 +153,9 @@ func (s *GithubService) SyncRepositories(ctx context.Context, userID string, tok
 		}
 	}
 
+	// Link repositories to App installations of their owners so background work can use them
+	s.linkAccountInstallations(ctx, owners)
+
 	return result, nil
 }
 
@@ -107,10 +179,14 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 	
 	// Create map of existing open PRs by number
 	existingOpenPRs := make(map[int64]bool)
+	existingMergedPRs := make(map[int64]bool)
 	for _, pr := range existingPRs {
-		if pr.State != nil && *pr.State == "open" {
+		if pr.State != nil && (*pr.State == models.PRStateOpen || *pr.State == models.PRStateDraft) {
 			existingOpenPRs[*pr.Number] = true
 		}
+		if pr.State != nil && *pr.State == models.PRStateMerged {
+			existingMergedPRs[*pr.Number] = true
+		}
 	}
 	log.Info().Int("existing_open_count", len(existingOpenPRs)).Msg("[Service.SyncPullRequests] Found existing open PRs in DB")
 
@@ -119,7 +195,7 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	client := s.github.NewClient(tc)
 
 	// 4. Fetch open PRs from GitHub
 	prOpt := &github.PullRequestListOptions{
@@ -140,6 +216,9 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 		openPRNumbers[int64(pr.GetNumber())] = true
 	}
 
+	// PRs whose commits are ingested at the end of the sync
+	var commitPRs []*models.PullRequest
+
 	// 5. Check for PRs that were open in DB but not in GitHub's open list
 	// These PRs have been closed or merged
 	for prNumber := range existingOpenPRs {
@@ -153,9 +232,8 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 				continue
 			}
 			
-			state := pr.GetState()
-			if pr.GetMerged() {
-				state = "merged"
+			state := models.PullRequestState(pr.GetState(), pr.GetDraft(), pr.GetMerged())
+			if state == models.PRStateMerged {
 				log.Info().Int64("pr_number", prNumber).Msg("[Service.SyncPullRequests] PR was merged")
 			} else {
 				log.Info().Int64("pr_number", prNumber).Msg("[Service.SyncPullRequests] PR was closed without merging")
@@ -175,7 +253,12 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 			}
 			if err := s.repo.UpsertPullRequest(ctx, prModel); err != nil {
 				log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Upsert Error")
+				continue
 			}
+			if err := s.syncPRTiming(ctx, client, repo, prModel, pr); err != nil {
+				log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Failed to record PR timing")
+			}
+			commitPRs = append(commitPRs, prModel)
 		}
 	}
 
@@ -187,7 +270,7 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 			GithubPRID: github.Int64(pr.GetID()),
 			Number:     github.Int64(int64(pr.GetNumber())),
 			Title:      github.String(pr.GetTitle()),
-			State:      github.String(pr.GetState()),
+			State:      github.String(models.PullRequestState(pr.GetState(), pr.GetDraft(), false)),
 			RepoID:     &repo.ID,
 			AuthorID:   github.Int64(pr.GetUser().GetID()),
 			AuthorName: github.String(pr.GetUser().GetLogin()),
@@ -198,7 +281,53 @@ func (s *GithubService) SyncPullRequests(ctx context.Context, repoID string, tok
 			log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Upsert Error")
 			return nil, err
 		}
+		if err := s.syncPRTiming(ctx, client, repo, prModel, pr); err != nil {
+			log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Failed to record PR timing")
+		}
 		syncedPRs = append(syncedPRs, prModel)
+		commitPRs = append(commitPRs, prModel)
+	}
+
+	// 7. Record timing facts for recently merged PRs so cycle time covers completed work
+	closedOpt := &github.PullRequestListOptions{
+		State:       "closed",
+		Sort:        "updated",
+		Direction:   "desc",
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+	closedPRs, _, err := client.PullRequests.List(ctx, repo.Owner, repo.Name, closedOpt)
+	if err != nil {
+		log.Error().Err(err).Msg("[Service.SyncPullRequests] Failed to fetch closed PRs")
+	}
+	for _, pr := range closedPRs {
+		if pr.MergedAt == nil || existingOpenPRs[int64(pr.GetNumber())] || existingMergedPRs[int64(pr.GetNumber())] {
+			// Closed-unmerged PRs have no cycle time; the others are already recorded
+			continue
+		}
+		prModel := &models.PullRequest{
+			GithubPRID: github.Int64(pr.GetID()),
+			Number:     github.Int64(int64(pr.GetNumber())),
+			Title:      github.String(pr.GetTitle()),
+			State:      github.String(models.PRStateMerged),
+			RepoID:     &repo.ID,
+			AuthorID:   github.Int64(pr.GetUser().GetID()),
+			AuthorName: github.String(pr.GetUser().GetLogin()),
+			CreatedAt:  &pr.CreatedAt.Time,
+			UpdatedAt:  &pr.UpdatedAt.Time,
+		}
+		if err := s.repo.UpsertPullRequest(ctx, prModel); err != nil {
+			log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Upsert Error")
+			continue
+		}
+		if err := s.syncPRTiming(ctx, client, repo, prModel, pr); err != nil {
+			log.Error().Int("pr_number", pr.GetNumber()).Err(err).Msg("[Service.SyncPullRequests] Failed to record PR timing")
+		}
+		commitPRs = append(commitPRs, prModel)
+	}
+
+	// 8. Ingest commits from the default branch and the synced PRs
+	if err := s.syncCommits(ctx, client, repo, commitPRs); err != nil {
+		log.Error().Err(err).Msg("[Service.SyncPullRequests] Failed to sync commits")
 	}
 
 	log.Info().Int("count", len(syncedPRs)).Msg("[Service.SyncPullRequests] Successfully synced PRs")
@@ -257,8 +386,11 @@ func (s *GithubService) AnalyzeRepository(ctx context.Context, repoID string) er
 	}
 
 	// Trigger AI Service
-	// Construct Callback URL
-	callbackURL := fmt.Sprintf("%s/api/v1/webhook/ai/repo", s.backendURL)
+	// Construct Callback URL, signed so only this analysis can report back
+	callbackURL, err := s.callbacks.SignURL(fmt.Sprintf("%s/api/v1/webhook/ai/repo", s.backendURL), ai.CallbackRepoAnalysis, repo.ID)
+	if err != nil {
+		return err
+	}
 
 	// Get AI Service (Kestra)
 	aiService, err := s.aiFactory.GetAIService("kestra")
@@ -266,7 +398,7 @@ func (s *GithubService) AnalyzeRepository(ctx context.Context, repoID string) er
 		return err
 	}
 
-	return aiService.AnalyzeRepo(ctx, repo, callbackURL)
+	return aiService.AnalyzeRepo(ai.WithGithubToken(ctx, s.installationToken(ctx, repo)), repo, callbackURL)
 }
 
 func (s *GithubService) UpdateRepositoryAnalysis(ctx context.Context, repoID string, summary string) error {
@@ -287,9 +419,23 @@ func (s *GithubService) AnalyzePullRequest(ctx context.Context, repoID string, p
 		return err
 	}
 
-	// Construct Callback URL
-	callbackURL := s.backendURL + "/api/v1/webhook/ai"
+	// Construct Callback URL, signed so only this analysis can report back
+	callbackURL, err := s.callbacks.SignURL(s.backendURL+"/api/v1/webhook/ai", ai.CallbackPRAnalysis, pr.ID)
+	if err != nil {
+		return err
+	}
 
+	switch {
+	case pr.Repository != nil && !pr.Repository.IsGithub():
+		// Merge requests on other code hosts are analysed with the diff from their host
+		diff, err := s.codeHosts.PullRequestDiff(ctx, pr.Repository, prNumber)
+		if err != nil {
+			return err
+		}
+		ctx = ai.WithPRDiff(ctx, diff)
+	case pr.Repository != nil:
+		ctx = ai.WithGithubToken(ctx, s.installationToken(ctx, pr.Repository))
+	}
 	return aiService.AnalyzePR(ctx, pr, callbackURL)
 }
 
@@ -298,7 +444,7 @@ func (s *GithubService) GetPersonalMetrics(ctx context.Context, userID string, t
 		&oauth2.Token{AccessToken: token},
 	)
 	tc := oauth2.NewClient(ctx, ts)
-	client := github.NewClient(tc)
+	client := s.github.NewClient(tc)
 
 	metrics := &models.PersonalMetrics{
 		LanguageStats:      []models.LanguageStat{},
@@ -560,22 +706,47 @@ func splitRepoName(fullName string) []string {
 	return parts
 }
 
-// TriggerReleaseRiskAnalysis triggers the Kestra workflow for release risk analysis
-func (s *GithubService) TriggerReleaseRiskAnalysis(ctx context.Context, repoID string, owner string, name string, prData string) error {
+// TriggerReleaseRiskAnalysis triggers the Kestra workflow for a release risk run
+func (s *GithubService) TriggerReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, owner string, name string, prData string) error {
 	aiService, err := s.aiFactory.GetAIService("kestra")
 	if err != nil {
 		return err
 	}
 
-	// Construct callback URL
-	callbackURL := fmt.Sprintf("%s/api/v1/webhook/release-risk", s.backendURL)
+	// Construct callback URL, signed so only this run can report back
+	callbackURL, err := s.callbacks.SignURL(fmt.Sprintf("%s/api/v1/webhook/release-risk?run_id=%s", s.backendURL, runID), ai.CallbackReleaseRisk, runID)
+	if err != nil {
+		return err
+	}
 
 	return aiService.TriggerReleaseRiskAnalysis(repoID, owner, name, prData, callbackURL)
 }
 
-// UpdateReleaseRiskAnalysis updates the repository with release risk analysis results
-func (s *GithubService) UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, riskScore int, changelog string, rawAnalysis string) error {
-	return s.repo.UpdateReleaseRiskAnalysis(ctx, repoID, riskScore, changelog, rawAnalysis)
+// UpdateReleaseRiskAnalysis blends the AI risk score with the stored rule-based breakdown,
+// updates the repository with the release risk analysis results and returns it. Results of
+// a run that is no longer the repository's current run return ErrStaleReleaseRiskRun.
+func (s *GithubService) UpdateReleaseRiskAnalysis(ctx context.Context, repoID string, runID string, aiRiskScore int, changelog string, rawAnalysis string) (*models.Repository, error) {
+	repo, err := s.repo.GetRepository(ctx, "", repoID)
+	if err != nil {
+		return nil, err
+	}
+	if repo.ReleaseRiskRunID == nil || *repo.ReleaseRiskRunID != runID {
+		return nil, ErrStaleReleaseRiskRun
+	}
+
+	breakdown := risk.Blend(repo.ReleaseRiskBreakdown, aiRiskScore)
+	if err := s.repo.UpdateReleaseRiskAnalysis(ctx, repoID, runID, breakdown.Score, changelog, rawAnalysis, breakdown); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrStaleReleaseRiskRun
+		}
+		return nil, err
+	}
+
+	repo.ReleaseRiskScore = breakdown.Score
+	repo.ReleaseRiskBreakdown = breakdown
+	repo.ReleaseChangelog = changelog
+	repo.ReleaseRiskAnalysis = rawAnalysis
+	return repo, nil
 }
 
 // GetPullRequestsByRepoID retrieves all pull requests for a repository
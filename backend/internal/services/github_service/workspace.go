@@ -0,0 +1,43 @@
+package github_service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/go-github/v50/github"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// accountAdmin reports whether the user owns the repository owner: it is their own account,
+// or an organisation they are an active admin of. Membership is read with the read:org scope;
+// tokens without it are treated as plain members.
+func accountAdmin(ctx context.Context, client *github.Client, owner *github.User, userLogin string) bool {
+	if strings.EqualFold(owner.GetLogin(), userLogin) {
+		return true
+	}
+	if owner.GetType() != "Organization" {
+		return false
+	}
+
+	membership, _, err := client.Organizations.GetOrgMembership(ctx, "", owner.GetLogin())
+	if err != nil {
+		log.Warn().Err(err).Str("org", owner.GetLogin()).Msg("[Service.accountAdmin] Cannot read organisation membership")
+		return false
+	}
+	return membership.GetState() == "active" && membership.GetRole() == "admin"
+}
+
+// repositoryRole maps the user's GitHub repository permissions to a role: admins manage the
+// repository, users who can push maintain it
+func repositoryRole(permissions map[string]bool) string {
+	switch {
+	case permissions["admin"]:
+		return models.RoleOwner
+	case permissions["maintain"], permissions["push"]:
+		return models.RoleMaintainer
+	default:
+		return models.RoleViewer
+	}
+}
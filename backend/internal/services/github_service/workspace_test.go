@@ -0,0 +1,29 @@
+package github_service
+
+import (
+	"testing"
+
+	"devplus-backend/internal/models"
+)
+
+func TestRepositoryRole(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions map[string]bool
+		want        string
+	}{
+		{"admin", map[string]bool{"admin": true, "push": true, "pull": true}, models.RoleOwner},
+		{"maintain", map[string]bool{"maintain": true, "pull": true}, models.RoleMaintainer},
+		{"push", map[string]bool{"push": true, "pull": true}, models.RoleMaintainer},
+		{"triage", map[string]bool{"triage": true, "pull": true}, models.RoleViewer},
+		{"pull", map[string]bool{"pull": true}, models.RoleViewer},
+		{"not listed", nil, models.RoleViewer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := repositoryRole(tt.permissions); got != tt.want {
+				t.Errorf("repositoryRole() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
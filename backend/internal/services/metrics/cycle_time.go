@@ -0,0 +1,189 @@
+package metrics
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+// Kinds of pull request timeline events that matter for review timing
+const (
+	EventCommit         = "commit"
+	EventReview         = "review"
+	EventReadyForReview = "ready_for_review"
+)
+
+// TimelineEvent is a simplified pull request timeline entry
+type TimelineEvent struct {
+	Kind string
+	At   time.Time
+	// Actor is the login of the reviewer for review events
+	Actor string
+	// ReviewState is approved, changes_requested or commented for review events (any case)
+	ReviewState string
+}
+
+// BuildPRTiming derives review timing facts for a pull request from its timeline.
+// Waiting for review starts when the PR is opened, or when it is first marked ready
+// for review if it was opened as a draft. Reviews by the author or of the draft are ignored,
+// and a new review round starts whenever someone reviews commits pushed after the last review.
+func BuildPRTiming(author string, openedAt time.Time, mergedAt *time.Time, events []TimelineEvent) models.PRTiming {
+	timing := models.PRTiming{Author: author, OpenedAt: openedAt, MergedAt: mergedAt}
+
+	sorted := append([]TimelineEvent(nil), events...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].At.Before(sorted[j].At) })
+
+	// Reviews of a draft don't end the wait for review, so find where it starts first
+	reviewStart := openedAt
+	for _, e := range sorted {
+		if e.Kind == EventReadyForReview {
+			if e.At.After(reviewStart) {
+				reviewStart = e.At
+			}
+			break
+		}
+	}
+
+	newCommits := false
+	for _, e := range sorted {
+		switch e.Kind {
+		case EventCommit:
+			if timing.FirstReviewAt != nil {
+				newCommits = true
+			}
+		case EventReview:
+			if strings.EqualFold(e.Actor, author) || e.At.Before(reviewStart) {
+				continue
+			}
+			at := e.At
+			if timing.FirstReviewAt == nil {
+				timing.FirstReviewAt = &at
+				timing.ReviewRounds = 1
+			} else if newCommits {
+				timing.ReviewRounds++
+			}
+			newCommits = false
+			if strings.EqualFold(e.ReviewState, "APPROVED") && timing.ApprovedAt == nil {
+				timing.ApprovedAt = &at
+			}
+		}
+	}
+
+	timing.TimeToFirstReviewHours = hoursBetween(&reviewStart, timing.FirstReviewAt)
+	timing.TimeToApprovalHours = hoursBetween(&reviewStart, timing.ApprovedAt)
+	timing.ApprovalToMergeHours = hoursBetween(timing.ApprovedAt, mergedAt)
+	timing.CycleTimeHours = hoursBetween(&openedAt, mergedAt)
+	return timing
+}
+
+// SummarizeCycleTimes computes percentile stats for the given timings
+func SummarizeCycleTimes(timings []*models.PRTiming) models.CycleTimeStats {
+	var firstReview, approval, toMerge, cycle, rounds []float64
+	for _, t := range timings {
+		firstReview = appendValue(firstReview, t.TimeToFirstReviewHours)
+		approval = appendValue(approval, t.TimeToApprovalHours)
+		toMerge = appendValue(toMerge, t.ApprovalToMergeHours)
+		cycle = appendValue(cycle, t.CycleTimeHours)
+		if t.FirstReviewAt != nil {
+			rounds = append(rounds, float64(t.ReviewRounds))
+		}
+	}
+	return models.CycleTimeStats{
+		PRCount:           len(timings),
+		TimeToFirstReview: percentiles(firstReview),
+		TimeToApproval:    percentiles(approval),
+		ApprovalToMerge:   percentiles(toMerge),
+		CycleTime:         percentiles(cycle),
+		ReviewRounds:      percentiles(rounds),
+	}
+}
+
+// BuildCycleTimeReport groups timings by repository, author and ISO week (starting Monday).
+// repoNames maps repository IDs to display names.
+func BuildCycleTimeReport(timings []*models.PRTiming, repoNames map[string]string) *models.CycleTimeReport {
+	return &models.CycleTimeReport{
+		Overall: SummarizeCycleTimes(timings),
+		ByRepo: groupTimings(timings, func(t *models.PRTiming) (string, string) {
+			return t.RepoID, repoNames[t.RepoID]
+		}),
+		ByAuthor: groupTimings(timings, func(t *models.PRTiming) (string, string) {
+			return t.Author, ""
+		}),
+		ByWeek: groupTimings(timings, func(t *models.PRTiming) (string, string) {
+			return weekStart(t.OpenedAt).Format("2006-01-02"), ""
+		}),
+	}
+}
+
+func groupTimings(timings []*models.PRTiming, key func(*models.PRTiming) (string, string)) []models.CycleTimeGroup {
+	groups := make(map[string][]*models.PRTiming)
+	labels := make(map[string]string)
+	for _, t := range timings {
+		k, label := key(t)
+		groups[k] = append(groups[k], t)
+		labels[k] = label
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	result := make([]models.CycleTimeGroup, 0, len(keys))
+	for _, k := range keys {
+		result = append(result, models.CycleTimeGroup{
+			Key:   k,
+			Label: labels[k],
+			Stats: SummarizeCycleTimes(groups[k]),
+		})
+	}
+	return result
+}
+
+func weekStart(t time.Time) time.Time {
+	t = t.UTC()
+	offset := (int(t.Weekday()) + 6) % 7
+	return time.Date(t.Year(), t.Month(), t.Day()-offset, 0, 0, 0, 0, time.UTC)
+}
+
+func hoursBetween(from, to *time.Time) *float64 {
+	if from == nil || to == nil || to.Before(*from) {
+		return nil
+	}
+	h := round2(to.Sub(*from).Hours())
+	return &h
+}
+
+func appendValue(values []float64, v *float64) []float64 {
+	if v == nil {
+		return values
+	}
+	return append(values, *v)
+}
+
+func percentiles(values []float64) models.Percentiles {
+	p := models.Percentiles{Count: len(values)}
+	if len(values) == 0 {
+		return p
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	p.P50 = percentile(sorted, 0.50)
+	p.P75 = percentile(sorted, 0.75)
+	p.P90 = percentile(sorted, 0.90)
+	return p
+}
+
+// percentile interpolates linearly between the closest ranks of sorted values
+func percentile(sorted []float64, q float64) *float64 {
+	pos := q * float64(len(sorted)-1)
+	lower := int(math.Floor(pos))
+	upper := int(math.Ceil(pos))
+	v := sorted[lower] + (sorted[upper]-sorted[lower])*(pos-float64(lower))
+	v = round2(v)
+	return &v
+}
@@ -0,0 +1,198 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+func TestBuildPRTiming(t *testing.T) {
+	review := func(hours float64, actor, state string) TimelineEvent {
+		return TimelineEvent{Kind: EventReview, At: at(hours), Actor: actor, ReviewState: state}
+	}
+	commit := func(hours float64) TimelineEvent {
+		return TimelineEvent{Kind: EventCommit, At: at(hours)}
+	}
+	ready := func(hours float64) TimelineEvent {
+		return TimelineEvent{Kind: EventReadyForReview, At: at(hours)}
+	}
+	merged := func(hours float64) *time.Time {
+		m := at(hours)
+		return &m
+	}
+
+	tests := []struct {
+		name            string
+		mergedAt        *time.Time
+		events          []TimelineEvent
+		firstReview     *float64
+		approval        *float64
+		approvalToMerge *float64
+		cycle           *float64
+		rounds          int
+	}{
+		{
+			name: "open without reviews",
+		},
+		{
+			name:     "merged without reviews",
+			mergedAt: merged(6),
+			cycle:    ptr(6),
+		},
+		{
+			name:            "approved and merged",
+			mergedAt:        merged(5),
+			events:          []TimelineEvent{review(2, "bob", "APPROVED")},
+			firstReview:     ptr(2),
+			approval:        ptr(2),
+			approvalToMerge: ptr(3),
+			cycle:           ptr(5),
+			rounds:          1,
+		},
+		{
+			name:     "draft waits from ready for review",
+			mergedAt: merged(20),
+			events: []TimelineEvent{
+				review(1, "bob", "commented"),
+				ready(10),
+				review(12.5, "bob", "approved"),
+				ready(15),
+			},
+			firstReview:     ptr(2.5),
+			approval:        ptr(2.5),
+			approvalToMerge: ptr(7.5),
+			cycle:           ptr(20),
+			rounds:          1,
+		},
+		{
+			name:   "reviews by the author are ignored",
+			events: []TimelineEvent{review(1, "Alice", "commented"), review(3, "bob", "commented")},
+			// Only bob's review counts
+			firstReview: ptr(3),
+			rounds:      1,
+		},
+		{
+			name:     "new rounds after new commits",
+			mergedAt: merged(8),
+			// Listed out of order on purpose
+			events: []TimelineEvent{
+				review(6, "carol", "approved"),
+				commit(0.5),
+				review(1, "bob", "changes_requested"),
+				commit(2),
+				review(3, "bob", "commented"),
+				review(4, "bob", "approved"),
+				commit(5),
+			},
+			firstReview:     ptr(1),
+			approval:        ptr(4),
+			approvalToMerge: ptr(4),
+			cycle:           ptr(8),
+			rounds:          3,
+		},
+		{
+			name:     "rounded to two decimals",
+			mergedAt: merged(1),
+			events:   []TimelineEvent{review(1.0/3, "bob", "APPROVED")},
+			// 20 minutes
+			firstReview:     ptr(0.33),
+			approval:        ptr(0.33),
+			approvalToMerge: ptr(0.67),
+			cycle:           ptr(1),
+			rounds:          1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildPRTiming("alice", day0, tt.mergedAt, tt.events)
+			if !floatPtrEqual(got.TimeToFirstReviewHours, tt.firstReview) {
+				t.Errorf("TimeToFirstReviewHours = %v, want %v", deref(got.TimeToFirstReviewHours), deref(tt.firstReview))
+			}
+			if !floatPtrEqual(got.TimeToApprovalHours, tt.approval) {
+				t.Errorf("TimeToApprovalHours = %v, want %v", deref(got.TimeToApprovalHours), deref(tt.approval))
+			}
+			if !floatPtrEqual(got.ApprovalToMergeHours, tt.approvalToMerge) {
+				t.Errorf("ApprovalToMergeHours = %v, want %v", deref(got.ApprovalToMergeHours), deref(tt.approvalToMerge))
+			}
+			if !floatPtrEqual(got.CycleTimeHours, tt.cycle) {
+				t.Errorf("CycleTimeHours = %v, want %v", deref(got.CycleTimeHours), deref(tt.cycle))
+			}
+			if got.ReviewRounds != tt.rounds {
+				t.Errorf("ReviewRounds = %d, want %d", got.ReviewRounds, tt.rounds)
+			}
+		})
+	}
+}
+
+func TestPercentiles(t *testing.T) {
+	tests := []struct {
+		name          string
+		values        []float64
+		p50, p75, p90 *float64
+	}{
+		{name: "empty"},
+		{name: "single sample", values: []float64{5}, p50: ptr(5), p75: ptr(5), p90: ptr(5)},
+		{name: "two samples", values: []float64{2, 1}, p50: ptr(1.5), p75: ptr(1.75), p90: ptr(1.9)},
+		{name: "interpolated", values: []float64{4, 1, 3, 2}, p50: ptr(2.5), p75: ptr(3.25), p90: ptr(3.7)},
+		{name: "exact ranks", values: []float64{10, 20, 30, 40, 50}, p50: ptr(30), p75: ptr(40), p90: ptr(46)},
+		{name: "rounded", values: []float64{0, 1, 1}, p50: ptr(1), p75: ptr(1), p90: ptr(1)},
+		{name: "rounded to two decimals", values: []float64{0, 1.0 / 3}, p50: ptr(0.17), p75: ptr(0.25), p90: ptr(0.3)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := percentiles(tt.values)
+			if got.Count != len(tt.values) {
+				t.Errorf("Count = %d, want %d", got.Count, len(tt.values))
+			}
+			if !floatPtrEqual(got.P50, tt.p50) || !floatPtrEqual(got.P75, tt.p75) || !floatPtrEqual(got.P90, tt.p90) {
+				t.Errorf("p50, p75, p90 = %v, %v, %v, want %v, %v, %v",
+					deref(got.P50), deref(got.P75), deref(got.P90), deref(tt.p50), deref(tt.p75), deref(tt.p90))
+			}
+		})
+	}
+
+	values := []float64{3, 1, 2}
+	percentiles(values)
+	if values[0] != 3 || values[1] != 1 {
+		t.Errorf("percentiles sorted its input: %v", values)
+	}
+}
+
+func TestSummarizeCycleTimes(t *testing.T) {
+	reviewed := BuildPRTiming("alice", day0, nil, []TimelineEvent{{Kind: EventReview, At: at(4), Actor: "bob"}})
+	unreviewed := BuildPRTiming("alice", day0, nil, nil)
+
+	stats := SummarizeCycleTimes([]*models.PRTiming{&reviewed, &unreviewed})
+	if stats.PRCount != 2 {
+		t.Errorf("PRCount = %d, want 2", stats.PRCount)
+	}
+	// Unreviewed and unmerged PRs don't count towards the stats they lack
+	if stats.TimeToFirstReview.Count != 1 || !floatPtrEqual(stats.TimeToFirstReview.P50, ptr(4)) {
+		t.Errorf("TimeToFirstReview = %+v, want one sample of 4", stats.TimeToFirstReview)
+	}
+	if stats.ReviewRounds.Count != 1 || stats.CycleTime.Count != 0 || stats.CycleTime.P50 != nil {
+		t.Errorf("ReviewRounds, CycleTime = %+v, %+v, want one round sample and no cycle times", stats.ReviewRounds, stats.CycleTime)
+	}
+
+	if empty := SummarizeCycleTimes(nil); empty.PRCount != 0 || empty.CycleTime.P50 != nil {
+		t.Errorf("SummarizeCycleTimes(nil) = %+v", empty)
+	}
+}
+
+func TestWeekStart(t *testing.T) {
+	for _, tt := range []struct {
+		at   time.Time
+		want string
+	}{
+		{time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC), "2025-03-03"},
+		{time.Date(2025, 3, 9, 23, 59, 0, 0, time.UTC), "2025-03-03"},
+		{time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), "2025-03-10"},
+		// Sunday evening in New York is already Monday in UTC
+		{time.Date(2025, 3, 9, 21, 0, 0, 0, time.FixedZone("EDT", -4*3600)), "2025-03-10"},
+	} {
+		if got := weekStart(tt.at).Format("2006-01-02"); got != tt.want {
+			t.Errorf("weekStart(%v) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
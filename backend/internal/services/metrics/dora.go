@@ -0,0 +1,164 @@
+package metrics
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+// Where deployments for a repository come from
+const (
+	SourceDeployments = "deployments"
+	SourceReleases    = "releases"
+)
+
+var fixPattern = regexp.MustCompile(`(?i)^("?revert\b|hotfix\b)|\bhotfix\b|\brollback\b`)
+
+var fixLabels = map[string]bool{
+	"hotfix":   true,
+	"revert":   true,
+	"incident": true,
+	"rollback": true,
+}
+
+// Deployment is a single deployment (or release) to production
+type Deployment struct {
+	At time.Time
+	// Failed is true when the deployment's final status was failure or error
+	Failed bool
+}
+
+// Change is a merged pull request
+type Change struct {
+	CreatedAt time.Time
+	MergedAt  time.Time
+	Title     string
+	Labels    []string
+}
+
+// IsFix reports whether the change reverts or hot-fixes an earlier deployment
+func (c Change) IsFix() bool {
+	if fixPattern.MatchString(strings.TrimSpace(c.Title)) {
+		return true
+	}
+	for _, l := range c.Labels {
+		if fixLabels[strings.ToLower(l)] {
+			return true
+		}
+	}
+	return false
+}
+
+// Input is the deployment history and merged changes of one repository
+type Input struct {
+	Source      string
+	Deployments []Deployment
+	Changes     []Change
+}
+
+// Compute calculates DORA metrics for the given repositories between start and end.
+// Passing several inputs yields the team-wide aggregate; lead times are still matched
+// against each repository's own deployments.
+func Compute(inputs []Input, start, end time.Time) models.DoraMetrics {
+	result := models.DoraMetrics{StartDate: start, EndDate: end}
+
+	var leadTimes, restoreTimes []float64
+	sources := make(map[string]bool)
+
+	for _, in := range inputs {
+		sources[in.Source] = true
+
+		deploys := append([]Deployment(nil), in.Deployments...)
+		sort.Slice(deploys, func(i, j int) bool { return deploys[i].At.Before(deploys[j].At) })
+
+		if in.Source == SourceReleases {
+			// Releases carry no status, so a release counts as failed when a revert or
+			// hotfix was merged before the next release went out.
+			markFailedReleases(deploys, in.Changes)
+		}
+
+		for i, d := range deploys {
+			if !inRange(d.At, start, end) {
+				continue
+			}
+			result.Deployments++
+			if !d.Failed {
+				continue
+			}
+			result.FailedDeployments++
+			for _, next := range deploys[i+1:] {
+				if !next.Failed {
+					restoreTimes = append(restoreTimes, next.At.Sub(d.At).Hours())
+					break
+				}
+			}
+		}
+
+		for _, c := range in.Changes {
+			if !inRange(c.MergedAt, start, end) {
+				continue
+			}
+			result.MergedPRs++
+			for _, d := range deploys {
+				if !d.Failed && !d.At.Before(c.MergedAt) {
+					leadTimes = append(leadTimes, d.At.Sub(c.CreatedAt).Hours())
+					break
+				}
+			}
+		}
+	}
+
+	if len(sources) == 1 {
+		for s := range sources {
+			result.Source = s
+		}
+	}
+
+	if weeks := end.Sub(start).Hours() / (24 * 7); weeks > 0 {
+		result.DeploymentFrequency = round2(float64(result.Deployments) / weeks)
+	}
+	if result.Deployments > 0 {
+		cfr := round2(float64(result.FailedDeployments) / float64(result.Deployments))
+		result.ChangeFailureRate = &cfr
+	}
+	result.LeadTimeHours = median(leadTimes)
+	result.TimeToRestoreHours = median(restoreTimes)
+
+	return result
+}
+
+func markFailedReleases(releases []Deployment, changes []Change) {
+	for _, c := range changes {
+		if !c.IsFix() {
+			continue
+		}
+		// The fix belongs to the last release published before it was merged
+		for i := len(releases) - 1; i >= 0; i-- {
+			if releases[i].At.Before(c.MergedAt) {
+				releases[i].Failed = true
+				break
+			}
+		}
+	}
+}
+
+func inRange(t, start, end time.Time) bool {
+	return !t.Before(start) && !t.After(end)
+}
+
+func median(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	return percentile(sorted, 0.5)
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,219 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+)
+
+var day0 = time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+
+func at(hours float64) time.Time {
+	return day0.Add(time.Duration(hours * float64(time.Hour)))
+}
+
+func floatPtrEqual(got *float64, want *float64) bool {
+	if got == nil || want == nil {
+		return got == nil && want == nil
+	}
+	return *got == *want
+}
+
+func ptr(v float64) *float64 {
+	return &v
+}
+
+func TestCompute(t *testing.T) {
+	start, end := day0, day0.Add(14*24*time.Hour)
+
+	tests := []struct {
+		name        string
+		inputs      []Input
+		source      string
+		deployments int
+		failed      int
+		merged      int
+		frequency   float64
+		cfr         *float64
+		leadTime    *float64
+		restore     *float64
+	}{
+		{
+			name:   "no input",
+			inputs: nil,
+		},
+		{
+			name:   "no deployments",
+			inputs: []Input{{Source: SourceDeployments, Changes: []Change{{CreatedAt: at(1), MergedAt: at(2)}}}},
+			source: SourceDeployments,
+			merged: 1,
+		},
+		{
+			name: "single deployment",
+			inputs: []Input{{
+				Source:      SourceDeployments,
+				Deployments: []Deployment{{At: at(24)}},
+				Changes:     []Change{{CreatedAt: at(0), MergedAt: at(22)}},
+			}},
+			source:      SourceDeployments,
+			deployments: 1,
+			merged:      1,
+			frequency:   0.5,
+			cfr:         ptr(0),
+			leadTime:    ptr(24),
+		},
+		{
+			name: "failed deployments and restores",
+			inputs: []Input{{
+				Source: SourceDeployments,
+				// Listed out of order on purpose
+				Deployments: []Deployment{{At: at(53)}, {At: at(24)}, {At: at(51), Failed: true}, {At: at(48), Failed: true}},
+				Changes: []Change{
+					{CreatedAt: at(0), MergedAt: at(20)},
+					// Merged after the failed deployment, shipped by the one that restored service
+					{CreatedAt: at(47), MergedAt: at(49)},
+				},
+			}},
+			source:      SourceDeployments,
+			deployments: 4,
+			failed:      2,
+			merged:      2,
+			frequency:   2,
+			cfr:         ptr(0.5),
+			leadTime:    ptr(15),
+			restore:     ptr(3.5),
+		},
+		{
+			name: "releases fixed by a revert",
+			inputs: []Input{{
+				Source:      SourceReleases,
+				Deployments: []Deployment{{At: at(24)}, {At: at(72)}},
+				Changes: []Change{
+					{CreatedAt: at(0), MergedAt: at(12), Title: "feat: sessions"},
+					{CreatedAt: at(30), MergedAt: at(36), Title: `Revert "feat: sessions"`},
+				},
+			}},
+			source:      SourceReleases,
+			deployments: 2,
+			failed:      1,
+			merged:      2,
+			frequency:   1,
+			cfr:         ptr(0.5),
+			// Both changes ship with the release after the failed one: 72 and 42 hours
+			leadTime: ptr(57),
+			restore:  ptr(48),
+		},
+		{
+			name: "outside the range",
+			inputs: []Input{{
+				Source:      SourceDeployments,
+				Deployments: []Deployment{{At: at(-24)}, {At: at(24)}, {At: at(15 * 24)}},
+				Changes:     []Change{{CreatedAt: at(-48), MergedAt: at(-30)}, {CreatedAt: at(10), MergedAt: at(12)}},
+			}},
+			source:      SourceDeployments,
+			deployments: 1,
+			merged:      1,
+			frequency:   0.5,
+			cfr:         ptr(0),
+			leadTime:    ptr(14),
+		},
+		{
+			name: "team aggregate matches each repository's own deployments",
+			inputs: []Input{
+				{
+					Source:      SourceDeployments,
+					Deployments: []Deployment{{At: at(10)}},
+					Changes:     []Change{{CreatedAt: at(0), MergedAt: at(5)}},
+				},
+				{
+					Source:      SourceReleases,
+					Deployments: []Deployment{{At: at(100)}},
+					Changes:     []Change{{CreatedAt: at(0), MergedAt: at(6)}},
+				},
+			},
+			deployments: 2,
+			merged:      2,
+			frequency:   1,
+			cfr:         ptr(0),
+			leadTime:    ptr(55),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Compute(tt.inputs, start, end)
+			if got.Source != tt.source || got.Deployments != tt.deployments || got.FailedDeployments != tt.failed || got.MergedPRs != tt.merged {
+				t.Errorf("source, deployments, failed, merged = %q, %d, %d, %d, want %q, %d, %d, %d",
+					got.Source, got.Deployments, got.FailedDeployments, got.MergedPRs, tt.source, tt.deployments, tt.failed, tt.merged)
+			}
+			if got.DeploymentFrequency != tt.frequency {
+				t.Errorf("DeploymentFrequency = %v, want %v", got.DeploymentFrequency, tt.frequency)
+			}
+			if !floatPtrEqual(got.ChangeFailureRate, tt.cfr) {
+				t.Errorf("ChangeFailureRate = %v, want %v", deref(got.ChangeFailureRate), deref(tt.cfr))
+			}
+			if !floatPtrEqual(got.LeadTimeHours, tt.leadTime) {
+				t.Errorf("LeadTimeHours = %v, want %v", deref(got.LeadTimeHours), deref(tt.leadTime))
+			}
+			if !floatPtrEqual(got.TimeToRestoreHours, tt.restore) {
+				t.Errorf("TimeToRestoreHours = %v, want %v", deref(got.TimeToRestoreHours), deref(tt.restore))
+			}
+		})
+	}
+}
+
+func TestComputeDoesNotModifyInput(t *testing.T) {
+	input := Input{
+		Source:      SourceReleases,
+		Deployments: []Deployment{{At: at(48)}, {At: at(24)}},
+		Changes:     []Change{{CreatedAt: at(25), MergedAt: at(30), Title: "hotfix: login"}},
+	}
+	Compute([]Input{input}, day0, day0.Add(7*24*time.Hour))
+	if !input.Deployments[0].At.Equal(at(48)) || input.Deployments[0].Failed || input.Deployments[1].Failed {
+		t.Errorf("Compute modified the input deployments: %+v", input.Deployments)
+	}
+}
+
+func TestChangeIsFix(t *testing.T) {
+	for _, tt := range []struct {
+		change Change
+		want   bool
+	}{
+		{Change{Title: `Revert "feat: sessions"`}, true},
+		{Change{Title: `"Revert" everything`}, true},
+		{Change{Title: "Hotfix: login loop"}, true},
+		{Change{Title: "fix(auth): hotfix for expired tokens"}, true},
+		{Change{Title: "Rollback the cache change"}, true},
+		{Change{Title: "fix: typo"}, false},
+		{Change{Title: "Add revert button"}, false},
+		{Change{Title: "fix: login", Labels: []string{"Incident"}}, true},
+		{Change{Title: "fix: login", Labels: []string{"bug"}}, false},
+	} {
+		if got := tt.change.IsFix(); got != tt.want {
+			t.Errorf("IsFix(%q, %v) = %v, want %v", tt.change.Title, tt.change.Labels, got, tt.want)
+		}
+	}
+}
+
+func TestMedian(t *testing.T) {
+	if got := median(nil); got != nil {
+		t.Errorf("median(nil) = %v, want nil", *got)
+	}
+	for _, tt := range []struct {
+		values []float64
+		want   float64
+	}{
+		{[]float64{7}, 7},
+		{[]float64{3, 1, 2}, 2},
+		{[]float64{10, 1, 3, 2}, 2.5},
+	} {
+		if got := median(tt.values); got == nil || *got != tt.want {
+			t.Errorf("median(%v) = %v, want %v", tt.values, deref(got), tt.want)
+		}
+	}
+}
+
+func deref(v *float64) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
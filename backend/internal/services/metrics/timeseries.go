@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"sort"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+// additiveMetrics are summed when combining repositories or days; the others are averaged
+var additiveMetrics = map[string]bool{
+	models.MetricOpenPRs:   true,
+	models.MetricMergedPRs: true,
+}
+
+// ValidMetricType reports whether t is one of the rolled up metric types
+func ValidMetricType(t string) bool {
+	switch t {
+	case models.MetricOpenPRs, models.MetricMergedPRs, models.MetricAvgCycleTime, models.MetricAIApprovalRate, models.MetricRiskScore:
+		return true
+	}
+	return false
+}
+
+// ValidInterval reports whether interval is day, week or month
+func ValidInterval(interval string) bool {
+	return interval == models.IntervalDay || interval == models.IntervalWeek || interval == models.IntervalMonth
+}
+
+// BuildTimeSeries turns daily per-repository snapshots into a series bucketed by interval.
+// Repositories are first combined per day (summed for counts, averaged otherwise). Days are
+// then combined per bucket: merged PRs are summed, while gauges such as open PRs or the risk
+// score are averaged over the days in the bucket.
+func BuildTimeSeries(points []*models.Metric, metricType, interval string) []models.TimeSeriesPoint {
+	type acc struct {
+		sum   float64
+		count int
+	}
+
+	// 1. Combine repositories per day
+	days := make(map[time.Time]*acc)
+	for _, p := range points {
+		if p.Date == nil || p.Value == nil {
+			continue
+		}
+		day := truncate(*p.Date, models.IntervalDay)
+		if days[day] == nil {
+			days[day] = &acc{}
+		}
+		days[day].sum += *p.Value
+		days[day].count++
+	}
+
+	// 2. Combine days per bucket
+	buckets := make(map[time.Time]*acc)
+	for day, a := range days {
+		value := a.sum
+		if !additiveMetrics[metricType] {
+			value = a.sum / float64(a.count)
+		}
+		bucket := truncate(day, interval)
+		if buckets[bucket] == nil {
+			buckets[bucket] = &acc{}
+		}
+		buckets[bucket].sum += value
+		buckets[bucket].count++
+	}
+
+	series := make([]models.TimeSeriesPoint, 0, len(buckets))
+	for bucket, a := range buckets {
+		value := a.sum
+		if metricType != models.MetricMergedPRs {
+			value = a.sum / float64(a.count)
+		}
+		series = append(series, models.TimeSeriesPoint{Date: bucket, Value: round2(value)})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Date.Before(series[j].Date) })
+	return series
+}
+
+// truncate returns the start (UTC) of the day, ISO week or month containing t
+func truncate(t time.Time, interval string) time.Time {
+	t = t.UTC()
+	switch interval {
+	case models.IntervalWeek:
+		return weekStart(t)
+	case models.IntervalMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+}
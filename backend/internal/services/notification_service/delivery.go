@@ -0,0 +1,154 @@
+package notification_service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	// deliveryLease keeps a delivery from being picked up by a retry run while it is being sent
+	deliveryLease = 2 * time.Minute
+	// deliveryRetention is how long sent and failed deliveries stay in the log
+	deliveryRetention = 30 * 24 * time.Hour
+	// retryBatchSize bounds the deliveries a retry run attempts
+	retryBatchSize = 100
+)
+
+const (
+	// endpointRetryBase is the delay before the first retry of a webhook endpoint delivery,
+	// doubled after each further failed attempt
+	endpointRetryBase = 30 * time.Second
+	// endpointMaxAttempts gives consumers about four hours to recover before a delivery becomes
+	// a dead letter
+	endpointMaxAttempts = 10
+)
+
+// retryDelays is how long to wait before retrying after each failed attempt; a delivery is
+// marked failed when its last retry fails
+var retryDelays = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour}
+
+// retryDelay returns how long to wait before retrying a delivery after its nth failed attempt,
+// or false when that was the last attempt. Webhook endpoints back off exponentially; test
+// events aren't retried.
+func retryDelay(delivery *models.NotificationDelivery, attempts int) (time.Duration, bool) {
+	switch {
+	case delivery.Event == models.EventWebhookTest:
+		return 0, false
+	case delivery.EndpointID != nil:
+		return endpointRetryBase << (attempts - 1), attempts < endpointMaxAttempts
+	default:
+		if attempts > len(retryDelays) {
+			return 0, false
+		}
+		return retryDelays[attempts-1], true
+	}
+}
+
+// RetryDeliveries attempts the pending deliveries that are due
+func (s *NotificationService) RetryDeliveries(ctx context.Context) error {
+	var ids []string
+	err := s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, time.Now()).
+		Order("next_attempt_at").
+		Limit(retryBatchSize).
+		Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		s.deliver(ctx, id)
+	}
+	return nil
+}
+
+// PurgeDeliveries deletes sent and failed deliveries older than deliveryRetention
+func (s *NotificationService) PurgeDeliveries(ctx context.Context) error {
+	result := s.db.WithContext(ctx).
+		Where("status <> ? AND created_at < ?", models.DeliveryPending, time.Now().Add(-deliveryRetention)).
+		Delete(&models.NotificationDelivery{})
+	if result.Error != nil {
+		return result.Error
+	}
+	log.Info().Int64("deliveries", result.RowsAffected).Msg("[NotificationService.PurgeDeliveries] Removed old deliveries")
+	return nil
+}
+
+// deliver makes one attempt at a pending delivery that is due and records the outcome. The
+// delivery is claimed first so concurrent attempts don't send it twice.
+func (s *NotificationService) deliver(ctx context.Context, deliveryID string) {
+	now := time.Now()
+	claim := s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+		Where("id = ? AND status = ? AND next_attempt_at <= ?", deliveryID, models.DeliveryPending, now).
+		Update("next_attempt_at", now.Add(deliveryLease))
+	if claim.Error != nil {
+		log.Error().Err(claim.Error).Str("delivery_id", deliveryID).Msg("[NotificationService.deliver] Failed to claim delivery")
+		return
+	}
+	if claim.RowsAffected == 0 {
+		return
+	}
+
+	var delivery models.NotificationDelivery
+	if err := s.db.WithContext(ctx).Where("id = ?", deliveryID).First(&delivery).Error; err != nil {
+		log.Error().Err(err).Str("delivery_id", deliveryID).Msg("[NotificationService.deliver] Failed to load delivery")
+		return
+	}
+
+	sendErr := s.send(ctx, &delivery)
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{"attempts": attempts}
+	delay, retry := time.Duration(0), false
+	if sendErr != nil && !isPermanent(sendErr) {
+		delay, retry = retryDelay(&delivery, attempts)
+	}
+	switch {
+	case sendErr == nil:
+		deliveredAt := time.Now()
+		updates["status"] = models.DeliverySent
+		updates["delivered_at"] = &deliveredAt
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = ""
+	case !retry:
+		updates["status"] = models.DeliveryFailed
+		updates["next_attempt_at"] = nil
+		updates["last_error"] = sendErr.Error()
+	default:
+		updates["next_attempt_at"] = time.Now().Add(delay)
+		updates["last_error"] = sendErr.Error()
+	}
+
+	if err := s.db.WithContext(ctx).Model(&delivery).Updates(updates).Error; err != nil {
+		log.Error().Err(err).Str("delivery_id", deliveryID).Msg("[NotificationService.deliver] Failed to record attempt")
+		return
+	}
+	if sendErr != nil {
+		log.Warn().Err(sendErr).Str("delivery_id", deliveryID).Str("kind", delivery.Kind).Int("attempts", attempts).Msg("[NotificationService.deliver] Delivery failed")
+	}
+}
+
+// send renders the delivery's message for its channel kind and sends it. Webhook endpoint
+// deliveries are sent as stored, signed.
+func (s *NotificationService) send(ctx context.Context, delivery *models.NotificationDelivery) error {
+	if delivery.EndpointID != nil {
+		return s.sendToEndpoint(ctx, delivery)
+	}
+	sender, ok := s.senders[delivery.Kind]
+	if !ok {
+		return permanent(fmt.Errorf("%s notifications are not configured", delivery.Kind))
+	}
+	var msg Message
+	if err := json.Unmarshal([]byte(delivery.Message), &msg); err != nil {
+		return permanent(fmt.Errorf("invalid message: %w", err))
+	}
+	return sender.Send(ctx, delivery.Target.String(), &msg)
+}
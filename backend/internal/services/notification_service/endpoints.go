@@ -0,0 +1,291 @@
+package notification_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// webhookSecretPrefix starts endpoint signing secrets so they are recognisable in config files
+const webhookSecretPrefix = "whsec_"
+
+// EndpointInput is a webhook endpoint to create
+type EndpointInput struct {
+	Name   string   `json:"name"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+// IssuedWebhookEndpoint carries the signing secret of a newly created endpoint. It is only
+// returned at creation.
+type IssuedWebhookEndpoint struct {
+	*models.WebhookEndpoint
+	Secret string `json:"secret"`
+}
+
+// CreateEndpoint validates and stores a webhook endpoint with a new signing secret
+func (s *NotificationService) CreateEndpoint(ctx context.Context, userID string, input EndpointInput) (*IssuedWebhookEndpoint, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidNotificationRequest)
+	}
+	target, hint, err := s.validateTarget(models.ChannelWebhook, strings.TrimSpace(input.URL))
+	if err != nil {
+		return nil, err
+	}
+	if len(input.Events) == 0 {
+		return nil, fmt.Errorf("%w: subscribe to at least one event", ErrInvalidNotificationRequest)
+	}
+	var events models.ScopeList
+	for _, event := range input.Events {
+		if !validEvent(event) {
+			return nil, fmt.Errorf("%w: events must be among %s", ErrInvalidNotificationRequest, strings.Join(models.NotificationEvents, ", "))
+		}
+		if !containsString(events, event) {
+			events = append(events, event)
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := webhookSecretPrefix + hex.EncodeToString(raw)
+
+	endpoint := &models.WebhookEndpoint{
+		UserID:  userID,
+		Name:    name,
+		URL:     models.EncryptedString(target),
+		URLHint: hint,
+		Events:  events,
+		Secret:  models.EncryptedString(secret),
+		Enabled: true,
+	}
+	if err := s.db.WithContext(ctx).Create(endpoint).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("endpoint_id", endpoint.ID).Strs("events", events).Msg("[NotificationService.CreateEndpoint] Created webhook endpoint")
+	return &IssuedWebhookEndpoint{WebhookEndpoint: endpoint, Secret: secret}, nil
+}
+
+// ListEndpoints returns the user's webhook endpoints, newest first
+func (s *NotificationService) ListEndpoints(ctx context.Context, userID string) ([]*models.WebhookEndpoint, error) {
+	var endpoints []*models.WebhookEndpoint
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&endpoints).Error; err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// DeleteEndpoint deletes one of the user's webhook endpoints. Its deliveries stay in the log
+// but can no longer be sent.
+func (s *NotificationService) DeleteEndpoint(ctx context.Context, userID string, endpointID string) error {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return ErrNotificationNotFound
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", endpointID, userID).Delete(&models.WebhookEndpoint{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// SendTestEvent sends a webhook.test event to the endpoint and returns the delivery once the
+// attempt completes. Test events aren't retried.
+func (s *NotificationService) SendTestEvent(ctx context.Context, userID string, endpointID string) (*models.NotificationDelivery, error) {
+	endpoint, err := s.getEndpoint(ctx, userID, endpointID)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := json.Marshal(&Message{
+		Event:      models.EventWebhookTest,
+		Title:      "DevPlus test event",
+		Text:       fmt.Sprintf("Test event for webhook endpoint %s", endpoint.Name),
+		Data:       map[string]interface{}{"endpoint_id": endpoint.ID},
+		OccurredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	delivery := endpointDelivery(endpoint, models.EventWebhookTest, nil, string(message))
+	if err := s.db.WithContext(ctx).Create(delivery).Error; err != nil {
+		return nil, err
+	}
+
+	s.deliver(ctx, delivery.ID)
+	if err := s.db.WithContext(ctx).Where("id = ?", delivery.ID).First(delivery).Error; err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// ListDeadLetters returns the user's webhook endpoint deliveries that failed for good, newest
+// first
+func (s *NotificationService) ListDeadLetters(ctx context.Context, userID string, limit int) ([]*models.NotificationDelivery, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+	var deliveries []*models.NotificationDelivery
+	err := s.db.WithContext(ctx).
+		Where("user_id = ? AND endpoint_id IS NOT NULL AND status = ?", userID, models.DeliveryFailed).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// ReplayDeadLetter sends a failed webhook endpoint delivery again, with a fresh set of retries
+func (s *NotificationService) ReplayDeadLetter(ctx context.Context, userID string, deliveryID string) (*models.NotificationDelivery, error) {
+	if _, err := uuid.Parse(deliveryID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	now := time.Now()
+	result := s.db.WithContext(ctx).Model(&models.NotificationDelivery{}).
+		Where("id = ? AND user_id = ? AND endpoint_id IS NOT NULL AND status = ?", deliveryID, userID, models.DeliveryFailed).
+		Updates(map[string]interface{}{
+			"status":          models.DeliveryPending,
+			"attempts":        0,
+			"last_error":      "",
+			"next_attempt_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotificationNotFound
+	}
+
+	var delivery models.NotificationDelivery
+	if err := s.db.WithContext(ctx).Where("id = ?", deliveryID).First(&delivery).Error; err != nil {
+		return nil, err
+	}
+	log.Info().Str("user_id", userID).Str("delivery_id", deliveryID).Msg("[NotificationService.ReplayDeadLetter] Replaying delivery")
+
+	go s.deliver(context.Background(), deliveryID)
+	return &delivery, nil
+}
+
+func (s *NotificationService) getEndpoint(ctx context.Context, userID string, endpointID string) (*models.WebhookEndpoint, error) {
+	if _, err := uuid.Parse(endpointID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	var endpoint models.WebhookEndpoint
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", endpointID, userID).Limit(1).Find(&endpoint)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotificationNotFound
+	}
+	return &endpoint, nil
+}
+
+// matchingEndpoints returns the enabled endpoints subscribed to the event whose owner can view
+// the repository
+func (s *NotificationService) matchingEndpoints(ctx context.Context, event Event) ([]*models.WebhookEndpoint, error) {
+	var endpoints []*models.WebhookEndpoint
+	err := s.db.WithContext(ctx).
+		Where("enabled").
+		Where("user_id IN (SELECT user_id FROM public.repository_access WHERE repo_id = ?)", event.Repository.ID).
+		Order("created_at").
+		Find(&endpoints).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matching := endpoints[:0]
+	for _, endpoint := range endpoints {
+		if endpoint.Subscribes(event.Type) {
+			matching = append(matching, endpoint)
+		}
+	}
+	return matching, nil
+}
+
+// endpointDelivery returns a pending delivery of message to the endpoint
+func endpointDelivery(endpoint *models.WebhookEndpoint, event string, repoID *string, message string) *models.NotificationDelivery {
+	now := time.Now()
+	endpointID := endpoint.ID
+	return &models.NotificationDelivery{
+		UserID:        endpoint.UserID,
+		EndpointID:    &endpointID,
+		Kind:          models.ChannelWebhook,
+		Target:        endpoint.URL,
+		TargetHint:    endpoint.URLHint,
+		Event:         event,
+		RepoID:        repoID,
+		Message:       message,
+		Status:        models.DeliveryPending,
+		NextAttemptAt: &now,
+	}
+}
+
+// sendToEndpoint posts a delivery to its webhook endpoint, signed with the endpoint's current secret
+func (s *NotificationService) sendToEndpoint(ctx context.Context, delivery *models.NotificationDelivery) error {
+	var endpoint models.WebhookEndpoint
+	result := s.db.WithContext(ctx).Where("id = ?", *delivery.EndpointID).Limit(1).Find(&endpoint)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return permanent(errors.New("webhook endpoint was deleted"))
+	}
+	if !endpoint.Enabled {
+		return permanent(errors.New("webhook endpoint is disabled"))
+	}
+
+	return postSigned(ctx, s.client, endpoint.URL.String(), endpoint.Secret.String(), delivery)
+}
+
+// postSigned posts a delivery's message signed with secret. Consumers verify
+// X-DevPlus-Signature, "sha256=" and the hex HMAC-SHA256 of "<X-DevPlus-Timestamp>.<body>",
+// and can deduplicate retries by X-DevPlus-Delivery.
+func postSigned(ctx context.Context, client *http.Client, target string, secret string, delivery *models.NotificationDelivery) error {
+	payload := []byte(delivery.Message)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	return postPayload(ctx, client, target, payload, map[string]string{
+		"X-DevPlus-Event":     delivery.Event,
+		"X-DevPlus-Delivery":  delivery.ID,
+		"X-DevPlus-Timestamp": timestamp,
+		"X-DevPlus-Signature": "sha256=" + sign(secret, timestamp, payload),
+	})
+}
+
+// sign returns the hex HMAC-SHA256 of the timestamp and payload keyed with the secret
+func sign(secret string, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package notification_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"devplus-backend/internal/models"
+)
+
+const testEndpointSecret = "whsec_0123456789abcdef"
+
+// verify checks a delivery the way the README tells consumers to
+func verify(t *testing.T, r *http.Request, body []byte, secret string) bool {
+	t.Helper()
+	signature, ok := strings.CutPrefix(r.Header.Get("X-DevPlus-Signature"), "sha256=")
+	if !ok {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(r.Header.Get("X-DevPlus-Timestamp") + "."))
+	mac.Write(body)
+	return hmac.Equal([]byte(signature), []byte(hex.EncodeToString(mac.Sum(nil))))
+}
+
+func TestPostSigned(t *testing.T) {
+	var (
+		received *http.Request
+		body     []byte
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	delivery := &models.NotificationDelivery{
+		ID:      "0b6f3c2e-3f7e-4d53-9a55-6f8d8c1f9a10",
+		Event:   models.EventPRAnalyzed,
+		Message: `{"event":"pr.analyzed","repository":"acme/api","number":12}`,
+	}
+	before := time.Now().Unix()
+	if err := postSigned(context.Background(), newWebhookClient(true), server.URL, testEndpointSecret, delivery); err != nil {
+		t.Fatalf("postSigned: %v", err)
+	}
+
+	if string(body) != delivery.Message {
+		t.Errorf("body = %s, want the delivery message unchanged", body)
+	}
+	for header, want := range map[string]string{
+		"Content-Type":       "application/json",
+		"X-DevPlus-Event":    models.EventPRAnalyzed,
+		"X-DevPlus-Delivery": delivery.ID,
+	} {
+		if got := received.Header.Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+	timestamp, err := strconv.ParseInt(received.Header.Get("X-DevPlus-Timestamp"), 10, 64)
+	if err != nil || timestamp < before || timestamp > time.Now().Unix() {
+		t.Errorf("X-DevPlus-Timestamp = %q, want the current Unix time", received.Header.Get("X-DevPlus-Timestamp"))
+	}
+
+	if !verify(t, received, body, testEndpointSecret) {
+		t.Errorf("X-DevPlus-Signature %q doesn't verify", received.Header.Get("X-DevPlus-Signature"))
+	}
+	if verify(t, received, body, "whsec_other") {
+		t.Error("signature verifies with another secret")
+	}
+	if verify(t, received, append(body, ' '), testEndpointSecret) {
+		t.Error("signature verifies for a modified body")
+	}
+	received.Header.Set("X-DevPlus-Timestamp", strconv.FormatInt(timestamp+60, 10))
+	if verify(t, received, body, testEndpointSecret) {
+		t.Error("signature verifies with another timestamp")
+	}
+}
+
+func TestSign(t *testing.T) {
+	// printf '1700000000.{"ok":true}' | openssl dgst -sha256 -hmac whsec_0123456789abcdef
+	const want = "c81ee1c6f73100686b8d191f277e82a6e9f768264b55a74491d7407c92347987"
+	if got := sign(testEndpointSecret, "1700000000", []byte(`{"ok":true}`)); got != want {
+		t.Errorf("sign() = %s, want %s", got, want)
+	}
+}
+
+func TestPostPayloadErrors(t *testing.T) {
+	tests := []struct {
+		status    int
+		ok        bool
+		permanent bool
+	}{
+		{http.StatusOK, true, false},
+		{http.StatusAccepted, true, false},
+		{http.StatusBadRequest, false, true},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusNotFound, false, true},
+		{http.StatusGone, false, true},
+		{http.StatusRequestTimeout, false, false},
+		{http.StatusTooManyRequests, false, false},
+		{http.StatusInternalServerError, false, false},
+		{http.StatusBadGateway, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.status), func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := postPayload(context.Background(), newWebhookClient(true), server.URL, []byte(`{}`), nil)
+			if (err == nil) != tt.ok {
+				t.Fatalf("postPayload() = %v, want ok %v", err, tt.ok)
+			}
+			if err != nil && isPermanent(err) != tt.permanent {
+				t.Errorf("isPermanent(%v) = %v, want %v", err, isPermanent(err), tt.permanent)
+			}
+		})
+	}
+}
+
+func TestWebhookClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached a loopback server")
+	}))
+	defer server.Close()
+
+	err := postPayload(context.Background(), newWebhookClient(false), server.URL, []byte(`{}`), nil)
+	if !errors.Is(err, errPrivateAddress) || !isPermanent(err) {
+		t.Errorf("postPayload(loopback) = %v, want a permanent errPrivateAddress", err)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	endpointID := "endpoint-1"
+	endpoint := &models.NotificationDelivery{EndpointID: &endpointID, Event: models.EventPRAnalyzed}
+	channel := &models.NotificationDelivery{Event: models.EventPRAnalyzed}
+	test := &models.NotificationDelivery{EndpointID: &endpointID, Event: models.EventWebhookTest}
+
+	tests := []struct {
+		name     string
+		delivery *models.NotificationDelivery
+		attempts int
+		delay    time.Duration
+		retry    bool
+	}{
+		{"endpoint first failure", endpoint, 1, 30 * time.Second, true},
+		{"endpoint second failure", endpoint, 2, time.Minute, true},
+		{"endpoint fifth failure", endpoint, 5, 8 * time.Minute, true},
+		{"endpoint ninth failure", endpoint, 9, 128 * time.Minute, true},
+		{"endpoint last attempt", endpoint, endpointMaxAttempts, 256 * time.Minute, false},
+		{"channel first failure", channel, 1, time.Minute, true},
+		{"channel last retry", channel, len(retryDelays), 2 * time.Hour, true},
+		{"channel gives up", channel, len(retryDelays) + 1, 0, false},
+		{"test event", test, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := retryDelay(tt.delivery, tt.attempts)
+			if retry != tt.retry || (tt.retry && delay != tt.delay) {
+				t.Errorf("retryDelay(%d) = %v, %v; want %v, %v", tt.attempts, delay, retry, tt.delay, tt.retry)
+			}
+		})
+	}
+}
@@ -0,0 +1,303 @@
+package notification_service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+)
+
+// summaryLength bounds the AI summaries and changelogs quoted in notifications
+const summaryLength = 1000
+
+// Event is something that happened to a repository that rules can subscribe to
+type Event struct {
+	Type        string
+	Repository  *models.Repository
+	PullRequest *models.PullRequest // pr.analyzed only
+	Decision    string              // AI decision (pr.analyzed)
+	Summary     string              // AI summary, or the changelog for release.risk_calculated
+	RiskScore   int                 // release.risk_calculated
+	PRCount     int                 // Pull requests synced (sync.completed)
+}
+
+// Message is the channel-independent content of a notification. Generic webhooks receive it
+// as is; the other channels format the title, text and link.
+type Message struct {
+	Event      string                 `json:"event"`
+	Title      string                 `json:"title"`
+	Text       string                 `json:"text"`
+	URL        string                 `json:"url,omitempty"`
+	Data       map[string]interface{} `json:"data"`
+	OccurredAt time.Time              `json:"occurred_at"`
+}
+
+// destination is where a matching rule sends the event
+type destination struct {
+	channelID *string
+	kind      string
+	target    string
+	hint      string
+}
+
+// Notify records a delivery for every destination and webhook endpoint subscribed to the event
+// and attempts them in the background, so callers aren't held up by slow channels. A
+// destination subscribed by several rules is notified once. Failed deliveries are retried by
+// RetryDeliveries.
+func (s *NotificationService) Notify(ctx context.Context, event Event) error {
+	if event.Repository == nil {
+		return errors.New("notification event has no repository")
+	}
+
+	rules, err := s.matchingRules(ctx, event)
+	if err != nil {
+		return err
+	}
+	endpoints, err := s.matchingEndpoints(ctx, event)
+	if err != nil {
+		return err
+	}
+	if len(rules) == 0 && len(endpoints) == 0 {
+		return nil
+	}
+
+	message, err := json.Marshal(s.render(event))
+	if err != nil {
+		return err
+	}
+
+	deliveries, err := s.ruleDeliveries(ctx, event, rules, string(message))
+	if err != nil {
+		return err
+	}
+	for _, endpoint := range endpoints {
+		deliveries = append(deliveries, endpointDelivery(endpoint, event.Type, &event.Repository.ID, string(message)))
+	}
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	if err := s.db.WithContext(ctx).Create(&deliveries).Error; err != nil {
+		return err
+	}
+	log.Info().Str("event", event.Type).Str("repo_id", event.Repository.ID).Int("deliveries", len(deliveries)).Msg("[NotificationService.Notify] Queued notifications")
+
+	for _, delivery := range deliveries {
+		go s.deliver(context.Background(), delivery.ID)
+	}
+	return nil
+}
+
+// ruleDeliveries returns a pending delivery of message for each destination of the rules
+func (s *NotificationService) ruleDeliveries(ctx context.Context, event Event, rules []*models.NotificationRule, message string) ([]*models.NotificationDelivery, error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+	channels, err := s.ruleChannels(ctx, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	var author *destination
+	for _, rule := range rules {
+		if rule.NotifyAuthor {
+			email, err := s.authorEmail(ctx, event)
+			if err != nil {
+				return nil, err
+			}
+			if email != "" {
+				author = &destination{kind: models.ChannelEmail, target: email, hint: email}
+			}
+			break
+		}
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool)
+	var deliveries []*models.NotificationDelivery
+	for _, rule := range rules {
+		var dest *destination
+		if rule.NotifyAuthor {
+			dest = author
+		} else if channel, ok := channels[*rule.ChannelID]; ok {
+			dest = &destination{channelID: &channel.ID, kind: channel.Kind, target: channel.Target.String(), hint: channel.TargetHint}
+		}
+		if dest == nil || seen[dest.kind+"|"+dest.target] {
+			continue
+		}
+		seen[dest.kind+"|"+dest.target] = true
+
+		ruleID := rule.ID
+		deliveries = append(deliveries, &models.NotificationDelivery{
+			UserID:        rule.UserID,
+			RuleID:        &ruleID,
+			ChannelID:     dest.channelID,
+			Kind:          dest.kind,
+			Target:        models.EncryptedString(dest.target),
+			TargetHint:    dest.hint,
+			Event:         event.Type,
+			RepoID:        &event.Repository.ID,
+			Message:       message,
+			Status:        models.DeliveryPending,
+			NextAttemptAt: &now,
+		})
+	}
+	return deliveries, nil
+}
+
+// matchingRules returns the enabled rules for the event whose owner can view the repository
+// and whose conditions match
+func (s *NotificationService) matchingRules(ctx context.Context, event Event) ([]*models.NotificationRule, error) {
+	var rules []*models.NotificationRule
+	err := s.db.WithContext(ctx).
+		Where("event = ? AND enabled", event.Type).
+		Where("(repo_id IS NULL OR repo_id = ?)", event.Repository.ID).
+		Where("user_id IN (SELECT user_id FROM public.repository_access WHERE repo_id = ?)", event.Repository.ID).
+		Order("created_at").
+		Find(&rules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matching := rules[:0]
+	for _, rule := range rules {
+		if rule.Decision != "" && !strings.EqualFold(rule.Decision, event.Decision) {
+			continue
+		}
+		if rule.RiskScoreAbove != nil && event.RiskScore <= *rule.RiskScoreAbove {
+			continue
+		}
+		matching = append(matching, rule)
+	}
+	return matching, nil
+}
+
+// ruleChannels loads the channels the rules send to, by ID
+func (s *NotificationService) ruleChannels(ctx context.Context, rules []*models.NotificationRule) (map[string]*models.NotificationChannel, error) {
+	var ids []string
+	for _, rule := range rules {
+		if rule.ChannelID != nil {
+			ids = append(ids, *rule.ChannelID)
+		}
+	}
+	channels := make(map[string]*models.NotificationChannel, len(ids))
+	if len(ids) == 0 {
+		return channels, nil
+	}
+
+	var found []*models.NotificationChannel
+	if err := s.db.WithContext(ctx).Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, err
+	}
+	for _, channel := range found {
+		channels[channel.ID] = channel
+	}
+	return channels, nil
+}
+
+// authorEmail returns the email of the pull request's author when they are a DevPlus user
+// who can view the repository. Authors are matched by their GitHub account, or their linked
+// account on other code hosts.
+func (s *NotificationService) authorEmail(ctx context.Context, event Event) (string, error) {
+	pr := event.PullRequest
+	if pr == nil || pr.AuthorID == nil {
+		return "", nil
+	}
+
+	query := s.db.WithContext(ctx).Where("id IN (SELECT user_id FROM public.repository_access WHERE repo_id = ?)", event.Repository.ID)
+	if event.Repository.IsGithub() {
+		query = query.Where("github_id = ?", *pr.AuthorID)
+	} else {
+		query = query.Where("id IN (SELECT user_id FROM public.code_host_accounts WHERE provider = ? AND external_id = ?)", event.Repository.Provider, *pr.AuthorID)
+	}
+
+	var user models.User
+	result := query.Limit(1).Find(&user)
+	if result.Error != nil {
+		return "", result.Error
+	}
+	return user.Email, nil
+}
+
+// render builds the message for an event, linking to its page on the frontend
+func (s *NotificationService) render(event Event) *Message {
+	repo := event.Repository
+	repoName := repo.Owner + "/" + repo.Name
+	msg := &Message{
+		Event:      event.Type,
+		URL:        fmt.Sprintf("%s/repositories/%s", s.frontendURL, repo.ID),
+		OccurredAt: time.Now().UTC(),
+		Data: map[string]interface{}{
+			"repo_id":    repo.ID,
+			"repository": repoName,
+			"provider":   repo.Provider,
+		},
+	}
+
+	switch event.Type {
+	case models.EventPRAnalyzed:
+		pr := event.PullRequest
+		var number int64
+		var title, author string
+		if pr != nil {
+			number, title, author = derefInt64(pr.Number), derefString(pr.Title), derefString(pr.AuthorName)
+			msg.Data["pr_id"] = pr.ID
+		}
+		msg.Title = fmt.Sprintf("AI review of %s#%d: %s", repoName, number, event.Decision)
+		msg.Text = fmt.Sprintf("%s (by %s)\n\n%s", title, author, truncate(event.Summary))
+		msg.URL = fmt.Sprintf("%s/pull-requests/%s/%d", s.frontendURL, repo.ID, number)
+		msg.Data["pr_number"] = number
+		msg.Data["pr_title"] = title
+		msg.Data["author"] = author
+		msg.Data["decision"] = event.Decision
+		msg.Data["summary"] = event.Summary
+	case models.EventRepoAnalyzed:
+		msg.Title = fmt.Sprintf("Repository analysis of %s completed", repoName)
+		msg.Text = truncate(event.Summary)
+		msg.Data["summary"] = event.Summary
+	case models.EventReleaseRiskCalculated:
+		msg.Title = fmt.Sprintf("Release risk for %s: %d/100", repoName, event.RiskScore)
+		msg.Text = truncate(event.Summary)
+		if suggestion := repo.ReleaseVersionSuggestion; suggestion != nil && suggestion.SuggestedVersion != "" {
+			msg.Text = fmt.Sprintf("Suggested version: %s (%s)\n\n%s", suggestion.SuggestedVersion, suggestion.Bump, msg.Text)
+			msg.Data["suggested_version"] = suggestion.SuggestedVersion
+		}
+		msg.Data["risk_score"] = event.RiskScore
+		msg.Data["risk_breakdown"] = repo.ReleaseRiskBreakdown
+		msg.Data["changelog"] = event.Summary
+	case models.EventSyncCompleted:
+		msg.Title = fmt.Sprintf("Synced %s", repoName)
+		msg.Text = fmt.Sprintf("%d pull requests synced", event.PRCount)
+		msg.Data["pr_count"] = event.PRCount
+	}
+	return msg
+}
+
+func truncate(text string) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) <= summaryLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:summaryLength])) + "…"
+}
+
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func derefInt64(n *int64) int64 {
+	if n == nil {
+		return 0
+	}
+	return *n
+}
@@ -0,0 +1,222 @@
+package notification_service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"syscall"
+	"time"
+
+	"devplus-backend/internal/config"
+)
+
+// errPrivateAddress is returned for webhooks resolving to loopback, private or link-local
+// addresses
+var errPrivateAddress = errors.New("webhook target is not a public address")
+
+// Sender delivers messages to one kind of channel
+type Sender interface {
+	Send(ctx context.Context, target string, msg *Message) error
+}
+
+// permanentError marks failures retrying won't fix, e.g. a deleted webhook
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p) || errors.Is(err, errPrivateAddress)
+}
+
+// slackSender posts to Slack incoming webhooks
+type slackSender struct {
+	client *http.Client
+}
+
+func (s *slackSender) Send(ctx context.Context, target string, msg *Message) error {
+	escape := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace
+	heading := "*" + escape(msg.Title) + "*"
+	if msg.URL != "" {
+		heading = "*<" + msg.URL + "|" + escape(msg.Title) + ">*"
+	}
+	payload := map[string]interface{}{
+		"text": msg.Title, // Shown in notifications and clients without Block Kit
+		"blocks": []interface{}{
+			map[string]interface{}{
+				"type": "section",
+				"text": map[string]string{"type": "mrkdwn", "text": heading + "\n" + escape(msg.Text)},
+			},
+		},
+	}
+	return postJSON(ctx, s.client, target, payload, nil)
+}
+
+// teamsSender posts Adaptive Cards to Microsoft Teams incoming webhooks (Workflows or the
+// legacy Office 365 connector)
+type teamsSender struct {
+	client *http.Client
+}
+
+func (s *teamsSender) Send(ctx context.Context, target string, msg *Message) error {
+	card := map[string]interface{}{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []interface{}{
+			map[string]interface{}{"type": "TextBlock", "text": msg.Title, "weight": "Bolder", "size": "Medium", "wrap": true},
+			map[string]interface{}{"type": "TextBlock", "text": msg.Text, "wrap": true},
+		},
+	}
+	if msg.URL != "" {
+		card["actions"] = []interface{}{
+			map[string]interface{}{"type": "Action.OpenUrl", "title": "Open in DevPlus", "url": msg.URL},
+		}
+	}
+	payload := map[string]interface{}{
+		"type": "message",
+		"attachments": []interface{}{
+			map[string]interface{}{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	}
+	return postJSON(ctx, s.client, target, payload, nil)
+}
+
+// webhookSender posts the message as JSON to generic webhooks
+type webhookSender struct {
+	client *http.Client
+}
+
+func (s *webhookSender) Send(ctx context.Context, target string, msg *Message) error {
+	return postJSON(ctx, s.client, target, msg, map[string]string{"X-DevPlus-Event": msg.Event})
+}
+
+// emailSender sends plain-text emails over SMTP, upgrading to TLS with STARTTLS when the
+// server supports it
+type emailSender struct {
+	addr     string
+	from     string // Header, e.g. DevPlus <devplus@example.com>
+	envelope string // Address only
+	auth     smtp.Auth
+}
+
+func newEmailSender(cfg *config.Config) *emailSender {
+	sender := &emailSender{
+		addr:     net.JoinHostPort(cfg.SMTPHost, cfg.SMTPPort),
+		from:     cfg.SMTPFrom,
+		envelope: cfg.SMTPFrom,
+	}
+	if address, err := mail.ParseAddress(cfg.SMTPFrom); err == nil {
+		sender.envelope = address.Address
+	}
+	if cfg.SMTPUsername != "" {
+		sender.auth = smtp.PlainAuth("", cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPHost)
+	}
+	return sender
+}
+
+func (s *emailSender) Send(ctx context.Context, target string, msg *Message) error {
+	// Titles quote pull request titles; keep them on one header line
+	subject := strings.Join(strings.Fields(msg.Title), " ")
+	body := msg.Text
+	if msg.URL != "" {
+		body += "\n\n" + msg.URL
+	}
+
+	var email bytes.Buffer
+	fmt.Fprintf(&email, "From: %s\r\n", s.from)
+	fmt.Fprintf(&email, "To: %s\r\n", target)
+	fmt.Fprintf(&email, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&email, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	email.WriteString("MIME-Version: 1.0\r\n")
+	email.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	email.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	email.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+
+	// net/smtp takes no context; servers that hang are bounded by the OS connect timeout
+	return smtp.SendMail(s.addr, s.auth, s.envelope, []string{target}, email.Bytes())
+}
+
+// postJSON posts body as JSON. Client errors other than timeouts and rate limits are
+// permanent: the webhook was deleted or rejects the payload.
+func postJSON(ctx context.Context, client *http.Client, target string, body interface{}, headers map[string]string) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return permanent(err)
+	}
+	return postPayload(ctx, client, target, payload, headers)
+}
+
+// postPayload posts an encoded JSON payload, e.g. one that was signed, like postJSON
+func postPayload(ctx context.Context, client *http.Client, target string, payload []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(payload))
+	if err != nil {
+		return permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "DevPlus-Notifications")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	err = fmt.Errorf("webhook responded %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 && resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return permanent(err)
+	}
+	return err
+}
+
+// newWebhookClient returns the HTTP client for webhook channels. Unless private networks are
+// allowed, it refuses to connect to loopback, private and link-local addresses, so channels
+// can't be used to reach internal services. The check runs on the resolved address, covering
+// DNS names and redirects; proxies would hide the destination, so the client connects directly.
+func newWebhookClient(allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !allowPrivate {
+		dialer.Control = func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !publicIP(ip) {
+				return fmt.Errorf("%w: %s", errPrivateAddress, host)
+			}
+			return nil
+		}
+		transport.Proxy = nil
+	}
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: 15 * time.Second, Transport: transport}
+}
+
+func publicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsUnspecified() && !ip.IsMulticast()
+}
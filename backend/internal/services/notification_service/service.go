@@ -0,0 +1,289 @@
+package notification_service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/models"
+)
+
+var (
+	// ErrNotificationNotFound is returned when addressing a channel, rule, webhook endpoint or
+	// dead letter the user doesn't have
+	ErrNotificationNotFound = errors.New("notification channel, rule, endpoint or delivery not found")
+	// ErrInvalidNotificationRequest is returned for invalid channels, rules, endpoints and filters
+	ErrInvalidNotificationRequest = errors.New("invalid notification request")
+)
+
+// NotificationService sends notifications about analysis and risk events to the channels
+// users subscribed with rules and to their signed webhook endpoints, and keeps a delivery
+// log with retries
+type NotificationService struct {
+	db          *gorm.DB
+	frontendURL string
+	client      *http.Client // Webhook endpoints
+	senders     map[string]Sender
+}
+
+func NewNotificationService(database *gorm.DB, cfg *config.Config) *NotificationService {
+	client := newWebhookClient(cfg.PrivateWebhooks)
+	s := &NotificationService{
+		db:          database,
+		frontendURL: strings.TrimSuffix(cfg.FrontendURL, "/"),
+		client:      client,
+		senders: map[string]Sender{
+			models.ChannelSlack:   &slackSender{client: client},
+			models.ChannelTeams:   &teamsSender{client: client},
+			models.ChannelWebhook: &webhookSender{client: client},
+		},
+	}
+	if cfg.SMTPHost != "" && cfg.SMTPFrom != "" {
+		s.senders[models.ChannelEmail] = newEmailSender(cfg)
+	}
+	return s
+}
+
+// ChannelInput is a channel to create
+type ChannelInput struct {
+	Name   string `json:"name"`
+	Kind   string `json:"kind"`
+	Target string `json:"target"` // Webhook URL, or email address for email channels
+}
+
+// CreateChannel validates and stores a notification channel
+func (s *NotificationService) CreateChannel(ctx context.Context, userID string, input ChannelInput) (*models.NotificationChannel, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" {
+		return nil, fmt.Errorf("%w: name is required", ErrInvalidNotificationRequest)
+	}
+	target, hint, err := s.validateTarget(input.Kind, strings.TrimSpace(input.Target))
+	if err != nil {
+		return nil, err
+	}
+
+	channel := &models.NotificationChannel{
+		UserID:     userID,
+		Name:       name,
+		Kind:       input.Kind,
+		Target:     models.EncryptedString(target),
+		TargetHint: hint,
+	}
+	if err := s.db.WithContext(ctx).Create(channel).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("channel_id", channel.ID).Str("kind", channel.Kind).Msg("[NotificationService.CreateChannel] Created channel")
+	return channel, nil
+}
+
+// ListChannels returns the user's channels, newest first
+func (s *NotificationService) ListChannels(ctx context.Context, userID string) ([]*models.NotificationChannel, error) {
+	var channels []*models.NotificationChannel
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&channels).Error; err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
+
+// DeleteChannel deletes one of the user's channels and the rules sending to it
+func (s *NotificationService) DeleteChannel(ctx context.Context, userID string, channelID string) error {
+	if _, err := uuid.Parse(channelID); err != nil {
+		return ErrNotificationNotFound
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", channelID, userID).Delete(&models.NotificationChannel{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// RuleInput is a rule to create. Exactly one of ChannelID and NotifyAuthor must be set.
+type RuleInput struct {
+	Event          string  `json:"event"`
+	RepoID         *string `json:"repo_id"`
+	ChannelID      *string `json:"channel_id"`
+	NotifyAuthor   bool    `json:"notify_author"`
+	Decision       string  `json:"decision"`
+	RiskScoreAbove *int    `json:"risk_score_above"`
+}
+
+// CreateRule validates and stores a subscription rule. The channel must be the user's and the
+// repository one they can view.
+func (s *NotificationService) CreateRule(ctx context.Context, userID string, input RuleInput) (*models.NotificationRule, error) {
+	if !validEvent(input.Event) {
+		return nil, fmt.Errorf("%w: event must be one of %s", ErrInvalidNotificationRequest, strings.Join(models.NotificationEvents, ", "))
+	}
+	if (input.ChannelID == nil) == !input.NotifyAuthor {
+		return nil, fmt.Errorf("%w: set either channel_id or notify_author", ErrInvalidNotificationRequest)
+	}
+	if input.NotifyAuthor && input.Event != models.EventPRAnalyzed {
+		return nil, fmt.Errorf("%w: notify_author is only supported for %s", ErrInvalidNotificationRequest, models.EventPRAnalyzed)
+	}
+	if input.NotifyAuthor && s.senders[models.ChannelEmail] == nil {
+		return nil, fmt.Errorf("%w: notify_author needs email, which is not configured", ErrInvalidNotificationRequest)
+	}
+	decision := strings.ToUpper(strings.TrimSpace(input.Decision))
+	if decision != "" && input.Event != models.EventPRAnalyzed {
+		return nil, fmt.Errorf("%w: decision is only supported for %s", ErrInvalidNotificationRequest, models.EventPRAnalyzed)
+	}
+	if input.RiskScoreAbove != nil {
+		if input.Event != models.EventReleaseRiskCalculated {
+			return nil, fmt.Errorf("%w: risk_score_above is only supported for %s", ErrInvalidNotificationRequest, models.EventReleaseRiskCalculated)
+		}
+		if *input.RiskScoreAbove < 0 || *input.RiskScoreAbove > 100 {
+			return nil, fmt.Errorf("%w: risk_score_above must be between 0 and 100", ErrInvalidNotificationRequest)
+		}
+	}
+
+	if input.ChannelID != nil {
+		if _, err := s.getChannel(ctx, userID, *input.ChannelID); err != nil {
+			return nil, err
+		}
+	}
+	if input.RepoID != nil {
+		canView, err := s.canViewRepository(ctx, userID, *input.RepoID)
+		if err != nil {
+			return nil, err
+		}
+		if !canView {
+			return nil, fmt.Errorf("%w: repository not found", ErrInvalidNotificationRequest)
+		}
+	}
+
+	rule := &models.NotificationRule{
+		UserID:         userID,
+		Event:          input.Event,
+		RepoID:         input.RepoID,
+		ChannelID:      input.ChannelID,
+		NotifyAuthor:   input.NotifyAuthor,
+		Decision:       decision,
+		RiskScoreAbove: input.RiskScoreAbove,
+		Enabled:        true,
+	}
+	if err := s.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("rule_id", rule.ID).Str("event", rule.Event).Msg("[NotificationService.CreateRule] Created rule")
+	return rule, nil
+}
+
+// ListRules returns the user's rules, newest first
+func (s *NotificationService) ListRules(ctx context.Context, userID string) ([]*models.NotificationRule, error) {
+	var rules []*models.NotificationRule
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// DeleteRule deletes one of the user's rules. Its deliveries stay in the log.
+func (s *NotificationService) DeleteRule(ctx context.Context, userID string, ruleID string) error {
+	if _, err := uuid.Parse(ruleID); err != nil {
+		return ErrNotificationNotFound
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", ruleID, userID).Delete(&models.NotificationRule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// ListDeliveries returns the user's most recent deliveries, optionally with the given status
+func (s *NotificationService) ListDeliveries(ctx context.Context, userID string, status string, limit int) ([]*models.NotificationDelivery, error) {
+	if status != "" && status != models.DeliveryPending && status != models.DeliverySent && status != models.DeliveryFailed {
+		return nil, fmt.Errorf("%w: status must be pending, sent or failed", ErrInvalidNotificationRequest)
+	}
+	if limit <= 0 || limit > 200 {
+		limit = 50
+	}
+
+	query := s.db.WithContext(ctx).Where("user_id = ?", userID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	var deliveries []*models.NotificationDelivery
+	if err := query.Order("created_at desc").Limit(limit).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func (s *NotificationService) getChannel(ctx context.Context, userID string, channelID string) (*models.NotificationChannel, error) {
+	if _, err := uuid.Parse(channelID); err != nil {
+		return nil, ErrNotificationNotFound
+	}
+	var channel models.NotificationChannel
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", channelID, userID).Limit(1).Find(&channel)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotificationNotFound
+	}
+	return &channel, nil
+}
+
+// canViewRepository reports whether the user has any role on the repository
+func (s *NotificationService) canViewRepository(ctx context.Context, userID string, repoID string) (bool, error) {
+	if _, err := uuid.Parse(repoID); err != nil {
+		return false, nil
+	}
+	var count int64
+	err := s.db.WithContext(ctx).Table("public.repository_access").Where("repo_id = ? AND user_id = ?", repoID, userID).Count(&count).Error
+	return count > 0, err
+}
+
+// validateTarget checks a channel target for its kind and returns it with a hint that can be
+// shown in listings without revealing webhook credentials
+func (s *NotificationService) validateTarget(kind string, target string) (string, string, error) {
+	switch kind {
+	case models.ChannelSlack, models.ChannelTeams, models.ChannelWebhook:
+		u, err := url.Parse(target)
+		// Generic webhooks may use plain http, e.g. for internal services
+		secure := u != nil && (u.Scheme == "https" || kind == models.ChannelWebhook && u.Scheme == "http")
+		if err != nil || u.Host == "" || !secure {
+			return "", "", fmt.Errorf("%w: target must be an https URL", ErrInvalidNotificationRequest)
+		}
+		if kind == models.ChannelSlack && u.Host != "hooks.slack.com" {
+			return "", "", fmt.Errorf("%w: target must be a Slack incoming webhook URL (https://hooks.slack.com/...)", ErrInvalidNotificationRequest)
+		}
+		return u.String(), u.Scheme + "://" + u.Host + "/…", nil
+	case models.ChannelEmail:
+		if s.senders[models.ChannelEmail] == nil {
+			return "", "", fmt.Errorf("%w: email is not configured", ErrInvalidNotificationRequest)
+		}
+		address, err := mail.ParseAddress(target)
+		if err != nil {
+			return "", "", fmt.Errorf("%w: target must be an email address", ErrInvalidNotificationRequest)
+		}
+		return address.Address, address.Address, nil
+	default:
+		return "", "", fmt.Errorf("%w: kind must be slack, teams, webhook or email", ErrInvalidNotificationRequest)
+	}
+}
+
+func validEvent(event string) bool {
+	for _, e := range models.NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
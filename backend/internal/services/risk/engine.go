@@ -0,0 +1,277 @@
+package risk
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strings"
+
+	"devplus-backend/internal/models"
+)
+
+// Weights used when blending the rule-based score with the AI estimate
+const (
+	RuleWeight = 0.6
+	AIWeight   = 0.4
+)
+
+// Maximum points per factor. They add up to 100.
+const (
+	maxLinesPoints       = 20.0
+	maxFilesPoints       = 15.0
+	maxMigrationPoints   = 15.0
+	maxDependencyPoints  = 10.0
+	maxTestGapPoints     = 15.0
+	maxAIFindingsPoints  = 15.0
+	maxFamiliarityPoints = 10.0
+)
+
+// PRFacts are the observable facts about a pull request that feed the score
+type PRFacts struct {
+	Number       int64
+	Title        string
+	Author       string
+	Additions    int
+	Deletions    int
+	ChangedFiles int
+	Files        []string
+	// AIDecision is the decision from the AI review (APPROVE, REQUEST_CHANGES, COMMENT).
+	// GitHub review states (APPROVED, CHANGES_REQUESTED, COMMENTED) are accepted as well.
+	// The AI review doesn't grade its individual findings, so the decision stands in for
+	// their severity: REQUEST_CHANGES and COMMENT add points, approved and unanalysed PRs none.
+	AIDecision string
+	// AuthorFamiliar is true when the author has prior commits in the paths the PR touches
+	AuthorFamiliar bool
+	// Informational metadata, not used for scoring
+	State        string
+	Labels       []string
+	LinkedIssues []string
+	// BreakingFooter is true when the PR description has a "BREAKING CHANGE:" footer
+	BreakingFooter bool
+}
+
+var (
+	migrationPattern = regexp.MustCompile(`(?i)(^|/)(migrations?|migrate|db/migrate|alembic|flyway|liquibase)/|\.sql$`)
+	testPattern      = regexp.MustCompile(`(?i)(_test\.go$|\.test\.[jt]sx?$|\.spec\.[jt]sx?$|(^|/)tests?/|(^|/)__tests__/|(^|/)test_[^/]+\.py$|_test\.py$|Test\.java$|_spec\.rb$)`)
+	codePattern      = regexp.MustCompile(`(?i)\.(go|js|jsx|ts|tsx|py|rb|java|kt|rs|c|cc|cpp|h|hpp|cs|php|swift|scala)$`)
+)
+
+var dependencyManifests = map[string]bool{
+	"go.mod":            true,
+	"go.sum":            true,
+	"package.json":      true,
+	"package-lock.json": true,
+	"yarn.lock":         true,
+	"pnpm-lock.yaml":    true,
+	"requirements.txt":  true,
+	"pipfile":           true,
+	"pipfile.lock":      true,
+	"poetry.lock":       true,
+	"pyproject.toml":    true,
+	"gemfile":           true,
+	"gemfile.lock":      true,
+	"pom.xml":           true,
+	"build.gradle":      true,
+	"build.gradle.kts":  true,
+	"cargo.toml":        true,
+	"cargo.lock":        true,
+	"composer.json":     true,
+	"composer.lock":     true,
+}
+
+// IsMigrationFile reports whether a path looks like a database migration
+func IsMigrationFile(file string) bool {
+	return migrationPattern.MatchString(file)
+}
+
+// IsDependencyManifest reports whether a path is a dependency manifest or lock file
+func IsDependencyManifest(file string) bool {
+	return dependencyManifests[strings.ToLower(path.Base(file))]
+}
+
+// IsTestFile reports whether a path looks like a test file
+func IsTestFile(file string) bool {
+	return testPattern.MatchString(file)
+}
+
+// Score computes the rule-based release risk for the given pull requests.
+// The result is deterministic for the same input.
+func Score(prs []PRFacts) *models.RiskBreakdown {
+	var (
+		lines         int
+		files         = make(map[string]bool)
+		migrations    = make(map[string]bool)
+		manifests     = make(map[string]bool)
+		testFiles     int
+		codeFiles     int
+		changesReq    int
+		comments      int
+		approved      int
+		unfamiliarPRs int
+		unlistedFiles int
+	)
+
+	for _, pr := range prs {
+		lines += pr.Additions + pr.Deletions
+		for _, f := range pr.Files {
+			if files[f] {
+				continue
+			}
+			files[f] = true
+			switch {
+			case IsMigrationFile(f):
+				migrations[f] = true
+			case IsDependencyManifest(f):
+				manifests[f] = true
+			case IsTestFile(f):
+				testFiles++
+			case codePattern.MatchString(f):
+				codeFiles++
+			}
+		}
+		// Fall back to the count reported by GitHub when the file list wasn't fetched
+		if len(pr.Files) == 0 {
+			unlistedFiles += pr.ChangedFiles
+		}
+
+		switch strings.ToUpper(strings.TrimSpace(pr.AIDecision)) {
+		case "REQUEST_CHANGES", "CHANGES_REQUESTED":
+			changesReq++
+		case "COMMENT", "COMMENTED":
+			comments++
+		case "APPROVE", "APPROVED":
+			approved++
+		}
+
+		if !pr.AuthorFamiliar {
+			unfamiliarPRs++
+		}
+	}
+
+	var contributions []models.RiskContribution
+
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "lines_changed",
+		Description: fmt.Sprintf("%d lines added or removed (1 point per 50 lines)", lines),
+		Value:       float64(lines),
+		Points:      math.Min(maxLinesPoints, float64(lines)/50),
+		MaxPoints:   maxLinesPoints,
+	})
+
+	fileCount := len(files) + unlistedFiles
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "files_touched",
+		Description: fmt.Sprintf("%d files touched (1 point per 4 files)", fileCount),
+		Value:       float64(fileCount),
+		Points:      math.Min(maxFilesPoints, float64(fileCount)/4),
+		MaxPoints:   maxFilesPoints,
+	})
+
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "migrations",
+		Description: fmt.Sprintf("%d database migration files (10 points for the first, 5 for each additional)", len(migrations)),
+		Value:       float64(len(migrations)),
+		Points:      migrationPoints(len(migrations)),
+		MaxPoints:   maxMigrationPoints,
+	})
+
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "dependency_changes",
+		Description: fmt.Sprintf("%d dependency manifests changed (5 points each)", len(manifests)),
+		Value:       float64(len(manifests)),
+		Points:      math.Min(maxDependencyPoints, float64(len(manifests))*5),
+		MaxPoints:   maxDependencyPoints,
+	})
+
+	testRatio := 0.0
+	testGap := 0.0
+	if codeFiles > 0 {
+		testRatio = float64(testFiles) / float64(codeFiles)
+		// A ratio of one test file per two code files or better is considered fully covered
+		testGap = maxTestGapPoints * (1 - math.Min(1, testRatio/0.5))
+	}
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "test_ratio",
+		Description: fmt.Sprintf("%d test files for %d code files (full points when no tests change, none at 1:2 or better)", testFiles, codeFiles),
+		Value:       round2(testRatio),
+		Points:      testGap,
+		MaxPoints:   maxTestGapPoints,
+	})
+
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "ai_findings",
+		Description: fmt.Sprintf("%d PRs with changes requested by AI review (8 points each), %d with comments (3 points each), %d approved and %d not analysed (no points)", changesReq, comments, approved, len(prs)-changesReq-comments-approved),
+		Value:       float64(changesReq + comments),
+		Points:      math.Min(maxAIFindingsPoints, float64(changesReq)*8+float64(comments)*3),
+		MaxPoints:   maxAIFindingsPoints,
+	})
+
+	unfamiliarShare := 0.0
+	if len(prs) > 0 {
+		unfamiliarShare = float64(unfamiliarPRs) / float64(len(prs))
+	}
+	contributions = append(contributions, models.RiskContribution{
+		Factor:      "author_familiarity",
+		Description: fmt.Sprintf("%d of %d PRs by authors without prior commits in the touched paths", unfamiliarPRs, len(prs)),
+		Value:       round2(unfamiliarShare),
+		Points:      maxFamiliarityPoints * unfamiliarShare,
+		MaxPoints:   maxFamiliarityPoints,
+	})
+
+	total := 0.0
+	for i := range contributions {
+		contributions[i].Points = round2(contributions[i].Points)
+		total += contributions[i].Points
+	}
+
+	ruleScore := clampScore(int(math.Round(total)))
+	return &models.RiskBreakdown{
+		Score:         ruleScore,
+		RuleScore:     ruleScore,
+		RuleWeight:    RuleWeight,
+		AIWeight:      AIWeight,
+		PRCount:       len(prs),
+		Contributions: contributions,
+	}
+}
+
+// Blend combines a rule-based breakdown with the score returned by the AI and
+// updates Score in place. A nil breakdown yields the AI score unchanged.
+func Blend(b *models.RiskBreakdown, aiScore int) *models.RiskBreakdown {
+	aiScore = clampScore(aiScore)
+	if b == nil {
+		return &models.RiskBreakdown{
+			Score:      aiScore,
+			AIScore:    &aiScore,
+			RuleWeight: 0,
+			AIWeight:   1,
+		}
+	}
+	b.AIScore = &aiScore
+	b.RuleWeight = RuleWeight
+	b.AIWeight = AIWeight
+	b.Score = clampScore(int(math.Round(RuleWeight*float64(b.RuleScore) + AIWeight*float64(aiScore))))
+	return b
+}
+
+func migrationPoints(n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return math.Min(maxMigrationPoints, 10+float64(n-1)*5)
+}
+
+func clampScore(score int) int {
+	if score < 0 {
+		return 0
+	}
+	if score > 100 {
+		return 100
+	}
+	return score
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,208 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+
+	"devplus-backend/internal/models"
+)
+
+func points(b *models.RiskBreakdown) map[string]float64 {
+	got := make(map[string]float64, len(b.Contributions))
+	for _, c := range b.Contributions {
+		got[c.Factor] = c.Points
+	}
+	return got
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		prs   []PRFacts
+		want  map[string]float64
+		score int
+	}{
+		{
+			name: "no pull requests",
+			want: map[string]float64{
+				"lines_changed": 0, "files_touched": 0, "migrations": 0, "dependency_changes": 0,
+				"test_ratio": 0, "ai_findings": 0, "author_familiarity": 0,
+			},
+			score: 0,
+		},
+		{
+			name: "small familiar change with tests",
+			prs: []PRFacts{{
+				Additions: 40, Deletions: 10, AuthorFamiliar: true, AIDecision: "APPROVE",
+				Files: []string{"api/handler.go", "api/handler_test.go"},
+			}},
+			want: map[string]float64{
+				"lines_changed": 1, "files_touched": 0.5, "migrations": 0, "dependency_changes": 0,
+				"test_ratio": 0, "ai_findings": 0, "author_familiarity": 0,
+			},
+			score: 2,
+		},
+		{
+			name: "migrations, manifests and no tests",
+			prs: []PRFacts{
+				{
+					Additions: 600, Deletions: 100, AIDecision: "REQUEST_CHANGES",
+					Files: []string{"db/migrations/001_init.sql", "db/migrations/002_users.sql", "go.mod", "go.sum", "store/users.go"},
+				},
+				{
+					Additions: 300, Deletions: 100, AuthorFamiliar: true, AIDecision: "COMMENT",
+					Files: []string{"store/users.go", "migrations/003_index.sql", "web/package.json"},
+				},
+			},
+			want: map[string]float64{
+				// 1100 lines hit the 20 point cap
+				"lines_changed": 20,
+				// store/users.go is counted once
+				"files_touched": 1.75,
+				// Three migrations: 10 + 5 + 5, capped at 15
+				"migrations": 15,
+				// Three manifests at 5 points each, capped at 10
+				"dependency_changes": 10,
+				"test_ratio":         15,
+				"ai_findings":        11,
+				"author_familiarity": 5,
+			},
+			score: 78,
+		},
+		{
+			name: "file count falls back to ChangedFiles",
+			prs: []PRFacts{
+				{ChangedFiles: 20, AuthorFamiliar: true},
+				{ChangedFiles: 4, AuthorFamiliar: true, Files: []string{"a.go", "b.go", "c.go", "a_test.go"}},
+			},
+			want: map[string]float64{
+				"lines_changed": 0, "files_touched": 6, "migrations": 0, "dependency_changes": 0,
+				// One test file for three code files is two thirds of the 1:2 target
+				"test_ratio": 5, "ai_findings": 0, "author_familiarity": 0,
+			},
+			score: 11,
+		},
+		{
+			name: "AI findings are capped",
+			prs: []PRFacts{
+				{AIDecision: "REQUEST_CHANGES", AuthorFamiliar: true},
+				{AIDecision: "changes_requested", AuthorFamiliar: true},
+				{AIDecision: "COMMENTED", AuthorFamiliar: true},
+			},
+			want: map[string]float64{
+				"lines_changed": 0, "files_touched": 0, "migrations": 0, "dependency_changes": 0,
+				"test_ratio": 0, "ai_findings": 15, "author_familiarity": 0,
+			},
+			score: 15,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.prs)
+			if !reflect.DeepEqual(points(got), tt.want) {
+				t.Errorf("Score() points = %v, want %v", points(got), tt.want)
+			}
+			if got.RuleScore != tt.score || got.Score != tt.score {
+				t.Errorf("Score() = %d (rule %d), want %d", got.Score, got.RuleScore, tt.score)
+			}
+			if got.PRCount != len(tt.prs) || got.AIScore != nil {
+				t.Errorf("Score() PRCount = %d, AIScore = %v", got.PRCount, got.AIScore)
+			}
+			total := 0.0
+			for _, c := range got.Contributions {
+				total += c.MaxPoints
+			}
+			if total != 100 {
+				t.Errorf("max points add up to %v, want 100", total)
+			}
+		})
+	}
+}
+
+func TestScoreIsDeterministic(t *testing.T) {
+	prs := []PRFacts{
+		{Additions: 123, Deletions: 45, Files: []string{"b.go", "a.go", "package.json"}, AIDecision: "COMMENT"},
+		{Additions: 7, Files: []string{"a.go", "a_test.go"}, AuthorFamiliar: true},
+	}
+	first := Score(prs)
+	for i := 0; i < 10; i++ {
+		if got := Score(prs); !reflect.DeepEqual(got, first) {
+			t.Fatalf("Score() = %+v, want %+v", got, first)
+		}
+	}
+}
+
+func TestBlend(t *testing.T) {
+	tests := []struct {
+		name      string
+		ruleScore int
+		aiScore   int
+		want      int
+	}{
+		{"equal scores", 50, 50, 50},
+		{"rules weigh 60%", 100, 0, 60},
+		{"AI weighs 40%", 0, 100, 40},
+		{"rounds to nearest", 33, 67, 47}, // 19.8 + 26.8 = 46.6
+		{"AI score is clamped high", 80, 250, 88},
+		{"AI score is clamped low", 50, -20, 30},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &models.RiskBreakdown{Score: tt.ruleScore, RuleScore: tt.ruleScore}
+			got := Blend(b, tt.aiScore)
+			if got != b {
+				t.Error("Blend() did not update the breakdown in place")
+			}
+			if got.Score != tt.want {
+				t.Errorf("Blend(%d, %d) = %d, want %d", tt.ruleScore, tt.aiScore, got.Score, tt.want)
+			}
+			if got.RuleScore != tt.ruleScore {
+				t.Errorf("RuleScore = %d, want %d", got.RuleScore, tt.ruleScore)
+			}
+			if got.RuleWeight != RuleWeight || got.AIWeight != AIWeight {
+				t.Errorf("weights = %v/%v, want %v/%v", got.RuleWeight, got.AIWeight, RuleWeight, AIWeight)
+			}
+			if got.AIScore == nil || *got.AIScore != clampScore(tt.aiScore) {
+				t.Errorf("AIScore = %v, want %d", got.AIScore, clampScore(tt.aiScore))
+			}
+		})
+	}
+}
+
+func TestBlendWithoutRuleScore(t *testing.T) {
+	got := Blend(nil, 72)
+	if got.Score != 72 || got.RuleWeight != 0 || got.AIWeight != 1 || got.AIScore == nil || *got.AIScore != 72 {
+		t.Errorf("Blend(nil, 72) = %+v", got)
+	}
+}
+
+func TestFileClassification(t *testing.T) {
+	tests := []struct {
+		file                        string
+		migration, manifest, isTest bool
+	}{
+		{"db/migrations/001_init.sql", true, false, false},
+		{"backend/internal/migrations/024_webhooks.sql", true, false, false},
+		{"alembic/versions/abc.py", true, false, false},
+		{"schema.sql", true, false, false},
+		{"go.mod", false, true, false},
+		{"web/Package.json", false, true, false},
+		{"Cargo.lock", false, true, false},
+		{"internal/risk/engine_test.go", false, false, true},
+		{"src/App.test.tsx", false, false, true},
+		{"tests/test_api.py", false, false, true},
+		{"internal/risk/engine.go", false, false, false},
+		{"docs/migration-guide.md", false, false, false},
+	}
+	for _, tt := range tests {
+		if got := IsMigrationFile(tt.file); got != tt.migration {
+			t.Errorf("IsMigrationFile(%q) = %v, want %v", tt.file, got, tt.migration)
+		}
+		if got := IsDependencyManifest(tt.file); got != tt.manifest {
+			t.Errorf("IsDependencyManifest(%q) = %v, want %v", tt.file, got, tt.manifest)
+		}
+		if got := IsTestFile(tt.file); got != tt.isTest {
+			t.Errorf("IsTestFile(%q) = %v, want %v", tt.file, got, tt.isTest)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package slack_service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"devplus-backend/internal/models"
+)
+
+const (
+	responseEphemeral = "ephemeral"  // Only visible to the user who ran the command
+	responseInChannel = "in_channel" // Visible to the whole channel
+
+	// sectionTextLength is below Slack's 3000 character limit for section text
+	sectionTextLength = 2900
+	// riskFactorCount is how many of the largest risk contributions results list
+	riskFactorCount = 3
+)
+
+// Message is a Slack message with Block Kit blocks; Text is the notification fallback
+type Message struct {
+	ResponseType    string  `json:"response_type,omitempty"`
+	ReplaceOriginal bool    `json:"replace_original"`
+	Text            string  `json:"text"`
+	Blocks          []Block `json:"blocks,omitempty"`
+}
+
+// Block is a Block Kit layout block
+type Block map[string]interface{}
+
+func ephemeral(text string) *Message {
+	return &Message{ResponseType: responseEphemeral, Text: text, Blocks: []Block{section(text)}}
+}
+
+func helpMessage() *Message {
+	text := "*DevPlus commands*\n" +
+		"`/devplus analyze owner/repo#123` - Run the AI review of a pull request\n" +
+		"`/devplus risk owner/repo` - Calculate the release risk of the open pull requests\n" +
+		"`/devplus link` - Link your Slack account to DevPlus\n" +
+		"Analyses need the maintainer role on the repository."
+	return &Message{ResponseType: responseEphemeral, Text: "DevPlus commands", Blocks: []Block{section(text)}}
+}
+
+// analysisMessage posts the AI review of a pull request to the channel
+func (s *SlackService) analysisMessage(repo *models.Repository, pr *models.PullRequest, decision string, summary string) *Message {
+	number := int64(0)
+	if pr.Number != nil {
+		number = *pr.Number
+	}
+	ref := fmt.Sprintf("%s#%d", repoName(repo), number)
+	link := fmt.Sprintf("%s/pull-requests/%s/%d", s.frontendURL, repo.ID, number)
+
+	heading := "*" + mrkdwnLink(link, ref) + "*"
+	if pr.Title != nil {
+		heading += " " + escape(*pr.Title)
+	}
+	if pr.AuthorName != nil {
+		heading += "\nby " + escape(*pr.AuthorName)
+	}
+
+	return &Message{
+		ResponseType: responseInChannel,
+		Text:         fmt.Sprintf("AI review of %s: %s", ref, decision),
+		Blocks: []Block{
+			section(heading),
+			fields("*Decision*\n" + decisionEmoji(decision) + " " + escape(decision)),
+			section(escape(truncate(summary))),
+			actions(
+				linkButton("Open in DevPlus", link),
+				Block{
+					"type":      "button",
+					"action_id": analyzeActionID,
+					"text":      plainText("Analyze again"),
+					"value":     ref,
+				},
+			),
+		},
+	}
+}
+
+// riskEstimateMessage posts the rule-based release risk while the AI analysis runs
+func (s *SlackService) riskEstimateMessage(repo *models.Repository, prCount int, breakdown *models.RiskBreakdown, suggestion *models.VersionSuggestion) *Message {
+	text := fmt.Sprintf("Rule-based release risk for %s: %d/100", repoName(repo), breakdown.RuleScore)
+	blockFields := []string{
+		fmt.Sprintf("*Rule-based risk*\n%d/100", breakdown.RuleScore),
+		fmt.Sprintf("*Pull requests*\n%d open", prCount),
+	}
+	if suggestion != nil && suggestion.SuggestedVersion != "" {
+		blockFields = append(blockFields, fmt.Sprintf("*Suggested version*\n%s (%s)", escape(suggestion.SuggestedVersion), escape(suggestion.Bump)))
+	}
+	return &Message{
+		ResponseType: responseEphemeral,
+		Text:         text,
+		Blocks: []Block{
+			section("*" + escape(text) + "*\nThe AI estimate and changelog follow when the analysis completes."),
+			fields(blockFields...),
+			riskFactors(breakdown),
+		},
+	}
+}
+
+// riskMessage posts the final release risk to the channel
+func (s *SlackService) riskMessage(repo *models.Repository, changelog string) *Message {
+	link := fmt.Sprintf("%s/repositories/%s", s.frontendURL, repo.ID)
+	text := fmt.Sprintf("Release risk for %s: %d/100", repoName(repo), repo.ReleaseRiskScore)
+	blockFields := []string{fmt.Sprintf("*Risk score*\n%s %d/100", riskEmoji(repo.ReleaseRiskScore), repo.ReleaseRiskScore)}
+	if suggestion := repo.ReleaseVersionSuggestion; suggestion != nil && suggestion.SuggestedVersion != "" {
+		blockFields = append(blockFields, fmt.Sprintf("*Suggested version*\n%s (%s)", escape(suggestion.SuggestedVersion), escape(suggestion.Bump)))
+	}
+
+	blocks := []Block{
+		{"type": "header", "text": plainText(text)},
+		fields(blockFields...),
+	}
+	if repo.ReleaseRiskBreakdown != nil {
+		blocks = append(blocks, riskFactors(repo.ReleaseRiskBreakdown))
+	}
+	if changelog = strings.TrimSpace(changelog); changelog != "" {
+		blocks = append(blocks, section("*Changelog*\n"+escape(truncate(changelog))))
+	}
+	blocks = append(blocks, actions(linkButton("Open in DevPlus", link)))
+
+	return &Message{ResponseType: responseInChannel, Text: text, Blocks: blocks}
+}
+
+// riskFactors lists the largest contributions to a rule-based score as a context block
+func riskFactors(breakdown *models.RiskBreakdown) Block {
+	contributions := append([]models.RiskContribution(nil), breakdown.Contributions...)
+	sort.Slice(contributions, func(i, j int) bool { return contributions[i].Points > contributions[j].Points })
+
+	var lines []string
+	for _, c := range contributions {
+		if len(lines) == riskFactorCount || c.Points <= 0 {
+			break
+		}
+		lines = append(lines, fmt.Sprintf("%s: +%.0f", escape(c.Description), c.Points))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "No risk factors found")
+	}
+	return Block{
+		"type":     "context",
+		"elements": []Block{{"type": "mrkdwn", "text": strings.Join(lines, "\n")}},
+	}
+}
+
+func section(text string) Block {
+	return Block{"type": "section", "text": Block{"type": "mrkdwn", "text": text}}
+}
+
+func fields(texts ...string) Block {
+	blockFields := make([]Block, 0, len(texts))
+	for _, text := range texts {
+		blockFields = append(blockFields, Block{"type": "mrkdwn", "text": text})
+	}
+	return Block{"type": "section", "fields": blockFields}
+}
+
+func actions(elements ...Block) Block {
+	return Block{"type": "actions", "elements": elements}
+}
+
+func linkButton(text string, url string) Block {
+	return Block{"type": "button", "text": plainText(text), "url": url}
+}
+
+func plainText(text string) Block {
+	return Block{"type": "plain_text", "text": text}
+}
+
+func mrkdwnLink(url string, text string) string {
+	return "<" + url + "|" + escape(text) + ">"
+}
+
+// escape escapes the characters Slack treats as markup in mrkdwn text
+func escape(text string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(text)
+}
+
+func truncate(text string) string {
+	text = strings.TrimSpace(text)
+	runes := []rune(text)
+	if len(runes) <= sectionTextLength {
+		return text
+	}
+	return strings.TrimSpace(string(runes[:sectionTextLength])) + "…"
+}
+
+func decisionEmoji(decision string) string {
+	switch strings.ToUpper(decision) {
+	case "APPROVE":
+		return ":white_check_mark:"
+	case "REQUEST_CHANGES":
+		return ":x:"
+	default:
+		return ":speech_balloon:"
+	}
+}
+
+func riskEmoji(score int) string {
+	switch {
+	case score >= 70:
+		return ":red_circle:"
+	case score >= 40:
+		return ":large_orange_circle:"
+	default:
+		return ":large_green_circle:"
+	}
+}
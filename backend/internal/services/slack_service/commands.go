@@ -0,0 +1,289 @@
+package slack_service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/workspace_service"
+)
+
+// responseURLTTL is how long Slack accepts messages posted to a command's response URL
+const responseURLTTL = 30 * time.Minute
+
+// analyzeActionID is the action of the "Analyze again" button on analysis results
+const analyzeActionID = "devplus_analyze"
+
+// Command is a /devplus slash command invocation
+type Command struct {
+	TeamID      string
+	UserID      string
+	UserName    string
+	Text        string
+	ResponseURL string
+}
+
+// Interaction is a click on a button of a DevPlus message
+type Interaction struct {
+	TeamID      string
+	UserID      string
+	UserName    string
+	ActionID    string
+	Value       string
+	ResponseURL string
+}
+
+// HandleCommand runs a /devplus command and returns the immediate reply. Slack expects it
+// within three seconds, so analyses are triggered in the background and their results posted
+// to the response URL once they complete.
+func (s *SlackService) HandleCommand(ctx context.Context, cmd Command) (*Message, error) {
+	fields := strings.Fields(cmd.Text)
+	if len(fields) == 0 || strings.EqualFold(fields[0], "help") {
+		return helpMessage(), nil
+	}
+
+	subcommand := strings.ToLower(fields[0])
+	if subcommand == "link" {
+		return s.linkMessage(cmd, "Link your DevPlus account to run commands as yourself.")
+	}
+
+	user, err := s.linkedUser(ctx, cmd.TeamID, cmd.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return s.linkMessage(cmd, "Your Slack account isn't linked to DevPlus yet.")
+	}
+
+	switch subcommand {
+	case models.SlackCommandAnalyze:
+		if len(fields) != 2 {
+			return ephemeral("Usage: `/devplus analyze owner/repo#123`"), nil
+		}
+		return s.analyze(ctx, user, fields[1], cmd.ResponseURL)
+	case models.SlackCommandRisk:
+		if len(fields) != 2 {
+			return ephemeral("Usage: `/devplus risk owner/repo`"), nil
+		}
+		return s.risk(ctx, user, fields[1], cmd.ResponseURL)
+	default:
+		return helpMessage(), nil
+	}
+}
+
+// HandleInteraction runs the command behind a button and posts the reply to the response URL,
+// since Slack ignores the response body of button clicks
+func (s *SlackService) HandleInteraction(ctx context.Context, interaction Interaction) error {
+	if interaction.ActionID != analyzeActionID {
+		return nil
+	}
+	reply, err := s.HandleCommand(ctx, Command{
+		TeamID:      interaction.TeamID,
+		UserID:      interaction.UserID,
+		UserName:    interaction.UserName,
+		Text:        models.SlackCommandAnalyze + " " + interaction.Value,
+		ResponseURL: interaction.ResponseURL,
+	})
+	if err != nil {
+		return err
+	}
+	return s.postResponse(ctx, interaction.ResponseURL, reply)
+}
+
+// analyze triggers the AI review of a pull request
+func (s *SlackService) analyze(ctx context.Context, user *models.User, ref string, responseURL string) (*Message, error) {
+	repoRef, numberRef, ok := strings.Cut(ref, "#")
+	number, err := strconv.Atoi(numberRef)
+	if !ok || err != nil || number <= 0 {
+		return ephemeral("Usage: `/devplus analyze owner/repo#123`"), nil
+	}
+	repo, reply, err := s.maintainedRepository(ctx, user, repoRef)
+	if repo == nil {
+		return reply, err
+	}
+
+	prNumber := int64(number)
+	pending, err := s.awaitResult(ctx, user.ID, models.SlackCommandAnalyze, repo.ID, &prNumber, responseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ctx := context.Background()
+		if err := s.analyzer.AnalyzePullRequest(ctx, repo.ID, number); err != nil {
+			log.Error().Err(err).Str("repo_id", repo.ID).Int("pr_number", number).Msg("[SlackService.analyze] Failed to trigger analysis")
+			s.cancelResult(ctx, pending)
+			s.postResponse(ctx, responseURL, ephemeral(fmt.Sprintf(":warning: Couldn't start the analysis of %s#%d: %s", repoName(repo), number, err)))
+		}
+	}()
+
+	return ephemeral(fmt.Sprintf(":hourglass_flowing_sand: Analyzing %s#%d. The review will be posted here when it's ready.", repoName(repo), number)), nil
+}
+
+// risk calculates the release risk of the repository's open pull requests
+func (s *SlackService) risk(ctx context.Context, user *models.User, ref string, responseURL string) (*Message, error) {
+	repo, reply, err := s.maintainedRepository(ctx, user, ref)
+	if repo == nil {
+		return reply, err
+	}
+	if !repo.IsGithub() {
+		return ephemeral(fmt.Sprintf("%s isn't on GitHub. Release risk is only available for GitHub repositories.", repoName(repo))), nil
+	}
+
+	prs, err := s.analyzer.GetPullRequestsByRepoID(ctx, repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	var prIDs []string
+	for _, pr := range prs {
+		if pr.State != nil && (*pr.State == models.PRStateOpen || *pr.State == models.PRStateDraft) {
+			prIDs = append(prIDs, pr.ID)
+		}
+	}
+	if len(prIDs) == 0 {
+		return ephemeral(fmt.Sprintf("%s has no open pull requests. Sync it in DevPlus if that looks wrong.", repoName(repo))), nil
+	}
+
+	token, err := s.tokens.GithubToken(ctx, user)
+	if err != nil {
+		return ephemeral(":warning: Your GitHub authorization expired or was revoked. Log in to DevPlus again, then retry."), nil
+	}
+
+	pending, err := s.awaitResult(ctx, user.ID, models.SlackCommandRisk, repo.ID, nil, responseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		ctx := context.Background()
+		breakdown, suggestion, err := s.analyzer.CalculateReleaseRisk(ctx, user.ID, repo.ID, prIDs, token)
+		if err != nil {
+			log.Error().Err(err).Str("repo_id", repo.ID).Msg("[SlackService.risk] Failed to start release risk analysis")
+			s.cancelResult(ctx, pending)
+			s.postResponse(ctx, responseURL, ephemeral(fmt.Sprintf(":warning: Couldn't start the release risk analysis of %s: %s", repoName(repo), err)))
+			return
+		}
+		s.postResponse(ctx, responseURL, s.riskEstimateMessage(repo, len(prIDs), breakdown, suggestion))
+	}()
+
+	return ephemeral(fmt.Sprintf(":hourglass_flowing_sand: Calculating the release risk of %d open pull requests in %s.", len(prIDs), repoName(repo))), nil
+}
+
+// maintainedRepository finds a repository by owner/name among those the user can access and
+// checks they may trigger analyses on it. Without a repository, it returns the reply to send.
+func (s *SlackService) maintainedRepository(ctx context.Context, user *models.User, ref string) (*models.Repository, *Message, error) {
+	slash := strings.LastIndex(ref, "/")
+	if slash <= 0 || slash == len(ref)-1 {
+		return nil, ephemeral(fmt.Sprintf("`%s` isn't a repository. Use `owner/repo`.", ref)), nil
+	}
+	owner, name := ref[:slash], ref[slash+1:]
+
+	var repo models.Repository
+	result := s.db.WithContext(ctx).
+		Where("LOWER(owner) = LOWER(?) AND LOWER(name) = LOWER(?) AND deleted_at IS NULL", owner, name).
+		Where("id IN (SELECT repo_id FROM public.repository_access WHERE user_id = ?)", user.ID).
+		Order("created_at").
+		Limit(1).
+		Find(&repo)
+	if result.Error != nil {
+		return nil, nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ephemeral(fmt.Sprintf("Repository `%s` not found. Sync it in DevPlus first.", ref)), nil
+	}
+
+	if err := s.authorizer.AuthorizeRepository(ctx, user.ID, repo.ID, models.RoleMaintainer); err != nil {
+		if errors.Is(err, workspace_service.ErrForbidden) {
+			return nil, ephemeral(fmt.Sprintf("You need the maintainer role on %s to run analyses.", repoName(&repo))), nil
+		}
+		return nil, nil, err
+	}
+	return &repo, nil, nil
+}
+
+// awaitResult records that the command's result should be posted to its response URL
+func (s *SlackService) awaitResult(ctx context.Context, userID string, command string, repoID string, prNumber *int64, responseURL string) (*models.SlackCommandResponse, error) {
+	pending := &models.SlackCommandResponse{
+		UserID:      userID,
+		Command:     command,
+		RepoID:      repoID,
+		PRNumber:    prNumber,
+		ResponseURL: models.EncryptedString(responseURL),
+		ExpiresAt:   time.Now().Add(responseURLTTL),
+	}
+	if err := s.db.WithContext(ctx).Create(pending).Error; err != nil {
+		return nil, err
+	}
+	return pending, nil
+}
+
+// cancelResult forgets a command whose analysis couldn't be started
+func (s *SlackService) cancelResult(ctx context.Context, pending *models.SlackCommandResponse) {
+	if err := s.db.WithContext(ctx).Delete(pending).Error; err != nil {
+		log.Error().Err(err).Str("response_id", pending.ID).Msg("[SlackService.cancelResult] Failed to delete pending response")
+	}
+}
+
+// postResponse posts a message to a command's response URL. Failures are logged: there's no
+// one left to report them to.
+func (s *SlackService) postResponse(ctx context.Context, responseURL string, msg *Message) error {
+	if u, err := url.Parse(responseURL); err != nil || u.Scheme != "https" || u.Host != "hooks.slack.com" {
+		log.Warn().Msg("[SlackService.postResponse] Ignoring response URL outside hooks.slack.com")
+		return nil
+	}
+
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, responseURL, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		log.Error().Err(err).Msg("[SlackService.postResponse] Failed to post response")
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("slack responded %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		log.Error().Err(err).Msg("[SlackService.postResponse] Failed to post response")
+		return err
+	}
+	return nil
+}
+
+// linkMessage replies with a button to link the Slack user to their DevPlus account
+func (s *SlackService) linkMessage(cmd Command, text string) (*Message, error) {
+	linkURL, err := s.linkURL(cmd.TeamID, cmd.UserID, cmd.UserName)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		ResponseType: responseEphemeral,
+		Text:         text,
+		Blocks: []Block{
+			section(text + " The link is valid for 15 minutes."),
+			actions(linkButton("Link DevPlus account", linkURL)),
+		},
+	}, nil
+}
+
+func repoName(repo *models.Repository) string {
+	return repo.Owner + "/" + repo.Name
+}
@@ -0,0 +1,60 @@
+package slack_service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm/clause"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/services/notification_service"
+)
+
+// Notify posts analysis results to the /devplus commands waiting for them. Waiting commands
+// are claimed by deleting them, so each result is posted once; expired ones are dropped.
+func (s *SlackService) Notify(ctx context.Context, event notification_service.Event) error {
+	if event.Repository == nil {
+		return nil
+	}
+
+	query := s.db.WithContext(ctx).Clauses(clause.Returning{}).Where("repo_id = ?", event.Repository.ID)
+	switch event.Type {
+	case models.EventPRAnalyzed:
+		if event.PullRequest == nil || event.PullRequest.Number == nil {
+			return nil
+		}
+		query = query.Where("command = ? AND pr_number = ?", models.SlackCommandAnalyze, *event.PullRequest.Number)
+	case models.EventReleaseRiskCalculated:
+		query = query.Where("command = ?", models.SlackCommandRisk)
+	default:
+		return nil
+	}
+
+	var waiting []*models.SlackCommandResponse
+	if err := query.Delete(&waiting).Error; err != nil {
+		return err
+	}
+	if err := s.db.WithContext(ctx).Where("expires_at < ?", time.Now()).Delete(&models.SlackCommandResponse{}).Error; err != nil {
+		log.Warn().Err(err).Msg("[SlackService.Notify] Failed to delete expired command responses")
+	}
+
+	var msg *Message
+	if event.Type == models.EventPRAnalyzed {
+		msg = s.analysisMessage(event.Repository, event.PullRequest, event.Decision, event.Summary)
+	} else {
+		msg = s.riskMessage(event.Repository, event.Summary)
+	}
+
+	// Several users may have asked for the same result; post it to each of their channels
+	posted := make(map[string]bool)
+	for _, response := range waiting {
+		responseURL := response.ResponseURL.String()
+		if response.ExpiresAt.Before(time.Now()) || posted[responseURL] {
+			continue
+		}
+		posted[responseURL] = true
+		go s.postResponse(context.Background(), responseURL, msg)
+	}
+	return nil
+}
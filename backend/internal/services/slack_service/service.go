@@ -0,0 +1,209 @@
+package slack_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"devplus-backend/internal/config"
+	"devplus-backend/internal/models"
+)
+
+const (
+	// requestMaxAge rejects replayed requests, as recommended by Slack
+	requestMaxAge = 5 * time.Minute
+	// linkTokenTTL is how long a /devplus link URL can be used
+	linkTokenTTL = 15 * time.Minute
+	// linkTokenAudience keeps link tokens from being mistaken for other tokens signed with the secret
+	linkTokenAudience = "devplus-slack-link"
+)
+
+var (
+	// ErrInvalidSignature is returned for requests not signed with the signing secret, or too
+	// old, and for every request while no secret is configured
+	ErrInvalidSignature = errors.New("invalid slack request signature")
+	// ErrInvalidLinkToken is returned for unknown or expired account link tokens
+	ErrInvalidLinkToken = errors.New("invalid or expired slack link token")
+	// ErrSlackAccountNotFound is returned when unlinking an account the user doesn't have
+	ErrSlackAccountNotFound = errors.New("slack account not found")
+)
+
+// PullRequestAnalyzer triggers AI analyses
+type PullRequestAnalyzer interface {
+	AnalyzePullRequest(ctx context.Context, repoID string, prNumber int) error
+	CalculateReleaseRisk(ctx context.Context, userID string, repoID string, prIDs []string, token string) (*models.RiskBreakdown, *models.VersionSuggestion, error)
+	GetPullRequestsByRepoID(ctx context.Context, repoID string) ([]*models.PullRequest, error)
+}
+
+// RepositoryAuthorizer checks the user's role on a repository
+type RepositoryAuthorizer interface {
+	AuthorizeRepository(ctx context.Context, userID string, repoID string, minRole string) error
+}
+
+// GithubTokens returns users' GitHub access tokens, refreshing them when needed
+type GithubTokens interface {
+	GithubToken(ctx context.Context, user *models.User) (string, error)
+}
+
+// SlackService serves the /devplus Slack command. Commands run as the DevPlus user the Slack
+// user linked, with their repository roles, and results are posted back as Block Kit messages.
+type SlackService struct {
+	db            *gorm.DB
+	signingSecret string
+	frontendURL   string
+	analyzer      PullRequestAnalyzer
+	authorizer    RepositoryAuthorizer
+	tokens        GithubTokens
+	client        *http.Client
+}
+
+func NewSlackService(database *gorm.DB, cfg *config.Config, analyzer PullRequestAnalyzer, authorizer RepositoryAuthorizer, tokens GithubTokens) *SlackService {
+	return &SlackService{
+		db:            database,
+		signingSecret: cfg.SlackSigningSecret,
+		frontendURL:   strings.TrimSuffix(cfg.FrontendURL, "/"),
+		analyzer:      analyzer,
+		authorizer:    authorizer,
+		tokens:        tokens,
+		client:        &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// VerifyRequest checks Slack's v0 request signature, an HMAC-SHA256 of the timestamp and body
+// keyed with the signing secret
+func (s *SlackService) VerifyRequest(r *http.Request, body []byte) error {
+	if s.signingSecret == "" {
+		return ErrInvalidSignature
+	}
+	timestamp := r.Header.Get("X-Slack-Request-Timestamp")
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	if age := time.Since(time.Unix(seconds, 0)); age > requestMaxAge || age < -requestMaxAge {
+		return ErrInvalidSignature
+	}
+
+	mac := hmac.New(sha256.New, []byte(s.signingSecret))
+	mac.Write([]byte("v0:" + timestamp + ":"))
+	mac.Write(body)
+	expected := "v0=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(r.Header.Get("X-Slack-Signature"))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// linkClaims identify the Slack user a link token was issued to
+type linkClaims struct {
+	TeamID        string `json:"team_id"`
+	SlackUserID   string `json:"slack_user_id"`
+	SlackUsername string `json:"slack_username"`
+	jwt.RegisteredClaims
+}
+
+// linkURL returns the frontend URL where the Slack user confirms linking their DevPlus account
+func (s *SlackService) linkURL(teamID string, slackUserID string, slackUsername string) (string, error) {
+	now := time.Now()
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, linkClaims{
+		TeamID:        teamID,
+		SlackUserID:   slackUserID,
+		SlackUsername: slackUsername,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Audience:  jwt.ClaimStrings{linkTokenAudience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(linkTokenTTL)),
+		},
+	}).SignedString([]byte(s.signingSecret))
+	if err != nil {
+		return "", err
+	}
+	return s.frontendURL + "/slack/link?token=" + token, nil
+}
+
+// LinkAccount links the Slack user a link token was issued to with the DevPlus user. A Slack
+// user linked before is moved to the new DevPlus user.
+func (s *SlackService) LinkAccount(ctx context.Context, userID string, token string) (*models.SlackAccount, error) {
+	if s.signingSecret == "" {
+		return nil, ErrInvalidLinkToken
+	}
+	var claims linkClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return []byte(s.signingSecret), nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}), jwt.WithAudience(linkTokenAudience), jwt.WithExpirationRequired())
+	if err != nil || claims.TeamID == "" || claims.SlackUserID == "" {
+		return nil, ErrInvalidLinkToken
+	}
+
+	account := &models.SlackAccount{
+		UserID:        userID,
+		TeamID:        claims.TeamID,
+		SlackUserID:   claims.SlackUserID,
+		SlackUsername: claims.SlackUsername,
+	}
+	err = s.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "team_id"}, {Name: "slack_user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"user_id", "slack_username"}),
+	}).Create(account).Error
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.WithContext(ctx).Where("team_id = ? AND slack_user_id = ?", claims.TeamID, claims.SlackUserID).First(account).Error; err != nil {
+		return nil, err
+	}
+
+	log.Info().Str("user_id", userID).Str("team_id", claims.TeamID).Str("slack_user_id", claims.SlackUserID).Msg("[SlackService.LinkAccount] Linked Slack account")
+	return account, nil
+}
+
+// ListAccounts returns the Slack accounts linked to the user
+func (s *SlackService) ListAccounts(ctx context.Context, userID string) ([]*models.SlackAccount, error) {
+	var accounts []*models.SlackAccount
+	if err := s.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at desc").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// UnlinkAccount removes one of the user's linked Slack accounts
+func (s *SlackService) UnlinkAccount(ctx context.Context, userID string, accountID string) error {
+	if _, err := uuid.Parse(accountID); err != nil {
+		return ErrSlackAccountNotFound
+	}
+	result := s.db.WithContext(ctx).Where("id = ? AND user_id = ?", accountID, userID).Delete(&models.SlackAccount{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrSlackAccountNotFound
+	}
+	return nil
+}
+
+// linkedUser returns the DevPlus user linked to the Slack user, or nil
+func (s *SlackService) linkedUser(ctx context.Context, teamID string, slackUserID string) (*models.User, error) {
+	var user models.User
+	result := s.db.WithContext(ctx).
+		Where("id IN (SELECT user_id FROM public.slack_accounts WHERE team_id = ? AND slack_user_id = ?)", teamID, slackUserID).
+		Limit(1).
+		Find(&user)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &user, nil
+}
@@ -0,0 +1,127 @@
+package slack_service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"devplus-backend/internal/config"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func slackSign(secret string, timestamp string, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("v0:" + timestamp + ":" + body))
+	return "v0=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := "token=x&team_id=T1&user_id=U1&command=%2Fdevplus&text=analyze+acme%2Fapi%2312"
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	stale := strconv.FormatInt(time.Now().Add(-6*time.Minute).Unix(), 10)
+	future := strconv.FormatInt(time.Now().Add(6*time.Minute).Unix(), 10)
+	recent := strconv.FormatInt(time.Now().Add(-4*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		signature string
+		body      string
+		ok        bool
+	}{
+		{"valid", testSigningSecret, now, slackSign(testSigningSecret, now, body), body, true},
+		{"within the replay window", testSigningSecret, recent, slackSign(testSigningSecret, recent, body), body, true},
+		{"other secret", testSigningSecret, now, slackSign("other", now, body), body, false},
+		{"tampered body", testSigningSecret, now, slackSign(testSigningSecret, now, body), body + "&x=1", false},
+		{"timestamp not covered by the signature", testSigningSecret, recent, slackSign(testSigningSecret, now, body), body, false},
+		{"replayed", testSigningSecret, stale, slackSign(testSigningSecret, stale, body), body, false},
+		{"from the future", testSigningSecret, future, slackSign(testSigningSecret, future, body), body, false},
+		{"missing timestamp", testSigningSecret, "", slackSign(testSigningSecret, "", body), body, false},
+		{"missing signature", testSigningSecret, now, "", body, false},
+		{"unsupported version", testSigningSecret, now, "v1=" + slackSign(testSigningSecret, now, body)[3:], body, false},
+		{"no secret configured", "", now, slackSign("", now, body), body, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSlackService(nil, &config.Config{SlackSigningSecret: tt.secret}, nil, nil, nil)
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/slack/commands", nil)
+			r.Header.Set("X-Slack-Request-Timestamp", tt.timestamp)
+			r.Header.Set("X-Slack-Signature", tt.signature)
+
+			err := s.VerifyRequest(r, []byte(tt.body))
+			if tt.ok && err != nil {
+				t.Errorf("VerifyRequest() = %v, want nil", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("VerifyRequest() = %v, want ErrInvalidSignature", err)
+			}
+		})
+	}
+}
+
+func TestLinkAccountRejectsInvalidTokens(t *testing.T) {
+	s := NewSlackService(nil, &config.Config{SlackSigningSecret: testSigningSecret, FrontendURL: "https://devplus.example.com/"}, nil, nil, nil)
+
+	link, err := s.linkURL("T1", "U1", "alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Host != "devplus.example.com" || parsed.Path != "/slack/link" {
+		t.Fatalf("linkURL() = %q", link)
+	}
+	valid := parsed.Query().Get("token")
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+		token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+	claims := func(audience string, expiresAt time.Time) linkClaims {
+		return linkClaims{
+			TeamID:      "T1",
+			SlackUserID: "U1",
+			RegisteredClaims: jwt.RegisteredClaims{
+				Audience:  jwt.ClaimStrings{audience},
+				ExpiresAt: jwt.NewNumericDate(expiresAt),
+			},
+		}
+	}
+	later := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name    string
+		service *SlackService
+		token   string
+	}{
+		{"garbage", s, "not-a-token"},
+		{"signed with another secret", s, sign(jwt.SigningMethodHS256, []byte("other"), claims(linkTokenAudience, later))},
+		{"other audience", s, sign(jwt.SigningMethodHS256, []byte(testSigningSecret), claims("devplus", later))},
+		{"expired", s, sign(jwt.SigningMethodHS256, []byte(testSigningSecret), claims(linkTokenAudience, time.Now().Add(-time.Minute)))},
+		{"without expiry", s, sign(jwt.SigningMethodHS256, []byte(testSigningSecret), linkClaims{TeamID: "T1", SlackUserID: "U1", RegisteredClaims: jwt.RegisteredClaims{Audience: jwt.ClaimStrings{linkTokenAudience}}})},
+		{"without Slack user", s, sign(jwt.SigningMethodHS256, []byte(testSigningSecret), linkClaims{TeamID: "T1", RegisteredClaims: claims(linkTokenAudience, later).RegisteredClaims})},
+		{"alg none", s, sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, claims(linkTokenAudience, later))},
+		{"no secret configured", NewSlackService(nil, &config.Config{}, nil, nil, nil), valid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.service.LinkAccount(context.Background(), "user-1", tt.token); !errors.Is(err, ErrInvalidLinkToken) {
+				t.Errorf("LinkAccount() = %v, want ErrInvalidLinkToken", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package versioning
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"devplus-backend/internal/models"
+)
+
+// Bump levels, ordered by severity
+const (
+	BumpNone  = "none"
+	BumpPatch = "patch"
+	BumpMinor = "minor"
+	BumpMajor = "major"
+)
+
+var bumpRank = map[string]int{BumpNone: 0, BumpPatch: 1, BumpMinor: 2, BumpMajor: 3}
+
+var (
+	semverPattern       = regexp.MustCompile(`^(v?)(\d+)\.(\d+)\.(\d+)(?:-([0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*))?(?:\+[0-9A-Za-z-]+(?:\.[0-9A-Za-z-]+)*)?$`)
+	conventionalPattern = regexp.MustCompile(`^(\w+)(?:\([^)]*\))?(!)?:\s`)
+	aiBreakingPattern   = regexp.MustCompile(`(?i)\bbreaking[ -]changes?\b`)
+	aiNoBreakingPattern = regexp.MustCompile(`(?i)\b(no|none|without)\b[^.\n]{0,20}\bbreaking[ -]changes?\b|\bbreaking[ -]changes?\b\W{0,5}(none|n/a)\b`)
+)
+
+// Version is a parsed semantic version. Prefix keeps a leading "v" so the
+// suggestion follows the repository's tagging convention. Build metadata is dropped since it
+// doesn't affect precedence.
+type Version struct {
+	Prefix     string
+	Major      int
+	Minor      int
+	Patch      int
+	PreRelease string // e.g. "rc.1"; empty for releases
+}
+
+func (v Version) String() string {
+	s := fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+	if v.PreRelease != "" {
+		s += "-" + v.PreRelease
+	}
+	return s
+}
+
+// Less reports whether v sorts before o, following SemVer 2.0.0 §11: a pre-release sorts
+// before its release, and pre-release identifiers are compared one by one
+func (v Version) Less(o Version) bool {
+	if v.Major != o.Major {
+		return v.Major < o.Major
+	}
+	if v.Minor != o.Minor {
+		return v.Minor < o.Minor
+	}
+	if v.Patch != o.Patch {
+		return v.Patch < o.Patch
+	}
+	if v.PreRelease == "" || o.PreRelease == "" {
+		return v.PreRelease != "" && o.PreRelease == ""
+	}
+
+	a, b := strings.Split(v.PreRelease, "."), strings.Split(o.PreRelease, ".")
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] == b[i] {
+			continue
+		}
+		an, aErr := strconv.ParseUint(a[i], 10, 64)
+		bn, bErr := strconv.ParseUint(b[i], 10, 64)
+		switch {
+		case aErr == nil && bErr == nil:
+			return an < bn
+		case aErr == nil || bErr == nil:
+			// Numeric identifiers sort before alphanumeric ones
+			return aErr == nil
+		default:
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// Parse parses tags like "v1.2.3" or "1.2.3-rc.1"
+func Parse(tag string) (Version, bool) {
+	m := semverPattern.FindStringSubmatch(strings.TrimSpace(tag))
+	if m == nil {
+		return Version{}, false
+	}
+	major, _ := strconv.Atoi(m[2])
+	minor, _ := strconv.Atoi(m[3])
+	patch, _ := strconv.Atoi(m[4])
+	return Version{Prefix: m[1], Major: major, Minor: minor, Patch: patch, PreRelease: m[5]}, true
+}
+
+// Latest returns the highest semantic version among tags
+func Latest(tags []string) (Version, bool) {
+	var latest Version
+	found := false
+	for _, tag := range tags {
+		v, ok := Parse(tag)
+		if !ok {
+			continue
+		}
+		if !found || latest.Less(v) {
+			latest = v
+			found = true
+		}
+	}
+	return latest, found
+}
+
+// Change describes a pull request included in the release
+type Change struct {
+	Number         int64
+	Title          string
+	Labels         []string
+	BreakingFooter bool
+	AISummary      string
+}
+
+// Classify returns the bump a single change requires and why
+func Classify(c Change) (string, string) {
+	ref := fmt.Sprintf("PR #%d", c.Number)
+
+	// Breaking changes win regardless of where they were detected
+	if m := conventionalPattern.FindStringSubmatch(c.Title); m != nil && m[2] == "!" {
+		return BumpMajor, fmt.Sprintf("%s is marked breaking in its title (%q)", ref, c.Title)
+	}
+	if c.BreakingFooter {
+		return BumpMajor, fmt.Sprintf("%s has a BREAKING CHANGE footer", ref)
+	}
+	for _, label := range c.Labels {
+		if l := strings.ToLower(label); l == "breaking" || l == "breaking-change" || l == "breaking change" {
+			return BumpMajor, fmt.Sprintf("%s is labelled %q", ref, label)
+		}
+	}
+	if aiBreakingPattern.MatchString(c.AISummary) && !aiNoBreakingPattern.MatchString(c.AISummary) {
+		return BumpMajor, fmt.Sprintf("%s: AI review reports a breaking change", ref)
+	}
+
+	// Features
+	if m := conventionalPattern.FindStringSubmatch(c.Title); m != nil {
+		switch strings.ToLower(m[1]) {
+		case "feat", "feature":
+			return BumpMinor, fmt.Sprintf("%s is a feature (%q)", ref, c.Title)
+		case "fix", "perf", "revert", "refactor", "chore", "docs", "style", "test", "build", "ci":
+			return BumpPatch, fmt.Sprintf("%s is a %s change (%q)", ref, strings.ToLower(m[1]), c.Title)
+		}
+	}
+	for _, label := range c.Labels {
+		switch strings.ToLower(label) {
+		case "feature", "enhancement":
+			return BumpMinor, fmt.Sprintf("%s is labelled %q", ref, label)
+		}
+	}
+	for _, label := range c.Labels {
+		switch strings.ToLower(label) {
+		case "fix", "bug", "bugfix":
+			return BumpPatch, fmt.Sprintf("%s is labelled %q", ref, label)
+		}
+	}
+
+	// Anything unclassified still ships, so it needs at least a patch release
+	return BumpPatch, fmt.Sprintf("%s has no conventional type or label; treated as a patch", ref)
+}
+
+// Suggest proposes the next version after the latest tag for the given changes
+func Suggest(tags []string, changes []Change) *models.VersionSuggestion {
+	current, found := Latest(tags)
+
+	bump := BumpNone
+	var reasons []string
+	for _, c := range changes {
+		b, reason := Classify(c)
+		reasons = append(reasons, reason)
+		if bumpRank[b] > bumpRank[bump] {
+			bump = b
+		}
+	}
+
+	next := current
+	if !found {
+		// First release of the repository
+		next = Version{Prefix: "v", Major: 0, Minor: 1, Patch: 0}
+		reasons = append(reasons, "No semantic version tag found; suggesting an initial release")
+		return &models.VersionSuggestion{
+			SuggestedVersion: next.String(),
+			Bump:             bump,
+			Justification:    reasons,
+		}
+	}
+
+	effective := bump
+	if bump == BumpMajor && current.Major == 0 {
+		// Semver 0.x: breaking changes bump the minor version
+		effective = BumpMinor
+		reasons = append(reasons, fmt.Sprintf("%s is pre-1.0, so breaking changes bump the minor version", current))
+	}
+
+	release := Version{Prefix: current.Prefix, Major: current.Major, Minor: current.Minor, Patch: current.Patch}
+	switch {
+	case current.PreRelease != "" && releases(release, effective):
+		// The pre-release already carries the bump; the release is its final version
+		next = release
+		reasons = append(reasons, fmt.Sprintf("%s is a pre-release of %s", current, release))
+	case effective == BumpMajor:
+		next = Version{Prefix: current.Prefix, Major: current.Major + 1}
+	case effective == BumpMinor:
+		next = Version{Prefix: current.Prefix, Major: current.Major, Minor: current.Minor + 1}
+	case effective == BumpPatch:
+		next = Version{Prefix: current.Prefix, Major: current.Major, Minor: current.Minor, Patch: current.Patch + 1}
+	}
+
+	return &models.VersionSuggestion{
+		CurrentVersion:   current.String(),
+		SuggestedVersion: next.String(),
+		Bump:             bump,
+		Justification:    reasons,
+	}
+}
+
+// releases reports whether releasing v, the version of a pre-release, is enough of a bump:
+// 2.0.0-rc.1 becomes 2.0.0 for any bump, while 2.1.3-rc.1 only covers a patch
+func releases(v Version, bump string) bool {
+	switch bump {
+	case BumpMajor:
+		return v.Minor == 0 && v.Patch == 0
+	case BumpMinor:
+		return v.Patch == 0
+	default:
+		return true
+	}
+}
@@ -0,0 +1,114 @@
+package versioning
+
+import (
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want Version
+		ok   bool
+	}{
+		{"v1.2.3", Version{Prefix: "v", Major: 1, Minor: 2, Patch: 3}, true},
+		{"1.2.3", Version{Major: 1, Minor: 2, Patch: 3}, true},
+		{"v2.0.0-rc.1", Version{Prefix: "v", Major: 2, PreRelease: "rc.1"}, true},
+		{"1.0.0-alpha+001", Version{Major: 1, PreRelease: "alpha"}, true},
+		{"1.0.0+20130313144700", Version{Major: 1}, true},
+		{"1.0.0-x-y.7.z-92", Version{Major: 1, PreRelease: "x-y.7.z-92"}, true},
+		{"1.0.0-", Version{}, false},
+		{"1.0.0-rc..1", Version{}, false},
+		{"1.0", Version{}, false},
+		{"release-1.0.0", Version{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			got, ok := Parse(tt.tag)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.tag, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+// The precedence example from SemVer 2.0.0 §11, in ascending order
+func TestLessFollowsSemverPrecedence(t *testing.T) {
+	ordered := []string{
+		"1.0.0-alpha",
+		"1.0.0-alpha.1",
+		"1.0.0-alpha.beta",
+		"1.0.0-beta",
+		"1.0.0-beta.2",
+		"1.0.0-beta.11",
+		"1.0.0-rc.1",
+		"1.0.0",
+		"1.0.1",
+		"1.1.0",
+		"2.0.0-rc.1",
+		"2.0.0",
+	}
+	for i := range ordered {
+		for j := range ordered {
+			a, _ := Parse(ordered[i])
+			b, _ := Parse(ordered[j])
+			if got, want := a.Less(b), i < j; got != want {
+				t.Errorf("%s.Less(%s) = %v, want %v", ordered[i], ordered[j], got, want)
+			}
+		}
+	}
+}
+
+func TestLatest(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+		ok   bool
+	}{
+		{"pre-release above the last release", []string{"v1.9.0", "v2.0.0-rc.1", "v2.0.0-beta.3"}, "v2.0.0-rc.1", true},
+		{"release above its pre-releases", []string{"v2.0.0-rc.1", "v2.0.0", "v2.0.0-rc.2"}, "v2.0.0", true},
+		{"numeric pre-release identifiers", []string{"1.0.0-beta.2", "1.0.0-beta.11"}, "1.0.0-beta.11", true},
+		{"no semantic versions", []string{"latest", "nightly"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Latest(tt.tags)
+			if ok != tt.ok || (ok && got.String() != tt.want) {
+				t.Errorf("Latest() = %s, %v; want %s, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	fix := Change{Number: 1, Title: "fix: handle empty diff"}
+	feat := Change{Number: 2, Title: "feat: add release notes"}
+	breaking := Change{Number: 3, Title: "feat!: drop the v1 API"}
+
+	tests := []struct {
+		name    string
+		tags    []string
+		changes []Change
+		want    string
+		bump    string
+	}{
+		{"first release", nil, []Change{feat}, "v0.1.0", BumpMinor},
+		{"patch", []string{"v1.2.3"}, []Change{fix}, "v1.2.4", BumpPatch},
+		{"minor", []string{"v1.2.3"}, []Change{fix, feat}, "v1.3.0", BumpMinor},
+		{"major", []string{"v1.2.3"}, []Change{breaking}, "v2.0.0", BumpMajor},
+		{"breaking before 1.0 bumps the minor", []string{"0.4.1"}, []Change{breaking}, "0.5.0", BumpMajor},
+		{"release candidate of a major", []string{"v1.9.0", "v2.0.0-rc.1"}, []Change{fix}, "v2.0.0", BumpPatch},
+		{"release candidate covers a breaking change", []string{"v2.0.0-rc.1"}, []Change{breaking}, "v2.0.0", BumpMajor},
+		{"patch pre-release doesn't cover a feature", []string{"v2.1.3-rc.1"}, []Change{feat}, "v2.2.0", BumpMinor},
+		{"minor pre-release covers a feature", []string{"v2.2.0-beta.2"}, []Change{feat}, "v2.2.0", BumpMinor},
+		{"build metadata is dropped", []string{"v1.0.0+build.5"}, []Change{fix}, "v1.0.1", BumpPatch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Suggest(tt.tags, tt.changes)
+			if got.SuggestedVersion != tt.want || got.Bump != tt.bump {
+				t.Errorf("Suggest() = %s (%s), want %s (%s); justification: %v", got.SuggestedVersion, got.Bump, tt.want, tt.bump, got.Justification)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package workspace_service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/repositories"
+)
+
+var (
+	// ErrForbidden is returned when the user's role doesn't allow the operation
+	ErrForbidden = models.ErrForbidden
+	// ErrNotFound is returned for unknown workspaces, members or users
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidRole is returned for roles other than owner, maintainer or viewer
+	ErrInvalidRole = errors.New("role must be owner, maintainer or viewer")
+	// ErrLastOwner is returned when a change would leave a workspace without an owner
+	ErrLastOwner = errors.New("a workspace must keep at least one owner")
+	// ErrAmbiguousUser is returned when several users have the login a request names
+	ErrAmbiguousUser = errors.New("several users have this login, identify the user by user_id")
+)
+
+type WorkspaceService struct {
+	repo repositories.WorkspaceRepository
+}
+
+func NewWorkspaceService(repo repositories.WorkspaceRepository) *WorkspaceService {
+	return &WorkspaceService{repo: repo}
+}
+
+// EnsureAccountWorkspace returns the workspace for an account (organisation or user) on a code
+// host, creating it on first sync. Only users who own or administer the account on the host
+// join it, as owners; syncing one of the account's repositories doesn't give access to the
+// rest, see GrantRepositoryAccess.
+func (s *WorkspaceService) EnsureAccountWorkspace(ctx context.Context, provider string, accountLogin string, kind string, userID string, accountAdmin bool) (*models.Workspace, error) {
+	workspace, err := s.repo.GetWorkspaceByAccount(ctx, provider, accountLogin)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		now := time.Now()
+		workspace = &models.Workspace{
+			CreatedAt:          &now,
+			UpdatedAt:          &now,
+			Name:               accountLogin,
+			Kind:               kind,
+			GithubAccountLogin: accountLogin,
+			Provider:           provider,
+		}
+		if err := s.repo.CreateWorkspace(ctx, workspace); err != nil {
+			return nil, err
+		}
+		log.Info().Str("workspace_id", workspace.ID).Str("account", accountLogin).Msg("[WorkspaceService.EnsureAccountWorkspace] Created workspace")
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !accountAdmin {
+		return workspace, nil
+	}
+
+	member, err := s.repo.GetMember(ctx, workspace.ID, userID)
+	if err == nil {
+		if member.Role != models.RoleOwner {
+			return workspace, s.repo.UpdateMemberRole(ctx, workspace.ID, userID, models.RoleOwner)
+		}
+		return workspace, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	if err := s.repo.AddMember(ctx, &models.WorkspaceMember{
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+		WorkspaceID: workspace.ID,
+		UserID:      userID,
+		Role:        models.RoleOwner,
+	}); err != nil {
+		return nil, err
+	}
+	log.Info().Str("workspace_id", workspace.ID).Str("user_id", userID).Msg("[WorkspaceService.EnsureAccountWorkspace] Added account admin as owner")
+	return workspace, nil
+}
+
+// GrantRepositoryAccess gives a user who synced a repository but doesn't administer its
+// account a role on that repository alone, mapped from their permissions on the host.
+// Workspace members keep their workspace role, and a role a repository owner already set
+// isn't changed.
+func (s *WorkspaceService) GrantRepositoryAccess(ctx context.Context, workspaceID string, repoID string, userID string, role string) error {
+	if !models.ValidRole(role) {
+		return ErrInvalidRole
+	}
+	if _, err := s.repo.GetMember(ctx, workspaceID, userID); err == nil {
+		return nil
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	now := time.Now()
+	return s.repo.AddRepositoryMember(ctx, &models.RepositoryMember{
+		CreatedAt: &now,
+		UpdatedAt: &now,
+		RepoID:    repoID,
+		UserID:    userID,
+		Role:      role,
+	})
+}
+
+// GetWorkspaces lists the workspaces the user belongs to, with the user's role in each
+func (s *WorkspaceService) GetWorkspaces(ctx context.Context, userID string) ([]*models.Workspace, error) {
+	return s.repo.GetWorkspacesForUser(ctx, userID)
+}
+
+// GetMembers lists the members of a workspace the user belongs to
+func (s *WorkspaceService) GetMembers(ctx context.Context, userID string, workspaceID string) ([]*models.WorkspaceMember, error) {
+	if _, err := s.requireRole(ctx, userID, workspaceID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMembers(ctx, workspaceID)
+}
+
+// AddMember adds an existing DevPlus user to the workspace. Only owners can add members.
+func (s *WorkspaceService) AddMember(ctx context.Context, userID string, workspaceID string, ref models.UserRef, role string) (*models.WorkspaceMember, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.requireRole(ctx, userID, workspaceID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	member := &models.WorkspaceMember{
+		CreatedAt:   &now,
+		UpdatedAt:   &now,
+		WorkspaceID: workspaceID,
+		UserID:      user.ID,
+		Role:        role,
+	}
+	if err := s.repo.AddMember(ctx, member); err != nil {
+		return nil, err
+	}
+	// Adding someone who is already a member updates their role
+	if err := s.repo.UpdateMemberRole(ctx, workspaceID, user.ID, role); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMember(ctx, workspaceID, user.ID)
+}
+
+// UpdateMemberRole changes a member's role. Only owners can change roles, and the last
+// owner can't be demoted.
+func (s *WorkspaceService) UpdateMemberRole(ctx context.Context, userID string, workspaceID string, memberID string, role string) (*models.WorkspaceMember, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if _, err := s.requireRole(ctx, userID, workspaceID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	member, err := s.getMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return nil, err
+	}
+	if member.Role == models.RoleOwner && role != models.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.repo.UpdateMemberRole(ctx, workspaceID, memberID, role); err != nil {
+		return nil, err
+	}
+	return s.repo.GetMember(ctx, workspaceID, memberID)
+}
+
+// RemoveMember removes a member from the workspace. Owners can remove anyone and members
+// can remove themselves, as long as an owner remains.
+func (s *WorkspaceService) RemoveMember(ctx context.Context, userID string, workspaceID string, memberID string) error {
+	if memberID != userID {
+		if _, err := s.requireRole(ctx, userID, workspaceID, models.RoleOwner); err != nil {
+			return err
+		}
+	}
+
+	member, err := s.getMember(ctx, workspaceID, memberID)
+	if err != nil {
+		return err
+	}
+	if member.Role == models.RoleOwner {
+		if err := s.ensureAnotherOwner(ctx, workspaceID); err != nil {
+			return err
+		}
+	}
+	return s.repo.RemoveMember(ctx, workspaceID, memberID)
+}
+
+// requireRole returns the user's membership if their role is at least minRole. Non-members
+// are forbidden like members with too low a role.
+func (s *WorkspaceService) requireRole(ctx context.Context, userID string, workspaceID string, minRole string) (*models.WorkspaceMember, error) {
+	member, err := s.getMember(ctx, workspaceID, userID)
+	if errors.Is(err, ErrNotFound) {
+		return nil, ErrForbidden
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !models.RoleAtLeast(member.Role, minRole) {
+		return nil, ErrForbidden
+	}
+	return member, nil
+}
+
+// resolveUser finds the user a request names. A login shared by several users, e.g. a GitHub
+// login that was renamed and taken by someone else, is rejected rather than guessed.
+func (s *WorkspaceService) resolveUser(ctx context.Context, ref models.UserRef) (*models.User, error) {
+	if ref.UserID != "" {
+		if _, err := uuid.Parse(ref.UserID); err != nil {
+			return nil, ErrNotFound
+		}
+		user, err := s.repo.GetUser(ctx, ref.UserID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotFound
+		}
+		return user, err
+	}
+	if ref.Username == "" {
+		return nil, ErrNotFound
+	}
+
+	provider := ref.Provider
+	if provider == "" {
+		provider = models.CodeHostGithub
+	}
+	users, err := s.repo.FindUsersByLogin(ctx, provider, ref.Username, 2)
+	if err != nil {
+		return nil, err
+	}
+	switch len(users) {
+	case 0:
+		return nil, ErrNotFound
+	case 1:
+		return users[0], nil
+	default:
+		return nil, ErrAmbiguousUser
+	}
+}
+
+func (s *WorkspaceService) getMember(ctx context.Context, workspaceID string, userID string) (*models.WorkspaceMember, error) {
+	member, err := s.repo.GetMember(ctx, workspaceID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrNotFound
+	}
+	return member, err
+}
+
+func (s *WorkspaceService) ensureAnotherOwner(ctx context.Context, workspaceID string) error {
+	owners, err := s.repo.CountOwners(ctx, workspaceID)
+	if err != nil {
+		return err
+	}
+	if owners <= 1 {
+		return ErrLastOwner
+	}
+	return nil
+}
+
+// AuthorizeRepository checks that the user's effective role on the repository (its
+// repository role, or else its workspace role) is at least minRole. Users without any
+// access get ErrForbidden as well.
+func (s *WorkspaceService) AuthorizeRepository(ctx context.Context, userID string, repoID string, minRole string) error {
+	if _, err := uuid.Parse(repoID); err != nil {
+		return ErrForbidden
+	}
+	role, err := s.repo.GetRepositoryRole(ctx, repoID, userID)
+	if err != nil {
+		return err
+	}
+	if !models.RoleAtLeast(role, minRole) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// AuthorizeRepositoryByName is AuthorizeRepository for a repository identified by owner and name
+func (s *WorkspaceService) AuthorizeRepositoryByName(ctx context.Context, userID string, owner string, name string, minRole string) error {
+	role, err := s.repo.GetRepositoryRoleByName(ctx, owner, name, userID)
+	if err != nil {
+		return err
+	}
+	if !models.RoleAtLeast(role, minRole) {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// GetRepositoryMembers lists the repository-level role overrides of a repository
+func (s *WorkspaceService) GetRepositoryMembers(ctx context.Context, userID string, repoID string) ([]*models.RepositoryMember, error) {
+	if err := s.AuthorizeRepository(ctx, userID, repoID, models.RoleViewer); err != nil {
+		return nil, err
+	}
+	return s.repo.GetRepositoryMembers(ctx, repoID)
+}
+
+// SetRepositoryMember gives a user a role on one repository, overriding their workspace
+// role there. Only repository owners can set roles.
+func (s *WorkspaceService) SetRepositoryMember(ctx context.Context, userID string, repoID string, ref models.UserRef, role string) (*models.RepositoryMember, error) {
+	if !models.ValidRole(role) {
+		return nil, ErrInvalidRole
+	}
+	if err := s.AuthorizeRepository(ctx, userID, repoID, models.RoleOwner); err != nil {
+		return nil, err
+	}
+
+	user, err := s.resolveUser(ctx, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	member := &models.RepositoryMember{
+		CreatedAt: &now,
+		UpdatedAt: &now,
+		RepoID:    repoID,
+		UserID:    user.ID,
+		Role:      role,
+	}
+	if err := s.repo.UpsertRepositoryMember(ctx, member); err != nil {
+		return nil, err
+	}
+	return member, nil
+}
+
+// RemoveRepositoryMember drops a repository role override. Owners can remove anyone,
+// members can remove their own override.
+func (s *WorkspaceService) RemoveRepositoryMember(ctx context.Context, userID string, repoID string, memberID string) error {
+	if memberID != userID {
+		if err := s.AuthorizeRepository(ctx, userID, repoID, models.RoleOwner); err != nil {
+			return err
+		}
+	}
+	return s.repo.RemoveRepositoryMember(ctx, repoID, memberID)
+}
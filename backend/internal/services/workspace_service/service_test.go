@@ -0,0 +1,202 @@
+package workspace_service
+
+import (
+	"context"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"devplus-backend/internal/models"
+	"devplus-backend/internal/repositories"
+)
+
+// fakeWorkspaceRepository keeps one account workspace in memory. Methods the tests don't
+// reach panic through the nil embedded interface.
+type fakeWorkspaceRepository struct {
+	repositories.WorkspaceRepository
+	workspace   *models.Workspace
+	members     map[string]string // user ID -> role
+	repoMembers map[string]string // repo ID + ":" + user ID -> role
+	users       []*models.User
+	logins      map[string][]string // provider + ":" + login -> user IDs
+}
+
+func newFakeWorkspaceRepository() *fakeWorkspaceRepository {
+	return &fakeWorkspaceRepository{members: map[string]string{}, repoMembers: map[string]string{}}
+}
+
+func (f *fakeWorkspaceRepository) GetWorkspaceByAccount(ctx context.Context, provider string, login string) (*models.Workspace, error) {
+	if f.workspace == nil {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return f.workspace, nil
+}
+
+func (f *fakeWorkspaceRepository) CreateWorkspace(ctx context.Context, workspace *models.Workspace) error {
+	workspace.ID = "workspace-1"
+	f.workspace = workspace
+	return nil
+}
+
+func (f *fakeWorkspaceRepository) GetMember(ctx context.Context, workspaceID string, userID string) (*models.WorkspaceMember, error) {
+	role, ok := f.members[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.WorkspaceMember{WorkspaceID: workspaceID, UserID: userID, Role: role}, nil
+}
+
+func (f *fakeWorkspaceRepository) AddMember(ctx context.Context, member *models.WorkspaceMember) error {
+	if _, ok := f.members[member.UserID]; !ok {
+		f.members[member.UserID] = member.Role
+	}
+	return nil
+}
+
+func (f *fakeWorkspaceRepository) UpdateMemberRole(ctx context.Context, workspaceID string, userID string, role string) error {
+	f.members[userID] = role
+	return nil
+}
+
+func (f *fakeWorkspaceRepository) AddRepositoryMember(ctx context.Context, member *models.RepositoryMember) error {
+	key := member.RepoID + ":" + member.UserID
+	if _, ok := f.repoMembers[key]; !ok {
+		f.repoMembers[key] = member.Role
+	}
+	return nil
+}
+
+func (f *fakeWorkspaceRepository) GetUser(ctx context.Context, userID string) (*models.User, error) {
+	for _, user := range f.users {
+		if user.ID == userID {
+			return user, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeWorkspaceRepository) FindUsersByLogin(ctx context.Context, provider string, login string, limit int) ([]*models.User, error) {
+	var users []*models.User
+	for _, id := range f.logins[provider+":"+login] {
+		if len(users) == limit {
+			break
+		}
+		user, _ := f.GetUser(ctx, id)
+		users = append(users, user)
+	}
+	return users, nil
+}
+
+func TestEnsureAccountWorkspaceOnlyAddsAccountAdmins(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeWorkspaceRepository()
+	s := NewWorkspaceService(repo)
+
+	// The first user to sync an organisation's repository no longer owns its workspace
+	workspace, err := s.EnsureAccountWorkspace(ctx, models.CodeHostGithub, "acme", models.WorkspaceKindOrganization, "member", false)
+	if err != nil {
+		t.Fatalf("EnsureAccountWorkspace: %v", err)
+	}
+	if workspace.ID == "" {
+		t.Fatal("the workspace wasn't created")
+	}
+	if role, ok := repo.members["member"]; ok {
+		t.Errorf("a user who doesn't administer the account joined as %s", role)
+	}
+
+	if _, err := s.EnsureAccountWorkspace(ctx, models.CodeHostGithub, "acme", models.WorkspaceKindOrganization, "admin", true); err != nil {
+		t.Fatalf("EnsureAccountWorkspace: %v", err)
+	}
+	if role := repo.members["admin"]; role != models.RoleOwner {
+		t.Errorf("account admin joined as %q, want owner", role)
+	}
+
+	// Admins who were added with a lower role are promoted
+	repo.members["promoted"] = models.RoleViewer
+	if _, err := s.EnsureAccountWorkspace(ctx, models.CodeHostGithub, "acme", models.WorkspaceKindOrganization, "promoted", true); err != nil {
+		t.Fatalf("EnsureAccountWorkspace: %v", err)
+	}
+	if role := repo.members["promoted"]; role != models.RoleOwner {
+		t.Errorf("account admin kept role %q, want owner", role)
+	}
+}
+
+func TestGrantRepositoryAccess(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeWorkspaceRepository()
+	repo.members["maintainer"] = models.RoleMaintainer
+	repo.repoMembers["repo-1:raised"] = models.RoleMaintainer
+	s := NewWorkspaceService(repo)
+
+	for _, userID := range []string{"member", "maintainer", "raised"} {
+		if err := s.GrantRepositoryAccess(ctx, "workspace-1", "repo-1", userID, models.RoleViewer); err != nil {
+			t.Fatalf("GrantRepositoryAccess(%s): %v", userID, err)
+		}
+	}
+
+	if role := repo.repoMembers["repo-1:member"]; role != models.RoleViewer {
+		t.Errorf("syncing user got repository role %q, want viewer", role)
+	}
+	if role, ok := repo.repoMembers["repo-1:maintainer"]; ok {
+		t.Errorf("workspace member got a %s repository role overriding their workspace role", role)
+	}
+	if role := repo.repoMembers["repo-1:raised"]; role != models.RoleMaintainer {
+		t.Errorf("repository role set by an owner changed to %q", role)
+	}
+	if len(repo.repoMembers) != 2 {
+		t.Errorf("repository members = %v, want access to repo-1 only", repo.repoMembers)
+	}
+
+	if err := s.GrantRepositoryAccess(ctx, "workspace-1", "repo-1", "member", "admin"); err != ErrInvalidRole {
+		t.Errorf("GrantRepositoryAccess with an unknown role: %v, want ErrInvalidRole", err)
+	}
+}
+
+func TestResolveUser(t *testing.T) {
+	const (
+		alice    = "6f1c1c55-5a4b-4c44-9a55-0a1b2c3d4e01"
+		impostor = "0a000000-0000-4000-8000-000000000001" // sorts first, like the old First() pick
+		renamed  = "6f1c1c55-5a4b-4c44-9a55-0a1b2c3d4e02"
+	)
+	repo := newFakeWorkspaceRepository()
+	repo.users = []*models.User{
+		{ID: alice, Username: "alice"},
+		// An SSO user who picked someone else's GitHub login as their username
+		{ID: impostor, Username: "alice"},
+		{ID: renamed, Username: "bob"},
+	}
+	repo.logins = map[string][]string{
+		"github:alice": {alice},
+		"gitlab:alice": {impostor},
+		// bob renamed their GitHub account and someone else took the login
+		"github:bob": {renamed, alice},
+	}
+	s := NewWorkspaceService(repo)
+
+	tests := []struct {
+		name    string
+		ref     models.UserRef
+		want    string
+		wantErr error
+	}{
+		{"by user ID", models.UserRef{UserID: impostor}, impostor, nil},
+		{"GitHub login by default", models.UserRef{Username: "alice"}, alice, nil},
+		{"GitLab login", models.UserRef{Username: "alice", Provider: models.CodeHostGitlab}, impostor, nil},
+		{"login shared by several users", models.UserRef{Username: "bob"}, "", ErrAmbiguousUser},
+		{"unknown login", models.UserRef{Username: "carol"}, "", ErrNotFound},
+		{"unknown user ID", models.UserRef{UserID: "6f1c1c55-5a4b-4c44-9a55-0a1b2c3d4eff"}, "", ErrNotFound},
+		{"malformed user ID", models.UserRef{UserID: "alice"}, "", ErrNotFound},
+		{"empty", models.UserRef{}, "", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user, err := s.resolveUser(context.Background(), tt.ref)
+			if err != tt.wantErr {
+				t.Fatalf("resolveUser() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && user.ID != tt.want {
+				t.Errorf("resolveUser() = %s, want %s", user.ID, tt.want)
+			}
+		})
+	}
+}
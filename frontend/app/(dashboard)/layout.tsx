@@ -47,6 +47,7 @@ export default function DashboardLayout({
   const router = useRouter();
   const [user, setUser] = useState<User | null>(null);
   const [scrolled, setScrolled] = useState(false);
+  const [githubLinked, setGithubLinked] = useState(true);
 
   useEffect(() => {
     async function fetchUser() {
@@ -55,6 +56,17 @@ export default function DashboardLayout({
         if (res.success && res.data) {
           setUser(res.data);
         }
+
+        // GitHub revoked or expired the token: ask the user to log in again
+        const status = await apiClient.auth.status();
+        if (status.success && status.data?.needs_reauth) {
+          localStorage.removeItem('session_token');
+          router.push('/login');
+        }
+        // SSO users need to link GitHub before repository data can be synced
+        if (status.success && status.data) {
+          setGithubLinked(status.data.github_linked);
+        }
       } catch (error) {
         console.error('Failed to fetch user:', error);
       }
@@ -176,6 +188,15 @@ export default function DashboardLayout({
       {/* Main content */}
       <main className="flex-1 pt-16">
         <div className="w-full max-w-[2000px] mx-auto p-6 md:p-8">
+          {!githubLinked && (
+            <div className="mb-6 flex items-center justify-between gap-4 rounded-lg border border-sky-500/30 bg-sky-500/5 px-4 py-3 text-sm">
+              <span>Link your GitHub account to sync repositories and pull requests.</span>
+              <Button size="sm" onClick={() => apiClient.auth.linkGithub()}>
+                <Github className="mr-2 h-4 w-4" />
+                Link GitHub
+              </Button>
+            </div>
+          )}
           <AnimatePresence mode="wait">
             <motion.div
               key={pathname}
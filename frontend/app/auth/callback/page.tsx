@@ -1,15 +1,19 @@
 'use client';
 
-import { Suspense, useEffect } from 'react';
+import { Suspense, useEffect, useRef } from 'react';
 import { useRouter, useSearchParams } from 'next/navigation';
 import { LoadingPulse } from '@/components/ui/loading-pulse';
+import { apiClient } from '@/lib/api-client';
+import { takePkceVerifier } from '@/lib/utils/auth';
 
 function CallbackContent() {
     const router = useRouter();
     const searchParams = useSearchParams();
+    // Login codes are single-use, so the exchange must not run twice
+    const exchanged = useRef(false);
 
     useEffect(() => {
-        const token = searchParams?.get('token');
+        const code = searchParams?.get('code');
         const error = searchParams?.get('error');
 
         if (error) {
@@ -18,17 +22,39 @@ function CallbackContent() {
             return;
         }
 
-        if (token) {
-            // Store token in localStorage
-            localStorage.setItem('session_token', token);
+        if (!code) {
+            console.error('No login code received');
+            router.push('/login?error=no_code');
+            return;
+        }
+
+        if (exchanged.current) {
+            return;
+        }
+        exchanged.current = true;
+
+        const verifier = takePkceVerifier();
+        if (!verifier) {
+            console.error('No PKCE verifier for this login');
+            router.push('/login?error=invalid_login');
+            return;
+        }
+
+        // Exchange the one-time code for the session tokens
+        apiClient.auth.exchange(code, verifier).then((result) => {
+            if (!result.success || !result.data) {
+                console.error('Login code exchange failed:', result.error);
+                router.push('/login?error=exchange_failed');
+                return;
+            }
+
+            localStorage.setItem('session_token', result.data.token);
+            localStorage.setItem('refresh_token', result.data.refresh_token);
             console.log('Token stored successfully');
 
             // Redirect to dashboard
-            router.push('/dashboard');
-        } else {
-            console.error('No token received');
-            router.push('/login?error=no_token');
-        }
+            router.replace('/dashboard');
+        });
     }, [router, searchParams]);
 
     return (
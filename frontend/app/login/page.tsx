@@ -6,15 +6,39 @@ import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/com
 import { Github, Zap, BarChart3, GitPullRequest, Package, ArrowRight, ShieldCheck } from 'lucide-react';
 import { apiClient } from '@/lib/api-client';
 import { LoadingPulse } from '@/components/ui/loading-pulse';
+import type { LoginProvider } from '@/lib/types';
 
 export default function LoginPage() {
   const [isLoading, setIsLoading] = useState(false);
+  const [ssoProviders, setSsoProviders] = useState<LoginProvider[]>([]);
+
+  useEffect(() => {
+    apiClient.auth.providers().then((result) => {
+      if (result.success && result.data) {
+        setSsoProviders(result.data.filter((provider) => provider.kind !== 'github'));
+      }
+    });
+  }, []);
+
+  const handleSsoLogin = async (provider: LoginProvider) => {
+    setIsLoading(true);
+    try {
+      if (provider.kind === 'code_host') {
+        await apiClient.auth.codeHostLogin(provider.name);
+      } else {
+        await apiClient.auth.ssoLogin(provider.name);
+      }
+    } catch (error) {
+      console.error('Login error:', error);
+      setIsLoading(false);
+    }
+  };
 
   const handleGithubLogin = async () => {
     setIsLoading(true);
     try {
       // Redirect to GitHub OAuth
-      apiClient.auth.login();
+      await apiClient.auth.login();
     } catch (error) {
       console.error('Login error:', error);
       setIsLoading(false);
@@ -161,6 +185,20 @@ export default function LoginPage() {
                 {!isLoading && <ArrowRight className="ml-2 h-4 w-4 opacity-0 group-hover:opacity-100 transition-opacity translate-x-[-5px] group-hover:translate-x-0" />}
               </Button>
 
+              {ssoProviders.map((provider) => (
+                <Button
+                  key={provider.name}
+                  variant="outline"
+                  className="w-full h-14 text-base font-medium"
+                  size="lg"
+                  onClick={() => handleSsoLogin(provider)}
+                  disabled={isLoading}
+                >
+                  <ShieldCheck className="mr-2 h-5 w-5" />
+                  Continue with {provider.display_name}
+                </Button>
+              ))}
+
               {/* Removed Secure Authentication section as requested */}
 
               <div className="text-center text-sm text-muted-foreground px-8 pt-4">
@@ -0,0 +1,88 @@
+'use client';
+
+import { Suspense, useState } from 'react';
+import Link from 'next/link';
+import { useSearchParams } from 'next/navigation';
+import { LoadingPulse } from '@/components/ui/loading-pulse';
+import { Button } from '@/components/ui/button';
+import { apiClient } from '@/lib/api-client';
+import type { SlackAccount } from '@/lib/types';
+
+// slackUsername reads the Slack user a link token was issued to, so the user can check it's
+// theirs before linking; the backend verifies the token itself
+function slackUsername(token: string): string | null {
+    try {
+        const payload = JSON.parse(atob(token.split('.')[1].replace(/-/g, '+').replace(/_/g, '/')));
+        return payload.slack_username || payload.slack_user_id || null;
+    } catch {
+        return null;
+    }
+}
+
+function SlackLinkContent() {
+    const searchParams = useSearchParams();
+    const token = searchParams?.get('token');
+    const [linking, setLinking] = useState(false);
+    const [account, setAccount] = useState<SlackAccount | null>(null);
+    const [error, setError] = useState<string | null>(null);
+    const signedIn = typeof window !== 'undefined' && !!localStorage.getItem('session_token');
+
+    const username = token ? slackUsername(token) : null;
+
+    // Linking is confirmed explicitly, so opening someone else's link doesn't link their Slack user
+    const link = async () => {
+        if (!token || !username) {
+            return;
+        }
+        setLinking(true);
+        setError(null);
+        const result = await apiClient.slack.link(token);
+        setLinking(false);
+        if (!result.success || !result.data) {
+            setError('This link is invalid or has expired. Run /devplus link in Slack to get a new one.');
+            return;
+        }
+        setAccount(result.data);
+    };
+
+    let title = username ? `Link Slack account @${username}` : 'Link your Slack account';
+    let description = 'Your /devplus commands in Slack will run as your DevPlus user, with your repository roles. Only link a Slack account that is yours.';
+    if (!token || !username) {
+        title = 'Invalid link';
+        description = 'Run /devplus link in Slack to get a link to this page.';
+    } else if (account) {
+        title = 'Slack account linked';
+        description = `@${account.slack_username || account.slack_user_id} can now run /devplus commands. You can close this page.`;
+    } else if (!signedIn) {
+        description = 'Sign in to DevPlus, then open the link from Slack again.';
+    }
+
+    return (
+        <div className="flex min-h-screen items-center justify-center bg-gradient-to-br from-background via-background to-muted/20">
+            <div className="max-w-md text-center space-y-4">
+                <div className="space-y-2">
+                    <h2 className="text-2xl font-semibold">{title}</h2>
+                    <p className="text-muted-foreground">{description}</p>
+                    {error && <p className="text-sm text-destructive">{error}</p>}
+                </div>
+                {username && !account && (signedIn ? (
+                    <Button onClick={link} disabled={linking}>
+                        {linking ? 'Linking...' : 'Link account'}
+                    </Button>
+                ) : (
+                    <Button asChild>
+                        <Link href="/login">Sign in</Link>
+                    </Button>
+                ))}
+            </div>
+        </div>
+    );
+}
+
+export default function SlackLinkPage() {
+    return (
+        <Suspense fallback={<LoadingPulse />}>
+            <SlackLinkContent />
+        </Suspense>
+    );
+}
@@ -1,6 +1,25 @@
 import axios, { AxiosInstance, AxiosRequestConfig, AxiosError, AxiosResponse } from 'axios';
 import { API_BASE_URL, API_ENDPOINTS } from './constants';
-import type { ApiResponse, User } from './types';
+import { createPkceChallenge } from './utils/auth';
+import type {
+  ApiResponse,
+  ApiToken,
+  ApiTokenScope,
+  AuthStatus,
+  CodeHostAccount,
+  CreatedApiToken,
+  CreatedWebhookEndpoint,
+  LoginProvider,
+  NotificationChannel,
+  NotificationChannelKind,
+  NotificationDelivery,
+  NotificationEvent,
+  NotificationRule,
+  SessionInfo,
+  SlackAccount,
+  User,
+  WebhookEndpoint,
+} from './types';
 
 // Create Axios instance with default config
 const axiosInstance: AxiosInstance = axios.create({
@@ -29,18 +48,61 @@ axiosInstance.interceptors.request.use(
   }
 );
 
+// Clears stored tokens and sends the user back to login
+const redirectToLogin = () => {
+  if (typeof window !== 'undefined') {
+    localStorage.removeItem('session_token');
+    localStorage.removeItem('refresh_token');
+    window.location.href = '/login';
+  }
+};
+
+// Refreshes the session once for all requests that hit a 401 at the same time
+let refreshPromise: Promise<string | null> | null = null;
+
+const refreshSession = (): Promise<string | null> => {
+  const refreshToken = typeof window !== 'undefined' ? localStorage.getItem('refresh_token') : null;
+  if (!refreshToken) {
+    return Promise.resolve(null);
+  }
+  if (!refreshPromise) {
+    refreshPromise = axios
+      .post<{ token: string; refresh_token: string }>(
+        `${API_BASE_URL}${API_ENDPOINTS.AUTH_REFRESH}`,
+        { refresh_token: refreshToken },
+        { withCredentials: true }
+      )
+      .then(({ data }) => {
+        localStorage.setItem('session_token', data.token);
+        localStorage.setItem('refresh_token', data.refresh_token);
+        return data.token;
+      })
+      .catch(() => null)
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
 // Response interceptor for global error handling
 axiosInstance.interceptors.response.use(
   (response) => response,
-  (error: AxiosError) => {
+  async (error: AxiosError) => {
     if (error.response) {
       // Server responded with non-2xx code
       if (error.response.status === 401) {
-        // Clear token and redirect to login
-        if (typeof window !== 'undefined') {
-          localStorage.removeItem('session_token');
-          window.location.href = '/login';
+        // Try to refresh the session once, then retry the original request
+        const original = error.config as (AxiosRequestConfig & { _retried?: boolean }) | undefined;
+        if (original && !original._retried) {
+          original._retried = true;
+          const token = await refreshSession();
+          if (token) {
+            original.headers = { ...original.headers, Authorization: `Bearer ${token}` };
+            return axiosInstance(original);
+          }
         }
+        redirectToLogin();
         console.warn('Unauthorized access. Session might have expired.');
       }
     }
@@ -98,6 +160,15 @@ class ApiClient {
     }
   }
 
+  async patch<T>(url: string, data?: any, config?: AxiosRequestConfig): Promise<ApiResponse<T>> {
+    try {
+      const response = await axiosInstance.patch<T>(url, data, config);
+      return handleResponse(response);
+    } catch (error) {
+      return handleError<T>(error);
+    }
+  }
+
   async delete<T>(url: string, config?: AxiosRequestConfig): Promise<ApiResponse<T>> {
     try {
       const response = await axiosInstance.delete<T>(url, config);
@@ -111,15 +182,80 @@ class ApiClient {
 
   // Auth
   auth = {
-    login: () => {
+    login: async () => {
       // Redirect to backend login endpoint
       // Note: We use window.location because this starts the OAuth flow, it's not an AJAX call
       if (typeof window !== 'undefined') {
-        window.location.href = `${API_BASE_URL}${API_ENDPOINTS.AUTH_GITHUB_CONNECT}`;
+        // Bind the login to a PKCE verifier the callback page needs to redeem the login code
+        const challenge = await createPkceChallenge();
+        const params = new URLSearchParams({ code_challenge: challenge, code_challenge_method: 'S256' });
+        window.location.href = `${API_BASE_URL}${API_ENDPOINTS.AUTH_GITHUB_CONNECT}?${params}`;
+      }
+    },
+    ssoLogin: async (provider: string) => {
+      // Same PKCE binding as the GitHub login; the backend runs the OIDC flow with the provider
+      if (typeof window !== 'undefined') {
+        const challenge = await createPkceChallenge();
+        const params = new URLSearchParams({ code_challenge: challenge, code_challenge_method: 'S256' });
+        window.location.href = `${API_BASE_URL}${API_ENDPOINTS.AUTH_SSO_LOGIN(provider)}?${params}`;
+      }
+    },
+    codeHostLogin: async (provider: string) => {
+      // Code hosts such as GitLab log in with OAuth, bound to PKCE like the GitHub login
+      if (typeof window !== 'undefined') {
+        const challenge = await createPkceChallenge();
+        const params = new URLSearchParams({ code_challenge: challenge, code_challenge_method: 'S256' });
+        window.location.href = `${API_BASE_URL}${API_ENDPOINTS.AUTH_HOST_LOGIN(provider)}?${params}`;
+      }
+    },
+    providers: () => this.get<LoginProvider[]>(API_ENDPOINTS.AUTH_PROVIDERS),
+    linkGithub: async () => {
+      // The link URL needs the session, so it's requested via the API before navigating
+      const challenge = await createPkceChallenge();
+      const result = await this.post<{ url: string }>(API_ENDPOINTS.AUTH_GITHUB_LINK, { code_challenge: challenge });
+      if (result.success && result.data && typeof window !== 'undefined') {
+        window.location.href = result.data.url;
+      }
+      return result;
+    },
+    linkCodeHost: async (provider: string) => {
+      const challenge = await createPkceChallenge();
+      const result = await this.post<{ url: string }>(API_ENDPOINTS.AUTH_HOST_LINK(provider), { code_challenge: challenge });
+      if (result.success && result.data && typeof window !== 'undefined') {
+        window.location.href = result.data.url;
+      }
+      return result;
+    },
+    codeHostAccounts: () => this.get<CodeHostAccount[]>(API_ENDPOINTS.AUTH_HOSTS),
+    exchange: (code: string, codeVerifier: string) =>
+      this.post<{ token: string; refresh_token: string; expires_at: string }>(API_ENDPOINTS.AUTH_EXCHANGE, {
+        code,
+        code_verifier: codeVerifier,
+      }),
+    logout: async () => {
+      const result = await this.post(API_ENDPOINTS.AUTH_LOGOUT);
+      if (typeof window !== 'undefined') {
+        localStorage.removeItem('session_token');
+        localStorage.removeItem('refresh_token');
       }
+      return result;
     },
-    logout: () => this.post('/auth/logout'),
     me: () => this.get<User>(API_ENDPOINTS.AUTH_ME),
+    status: () => this.get<AuthStatus>(API_ENDPOINTS.AUTH_STATUS),
+    sessions: {
+      list: () => this.get<SessionInfo[]>(API_ENDPOINTS.AUTH_SESSIONS),
+      revoke: (id: string) => this.delete(API_ENDPOINTS.AUTH_SESSION(id)),
+      revokeAll: (keepCurrent = true) =>
+        this.delete<{ revoked: number }>(API_ENDPOINTS.AUTH_SESSIONS, { params: { keep_current: keepCurrent } }),
+    },
+    tokens: {
+      list: () => this.get<ApiToken[]>(API_ENDPOINTS.AUTH_TOKENS),
+      create: (name: string, scopes: ApiTokenScope[], expiresInDays = 0) =>
+        this.post<CreatedApiToken>(API_ENDPOINTS.AUTH_TOKENS, { name, scopes, expires_in_days: expiresInDays }),
+      update: (id: string, changes: { name?: string; scopes?: ApiTokenScope[] }) =>
+        this.patch<ApiToken>(API_ENDPOINTS.AUTH_TOKEN(id), changes),
+      revoke: (id: string) => this.delete(API_ENDPOINTS.AUTH_TOKEN(id)),
+    },
   };
 
   // Repositories
@@ -127,6 +263,7 @@ class ApiClient {
     list: () => this.get<any[]>(API_ENDPOINTS.REPOS_LIST),
     syncAll: () => this.post<any[]>('/v1/repos/sync'),
     syncOne: (id: string) => this.post<any[]>(`/v1/repos/${id}/sync`),
+    syncHost: (provider: string) => this.post<any[]>(API_ENDPOINTS.HOSTS_SYNC(provider)),
     analyze: (id: string) => this.post<{ status: string }>(API_ENDPOINTS.REPOS_ANALYZE(id)),
     get: (id: string) => this.get(API_ENDPOINTS.REPOS_DETAIL(id)),
     getPullRequests: (owner: string, repo: string) => this.get(`/v1/repos/${owner}/${repo}/pulls`),
@@ -147,6 +284,48 @@ class ApiClient {
     personal: (days?: string) => this.get<any>(`/v1/metrics/personal${days ? `?days=${days}` : ''}`),
   };
 
+  // Notifications
+  notifications = {
+    channels: {
+      list: () => this.get<NotificationChannel[]>(API_ENDPOINTS.NOTIFICATION_CHANNELS),
+      create: (name: string, kind: NotificationChannelKind, target: string) =>
+        this.post<NotificationChannel>(API_ENDPOINTS.NOTIFICATION_CHANNELS, { name, kind, target }),
+      delete: (id: string) => this.delete(API_ENDPOINTS.NOTIFICATION_CHANNEL(id)),
+    },
+    rules: {
+      list: () => this.get<NotificationRule[]>(API_ENDPOINTS.NOTIFICATION_RULES),
+      create: (rule: {
+        event: NotificationEvent;
+        repo_id?: string;
+        channel_id?: string;
+        notify_author?: boolean;
+        decision?: string;
+        risk_score_above?: number;
+      }) => this.post<NotificationRule>(API_ENDPOINTS.NOTIFICATION_RULES, rule),
+      delete: (id: string) => this.delete(API_ENDPOINTS.NOTIFICATION_RULE(id)),
+    },
+    deliveries: (status?: NotificationDelivery['status'], limit?: number) =>
+      this.get<NotificationDelivery[]>(API_ENDPOINTS.NOTIFICATION_DELIVERIES, { params: { status, limit } }),
+    endpoints: {
+      list: () => this.get<WebhookEndpoint[]>(API_ENDPOINTS.NOTIFICATION_ENDPOINTS),
+      create: (name: string, url: string, events: NotificationEvent[]) =>
+        this.post<CreatedWebhookEndpoint>(API_ENDPOINTS.NOTIFICATION_ENDPOINTS, { name, url, events }),
+      delete: (id: string) => this.delete(API_ENDPOINTS.NOTIFICATION_ENDPOINT(id)),
+      test: (id: string) => this.post<NotificationDelivery>(API_ENDPOINTS.NOTIFICATION_ENDPOINT_TEST(id)),
+    },
+    deadLetters: {
+      list: (limit?: number) => this.get<NotificationDelivery[]>(API_ENDPOINTS.NOTIFICATION_DEAD_LETTERS, { params: { limit } }),
+      replay: (id: string) => this.post<NotificationDelivery>(API_ENDPOINTS.NOTIFICATION_DEAD_LETTER_REPLAY(id)),
+    },
+  };
+
+  // Slack
+  slack = {
+    link: (token: string) => this.post<SlackAccount>(API_ENDPOINTS.SLACK_LINK, { token }),
+    accounts: () => this.get<SlackAccount[]>(API_ENDPOINTS.SLACK_ACCOUNTS),
+    unlink: (id: string) => this.delete(API_ENDPOINTS.SLACK_ACCOUNT(id)),
+  };
+
   // Dashboard
   dashboard = {
     stats: () => this.get<any>('/v1/dashboard/stats'),
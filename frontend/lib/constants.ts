@@ -13,11 +13,26 @@ export const API_ENDPOINTS = {
   AUTH_GITHUB_CONNECT: '/v1/auth/github/login',
   AUTH_GITHUB_CALLBACK: '/v1/auth/github/callback',
   AUTH_ME: '/v1/auth/me',
+  AUTH_STATUS: '/v1/auth/status',
+  AUTH_EXCHANGE: '/v1/auth/exchange',
+  AUTH_PROVIDERS: '/v1/auth/providers',
+  AUTH_SSO_LOGIN: (provider: string) => `/v1/auth/sso/${provider}/login`,
+  AUTH_GITHUB_LINK: '/v1/auth/github/link',
+  AUTH_HOST_LOGIN: (provider: string) => `/v1/auth/hosts/${provider}/login`,
+  AUTH_HOST_LINK: (provider: string) => `/v1/auth/hosts/${provider}/link`,
+  AUTH_HOSTS: '/v1/auth/hosts',
+  AUTH_LOGOUT: '/v1/auth/logout',
+  AUTH_REFRESH: '/v1/auth/refresh',
+  AUTH_SESSIONS: '/v1/auth/sessions',
+  AUTH_SESSION: (id: string) => `/v1/auth/sessions/${id}`,
+  AUTH_TOKENS: '/v1/auth/tokens',
+  AUTH_TOKEN: (id: string) => `/v1/auth/tokens/${id}`,
 
   // Repos
   REPOS_LIST: '/v1/repos',
   REPOS_DETAIL: (id: string) => `/v1/repos/${id}`,
   REPOS_SYNC: (id: string) => `/v1/repos/${id}/sync`,
+  HOSTS_SYNC: (provider: string) => `/v1/hosts/${provider}/sync`,
   REPOS_ANALYZE: (id: string) => `/v1/repos/${id}/analyze`,
   REPOS_PRS: (id: string) => `/v1/repos/${id}/prs`,
 
@@ -32,6 +47,21 @@ export const API_ENDPOINTS = {
   METRICS: '/v1/metrics',
   IMPACT: (prId: string) => `/v1/impact/${prId}`,
 
+  // Notifications
+  NOTIFICATION_CHANNELS: '/v1/notifications/channels',
+  NOTIFICATION_CHANNEL: (id: string) => `/v1/notifications/channels/${id}`,
+  NOTIFICATION_RULES: '/v1/notifications/rules',
+  NOTIFICATION_RULE: (id: string) => `/v1/notifications/rules/${id}`,
+  NOTIFICATION_DELIVERIES: '/v1/notifications/deliveries',
+  NOTIFICATION_ENDPOINTS: '/v1/notifications/endpoints',
+  NOTIFICATION_ENDPOINT: (id: string) => `/v1/notifications/endpoints/${id}`,
+  NOTIFICATION_ENDPOINT_TEST: (id: string) => `/v1/notifications/endpoints/${id}/test`,
+  NOTIFICATION_DEAD_LETTERS: '/v1/notifications/dead-letters',
+  NOTIFICATION_DEAD_LETTER_REPLAY: (id: string) => `/v1/notifications/dead-letters/${id}/replay`,
+  SLACK_LINK: '/v1/slack/link',
+  SLACK_ACCOUNTS: '/v1/slack/accounts',
+  SLACK_ACCOUNT: (id: string) => `/v1/slack/accounts/${id}`,
+
   // Webhook
   WEBHOOK_GITHUB: '/v1/webhook/github',
 
@@ -66,6 +96,7 @@ export const NAVIGATION_ITEMS = [
 // PR Status Colors
 export const PR_STATUS_COLORS = {
   open: 'bg-green-100 text-green-800 dark:bg-green-900 dark:text-green-200',
+  draft: 'bg-gray-100 text-gray-800 dark:bg-gray-800 dark:text-gray-200',
   closed: 'bg-red-100 text-red-800 dark:bg-red-900 dark:text-red-200',
   merged: 'bg-purple-100 text-purple-800 dark:bg-purple-900 dark:text-purple-200',
 } as const;
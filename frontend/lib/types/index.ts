@@ -10,6 +10,61 @@ export interface User {
   updatedAt: string;
 }
 
+// GitHub authorization state; needs_reauth means the user must log in again
+export interface AuthStatus {
+  authenticated: boolean;
+  needs_reauth: boolean;
+  token_expires_at: string | null;
+  github_linked: boolean;
+}
+
+export interface LoginProvider {
+  name: string;
+  display_name: string;
+  kind: 'github' | 'code_host' | 'oidc';
+}
+
+export interface CodeHostAccount {
+  id: string;
+  user_id: string;
+  provider: string;
+  external_id: number;
+  username: string;
+  avatar_url: string;
+  token_expires_at: string | null;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface SessionInfo {
+  id: string;
+  user_agent: string;
+  ip_address: string;
+  created_at: string;
+  last_seen_at: string;
+  expires_at: string;
+  current: boolean;
+}
+
+export type ApiTokenScope = 'repos:read' | 'repos:write' | 'analysis:write';
+
+export interface ApiToken {
+  id: string;
+  user_id: string;
+  name: string;
+  prefix: string;
+  scopes: ApiTokenScope[];
+  expires_at: string | null;
+  last_used_at: string | null;
+  last_used_ip: string;
+  created_at: string;
+}
+
+// Returned once on creation; `token` is never shown again
+export interface CreatedApiToken extends ApiToken {
+  token: string;
+}
+
 export interface AuthResponse {
   user: User;
   token: string;
@@ -48,7 +103,7 @@ export interface PullRequest {
   number: number;
   title: string;
   description?: string;
-  state: "open" | "closed" | "merged";
+  state: "open" | "draft" | "closed" | "merged";
   author?: {
     username: string;
     avatarUrl: string;
@@ -212,6 +267,75 @@ export interface WebhookPayload {
   [key: string]: unknown;
 }
 
+// ==================== Notification Types ====================
+export type NotificationEvent = "pr.analyzed" | "repo.analyzed" | "release.risk_calculated" | "sync.completed";
+export type NotificationChannelKind = "slack" | "teams" | "webhook" | "email";
+
+export interface NotificationChannel {
+  id: string;
+  user_id: string;
+  name: string;
+  kind: NotificationChannelKind;
+  target_hint: string;
+  created_at: string;
+}
+
+export interface NotificationRule {
+  id: string;
+  user_id: string;
+  event: NotificationEvent;
+  repo_id: string | null;
+  channel_id: string | null;
+  notify_author: boolean;
+  decision?: string;
+  risk_score_above?: number;
+  enabled: boolean;
+  created_at: string;
+}
+
+export interface NotificationDelivery {
+  id: string;
+  user_id: string;
+  rule_id: string | null;
+  channel_id: string | null;
+  endpoint_id: string | null;
+  kind: NotificationChannelKind;
+  target_hint: string;
+  event: NotificationEvent | "webhook.test";
+  repo_id: string | null;
+  status: "pending" | "sent" | "failed";
+  attempts: number;
+  last_error?: string;
+  next_attempt_at: string | null;
+  delivered_at: string | null;
+  created_at: string;
+}
+
+export interface WebhookEndpoint {
+  id: string;
+  user_id: string;
+  name: string;
+  url_hint: string;
+  events: NotificationEvent[];
+  enabled: boolean;
+  created_at: string;
+}
+
+// The signing secret is only returned when the endpoint is created
+export interface CreatedWebhookEndpoint extends WebhookEndpoint {
+  secret: string;
+}
+
+// ==================== Slack Types ====================
+export interface SlackAccount {
+  id: string;
+  user_id: string;
+  team_id: string;
+  slack_user_id: string;
+  slack_username: string;
+  created_at: string;
+}
+
 // ==================== API Response Types ====================
 export interface ApiResponse<T> {
   success: boolean;
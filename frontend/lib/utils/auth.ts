@@ -57,3 +57,33 @@ export function logout(): void {
     window.location.href = '/login';
   }
 }
+
+const PKCE_VERIFIER_KEY = 'pkce_code_verifier';
+
+function base64UrlEncode(bytes: Uint8Array): string {
+  let binary = '';
+  bytes.forEach((b) => {
+    binary += String.fromCharCode(b);
+  });
+  return btoa(binary).replace(/\+/g, '-').replace(/\//g, '_').replace(/=+$/, '');
+}
+
+// Creates a PKCE verifier for this login attempt, keeps it for the callback page and
+// returns its S256 challenge
+export async function createPkceChallenge(): Promise<string> {
+  const verifier = base64UrlEncode(crypto.getRandomValues(new Uint8Array(32)));
+  sessionStorage.setItem(PKCE_VERIFIER_KEY, verifier);
+
+  const digest = await crypto.subtle.digest('SHA-256', new TextEncoder().encode(verifier));
+  return base64UrlEncode(new Uint8Array(digest));
+}
+
+// Returns and forgets the verifier of the current login attempt
+export function takePkceVerifier(): string | null {
+  if (typeof window === 'undefined') {
+    return null;
+  }
+  const verifier = sessionStorage.getItem(PKCE_VERIFIER_KEY);
+  sessionStorage.removeItem(PKCE_VERIFIER_KEY);
+  return verifier;
+}